@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/AlecAivazis/survey/v2"
+
+	"github.com/nektos/act/pkg/runner"
+)
+
+// debugPrompt asks in the terminal what to do with a step debugged with --debug-on-failure or --break-before
+func debugPrompt(_ context.Context, step string, failed bool) (runner.DebugAction, error) {
+	run := "Run the step"
+	if failed {
+		run = "Retry the step"
+	}
+	options := []string{run, "Skip the step", "Abort the step"}
+
+	var answer string
+	prompt := &survey.Select{
+		Message: fmt.Sprintf("What do you want to do with step %s?", step),
+		Options: options,
+		Default: run,
+	}
+	if err := survey.AskOne(prompt, &answer); err != nil {
+		return runner.DebugActionAbort, err
+	}
+
+	switch answer {
+	case options[1]:
+		return runner.DebugActionSkip, nil
+	case options[2]:
+		return runner.DebugActionAbort, nil
+	}
+	return runner.DebugActionRun, nil
+}
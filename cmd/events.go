@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/nektos/act/pkg/runner"
+)
+
+// openEventStream opens the target of the --events flag, a file descriptor (fd:N), a Unix socket to connect to (unix:PATH) or a file
+func openEventStream(target string) (io.WriteCloser, error) {
+	switch {
+	case strings.HasPrefix(target, "fd:"):
+		fd, err := strconv.Atoi(strings.TrimPrefix(target, "fd:"))
+		if err != nil || fd < 0 {
+			return nil, fmt.Errorf("invalid file descriptor '%s'", target)
+		}
+		return os.NewFile(uintptr(fd), "events"), nil
+	case strings.HasPrefix(target, "unix:"):
+		return net.Dial("unix", strings.TrimPrefix(target, "unix:"))
+	default:
+		return os.Create(target)
+	}
+}
+
+// emitRunEvent emits an event of the servers of act, for the current run
+func emitRunEvent(config *runner.Config, event runner.Event) {
+	if config.RunState != nil {
+		event.RunID = config.RunState.ID
+	}
+	config.Events.Emit(event)
+}
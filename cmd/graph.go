@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/nektos/act/pkg/common"
@@ -12,6 +13,7 @@ func drawGraph(plan *model.Plan) error {
 
 	jobPen := common.NewPen(common.StyleSingleLine, 96)
 	arrowPen := common.NewPen(common.StyleNoLine, 97)
+	duplicateJobIDs := plan.DuplicateJobIDs()
 	for i, stage := range plan.Stages {
 		if i > 0 {
 			drawings = append(drawings, arrowPen.DrawArrow())
@@ -19,7 +21,12 @@ func drawGraph(plan *model.Plan) error {
 
 		ids := make([]string, 0)
 		for _, r := range stage.Runs {
-			ids = append(ids, r.String())
+			if duplicateJobIDs[r.JobID] {
+				// jobs with the same ID in several workflows are prefixed with their workflow file
+				ids = append(ids, fmt.Sprintf("%s:%s", r.Workflow.File, r.String()))
+			} else {
+				ids = append(ids, r.String())
+			}
 		}
 		drawings = append(drawings, jobPen.DrawBoxes(ids...))
 	}
@@ -4,6 +4,8 @@ import (
 	"path/filepath"
 
 	log "github.com/sirupsen/logrus"
+
+	"github.com/nektos/act/pkg/runner"
 )
 
 // Input contains the input for the root command
@@ -14,6 +16,9 @@ type Input struct {
 	autodetectEvent                    bool
 	eventPath                          string
 	reuseContainers                    bool
+	workspaceSnapshot                  bool
+	warmSnapshotAfter                  string
+	warmSnapshotKey                    string
 	bindWorkdir                        bool
 	secrets                            []string
 	vars                               []string
@@ -21,12 +26,14 @@ type Input struct {
 	inputs                             []string
 	platforms                          []string
 	dryrun                             bool
+	dryrunExplain                      bool
 	forcePull                          bool
 	forceRebuild                       bool
 	noOutput                           bool
 	envfile                            string
 	inputfile                          string
 	secretfile                         string
+	lazySecrets                        bool
 	varfile                            string
 	insecureSecrets                    bool
 	defaultBranch                      string
@@ -34,7 +41,17 @@ type Input struct {
 	usernsMode                         string
 	containerArchitecture              string
 	containerDaemonSocket              string
+	dockerIsolation                    string
+	dindImage                          string
+	dindCacheVolume                    string
+	containerEngine                    string
+	sandboxNetwork                     bool
 	containerOptions                   string
+	runnerProfilesFile                 string
+	runnerProfiles                     map[string]*runner.RunnerProfile
+	platformRegistryFile               string
+	platformRegistry                   *runner.PlatformRegistry
+	imageDigests                       *runner.ImageDigests
 	noWorkflowRecurse                  bool
 	useGitIgnore                       bool
 	githubInstance                     string
@@ -54,6 +71,7 @@ type Input struct {
 	replaceGheActionWithGithubCom      []string
 	replaceGheActionTokenWithGithubCom string
 	matrix                             []string
+	matrixIndex                        string
 	actionCachePath                    string
 	actionOfflineMode                  bool
 	logPrefixJobID                     bool
@@ -61,6 +79,16 @@ type Input struct {
 	useNewActionCache                  bool
 	localRepository                    []string
 	maxParallel                        int
+	watchInterval                      int
+	watchPathsFilter                   bool
+	runStatePath                       string
+	resume                             string
+	fromJob                            string
+	fromStep                           string
+	debugOnFailure                     bool
+	breakBefore                        []string
+	reports                            []string
+	events                             string
 }
 
 func (i *Input) resolve(path string) string {
@@ -28,8 +28,7 @@ func printList(plan *model.Plan) error {
 		events:  "Events",
 	}
 
-	jobs := map[string]bool{}
-	duplicateJobIDs := false
+	duplicateJobIDs := plan.DuplicateJobIDs()
 
 	jobIDMaxWidth := len(header.jobID)
 	jobNameMaxWidth := len(header.jobName)
@@ -41,6 +40,9 @@ func printList(plan *model.Plan) error {
 	for i, stage := range plan.Stages {
 		for _, r := range stage.Runs {
 			jobID := r.JobID
+			if duplicateJobIDs[jobID] {
+				jobID = r.QualifiedJobID()
+			}
 			line := lineInfoDef{
 				jobID:   jobID,
 				jobName: r.String(),
@@ -49,11 +51,6 @@ func printList(plan *model.Plan) error {
 				wfFile:  r.Workflow.File,
 				events:  strings.Join(r.Workflow.On(), `,`),
 			}
-			if _, ok := jobs[jobID]; ok {
-				duplicateJobIDs = true
-			} else {
-				jobs[jobID] = true
-			}
 			lineInfos = append(lineInfos, line)
 			if jobIDMaxWidth < len(line.jobID) {
 				jobIDMaxWidth = len(line.jobID)
@@ -100,8 +97,8 @@ func printList(plan *model.Plan) error {
 			-eventsMaxWidth, line.events,
 		)
 	}
-	if duplicateJobIDs {
-		fmt.Print("\nDetected multiple jobs with the same job name, use `-W` to specify the path to the specific workflow.\n")
+	if len(duplicateJobIDs) > 0 {
+		fmt.Print("\nDetected multiple jobs with the same job ID, use the job ID qualified with the workflow file (e.g. `-j workflow.yml:job`) to select a specific one.\n")
 	}
 	return nil
 }
@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/nektos/act/pkg/model"
+	"github.com/nektos/act/pkg/runner"
+)
+
+func newMatrixCommand(ctx context.Context, input *Input) *cobra.Command {
+	matrixCmd := &cobra.Command{
+		Use:   "matrix [event name]",
+		Short: "Print the expanded matrix of jobs, after applying include, exclude and the --matrix selection",
+		Args:  cobra.MaximumNArgs(1),
+		RunE:  newMatrixRunCommand(ctx, input),
+	}
+	matrixCmd.Flags().StringP("job", "j", "", "print the matrix of a specific job ID, optionally qualified with the workflow file (e.g. ci.yml:build)")
+	matrixCmd.Flags().StringP("format", "", "table", "output format, one of 'table' or 'json'")
+	matrixCmd.Flags().StringArrayVarP(&input.matrix, "matrix", "", []string{}, "specify which matrix configuration to include (e.g. --matrix java:13, --matrix os:ubuntu-*,macos-* or --matrix os:!windows-*)")
+	matrixCmd.Flags().StringVar(&input.matrixIndex, "matrix-index", "", "specify which matrix configurations to include by their position (e.g. --matrix-index 2-5 or --matrix-index 1,3)")
+	return matrixCmd
+}
+
+type jobMatrix struct {
+	JobID        string                     `json:"job"`
+	WorkflowFile string                     `json:"workflow"`
+	Combinations []runner.MatrixCombination `json:"combinations"`
+	Dynamic      bool                       `json:"dynamic,omitempty"` // the matrix depends on the outputs of needs, it's only known at run time
+}
+
+func newMatrixRunCommand(ctx context.Context, input *Input) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		jobID, err := cmd.Flags().GetString("job")
+		if err != nil {
+			return err
+		}
+		format, err := cmd.Flags().GetString("format")
+		if err != nil {
+			return err
+		}
+		if format != "table" && format != "json" {
+			return fmt.Errorf("invalid format '%s', must be one of 'table' or 'json'", format)
+		}
+
+		planner, err := model.NewWorkflowPlanner(input.WorkflowsPath(), input.noWorkflowRecurse)
+		if err != nil {
+			return err
+		}
+
+		eventName := "push"
+		var plan *model.Plan
+		if len(args) > 0 {
+			eventName = args[0]
+			plan, err = planner.PlanEvent(eventName)
+		} else if jobID != "" {
+			plan, err = planner.PlanJob(jobID)
+		} else {
+			plan, err = planner.PlanAll()
+		}
+		if plan == nil && err != nil {
+			return err
+		}
+		if jobID != "" {
+			if err := plan.CheckJobID(jobID); err != nil {
+				return err
+			}
+		}
+
+		log.Debugf("Loading vars from %s", input.Varfile())
+		vars := newSecrets(input.vars)
+		_ = readEnvs(input.Varfile(), vars)
+
+		inputs := parseEnvs(input.inputs)
+		_ = readEnvs(input.Inputfile(), inputs)
+
+		config := &runner.Config{
+			Actor:          input.actor,
+			EventName:      eventName,
+			EventPath:      input.EventPath(),
+			Workdir:        input.Workdir(),
+			Vars:           vars,
+			Inputs:         inputs,
+			GitHubInstance: input.githubInstance,
+			RemoteName:     input.remoteName,
+			Matrix:         parseMatrix(input.matrix),
+			MatrixIndexes:  parseMatrixIndexes(input.matrixIndex),
+		}
+
+		jobMatrixes := make([]jobMatrix, 0)
+		for _, stage := range plan.Stages {
+			for _, run := range stage.Runs {
+				if jobID != "" && !run.MatchesJobID(jobID) {
+					continue
+				}
+				if run.Job().Strategy == nil {
+					continue
+				}
+				if run.Job().MatrixReferencesNeeds() {
+					jobMatrixes = append(jobMatrixes, jobMatrix{
+						JobID:        run.JobID,
+						WorkflowFile: run.Workflow.File,
+						Combinations: []runner.MatrixCombination{},
+						Dynamic:      true,
+					})
+					continue
+				}
+				combinations, err := runner.ExpandMatrix(ctx, config, run)
+				if err != nil {
+					return fmt.Errorf("failed to expand the matrix of job '%s': %w", run.JobID, err)
+				}
+				jobMatrixes = append(jobMatrixes, jobMatrix{
+					JobID:        run.JobID,
+					WorkflowFile: run.Workflow.File,
+					Combinations: combinations,
+				})
+			}
+		}
+
+		if format == "json" {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(jobMatrixes)
+		}
+		printMatrix(jobMatrixes)
+		return nil
+	}
+}
+
+func printMatrix(jobMatrixes []jobMatrix) {
+	for i, jm := range jobMatrixes {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("%s (%s)\n", jm.JobID, jm.WorkflowFile)
+		if jm.Dynamic {
+			fmt.Println("The matrix depends on the outputs of the needed jobs and is expanded when they have finished")
+			continue
+		}
+
+		keySet := map[string]bool{}
+		for _, c := range jm.Combinations {
+			for k := range c.Matrix {
+				keySet[k] = true
+			}
+		}
+		keys := make([]string, 0, len(keySet))
+		for k := range keySet {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		header := append([]string{"Index"}, keys...)
+		lines := [][]string{header}
+		for _, c := range jm.Combinations {
+			line := []string{strconv.Itoa(c.Index)}
+			for _, k := range keys {
+				line = append(line, matrixValueString(c.Matrix[k]))
+			}
+			lines = append(lines, line)
+		}
+
+		printTable(lines)
+	}
+}
+
+func matrixValueString(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return ""
+	case map[string]interface{}, []interface{}:
+		b, err := json.Marshal(v)
+		if err == nil {
+			return string(b)
+		}
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+func parseMatrixIndexes(matrixIndex string) map[int]bool {
+	// the matrix index should be of the form - 1,3,5-7
+	indexes := make(map[int]bool)
+	if matrixIndex == "" {
+		return indexes
+	}
+	for _, part := range strings.Split(matrixIndex, ",") {
+		start, end, isRange := strings.Cut(strings.TrimSpace(part), "-")
+		first, err := strconv.Atoi(start)
+		last := first
+		if err == nil && isRange {
+			last, err = strconv.Atoi(end)
+		}
+		if err != nil || first < 1 || last < first {
+			log.Fatalf("Invalid matrix index format. Failed to parse %s", part)
+		}
+		for i := first; i <= last; i++ {
+			indexes[i] = true
+		}
+	}
+	return indexes
+}
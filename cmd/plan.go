@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/nektos/act/pkg/model"
+	"github.com/nektos/act/pkg/runner"
+)
+
+func newPlanCommand(ctx context.Context, input *Input) *cobra.Command {
+	planCmd := &cobra.Command{
+		Use:   "plan [event name]",
+		Short: "Explain what the jobs of a run would do, without running them",
+		Long: "Explain what the jobs of a run would do, without running them.\n\n" +
+			"For every job and combination of its matrix, print the image it runs on, its container options and services, " +
+			"the result of the if conditions which can be decided before the run, the commits the refs of the remote actions resolve to, " +
+			"and the names of the env variables and secrets each step receives.",
+		Args: cobra.MaximumNArgs(1),
+		RunE: newPlanRunCommand(ctx, input),
+	}
+	planCmd.Flags().StringP("job", "j", "", "explain a specific job ID and the jobs it needs, optionally qualified with the workflow file (e.g. ci.yml:build)")
+	planCmd.Flags().StringP("format", "", "text", "output format, one of 'text' or 'json'")
+	planCmd.Flags().Bool("no-resolve-refs", false, "don't resolve the refs of the remote actions to the SHAs of their commits, which requires network access")
+	planCmd.Flags().StringArrayVarP(&input.platforms, "platform", "P", []string{}, "custom image to use per platform (e.g. -P ubuntu-18.04=nektos/act-environments-ubuntu:18.04)")
+	planCmd.Flags().StringArrayVarP(&input.secrets, "secret", "s", []string{}, "secret to make available to actions with optional value (e.g. -s mysecret=foo or -s mysecret)")
+	planCmd.Flags().StringArrayVar(&input.vars, "var", []string{}, "variable to make available to actions with optional value (e.g. --var myvar=foo or --var myvar)")
+	planCmd.Flags().StringArrayVarP(&input.envs, "env", "", []string{}, "env to make available to actions with optional value (e.g. --env myenv=foo or --env myenv)")
+	planCmd.Flags().StringArrayVarP(&input.matrix, "matrix", "", []string{}, "specify which matrix configuration to include (e.g. --matrix java:13, --matrix os:ubuntu-*,macos-* or --matrix os:!windows-*)")
+	planCmd.Flags().StringVar(&input.matrixIndex, "matrix-index", "", "specify which matrix configurations to include by their position (e.g. --matrix-index 2-5 or --matrix-index 1,3)")
+	planCmd.Flags().StringVarP(&input.eventPath, "eventpath", "e", "", "path to event JSON file")
+	planCmd.Flags().StringVar(&input.defaultBranch, "defaultbranch", "", "the name of the main branch")
+	return planCmd
+}
+
+func newPlanRunCommand(ctx context.Context, input *Input) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		jobID, err := cmd.Flags().GetString("job")
+		if err != nil {
+			return err
+		}
+		format, err := cmd.Flags().GetString("format")
+		if err != nil {
+			return err
+		}
+		if format != "text" && format != "json" {
+			return fmt.Errorf("invalid format '%s', must be one of 'text' or 'json'", format)
+		}
+		noResolveRefs, err := cmd.Flags().GetBool("no-resolve-refs")
+		if err != nil {
+			return err
+		}
+
+		planner, err := model.NewWorkflowPlanner(input.WorkflowsPath(), input.noWorkflowRecurse)
+		if err != nil {
+			return err
+		}
+		eventName := "push"
+		if len(args) > 0 {
+			eventName = args[0]
+		} else if events := planner.GetEvents(); len(events) == 1 && len(events[0]) > 0 {
+			eventName = events[0]
+		}
+		var plan *model.Plan
+		var plannerErr error
+		if jobID != "" {
+			plan, plannerErr = planner.PlanJob(jobID)
+		} else {
+			plan, plannerErr = planner.PlanEvent(eventName)
+		}
+		if plan == nil && plannerErr != nil {
+			return plannerErr
+		}
+
+		envs := parseEnvs(input.envs)
+		_ = readEnvs(input.Envfile(), envs)
+		inputs := parseEnvs(input.inputs)
+		_ = readEnvs(input.Inputfile(), inputs)
+		log.Debugf("Loading secrets from %s", input.Secretfile())
+		secrets := newSecrets(input.secrets)
+		_ = readEnvs(input.Secretfile(), secrets)
+		vars := newSecrets(input.vars)
+		_ = readEnvs(input.Varfile(), vars)
+
+		if err := input.loadPlatformFiles(); err != nil {
+			return err
+		}
+		config := newRunnerConfig(input, eventName, envs, secrets, vars, inputs)
+		config.EventPath = input.EventPath()
+		config.Matrix = parseMatrix(input.matrix)
+		config.MatrixIndexes = parseMatrixIndexes(input.matrixIndex)
+		if err := explainPlan(ctx, config, plan, format, !noResolveRefs); err != nil {
+			return err
+		}
+		return plannerErr
+	}
+}
+
+// explainPlan prints what the jobs of the plan would do, as text or JSON
+func explainPlan(ctx context.Context, config *runner.Config, plan *model.Plan, format string, resolveRefs bool) error {
+	explanation, err := runner.ExplainPlan(ctx, config, plan, resolveRefs)
+	if err != nil {
+		return err
+	}
+	if format == "json" {
+		return explanation.WriteJSON(os.Stdout)
+	}
+	return explanation.WriteText(os.Stdout)
+}
+
+// dryrunValue is the value of --dryrun, a boolean or explain to print the explanation of the plan instead of running it
+type dryrunValue struct {
+	input *Input
+}
+
+func (v *dryrunValue) String() string {
+	if v.input.dryrunExplain {
+		return "explain"
+	}
+	return strconv.FormatBool(v.input.dryrun)
+}
+
+func (v *dryrunValue) Set(s string) error {
+	if s == "explain" {
+		v.input.dryrun = true
+		v.input.dryrunExplain = true
+		return nil
+	}
+	dryrun, err := strconv.ParseBool(s)
+	if err != nil {
+		return fmt.Errorf("must be a boolean or 'explain'")
+	}
+	v.input.dryrun = dryrun
+	v.input.dryrunExplain = false
+	return nil
+}
+
+func (v *dryrunValue) Type() string {
+	return "bool"
+}
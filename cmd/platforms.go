@@ -1,7 +1,18 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
 	"strings"
+
+	"github.com/adrg/xdg"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/nektos/act/pkg/runner"
 )
 
 func (i *Input) newPlatforms() map[string]string {
@@ -12,6 +23,13 @@ func (i *Input) newPlatforms() map[string]string {
 		"ubuntu-18.04":  "node:16-buster-slim",
 	}
 
+	// the image of a runner profile replaces the default image of its label
+	for label, profile := range i.runnerProfiles {
+		if profile.Image != "" {
+			delete(platforms, label)
+		}
+	}
+
 	for _, p := range i.platforms {
 		pParts := strings.Split(p, "=")
 		if len(pParts) == 2 {
@@ -20,3 +38,149 @@ func (i *Input) newPlatforms() map[string]string {
 	}
 	return platforms
 }
+
+// configFile returns path, or the file of name in the config directory, empty if there is none
+func configFile(path, name string) string {
+	if path != "" {
+		return path
+	}
+	path, err := xdg.SearchConfigFile(filepath.Join("act", name))
+	if err != nil {
+		return ""
+	}
+	return path
+}
+
+// loadPlatformFiles loads the runner profiles of --runner-profiles and the platform registry of --platform-registry,
+// or the ones of the config directory
+func (i *Input) loadPlatformFiles() error {
+	if path := configFile(i.runnerProfilesFile, "runner-profiles.yml"); path != "" {
+		profiles, err := runner.LoadRunnerProfiles(path)
+		if err != nil {
+			return err
+		}
+		log.Debugf("Loaded %d runner profiles from %s", len(profiles), path)
+		i.runnerProfiles = profiles
+	}
+
+	if path := configFile(i.platformRegistryFile, "platforms.yml"); path != "" {
+		registry, err := runner.LoadPlatformRegistry(path)
+		if err != nil {
+			return err
+		}
+		log.Debugf("Loaded %d platforms from %s", len(registry.Platforms), path)
+		i.platformRegistry = registry
+	}
+
+	imageDigests, err := runner.LoadImageDigests(filepath.Join(CacheHomeDir, "act", "image-digests.json"))
+	if err != nil {
+		log.Warnf("Failed to read the digests of the images of the last runs: %v", err)
+		return nil
+	}
+	i.imageDigests = imageDigests
+	return nil
+}
+
+// platformListEntry is a runner of the platform registry or a platform of -P, as listed by act platforms list
+type platformListEntry struct {
+	Name   string   `json:"name"`
+	Source string   `json:"source"` // registry or -P
+	Labels []string `json:"labels"`
+	OS     string   `json:"os,omitempty"`
+	Arch   string   `json:"arch,omitempty"`
+	Image  string   `json:"image"`
+	Digest string   `json:"digest,omitempty"` // the digest the image resolved to on the last run, unless it is pinned
+}
+
+func newPlatformsCommand(ctx context.Context, input *Input) *cobra.Command {
+	platformsCmd := &cobra.Command{
+		Use:   "platforms",
+		Short: "List the platforms the jobs run on, from --platform-registry and -P",
+	}
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the runners of the platform registry, matched by all the labels of runs-on first, then the platforms of -P matched by a label",
+		Args:  cobra.NoArgs,
+		RunE:  newPlatformsListCommand(ctx, input),
+	}
+	listCmd.Flags().StringP("format", "", "table", "output format, one of 'table' or 'json'")
+
+	platformsCmd.AddCommand(listCmd)
+	return platformsCmd
+}
+
+func newPlatformsListCommand(_ context.Context, input *Input) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, _ []string) error {
+		format, err := runsFormat(cmd)
+		if err != nil {
+			return err
+		}
+		if err := input.loadPlatformFiles(); err != nil {
+			return err
+		}
+
+		var entries []*platformListEntry
+		if input.platformRegistry != nil {
+			for _, platform := range input.platformRegistry.Platforms {
+				entry := &platformListEntry{
+					Name:   platform.Name,
+					Source: "registry",
+					Labels: platform.AllLabels(),
+					OS:     platform.OS,
+					Arch:   platform.Arch,
+					Image:  platform.Image,
+				}
+				if !platform.Pinned() && input.imageDigests != nil {
+					entry.Digest = input.imageDigests.Get(platform.Image)
+				}
+				entries = append(entries, entry)
+			}
+		}
+		platforms := input.newPlatforms()
+		labels := make([]string, 0, len(platforms))
+		for label := range platforms {
+			labels = append(labels, label)
+		}
+		sort.Strings(labels)
+		for _, label := range labels {
+			entry := &platformListEntry{
+				Name:   label,
+				Source: "-P",
+				Labels: []string{label},
+				Image:  platforms[label],
+			}
+			if input.imageDigests != nil {
+				entry.Digest = input.imageDigests.Get(entry.Image)
+			}
+			entries = append(entries, entry)
+		}
+
+		if format == "json" {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(entries)
+		}
+		lines := [][]string{{"Name", "Source", "Labels", "OS", "Arch", "Image", "Last digest"}}
+		for _, entry := range entries {
+			lines = append(lines, []string{
+				entry.Name,
+				entry.Source,
+				strings.Join(entry.Labels, ","),
+				valueOrDash(entry.OS),
+				valueOrDash(entry.Arch),
+				entry.Image,
+				valueOrDash(entry.Digest),
+			})
+		}
+		printTable(lines)
+		return nil
+	}
+}
+
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/runner"
+)
+
+type reportOutput struct {
+	format string
+	file   string
+}
+
+// parseReports parses the --report flags, of the form format=file
+func parseReports(reports []string) ([]reportOutput, error) {
+	outputs := make([]reportOutput, 0, len(reports))
+	for _, report := range reports {
+		format, file, ok := strings.Cut(report, "=")
+		if !ok || file == "" {
+			return nil, fmt.Errorf("invalid report '%s', must be of the form format=file", report)
+		}
+		if format != "junit" && format != "json" {
+			return nil, fmt.Errorf("invalid report format '%s', must be one of 'junit' or 'json'", format)
+		}
+		outputs = append(outputs, reportOutput{format: format, file: file})
+	}
+	return outputs, nil
+}
+
+// newReportExecutor collects the results of the run in a new report, and writes it to the outputs once the run has finished, even if it has failed
+func newReportExecutor(config *runner.Config, outputs []reportOutput, executor common.Executor) common.Executor {
+	if len(outputs) == 0 {
+		return executor
+	}
+	return func(ctx context.Context) error {
+		report := runner.NewReport()
+		config.Report = report
+		err := executor(ctx)
+		for _, output := range outputs {
+			if writeErr := writeReport(report, output); writeErr != nil {
+				log.Errorf("Failed to write the %s report to %s: %v", output.format, output.file, writeErr)
+			} else {
+				log.Infof("Wrote the %s report to %s", output.format, output.file)
+			}
+		}
+		return err
+	}
+}
+
+func writeReport(report *runner.Report, output reportOutput) error {
+	if dir := filepath.Dir(output.file); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	f, err := os.Create(output.file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if output.format == "junit" {
+		return report.WriteJUnit(f)
+	}
+	return report.WriteJSON(f)
+}
@@ -13,10 +13,8 @@ import (
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/adrg/xdg"
-	"github.com/andreaskoch/go-fswatch"
 	docker_container "github.com/moby/moby/api/types/container"
 	"github.com/joho/godotenv"
-	gitignore "github.com/sabhiram/go-gitignore"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"go.yaml.in/yaml/v4"
@@ -43,9 +41,11 @@ func Execute(ctx context.Context, version string) {
 		SilenceUsage:      true,
 	}
 	rootCmd.Flags().BoolP("watch", "w", false, "watch the contents of the local repo and run when files change")
+	rootCmd.Flags().IntVar(&input.watchInterval, "watch-interval", 2, "seconds between checks of the local repo for changes in watch mode")
+	rootCmd.Flags().BoolVar(&input.watchPathsFilter, "watch-paths-filter", false, "in watch mode, only rerun the workflows whose paths and paths-ignore filters match the changed files")
 	rootCmd.Flags().BoolP("list", "l", false, "list workflows")
 	rootCmd.Flags().BoolP("graph", "g", false, "draw workflows")
-	rootCmd.Flags().StringP("job", "j", "", "run a specific job ID")
+	rootCmd.Flags().StringP("job", "j", "", "run a specific job ID, optionally qualified with the workflow file (e.g. ci.yml:build)")
 	rootCmd.Flags().BoolP("bug-report", "", false, "Display system information for bug report")
 
 	rootCmd.Flags().StringVar(&input.remoteName, "remote-name", "origin", "git remote name that will be used to retrieve url of git repo")
@@ -54,7 +54,17 @@ func Execute(ctx context.Context, version string) {
 	rootCmd.Flags().StringArrayVarP(&input.envs, "env", "", []string{}, "env to make available to actions with optional value (e.g. --env myenv=foo or --env myenv)")
 	rootCmd.Flags().StringArrayVarP(&input.inputs, "input", "", []string{}, "action input to make available to actions (e.g. --input myinput=foo)")
 	rootCmd.Flags().StringArrayVarP(&input.platforms, "platform", "P", []string{}, "custom image to use per platform (e.g. -P ubuntu-18.04=nektos/act-environments-ubuntu:18.04)")
-	rootCmd.Flags().BoolVarP(&input.reuseContainers, "reuse", "r", false, "don't remove container(s) on successfully completed workflow(s) to maintain state between runs")
+	rootCmd.Flags().BoolVarP(&input.reuseContainers, "reuse", "r", false, "don't remove container(s) on successfully completed workflow(s) to maintain state between runs, a container is only reused while the definition and image of its job are unchanged")
+	rootCmd.Flags().BoolVar(&input.workspaceSnapshot, "workspace-snapshot", false, "copy the working directory once per run to a volume shared by the jobs, whose workspaces overlay it copy-on-write, and with --reuse only copy the files changed since the last run")
+	rootCmd.Flags().StringVar(&input.warmSnapshotAfter, "warm-snapshot-after", "", "ID of the step after which the job container is committed to a warm snapshot image, later runs of the job with the same definition start from it and skip the steps up to it. Only the changes outside of the workspace and tool cache volumes are kept, including the credentials the steps wrote to files such as ~/.npmrc, and the earlier snapshots of the job are removed")
+	rootCmd.Flags().StringVar(&input.warmSnapshotKey, "warm-snapshot-key", "", "key of the warm snapshot replacing the digest of the files of the working directory in its tag, e.g. the hash of the lock files the setup steps install from, so that the changes of the other files don't invalidate it")
+	rootCmd.Flags().StringVar(&input.resume, "resume", "", "resume the run with the given ID, skipping the jobs and steps that have completed, implies --reuse")
+	rootCmd.Flags().StringVar(&input.fromJob, "from-job", "", "with --resume, run the job with the given ID again, together with the jobs needing it")
+	rootCmd.Flags().StringVar(&input.fromStep, "from-step", "", "with --resume and --from-job, continue the job at the step with the given ID or position (starting at 1)")
+	rootCmd.Flags().BoolVar(&input.debugOnFailure, "debug-on-failure", false, "pause the job when a step fails and open a shell in the job container with the env of the step, then retry, skip or abort the step. On Windows, the first key pressed after the shell exits is dropped")
+	rootCmd.Flags().StringArrayVar(&input.breakBefore, "break-before", []string{}, "pause the job before the step with the given ID and open a shell in the job container with the env of the step, then run, skip or abort the step. On Windows, the first key pressed after the shell exits is dropped")
+	rootCmd.Flags().StringVar(&input.events, "events", "", "write the events of the run as NDJSON to a file descriptor (fd:3), a Unix socket to connect to (unix:/path/to/socket) or a file, for tools following the progress of the run")
+	rootCmd.Flags().StringArrayVar(&input.reports, "report", []string{}, "write the results of the jobs and steps to a file once the run has finished, as format=file with the format one of 'junit' or 'json' (e.g. --report junit=report.xml)")
 	rootCmd.Flags().BoolVarP(&input.bindWorkdir, "bind", "b", false, "bind working directory to container, rather than copy")
 	rootCmd.Flags().BoolVarP(&input.forcePull, "pull", "p", true, "pull docker image(s) even if already present")
 	rootCmd.Flags().BoolVarP(&input.forceRebuild, "rebuild", "", true, "rebuild local action docker image(s) even if already present")
@@ -69,7 +79,8 @@ func Execute(ctx context.Context, version string) {
 	rootCmd.Flags().BoolVar(&input.autoRemove, "rm", false, "automatically remove container(s)/volume(s) after a workflow(s) failure")
 	rootCmd.Flags().StringArrayVarP(&input.replaceGheActionWithGithubCom, "replace-ghe-action-with-github-com", "", []string{}, "If you are using GitHub Enterprise Server and allow specified actions from GitHub (github.com), you can set actions on this. (e.g. --replace-ghe-action-with-github-com =github/super-linter)")
 	rootCmd.Flags().StringVar(&input.replaceGheActionTokenWithGithubCom, "replace-ghe-action-token-with-github-com", "", "If you are using replace-ghe-action-with-github-com  and you want to use private actions on GitHub, you have to set personal access token")
-	rootCmd.Flags().StringArrayVarP(&input.matrix, "matrix", "", []string{}, "specify which matrix configuration to include (e.g. --matrix java:13")
+	rootCmd.Flags().StringArrayVarP(&input.matrix, "matrix", "", []string{}, "specify which matrix configuration to include (e.g. --matrix java:13, --matrix os:ubuntu-*,macos-* or --matrix os:!windows-*)")
+	rootCmd.Flags().StringVar(&input.matrixIndex, "matrix-index", "", "specify which matrix configurations to include by their position, as printed by `act matrix` (e.g. --matrix-index 2-5 or --matrix-index 1,3)")
 	rootCmd.PersistentFlags().StringVarP(&input.actor, "actor", "a", "nektos/act", "user that triggered the event")
 	rootCmd.PersistentFlags().StringVarP(&input.workflowsPath, "workflows", "W", "./.github/workflows/", "path to workflow file(s)")
 	rootCmd.PersistentFlags().BoolVarP(&input.noWorkflowRecurse, "no-recurse", "", false, "Flag to disable running workflows from subdirectories of specified path in '--workflows'/'-W' flag")
@@ -78,15 +89,23 @@ func Execute(ctx context.Context, version string) {
 	rootCmd.PersistentFlags().BoolVar(&input.jsonLogger, "json", false, "Output logs in json format")
 	rootCmd.PersistentFlags().BoolVar(&input.logPrefixJobID, "log-prefix-job-id", false, "Output the job id within non-json logs instead of the entire name")
 	rootCmd.PersistentFlags().BoolVarP(&input.noOutput, "quiet", "q", false, "disable logging of output from steps")
-	rootCmd.PersistentFlags().BoolVarP(&input.dryrun, "dryrun", "n", false, "dryrun mode")
-	rootCmd.PersistentFlags().StringVarP(&input.secretfile, "secret-file", "", ".secrets", "file with list of secrets to read from (e.g. --secret-file .secrets)")
+	rootCmd.PersistentFlags().VarPF(&dryrunValue{input: input}, "dryrun", "n", "dryrun mode, with --dryrun=explain print what the jobs would do instead, like act plan").NoOptDefVal = "true"
+	rootCmd.PersistentFlags().StringVarP(&input.secretfile, "secret-file", "", ".secrets", "file with list of secrets to read from (e.g. --secret-file .secrets), a value may reference the secret by URI (e.g. MY_SECRET=vault://secret/data/app#password)")
+	rootCmd.PersistentFlags().BoolVar(&input.lazySecrets, "lazy-secrets", false, "resolve the secrets referenced by URI (sops://, pass://, exec:// or vault://) when a job starts instead of at startup")
 	rootCmd.PersistentFlags().StringVarP(&input.varfile, "var-file", "", ".vars", "file with list of vars to read from (e.g. --var-file .vars)")
 	rootCmd.PersistentFlags().BoolVarP(&input.insecureSecrets, "insecure-secrets", "", false, "NOT RECOMMENDED! Doesn't hide secrets while printing logs.")
 	rootCmd.PersistentFlags().StringVarP(&input.envfile, "env-file", "", ".env", "environment file to read and use as env in the containers")
 	rootCmd.PersistentFlags().StringVarP(&input.inputfile, "input-file", "", ".input", "input file to read and use as action input")
-	rootCmd.PersistentFlags().StringVarP(&input.containerArchitecture, "container-architecture", "", "", "Architecture which should be used to run containers, e.g.: linux/amd64. If not specified, will use host default architecture. Requires Docker server API Version 1.41+. Ignored on earlier Docker server platforms.")
+	rootCmd.PersistentFlags().StringVarP(&input.containerArchitecture, "container-architecture", "", "", "Architecture which should be used to run containers, e.g.: linux/amd64, or an expression of the matrix, e.g.: ${{ matrix.platform }}. If not specified, will use the arch of the runner of the platform registry or the host default architecture. Requires Docker server API Version 1.41+. Ignored on earlier Docker server platforms.")
 	rootCmd.PersistentFlags().StringVarP(&input.containerDaemonSocket, "container-daemon-socket", "", "", "URI to Docker Engine socket (e.g.: unix://~/.docker/run/docker.sock or - to disable bind mounting the socket)")
+	rootCmd.PersistentFlags().StringVarP(&input.dockerIsolation, "docker-isolation", "", "", "isolate the docker daemon the jobs use from the host: 'dind' starts a Docker-in-Docker sidecar per run, which the jobs of the run share and DOCKER_HOST points to, instead of bind mounting the host docker socket. The jobs then run on networks of their own instead of --network, the sidecar joining each of them, and the images of the host the jobs reference are loaded into it. Not supported with podman")
+	rootCmd.PersistentFlags().StringVarP(&input.dindImage, "dind-image", "", "docker:dind", "image of the Docker-in-Docker sidecars of --docker-isolation dind")
+	rootCmd.PersistentFlags().StringVarP(&input.dindCacheVolume, "dind-cache-volume", "", "act-dind-cache", "volume of the /var/lib/docker of the Docker-in-Docker sidecars, so that the images a sidecar pulls, builds or loads from the host are there for the next sidecar mounting it. A numbered copy of it is used by each sidecar running at the same time, - to disable it")
+	rootCmd.PersistentFlags().StringVar(&input.containerEngine, "container-engine", container.EngineDocker, "container engine running the jobs, one of 'docker' or 'podman'. Podman is reached with CONTAINER_HOST or its usual socket locations, runs the services of a job in a pod and, when rootless, maps the bind mounted workspace to the user of the image")
+	rootCmd.PersistentFlags().BoolVar(&input.sandboxNetwork, "sandbox-network", false, "share the network of the host with the jobs running in a sandbox, on a platform mapped to -sandbox (e.g. -P ubuntu-latest=-sandbox), instead of isolating them with loopback only")
 	rootCmd.PersistentFlags().StringVarP(&input.containerOptions, "container-options", "", "", "Custom docker container options for the job container without an options property in the job definition")
+	rootCmd.PersistentFlags().StringVar(&input.runnerProfilesFile, "runner-profiles", "", "YAML file of the runner profiles by runs-on label, with the image and the cpus, memory, pids, shm-size and tmpfs limits of the job, service and step containers (default act/runner-profiles.yml in the config directory)")
+	rootCmd.PersistentFlags().StringVar(&input.platformRegistryFile, "platform-registry", "", "YAML file of the runners the jobs run on, with their labels, group, os, arch and image pinned by digest: a job runs on the first runner with all the labels of its runs-on, before the platforms of -P (default act/platforms.yml in the config directory)")
 	rootCmd.PersistentFlags().StringVarP(&input.githubInstance, "github-instance", "", "github.com", "GitHub instance to use. Don't use this if you are not using GitHub Enterprise Server.")
 	rootCmd.PersistentFlags().StringVarP(&input.artifactServerPath, "artifact-server-path", "", "", "Defines the path where the artifact server stores uploads and retrieves downloads from. If not specified the artifact server will not start.")
 	rootCmd.PersistentFlags().StringVarP(&input.artifactServerAddr, "artifact-server-addr", "", common.GetOutboundIP().String(), "Defines the address to which the artifact server binds.")
@@ -102,6 +121,12 @@ func Execute(ctx context.Context, version string) {
 	rootCmd.PersistentFlags().BoolVarP(&input.useNewActionCache, "use-new-action-cache", "", false, "Enable using the new Action Cache for storing Actions locally")
 	rootCmd.PersistentFlags().StringArrayVarP(&input.localRepository, "local-repository", "", []string{}, "Replaces the specified repository and ref with a local folder (e.g. https://github.com/test/test@v0=/home/act/test or test/test@v0=/home/act/test, the latter matches any hosts or protocols)")
 	rootCmd.PersistentFlags().IntVarP(&input.maxParallel, "max-parallel", "", 0, "Limits the number of jobs running in parallel across all workflows (0 = no limit, uses number of CPUs)")
+	rootCmd.PersistentFlags().StringVarP(&input.runStatePath, "run-state-path", "", filepath.Join(CacheHomeDir, "actruns"), "Defines the path where the state, results and logs of runs are recorded to resume and inspect them.")
+	rootCmd.AddCommand(newMatrixCommand(ctx, input))
+	rootCmd.AddCommand(newRunsCommand(ctx, input))
+	rootCmd.AddCommand(newServeCommand(ctx, input))
+	rootCmd.AddCommand(newPlanCommand(ctx, input))
+	rootCmd.AddCommand(newPlatformsCommand(ctx, input))
 	rootCmd.SetArgs(args())
 
 	if err := rootCmd.Execute(); err != nil {
@@ -314,20 +339,73 @@ func readEnvs(path string, envs map[string]string) bool {
 }
 
 func parseMatrix(matrix []string) map[string]map[string]bool {
+	matrixes, err := parseMatrixEntries(matrix)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return matrixes
+}
+
+// parseMatrixEntries parses the --matrix entries to the matrix config of the runner
+func parseMatrixEntries(matrix []string) (map[string]map[string]bool, error) {
 	// each matrix entry should be of the form - string:string
+	// the value may be a comma separated list of glob patterns, and is excluded when prefixed with '!'
 	r := regexp.MustCompile(":")
 	matrixes := make(map[string]map[string]bool)
 	for _, m := range matrix {
 		matrix := r.Split(m, 2)
 		if len(matrix) < 2 {
-			log.Fatalf("Invalid matrix format. Failed to parse %s", m)
+			return nil, fmt.Errorf("Invalid matrix format. Failed to parse %s", m)
 		}
 		if _, ok := matrixes[matrix[0]]; !ok {
 			matrixes[matrix[0]] = make(map[string]bool)
 		}
-		matrixes[matrix[0]][matrix[1]] = true
+		values, include := matrix[1], true
+		if strings.HasPrefix(values, "!") {
+			values, include = values[1:], false
+		}
+		for _, value := range strings.Split(values, ",") {
+			matrixes[matrix[0]][value] = include
+		}
 	}
-	return matrixes
+	return matrixes, nil
+}
+
+// setupContainerDaemonSocket resolves the docker host and the daemon socket to bind mount in the containers,
+// with podman the docker host is the Docker compatible API of the Podman service
+func setupContainerDaemonSocket(input *Input) error {
+	switch input.containerEngine {
+	case container.EngineDocker:
+		if ret, err := container.GetSocketAndHost(input.containerDaemonSocket); err != nil {
+			log.Warnf("Couldn't get a valid docker connection: %+v", err)
+		} else {
+			os.Setenv("DOCKER_HOST", ret.Host)
+			input.containerDaemonSocket = ret.Socket
+			log.Infof("Using docker host '%s', and daemon socket '%s'", ret.Host, ret.Socket)
+		}
+	case container.EnginePodman:
+		if ret, err := container.GetPodmanSocketAndHost(input.containerDaemonSocket); err != nil {
+			log.Warnf("Couldn't get a valid podman connection: %+v", err)
+		} else {
+			os.Setenv("DOCKER_HOST", ret.Host)
+			input.containerDaemonSocket = ret.Socket
+			log.Infof("Using podman host '%s', and daemon socket '%s'", ret.Host, ret.Socket)
+		}
+	default:
+		return fmt.Errorf("invalid container engine '%s', must be one of 'docker' or 'podman'", input.containerEngine)
+	}
+	switch input.dockerIsolation {
+	case "":
+	case runner.DockerIsolationDind:
+		if input.containerEngine == container.EnginePodman {
+			// the containers of a job share the network of its pod, which the sidecar of the run can't join
+			return fmt.Errorf("docker isolation '%s' is not supported with podman", input.dockerIsolation)
+		}
+		log.Infof("Using a Docker-in-Docker sidecar per run instead of the daemon socket")
+	default:
+		return fmt.Errorf("invalid docker isolation '%s', must be 'dind'", input.dockerIsolation)
+	}
+	return nil
 }
 
 //nolint:gocyclo
@@ -340,12 +418,8 @@ func newRunCommand(ctx context.Context, input *Input) func(*cobra.Command, []str
 		if ok, _ := cmd.Flags().GetBool("bug-report"); ok {
 			return bugReport(ctx, cmd.Version)
 		}
-		if ret, err := container.GetSocketAndHost(input.containerDaemonSocket); err != nil {
-			log.Warnf("Couldn't get a valid docker connection: %+v", err)
-		} else {
-			os.Setenv("DOCKER_HOST", ret.Host)
-			input.containerDaemonSocket = ret.Socket
-			log.Infof("Using docker host '%s', and daemon socket '%s'", ret.Host, ret.Socket)
+		if err := setupContainerDaemonSocket(input); err != nil {
+			return err
 		}
 
 		if runtime.GOOS == "darwin" && runtime.GOARCH == "arm64" && input.containerArchitecture == "" {
@@ -368,6 +442,14 @@ func newRunCommand(ctx context.Context, input *Input) func(*cobra.Command, []str
 		log.Debugf("Loading secrets from %s", input.Secretfile())
 		secrets := newSecrets(input.secrets)
 		_ = readEnvs(input.Secretfile(), secrets)
+		var resolveSecrets runner.SecretResolver
+		if !input.dryrunExplain {
+			// the explanation only names the secrets
+			var err error
+			if resolveSecrets, err = resolveSecretRefs(ctx, input, secrets); err != nil {
+				return err
+			}
+		}
 
 		log.Debugf("Loading vars from %s", input.Varfile())
 		vars := newSecrets(input.vars)
@@ -376,6 +458,40 @@ func newRunCommand(ctx context.Context, input *Input) func(*cobra.Command, []str
 		matrixes := parseMatrix(input.matrix)
 		log.Debugf("Evaluated matrix inclusions: %v", matrixes)
 
+		var runState *runner.RunState
+		if input.resume != "" {
+			var err error
+			runState, err = runner.LoadRunState(input.runStatePath, input.resume)
+			if err != nil {
+				return err
+			}
+			if len(args) == 0 {
+				args = []string{runState.EventName}
+			}
+			if !input.reuseContainers {
+				log.Infof("Reusing the containers of run %s", runState.ID)
+				input.reuseContainers = true
+			}
+		} else if input.fromJob != "" || input.fromStep != "" {
+			return fmt.Errorf("--from-job and --from-step can only be used with --resume")
+		}
+		if input.fromStep != "" && input.fromJob == "" {
+			return fmt.Errorf("--from-step requires --from-job")
+		}
+		reports, err := parseReports(input.reports)
+		if err != nil {
+			return err
+		}
+		var eventWriter *runner.EventWriter
+		if input.events != "" {
+			stream, err := openEventStream(input.events)
+			if err != nil {
+				return fmt.Errorf("failed to open the event stream: %w", err)
+			}
+			defer stream.Close()
+			eventWriter = runner.NewEventWriter(stream)
+		}
+
 		planner, err := model.NewWorkflowPlanner(input.WorkflowsPath(), input.noWorkflowRecurse)
 		if err != nil {
 			return err
@@ -482,12 +598,6 @@ func newRunCommand(ctx context.Context, input *Input) func(*cobra.Command, []str
 			return plannerErr
 		}
 
-		// check to see if the main branch was defined
-		defaultbranch, err := cmd.Flags().GetString("defaultbranch")
-		if err != nil {
-			return err
-		}
-
 		// Check if platforms flag is set, if not, run default image survey
 		if len(input.platforms) == 0 {
 			cfgFound := false
@@ -521,80 +631,54 @@ func newRunCommand(ctx context.Context, input *Input) func(*cobra.Command, []str
 		}
 
 		// run the plan
-		config := &runner.Config{
-			Actor:                              input.actor,
-			EventName:                          eventName,
-			EventPath:                          input.EventPath(),
-			DefaultBranch:                      defaultbranch,
-			ForcePull:                          !input.actionOfflineMode && input.forcePull,
-			ForceRebuild:                       input.forceRebuild,
-			ReuseContainers:                    input.reuseContainers,
-			Workdir:                            input.Workdir(),
-			ActionCacheDir:                     input.actionCachePath,
-			ActionOfflineMode:                  input.actionOfflineMode,
-			BindWorkdir:                        input.bindWorkdir,
-			LogOutput:                          !input.noOutput,
-			JSONLogger:                         input.jsonLogger,
-			LogPrefixJobID:                     input.logPrefixJobID,
-			Env:                                envs,
-			Secrets:                            secrets,
-			Vars:                               vars,
-			Inputs:                             inputs,
-			Token:                              secrets["GITHUB_TOKEN"],
-			InsecureSecrets:                    input.insecureSecrets,
-			Platforms:                          input.newPlatforms(),
-			Privileged:                         input.privileged,
-			UsernsMode:                         input.usernsMode,
-			ContainerArchitecture:              input.containerArchitecture,
-			ContainerDaemonSocket:              input.containerDaemonSocket,
-			ContainerOptions:                   input.containerOptions,
-			UseGitIgnore:                       input.useGitIgnore,
-			GitHubInstance:                     input.githubInstance,
-			ContainerCapAdd:                    input.containerCapAdd,
-			ContainerCapDrop:                   input.containerCapDrop,
-			AutoRemove:                         input.autoRemove,
-			ArtifactServerPath:                 input.artifactServerPath,
-			ArtifactServerAddr:                 input.artifactServerAddr,
-			ArtifactServerPort:                 input.artifactServerPort,
-			NoSkipCheckout:                     input.noSkipCheckout,
-			RemoteName:                         input.remoteName,
-			ReplaceGheActionWithGithubCom:      input.replaceGheActionWithGithubCom,
-			ReplaceGheActionTokenWithGithubCom: input.replaceGheActionTokenWithGithubCom,
-			Matrix:                             matrixes,
-			ContainerNetworkMode:               docker_container.NetworkMode(input.networkName),
-			MaxParallel:                        input.maxParallel,
-		}
-		if input.useNewActionCache || len(input.localRepository) > 0 {
-			if input.actionOfflineMode {
-				config.ActionCache = &runner.GoGitActionCacheOfflineMode{
-					Parent: runner.GoGitActionCache{
-						Path: config.ActionCacheDir,
-					},
-				}
-			} else {
-				config.ActionCache = &runner.GoGitActionCache{
-					Path: config.ActionCacheDir,
-				}
+		if err := input.loadPlatformFiles(); err != nil {
+			return err
+		}
+		config := newRunnerConfig(input, eventName, envs, secrets, vars, inputs)
+		config.EventPath = input.EventPath()
+		config.Matrix = matrixes
+		config.MatrixIndexes = parseMatrixIndexes(input.matrixIndex)
+		config.RunState = runState
+		if input.fromJob != "" {
+			if err := plan.CheckJobID(input.fromJob); err != nil {
+				return err
 			}
-			if len(input.localRepository) > 0 {
-				localRepositories := map[string]string{}
-				for _, l := range input.localRepository {
-					k, v, _ := strings.Cut(l, "=")
-					localRepositories[k] = v
-				}
-				config.ActionCache = &runner.LocalRepositoryCache{
-					Parent:            config.ActionCache,
-					LocalRepositories: localRepositories,
-					CacheDirCache:     map[string]string{},
-				}
+		}
+		config.ResumeFromJob = input.fromJob
+		config.ResumeFromStep = input.fromStep
+		config.DebugOnFailure = input.debugOnFailure
+		config.BreakBefore = input.breakBefore
+		config.DebugPrompt = debugPrompt
+		config.Events = eventWriter
+		config.ResolveSecrets = resolveSecrets
+		if input.dryrunExplain {
+			if err := explainPlan(ctx, config, plan, "text", true); err != nil {
+				return err
 			}
+			return plannerErr
 		}
+		newRunState := func() {
+			if input.resume != "" || input.dryrun {
+				return
+			}
+			var err error
+			if config.RunState, err = runner.NewRunState(input.runStatePath, eventName); err != nil {
+				log.Warnf("Failed to record the state of the run, it can't be resumed: %v", err)
+			}
+		}
+		config.ActionCache = newActionCache(input)
 		r, err := runner.New(config)
 		if err != nil {
 			return err
 		}
 
-		cancel := artifacts.Serve(ctx, input.artifactServerPath, input.artifactServerAddr, input.artifactServerPort)
+		var onUpload artifacts.UploadListener
+		if eventWriter != nil {
+			onUpload = func(runID, name string) {
+				emitRunEvent(config, runner.Event{Type: runner.EventArtifactUploaded, Artifact: &runner.ArtifactEvent{Name: name, RunID: runID}})
+			}
+		}
+		cancel := artifacts.ServeWithListener(ctx, input.artifactServerPath, input.artifactServerAddr, input.artifactServerPort, onUpload)
 
 		const cacheURLKey = "ACTIONS_CACHE_URL"
 		var cacheHandler *artifactcache.Handler
@@ -605,32 +689,156 @@ func newRunCommand(ctx context.Context, input *Input) func(*cobra.Command, []str
 				return err
 			}
 			envs[cacheURLKey] = cacheHandler.ExternalURL() + "/"
+			if eventWriter != nil {
+				cacheHandler.OnFind(func(keys []string, version string, cache *artifactcache.Cache) {
+					event := runner.Event{Type: runner.EventCacheMiss, Cache: &runner.CacheEvent{Keys: keys, Version: version}}
+					if cache != nil {
+						event.Type = runner.EventCacheHit
+						event.Cache.Key = cache.Key
+					}
+					emitRunEvent(config, event)
+				})
+			}
 		}
 
 		ctx = common.WithDryrun(ctx, input.dryrun)
 		if watch, err := cmd.Flags().GetBool("watch"); err != nil {
 			return err
 		} else if watch {
-			err = watchAndRun(ctx, r.NewPlanExecutor(plan))
+			err = watchAndRun(ctx, input.Workdir(), input.watchInterval, func(changedFiles []string) (common.Executor, error) {
+				watchPlan := plan
+				if changedFiles != nil && input.watchPathsFilter {
+					var filterErr error
+					watchPlan = plan.FilterWorkflows(func(w *model.Workflow) bool {
+						matches, err := w.MatchesPaths(eventName, changedFiles)
+						if err != nil {
+							filterErr = fmt.Errorf("workflow '%s': %w", w.File, err)
+						}
+						return matches
+					})
+					if filterErr != nil {
+						return nil, filterErr
+					}
+					if len(watchPlan.Stages) == 0 {
+						return nil, nil
+					}
+				}
+				newRunState()
+				return newReportExecutor(config, reports, r.NewPlanExecutor(watchPlan)), nil
+			})
+			cancel()
+			_ = cacheHandler.Close()
 			if err != nil {
 				return err
 			}
 			return plannerErr
 		}
 
-		executor := r.NewPlanExecutor(plan).Finally(func(ctx context.Context) error {
+		newRunState()
+		executor := newReportExecutor(config, reports, r.NewPlanExecutor(plan)).Finally(func(ctx context.Context) error {
 			cancel()
 			_ = cacheHandler.Close()
 			return nil
 		})
 		err = executor(ctx)
 		if err != nil {
+			if config.RunState != nil {
+				log.Infof("Resume the run with `--resume %s`, optionally with `--from-job <job>` and `--from-step <step>`", config.RunState.ID)
+			}
 			return err
 		}
 		return plannerErr
 	}
 }
 
+// newRunnerConfig creates the config of the runner from the flags shared by the commands running workflows
+func newRunnerConfig(input *Input, eventName string, envs, secrets, vars, inputs map[string]string) *runner.Config {
+	return &runner.Config{
+		Actor:                              input.actor,
+		EventName:                          eventName,
+		DefaultBranch:                      input.defaultBranch,
+		ForcePull:                          !input.actionOfflineMode && input.forcePull,
+		ForceRebuild:                       input.forceRebuild,
+		ReuseContainers:                    input.reuseContainers,
+		WorkspaceSnapshot:                  input.workspaceSnapshot,
+		WarmSnapshotAfter:                  input.warmSnapshotAfter,
+		WarmSnapshotKey:                    input.warmSnapshotKey,
+		Workdir:                            input.Workdir(),
+		ActionCacheDir:                     input.actionCachePath,
+		ActionOfflineMode:                  input.actionOfflineMode,
+		BindWorkdir:                        input.bindWorkdir,
+		LogOutput:                          !input.noOutput,
+		JSONLogger:                         input.jsonLogger,
+		LogPrefixJobID:                     input.logPrefixJobID,
+		Env:                                envs,
+		Secrets:                            secrets,
+		Vars:                               vars,
+		Inputs:                             inputs,
+		Token:                              secrets["GITHUB_TOKEN"],
+		InsecureSecrets:                    input.insecureSecrets,
+		Platforms:                          input.newPlatforms(),
+		Privileged:                         input.privileged,
+		UsernsMode:                         input.usernsMode,
+		ContainerArchitecture:              input.containerArchitecture,
+		ContainerDaemonSocket:              input.containerDaemonSocket,
+		DockerIsolation:                    input.dockerIsolation,
+		DindImage:                          input.dindImage,
+		DindCacheVolume:                    input.dindCacheVolume,
+		ContainerEngine:                    input.containerEngine,
+		SandboxNetwork:                     input.sandboxNetwork,
+		ContainerOptions:                   input.containerOptions,
+		RunnerProfiles:                     input.runnerProfiles,
+		PlatformRegistry:                   input.platformRegistry,
+		ImageDigests:                       input.imageDigests,
+		UseGitIgnore:                       input.useGitIgnore,
+		GitHubInstance:                     input.githubInstance,
+		ContainerCapAdd:                    input.containerCapAdd,
+		ContainerCapDrop:                   input.containerCapDrop,
+		AutoRemove:                         input.autoRemove,
+		ArtifactServerPath:                 input.artifactServerPath,
+		ArtifactServerAddr:                 input.artifactServerAddr,
+		ArtifactServerPort:                 input.artifactServerPort,
+		NoSkipCheckout:                     input.noSkipCheckout,
+		RemoteName:                         input.remoteName,
+		ReplaceGheActionWithGithubCom:      input.replaceGheActionWithGithubCom,
+		ReplaceGheActionTokenWithGithubCom: input.replaceGheActionTokenWithGithubCom,
+		ContainerNetworkMode:               docker_container.NetworkMode(input.networkName),
+		MaxParallel:                        input.maxParallel,
+	}
+}
+
+// newActionCache returns the action cache selected by --use-new-action-cache and --local-repository, nil for the default one
+func newActionCache(input *Input) runner.ActionCache {
+	if !input.useNewActionCache && len(input.localRepository) == 0 {
+		return nil
+	}
+	var actionCache runner.ActionCache
+	if input.actionOfflineMode {
+		actionCache = &runner.GoGitActionCacheOfflineMode{
+			Parent: runner.GoGitActionCache{
+				Path: input.actionCachePath,
+			},
+		}
+	} else {
+		actionCache = &runner.GoGitActionCache{
+			Path: input.actionCachePath,
+		}
+	}
+	if len(input.localRepository) > 0 {
+		localRepositories := map[string]string{}
+		for _, l := range input.localRepository {
+			k, v, _ := strings.Cut(l, "=")
+			localRepositories[k] = v
+		}
+		actionCache = &runner.LocalRepositoryCache{
+			Parent:            actionCache,
+			LocalRepositories: localRepositories,
+			CacheDirCache:     map[string]string{},
+		}
+	}
+	return actionCache
+}
+
 func defaultImageSurvey(actrc string) error {
 	var answer string
 	confirmation := &survey.Select{
@@ -673,49 +881,3 @@ func defaultImageSurvey(actrc string) error {
 
 	return nil
 }
-
-func watchAndRun(ctx context.Context, fn common.Executor) error {
-	dir, err := os.Getwd()
-	if err != nil {
-		return err
-	}
-
-	ignoreFile := filepath.Join(dir, ".gitignore")
-	ignore := &gitignore.GitIgnore{}
-	if info, err := os.Stat(ignoreFile); err == nil && !info.IsDir() {
-		ignore, err = gitignore.CompileIgnoreFile(ignoreFile)
-		if err != nil {
-			return fmt.Errorf("compile %q: %w", ignoreFile, err)
-		}
-	}
-
-	folderWatcher := fswatch.NewFolderWatcher(
-		dir,
-		true,
-		ignore.MatchesPath,
-		2, // 2 seconds
-	)
-
-	folderWatcher.Start()
-	defer folderWatcher.Stop()
-
-	// run once before watching
-	if err := fn(ctx); err != nil {
-		return err
-	}
-
-	for folderWatcher.IsRunning() {
-		log.Debugf("Watching %s for changes", dir)
-		select {
-		case <-ctx.Done():
-			return nil
-		case changes := <-folderWatcher.ChangeDetails():
-			log.Debugf("%s", changes.String())
-			if err := fn(ctx); err != nil {
-				return err
-			}
-		}
-	}
-
-	return nil
-}
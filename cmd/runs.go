@@ -0,0 +1,316 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nektos/act/pkg/runner"
+)
+
+func newRunsCommand(ctx context.Context, input *Input) *cobra.Command {
+	runsCmd := &cobra.Command{
+		Use:   "runs",
+		Short: "List, inspect and prune the runs recorded in --run-state-path",
+	}
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the recorded runs",
+		Args:  cobra.NoArgs,
+		RunE:  newRunsListCommand(ctx, input),
+	}
+	listCmd.Flags().StringP("format", "", "table", "output format, one of 'table' or 'json'")
+
+	showCmd := &cobra.Command{
+		Use:   "show <run id>",
+		Short: "Show the plan, results, timings and outputs of the jobs and steps of a run",
+		Args:  cobra.ExactArgs(1),
+		RunE:  newRunsShowCommand(ctx, input),
+	}
+	showCmd.Flags().StringP("format", "", "table", "output format, one of 'table' or 'json'")
+
+	logsCmd := &cobra.Command{
+		Use:   "logs <run id>",
+		Short: "Print the logs of a run",
+		Args:  cobra.ExactArgs(1),
+		RunE:  newRunsLogsCommand(ctx, input),
+	}
+	logsCmd.Flags().StringP("job", "j", "", "print the logs of a job, by ID or name (e.g. build or build-2 for a matrix combination)")
+	logsCmd.Flags().StringP("step", "s", "", "print the logs of a step, by ID or name")
+	logsCmd.Flags().Bool("json", false, "print the log entries as recorded, one JSON object per line")
+
+	pruneCmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove the recorded runs but the last ones",
+		Args:  cobra.NoArgs,
+		RunE:  newRunsPruneCommand(ctx, input),
+	}
+	pruneCmd.Flags().Int("keep", 10, "number of runs to keep")
+	pruneCmd.Flags().Duration("older-than", 0, "also remove the kept runs started before this duration ago (e.g. 168h)")
+
+	runsCmd.AddCommand(listCmd, showCmd, logsCmd, pruneCmd)
+	return runsCmd
+}
+
+func runsFormat(cmd *cobra.Command) (string, error) {
+	format, err := cmd.Flags().GetString("format")
+	if err != nil {
+		return "", err
+	}
+	if format != "table" && format != "json" {
+		return "", fmt.Errorf("invalid format '%s', must be one of 'table' or 'json'", format)
+	}
+	return format, nil
+}
+
+func newRunsListCommand(_ context.Context, input *Input) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, _ []string) error {
+		format, err := runsFormat(cmd)
+		if err != nil {
+			return err
+		}
+		runs, err := runner.ListRunStates(input.runStatePath)
+		if err != nil {
+			return err
+		}
+
+		if format == "json" {
+			for i, run := range runs {
+				runs[i] = run.Redacted()
+			}
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(runs)
+		}
+		if len(runs) == 0 {
+			fmt.Printf("No runs recorded in %s\n", input.runStatePath)
+			return nil
+		}
+		lines := [][]string{{"ID", "Event", "Result", "Started", "Duration", "Commit", "Jobs"}}
+		for _, run := range runs {
+			lines = append(lines, []string{
+				run.ID,
+				run.EventName,
+				runResultString(run.Result, run.FinishedAt),
+				run.StartedAt.Local().Format(time.DateTime),
+				durationString(&run.StartedAt, run.FinishedAt),
+				shortSHA(run.SHA),
+				strconv.Itoa(len(run.Jobs)),
+			})
+		}
+		printTable(lines)
+		return nil
+	}
+}
+
+func newRunsShowCommand(_ context.Context, input *Input) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		format, err := runsFormat(cmd)
+		if err != nil {
+			return err
+		}
+		run, err := runner.LoadRunState(input.runStatePath, args[0])
+		if err != nil {
+			return err
+		}
+
+		if format == "json" {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(run.Redacted())
+		}
+
+		fmt.Printf("Run %s\n", run.ID)
+		fmt.Printf("Event:    %s\n", run.EventName)
+		if run.SHA != "" {
+			fmt.Printf("Commit:   %s %s\n", run.SHA, run.Ref)
+		}
+		fmt.Printf("Result:   %s\n", runResultString(run.Result, run.FinishedAt))
+		fmt.Printf("Started:  %s\n", run.StartedAt.Local().Format(time.DateTime))
+		fmt.Printf("Duration: %s\n", durationString(&run.StartedAt, run.FinishedAt))
+		for i, stage := range run.Plan {
+			fmt.Printf("Stage %d:  %s\n", i, strings.Join(stage, ", "))
+		}
+
+		for _, key := range runJobKeys(run) {
+			job := run.Jobs[key]
+			fmt.Println()
+			fmt.Printf("Job %s (%s) %s %s\n", job.Name, key, runResultString(job.Result, job.FinishedAt), durationString(job.StartedAt, job.FinishedAt))
+			if len(job.Steps) > 0 {
+				lines := [][]string{{"Step", "ID", "Outcome", "Conclusion", "Duration"}}
+				for i, step := range job.Steps {
+					outcome, conclusion := "", ""
+					if step.Result != nil {
+						outcome, conclusion = step.Result.Outcome.String(), step.Result.Conclusion.String()
+					}
+					lines = append(lines, []string{
+						fmt.Sprintf("%d. %s", i+1, step.Name),
+						step.ID,
+						outcome,
+						conclusion,
+						durationString(step.StartedAt, step.FinishedAt),
+					})
+				}
+				printTable(lines)
+			}
+			if len(job.Outputs) > 0 {
+				fmt.Println("Outputs:")
+				names := make([]string, 0, len(job.Outputs))
+				for name := range job.Outputs {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				for _, name := range names {
+					fmt.Printf("  %s=%s\n", name, job.Outputs[name])
+				}
+			}
+		}
+		return nil
+	}
+}
+
+func newRunsLogsCommand(_ context.Context, input *Input) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		jobID, err := cmd.Flags().GetString("job")
+		if err != nil {
+			return err
+		}
+		stepID, err := cmd.Flags().GetString("step")
+		if err != nil {
+			return err
+		}
+		asJSON, err := cmd.Flags().GetBool("json")
+		if err != nil {
+			return err
+		}
+		run, err := runner.LoadRunState(input.runStatePath, args[0])
+		if err != nil {
+			return err
+		}
+		entries, err := run.ReadLogs(jobID, stepID)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			switch {
+			case asJSON:
+				fmt.Println(string(entry.Line))
+			case entry.RawOutput:
+				fmt.Printf("[%s]   | %s\n", strings.TrimSpace(entry.Job), entry.Msg)
+			default:
+				fmt.Printf("[%s] %s\n", strings.TrimSpace(entry.Job), entry.Msg)
+			}
+		}
+		return nil
+	}
+}
+
+func newRunsPruneCommand(_ context.Context, input *Input) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, _ []string) error {
+		keep, err := cmd.Flags().GetInt("keep")
+		if err != nil {
+			return err
+		}
+		if keep < 0 {
+			return fmt.Errorf("invalid number of runs to keep '%d'", keep)
+		}
+		olderThan, err := cmd.Flags().GetDuration("older-than")
+		if err != nil {
+			return err
+		}
+		removed, err := runner.PruneRunStates(input.runStatePath, keep, olderThan)
+		for _, id := range removed {
+			fmt.Printf("Removed run %s\n", id)
+		}
+		return err
+	}
+}
+
+// runJobKeys returns the keys of the recorded jobs in the order of the plan, the jobs of called workflows after their caller
+func runJobKeys(run *runner.RunState) []string {
+	order := map[string]int{}
+	for _, stage := range run.Plan {
+		for _, id := range stage {
+			order[id] = len(order)
+		}
+	}
+	position := func(key string) int {
+		// strip the index of the matrix combination and the jobs of the called workflow
+		id, _, _ := strings.Cut(key, "/")
+		if i, ok := order[id]; ok {
+			return i
+		}
+		if i := strings.LastIndex(id, "-"); i >= 0 {
+			if i, ok := order[id[:i]]; ok {
+				return i
+			}
+		}
+		return len(order)
+	}
+
+	keys := make([]string, 0, len(run.Jobs))
+	for key := range run.Jobs {
+		keys = append(keys, key)
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		if pi, pj := position(keys[i]), position(keys[j]); pi != pj {
+			return pi < pj
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+func runResultString(result string, finishedAt *time.Time) string {
+	if result == "" && finishedAt == nil {
+		return "incomplete"
+	}
+	return result
+}
+
+func durationString(startedAt, finishedAt *time.Time) string {
+	if startedAt == nil || finishedAt == nil {
+		return "-"
+	}
+	return finishedAt.Sub(*startedAt).Round(time.Second).String()
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
+// printTable prints the lines in left aligned columns, the first line being the header
+func printTable(lines [][]string) {
+	widths := make([]int, 0)
+	for _, line := range lines {
+		for j, v := range line {
+			if j >= len(widths) {
+				widths = append(widths, 0)
+			}
+			if widths[j] < len(v) {
+				widths[j] = len(v)
+			}
+		}
+	}
+	for _, line := range lines {
+		for j, v := range line {
+			if j == len(line)-1 {
+				fmt.Println(v)
+			} else {
+				fmt.Printf("%*s", -(widths[j] + 2), v)
+			}
+		}
+	}
+}
@@ -1,12 +1,16 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strings"
 
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/term"
+
+	"github.com/nektos/act/pkg/runner"
+	"github.com/nektos/act/pkg/secretprovider"
 )
 
 type secrets map[string]string
@@ -40,3 +44,33 @@ func newSecrets(secretList []string) secrets {
 func (s secrets) AsMap() map[string]string {
 	return s
 }
+
+// resolveSecretRefs resolves the secrets referenced by URI (e.g. vault://secret/data/app#password) in place,
+// or with --lazy-secrets removes them and returns the resolver adding them to each job when it starts
+func resolveSecretRefs(ctx context.Context, input *Input, s map[string]string) (runner.SecretResolver, error) {
+	resolver := secretprovider.NewResolver()
+	refs := map[string]string{}
+	for name, value := range s {
+		if _, ok := resolver.Parse(value); ok {
+			refs[name] = value
+			delete(s, name)
+		}
+	}
+	if len(refs) == 0 {
+		return nil, nil
+	}
+
+	if input.lazySecrets {
+		return func(ctx context.Context) (map[string]string, error) {
+			return resolver.ResolveAll(ctx, refs)
+		}, nil
+	}
+	resolved, err := resolver.ResolveAll(ctx, refs)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range resolved {
+		s[name] = value
+	}
+	return nil, nil
+}
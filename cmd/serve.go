@@ -0,0 +1,689 @@
+package cmd
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/nektos/act/pkg/artifactcache"
+	"github.com/nektos/act/pkg/artifacts"
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/model"
+	"github.com/nektos/act/pkg/runner"
+)
+
+const (
+	serveRunQueued    = "queued"
+	serveRunRunning   = "running"
+	serveRunCompleted = "completed"
+	serveRunCancelled = "cancelled"
+	serveRunAborted   = "incomplete" // the run was recorded by a process that stopped before the run finished
+
+	maxFinishedRuns = 100 // the finished runs whose report is kept in memory
+)
+
+func newServeCommand(ctx context.Context, input *Input) *cobra.Command {
+	serveCmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Run act as a daemon with a local HTTP API to list the workflows, and to trigger, follow and cancel runs",
+		Long: "Run act as a daemon with a local HTTP API to list the workflows, and to trigger, follow and cancel runs.\n\n" +
+			"The artifact and cache servers are shared by the runs, and --max-parallel limits the number of runs running at once (0 = number of CPUs), queuing the others.\n\n" +
+			"Every request must have the header 'Authorization: Bearer <token>', with the token of --token, or else the token generated and written to --token-file at startup. " +
+			"The requests from a browser are only accepted from the address of the API, and the bodies must be JSON.\n\n" +
+			"  GET  /workflows               list the workflows, their events and jobs\n" +
+			"  POST /runs                    trigger a run, with a JSON body of event, workflow, job, payload, inputs, secrets and matrix\n" +
+			"  GET  /runs                    list the recorded runs\n" +
+			"  GET  /runs/:id                get the status and the results of the jobs and steps of a run\n" +
+			"  GET  /runs/:id/logs           get the log of a run as NDJSON, filtered by ?job= and ?step=, followed until the run finishes with ?follow=true\n" +
+			"  GET  /runs/:id/report         get the report of a finished run, with ?format=json (default) or ?format=junit\n" +
+			"  POST /runs/:id/cancel         cancel a queued or running run",
+		Args: cobra.NoArgs,
+		RunE: newServeRunCommand(ctx, input),
+	}
+	serveCmd.Flags().String("addr", "127.0.0.1:34568", "address the HTTP API listens on")
+	serveCmd.Flags().String("token", "", "bearer token required by the HTTP API, a random one is generated and written to --token-file if not set")
+	serveCmd.Flags().String("token-file", "", "file the generated token of the HTTP API is written to (default serve-token in --run-state-path)")
+	serveCmd.Flags().StringArrayVarP(&input.platforms, "platform", "P", []string{}, "custom image to use per platform (e.g. -P ubuntu-18.04=nektos/act-environments-ubuntu:18.04)")
+	serveCmd.Flags().StringArrayVarP(&input.secrets, "secret", "s", []string{}, "secret to make available to the actions of every run with optional value (e.g. -s mysecret=foo or -s mysecret)")
+	serveCmd.Flags().StringArrayVar(&input.vars, "var", []string{}, "variable to make available to the actions of every run with optional value (e.g. --var myvar=foo or --var myvar)")
+	serveCmd.Flags().StringArrayVarP(&input.envs, "env", "", []string{}, "env to make available to the actions of every run with optional value (e.g. --env myenv=foo or --env myenv)")
+	serveCmd.Flags().BoolVarP(&input.bindWorkdir, "bind", "b", false, "bind working directory to container, rather than copy")
+	serveCmd.Flags().BoolVarP(&input.forcePull, "pull", "p", true, "pull docker image(s) even if already present")
+	serveCmd.Flags().BoolVarP(&input.forceRebuild, "rebuild", "", true, "rebuild local action docker image(s) even if already present")
+	serveCmd.Flags().BoolVarP(&input.reuseContainers, "reuse", "r", false, "don't remove container(s) on successfully completed workflow(s) to maintain state between runs")
+	serveCmd.Flags().BoolVar(&input.workspaceSnapshot, "workspace-snapshot", false, "copy the working directory once per run to a volume shared by the jobs, whose workspaces overlay it copy-on-write, and with --reuse only copy the files changed since the last run")
+	serveCmd.Flags().BoolVar(&input.autoRemove, "rm", false, "automatically remove container(s)/volume(s) after a workflow(s) failure")
+	serveCmd.Flags().StringVar(&input.defaultBranch, "defaultbranch", "", "the name of the main branch")
+	serveCmd.Flags().BoolVar(&input.useGitIgnore, "use-gitignore", true, "Controls whether paths specified in .gitignore should be copied into container")
+	return serveCmd
+}
+
+// runRequest is the body of a request to trigger a run
+type runRequest struct {
+	Event    string              `json:"event"`              // the event triggering the run, push by default
+	Workflow string              `json:"workflow,omitempty"` // run the workflow with the given file or name only
+	Job      string              `json:"job,omitempty"`      // run the job with the given ID only, optionally qualified with the workflow file
+	Payload  json.RawMessage     `json:"payload,omitempty"`  // the event payload, overrides the inputs in the event
+	Inputs   map[string]string   `json:"inputs,omitempty"`
+	Secrets  map[string]string   `json:"secrets,omitempty"` // added to the secrets of the daemon
+	Matrix   map[string][]string `json:"matrix,omitempty"`  // the matrix values to run, each value a comma separated list like in --matrix, excluded when starting with !
+}
+
+// runStatus is the recorded state of a run, with its status in the daemon
+type runStatus struct {
+	Status string `json:"status"`
+	*runner.RunState
+}
+
+type serveWorkflow struct {
+	File   string   `json:"file"`
+	Name   string   `json:"name"`
+	Events []string `json:"events"`
+	Jobs   []string `json:"jobs"`
+}
+
+type serveRun struct {
+	id     string
+	status string
+	report *runner.Report
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+type server struct {
+	ctx     context.Context
+	input   *Input
+	envs    map[string]string
+	inputs  map[string]string
+	secrets map[string]string
+	vars    map[string]string
+	slots   chan struct{} // limits the number of runs running at once
+	token   string        // the bearer token of the requests
+	addr    *net.TCPAddr  // the address the API listens on, the only host accepted in requests
+
+	resolveSecrets runner.SecretResolver // resolves the secrets referenced by URI with --lazy-secrets
+
+	mu       sync.Mutex
+	runs     map[string]*serveRun // the runs triggered by this daemon, by ID
+	finished []string             // the IDs of the finished runs in runs, oldest first
+	wg       sync.WaitGroup
+}
+
+func newServeRunCommand(ctx context.Context, input *Input) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, _ []string) error {
+		if input.jsonLogger {
+			log.SetFormatter(&log.JSONFormatter{})
+		}
+		addr, err := cmd.Flags().GetString("addr")
+		if err != nil {
+			return err
+		}
+		token, err := cmd.Flags().GetString("token")
+		if err != nil {
+			return err
+		}
+		tokenFile, err := cmd.Flags().GetString("token-file")
+		if err != nil {
+			return err
+		}
+		if err := setupContainerDaemonSocket(input); err != nil {
+			return err
+		}
+		if err := input.loadPlatformFiles(); err != nil {
+			return err
+		}
+
+		// check the workflows once, they are loaded again for every request so that changes are picked up
+		if _, err := model.NewWorkflowPlanner(input.WorkflowsPath(), input.noWorkflowRecurse); err != nil {
+			return err
+		}
+
+		s, err := newServer(ctx, input)
+		if err != nil {
+			return err
+		}
+		cancelArtifacts := artifacts.Serve(ctx, input.artifactServerPath, input.artifactServerAddr, input.artifactServerPort)
+		defer cancelArtifacts()
+
+		const cacheURLKey = "ACTIONS_CACHE_URL"
+		if !input.noCacheServer && s.envs[cacheURLKey] == "" {
+			cacheHandler, err := artifactcache.StartHandler(input.cacheServerPath, input.cacheServerAddr, input.cacheServerPort, common.Logger(ctx))
+			if err != nil {
+				return err
+			}
+			defer cacheHandler.Close()
+			s.envs[cacheURLKey] = cacheHandler.ExternalURL() + "/"
+		}
+
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			return err
+		}
+		s.addr, _ = listener.Addr().(*net.TCPAddr)
+		if token == "" {
+			if tokenFile == "" {
+				tokenFile = filepath.Join(input.runStatePath, "serve-token")
+			}
+			if token, err = generateServeToken(tokenFile); err != nil {
+				_ = listener.Close()
+				return err
+			}
+			log.Infof("The token of the act API is in %s", tokenFile)
+		}
+		s.token = token
+		httpServer := &http.Server{
+			ReadHeaderTimeout: 2 * time.Second,
+			Handler:           s.router(),
+		}
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_ = httpServer.Shutdown(shutdownCtx)
+		}()
+
+		log.Infof("Serving the act API on http://%s, with up to %d runs at once", listener.Addr(), cap(s.slots))
+		err = httpServer.Serve(listener)
+		// the runs are cancelled with ctx, wait for them to record their state
+		s.wg.Wait()
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	}
+}
+
+func newServer(ctx context.Context, input *Input) (*server, error) {
+	envs := parseEnvs(input.envs)
+	_ = readEnvs(input.Envfile(), envs)
+	inputs := map[string]string{}
+	_ = readEnvs(input.Inputfile(), inputs)
+	secrets := newSecrets(input.secrets)
+	_ = readEnvs(input.Secretfile(), secrets)
+	resolveSecrets, err := resolveSecretRefs(ctx, input, secrets)
+	if err != nil {
+		return nil, err
+	}
+	vars := newSecrets(input.vars)
+	_ = readEnvs(input.Varfile(), vars)
+
+	parallel := input.maxParallel
+	if parallel <= 0 {
+		parallel = runtime.NumCPU()
+	}
+	return &server{
+		ctx:     ctx,
+		input:   input,
+		envs:    envs,
+		inputs:  inputs,
+		secrets: secrets,
+		vars:    vars,
+		slots:   make(chan struct{}, parallel),
+		runs:    map[string]*serveRun{},
+
+		resolveSecrets: resolveSecrets,
+	}, nil
+}
+
+// generateServeToken writes a random token to a file only readable by the user
+func generateServeToken(file string) (string, error) {
+	token := rand.Text()
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(file, []byte(token+"\n"), 0o600); err != nil {
+		return "", fmt.Errorf("failed to write the token of the API: %w", err)
+	}
+	// the permissions of an existing file aren't changed by the write
+	if err := os.Chmod(file, 0o600); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// allowedHost returns whether host, the host and port of the Host or Origin header of a request, is the address the API listens on,
+// so that the pages of other sites can't reach it through a domain resolving to it
+func (s *server) allowedHost(host string) bool {
+	if s.addr == nil {
+		return false
+	}
+	hostname, port, err := net.SplitHostPort(host)
+	if err != nil || port != strconv.Itoa(s.addr.Port) {
+		return false
+	}
+	switch {
+	case s.addr.IP.IsUnspecified():
+		return true
+	case s.addr.IP.IsLoopback() && hostname == "localhost":
+		return true
+	}
+	ip := net.ParseIP(hostname)
+	return ip != nil && ip.Equal(s.addr.IP)
+}
+
+// authorize only passes the requests with the bearer token, to the address the API listens on
+func (s *server) authorize(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !s.allowedHost(req.Host) {
+			writeError(w, http.StatusForbidden, fmt.Errorf("invalid host '%s'", req.Host))
+			return
+		}
+		if origin := req.Header.Get("Origin"); origin != "" {
+			if u, err := url.Parse(origin); err != nil || !s.allowedHost(u.Host) {
+				writeError(w, http.StatusForbidden, fmt.Errorf("invalid origin '%s'", origin))
+				return
+			}
+		}
+		token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, errors.New("missing or invalid bearer token"))
+			return
+		}
+		handler.ServeHTTP(w, req)
+	})
+}
+
+func (s *server) router() http.Handler {
+	router := httprouter.New()
+	router.GET("/workflows", s.listWorkflows)
+	router.GET("/runs", s.listRuns)
+	router.POST("/runs", s.triggerRun)
+	router.GET("/runs/:id", s.getRun)
+	router.GET("/runs/:id/logs", s.getRunLogs)
+	router.GET("/runs/:id/report", s.getRunReport)
+	router.POST("/runs/:id/cancel", s.cancelRun)
+	return s.authorize(router)
+}
+
+func writeJSON(w http.ResponseWriter, code int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
+
+func (s *server) listWorkflows(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	planner, err := model.NewWorkflowPlanner(s.input.WorkflowsPath(), s.input.noWorkflowRecurse)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	plan, err := planner.PlanAll()
+	if plan == nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	seen := map[*model.Workflow]bool{}
+	workflows := make([]serveWorkflow, 0)
+	for _, stage := range plan.Stages {
+		for _, run := range stage.Runs {
+			if seen[run.Workflow] {
+				continue
+			}
+			seen[run.Workflow] = true
+			jobs := make([]string, 0, len(run.Workflow.Jobs))
+			for id := range run.Workflow.Jobs {
+				jobs = append(jobs, id)
+			}
+			sort.Strings(jobs)
+			workflows = append(workflows, serveWorkflow{
+				File:   run.Workflow.File,
+				Name:   run.Workflow.Name,
+				Events: run.Workflow.On(),
+				Jobs:   jobs,
+			})
+		}
+	}
+	sort.Slice(workflows, func(i, j int) bool {
+		return workflows[i].File < workflows[j].File
+	})
+	writeJSON(w, http.StatusOK, workflows)
+}
+
+// status returns the status of a recorded run
+func (s *server) status(state *runner.RunState) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if run, ok := s.runs[state.ID]; ok {
+		return run.status
+	}
+	switch {
+	case state.Result == "cancelled":
+		return serveRunCancelled
+	case state.FinishedAt != nil:
+		return serveRunCompleted
+	default:
+		return serveRunAborted
+	}
+}
+
+func (s *server) setStatus(run *serveRun, status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	run.status = status
+}
+
+// finishRun sets the final status of a run and evicts the oldest finished runs beyond maxFinishedRuns,
+// their status is then read from the recorded run state
+func (s *server) finishRun(run *serveRun, status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	run.status = status
+	s.finished = append(s.finished, run.id)
+	for len(s.finished) > maxFinishedRuns {
+		delete(s.runs, s.finished[0])
+		s.finished = s.finished[1:]
+	}
+}
+
+// run returns the run triggered by this daemon, nil if it has been recorded by another process
+func (s *server) run(id string) *serveRun {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.runs[id]
+}
+
+func (s *server) listRuns(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	states, err := runner.ListRunStates(s.input.runStatePath)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	runs := make([]runStatus, 0, len(states))
+	for _, state := range states {
+		runs = append(runs, runStatus{Status: s.status(state), RunState: state.Redacted()})
+	}
+	writeJSON(w, http.StatusOK, runs)
+}
+
+func (s *server) loadRun(w http.ResponseWriter, params httprouter.Params) *runner.RunState {
+	state, err := runner.LoadRunState(s.input.runStatePath, params.ByName("id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return nil
+	}
+	return state
+}
+
+func (s *server) getRun(w http.ResponseWriter, _ *http.Request, params httprouter.Params) {
+	state := s.loadRun(w, params)
+	if state == nil {
+		return
+	}
+	writeJSON(w, http.StatusOK, runStatus{Status: s.status(state), RunState: state.Redacted()})
+}
+
+func (s *server) getRunLogs(w http.ResponseWriter, req *http.Request, params httprouter.Params) {
+	state := s.loadRun(w, params)
+	if state == nil {
+		return
+	}
+	query := req.URL.Query()
+	job, step := query.Get("job"), query.Get("step")
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	flusher, _ := w.(http.Flusher)
+	write := func(entry *runner.RunLogEntry) error {
+		if _, err := w.Write(append(entry.Line, '\n')); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	}
+
+	if follow := query.Get("follow"); follow != "true" && follow != "1" {
+		entries, err := state.ReadLogs(job, step)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		for _, entry := range entries {
+			if write(entry) != nil {
+				return
+			}
+		}
+		return
+	}
+
+	finished := func() bool { return true }
+	if run := s.run(state.ID); run != nil {
+		finished = func() bool {
+			select {
+			case <-run.done:
+				return true
+			default:
+				return false
+			}
+		}
+	}
+	if err := state.FollowLogs(req.Context(), job, step, finished, write); err != nil && req.Context().Err() == nil {
+		log.Warnf("Failed to follow the log of run %s: %v", state.ID, err)
+	}
+}
+
+func (s *server) getRunReport(w http.ResponseWriter, req *http.Request, params httprouter.Params) {
+	id := params.ByName("id")
+	run := s.run(id)
+	if run == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("run '%s' has not been triggered by this daemon or is no longer kept", id))
+		return
+	}
+	select {
+	case <-run.done:
+	default:
+		writeError(w, http.StatusConflict, fmt.Errorf("run '%s' has not finished", id))
+		return
+	}
+
+	switch format := req.URL.Query().Get("format"); format {
+	case "", "json":
+		w.Header().Set("Content-Type", "application/json")
+		_ = run.report.WriteJSON(w)
+	case "junit":
+		w.Header().Set("Content-Type", "application/xml")
+		_ = run.report.WriteJUnit(w)
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid report format '%s', must be one of 'junit' or 'json'", format))
+	}
+}
+
+func (s *server) cancelRun(w http.ResponseWriter, _ *http.Request, params httprouter.Params) {
+	id := params.ByName("id")
+	run := s.run(id)
+	if run == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("run '%s' has not been triggered by this daemon or is no longer kept", id))
+		return
+	}
+	select {
+	case <-run.done:
+		writeError(w, http.StatusConflict, fmt.Errorf("run '%s' has already finished", id))
+		return
+	default:
+	}
+	run.cancel()
+	writeJSON(w, http.StatusAccepted, map[string]string{"id": id})
+}
+
+func (s *server) triggerRun(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	if mediaType, _, err := mime.ParseMediaType(req.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+		writeError(w, http.StatusUnsupportedMediaType, errors.New("the run request must be application/json"))
+		return
+	}
+	request := &runRequest{}
+	if err := json.NewDecoder(req.Body).Decode(request); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid run request: %w", err))
+		return
+	}
+	if request.Event == "" {
+		request.Event = "push"
+	}
+
+	matrix, err := matrixSelector(request.Matrix)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	plan, err := s.plan(request)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	run, err := s.startRun(request, plan, matrix)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]string{"id": run.id})
+}
+
+// plan plans the jobs of the requested run
+func (s *server) plan(request *runRequest) (*model.Plan, error) {
+	planner, err := model.NewWorkflowPlanner(s.input.WorkflowsPath(), s.input.noWorkflowRecurse)
+	if err != nil {
+		return nil, err
+	}
+	var plan *model.Plan
+	if request.Job != "" {
+		plan, err = planner.PlanJob(request.Job)
+	} else {
+		plan, err = planner.PlanEvent(request.Event)
+	}
+	if plan == nil {
+		return nil, err
+	}
+	if request.Workflow != "" {
+		plan = plan.FilterWorkflows(func(w *model.Workflow) bool {
+			return w.File == request.Workflow || w.Name == request.Workflow
+		})
+	}
+	if len(plan.Stages) == 0 {
+		if err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("no jobs to run for event '%s'", request.Event)
+	}
+	return plan, nil
+}
+
+// matrixSelector converts the matrix of a run request to the matrix config of the runner,
+// each value is parsed like the value of a --matrix entry of the key
+func matrixSelector(matrix map[string][]string) (map[string]map[string]bool, error) {
+	if len(matrix) == 0 {
+		return nil, nil
+	}
+	entries := make([]string, 0, len(matrix))
+	for key, values := range matrix {
+		if key == "" || strings.Contains(key, ":") {
+			return nil, fmt.Errorf("invalid matrix key '%s'", key)
+		}
+		for _, value := range values {
+			entries = append(entries, key+":"+value)
+		}
+	}
+	return parseMatrixEntries(entries)
+}
+
+// mergeMaps returns a copy of base with the values of overrides
+func mergeMaps(base, overrides map[string]string) map[string]string {
+	merged := make(map[string]string, len(base)+len(overrides))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range overrides {
+		merged[k] = v
+	}
+	return merged
+}
+
+// startRun records a new run and queues it until one of the slots is free
+func (s *server) startRun(request *runRequest, plan *model.Plan, matrix map[string]map[string]bool) (*serveRun, error) {
+	secrets := make(map[string]string, len(request.Secrets))
+	for k, v := range request.Secrets {
+		// secrets are case insensitive
+		secrets[strings.ToUpper(k)] = v
+	}
+
+	config := newRunnerConfig(s.input, request.Event, mergeMaps(s.envs, nil), mergeMaps(s.secrets, secrets), mergeMaps(s.vars, nil), mergeMaps(s.inputs, request.Inputs))
+	config.EventJSON = string(request.Payload)
+	config.Matrix = matrix
+	config.ActionCache = newActionCache(s.input)
+	config.Report = runner.NewReport()
+	config.ResolveSecrets = s.resolveSecrets
+	r, err := runner.New(config)
+	if err != nil {
+		return nil, err
+	}
+	if config.RunState, err = runner.NewRunState(s.input.runStatePath, request.Event); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	run := &serveRun{
+		id:     config.RunState.ID,
+		status: serveRunQueued,
+		report: config.Report,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	s.mu.Lock()
+	s.runs[run.id] = run
+	s.mu.Unlock()
+	log.Infof("Queued run %s of event '%s'", run.id, request.Event)
+
+	executor := r.NewPlanExecutor(plan)
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer close(run.done)
+		defer cancel()
+
+		select {
+		case s.slots <- struct{}{}:
+			defer func() { <-s.slots }()
+		case <-ctx.Done():
+		}
+		if ctx.Err() == nil {
+			s.setStatus(run, serveRunRunning)
+			log.Infof("Running run %s", run.id)
+			if err := executor(common.WithDryrun(ctx, s.input.dryrun)); err != nil {
+				log.Errorf("Run %s failed: %v", run.id, err)
+			}
+		}
+
+		if ctx.Err() != nil {
+			if err := config.RunState.Cancel(); err != nil {
+				log.Warnf("Failed to record the state of run %s: %v", run.id, err)
+			}
+			s.finishRun(run, serveRunCancelled)
+			return
+		}
+		s.finishRun(run, serveRunCompleted)
+	}()
+	return run, nil
+}
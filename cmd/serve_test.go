@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServeAuthorize(t *testing.T) {
+	s := &server{
+		token: "s3cr3t",
+		addr:  &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 34568},
+	}
+	handler := s.authorize(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	for _, tt := range []struct {
+		name          string
+		host          string
+		origin        string
+		authorization string
+		code          int
+	}{
+		{"authorized", "127.0.0.1:34568", "", "Bearer s3cr3t", http.StatusNoContent},
+		{"localhost", "localhost:34568", "http://localhost:34568", "Bearer s3cr3t", http.StatusNoContent},
+		{"no token", "127.0.0.1:34568", "", "", http.StatusUnauthorized},
+		{"wrong token", "127.0.0.1:34568", "", "Bearer other", http.StatusUnauthorized},
+		{"basic auth", "127.0.0.1:34568", "", "Basic s3cr3t", http.StatusUnauthorized},
+		{"rebound host", "attacker.example:34568", "", "Bearer s3cr3t", http.StatusForbidden},
+		{"other port", "127.0.0.1:80", "", "Bearer s3cr3t", http.StatusForbidden},
+		{"other origin", "127.0.0.1:34568", "https://attacker.example", "Bearer s3cr3t", http.StatusForbidden},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/runs", nil)
+			req.Host = tt.host
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			assert.Equal(t, tt.code, rec.Code)
+		})
+	}
+
+	// any host is accepted on the port when listening on all the addresses, the token still being required
+	s.addr = &net.TCPAddr{IP: net.IPv4zero, Port: 34568}
+	assert.True(t, s.allowedHost("build-server:34568"))
+	assert.False(t, s.allowedHost("build-server:80"))
+}
+
+func TestServeTriggerRunContentType(t *testing.T) {
+	s := &server{}
+	req := httptest.NewRequest(http.MethodPost, "/runs", strings.NewReader(`{"event":"push"}`))
+	req.Header.Set("Content-Type", "text/plain")
+	rec := httptest.NewRecorder()
+	s.triggerRun(rec, req, nil)
+	assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+}
+
+func TestGenerateServeToken(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "runs", "serve-token")
+	token, err := generateServeToken(file)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, token)
+	content, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	assert.Equal(t, token+"\n", string(content))
+	info, err := os.Stat(file)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	other, err := generateServeToken(file)
+	assert.NoError(t, err)
+	assert.NotEqual(t, token, other)
+}
+
+func TestServeMatrixSelector(t *testing.T) {
+	selector, err := matrixSelector(map[string][]string{
+		"os":   {"ubuntu-*,windows-latest"},
+		"node": {"!18,20"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]map[string]bool{
+		"os":   {"ubuntu-*": true, "windows-latest": true},
+		"node": {"18": false, "20": false},
+	}, selector)
+
+	selector, err = matrixSelector(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, selector)
+
+	_, err = matrixSelector(map[string][]string{"os:arch": {"x64"}})
+	assert.Error(t, err)
+
+	s := &server{}
+	req := httptest.NewRequest(http.MethodPost, "/runs", strings.NewReader(`{"event":"push","matrix":{"":["x64"]}}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	s.triggerRun(rec, req, nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestServeFinishRunEvictsOldestRuns(t *testing.T) {
+	s := &server{runs: map[string]*serveRun{}}
+	runs := make([]*serveRun, maxFinishedRuns+2)
+	for i := range runs {
+		runs[i] = &serveRun{id: strconv.Itoa(i), status: serveRunRunning}
+		s.runs[runs[i].id] = runs[i]
+	}
+	running := runs[len(runs)-1]
+	for _, run := range runs[:len(runs)-1] {
+		s.finishRun(run, serveRunCompleted)
+	}
+	assert.Nil(t, s.run("0"))
+	assert.Equal(t, serveRunCompleted, s.run("1").status)
+	assert.Same(t, running, s.run(running.id))
+	assert.Len(t, s.runs, maxFinishedRuns+1)
+}
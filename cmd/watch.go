@@ -0,0 +1,215 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/andreaskoch/go-fswatch"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/nektos/act/pkg/common"
+)
+
+// ignoreFiles are read in every directory of the watched folder, .actignore excludes files from watching only
+var ignoreFiles = []string{".gitignore", ".actignore"}
+
+// watchAndRun runs the executor returned by newExecutor, and again with the changed files every time files in dir change.
+// A change cancels the run in progress, and a failed run is reported without stopping to watch.
+// newExecutor may return a nil executor when nothing needs to run for the changed files.
+func watchAndRun(ctx context.Context, dir string, interval int, newExecutor func(changedFiles []string) (common.Executor, error)) error {
+	ignorer := &atomic.Pointer[gitignore.Matcher]{}
+	if err := loadWatchIgnorer(dir, ignorer); err != nil {
+		return err
+	}
+
+	folderWatcher := fswatch.NewFolderWatcher(
+		dir,
+		true,
+		func(path string) bool {
+			return isIgnoredByWatch(dir, *ignorer.Load(), path)
+		},
+		interval,
+	)
+
+	folderWatcher.Start()
+	defer folderWatcher.Stop()
+
+	var cancelRun context.CancelCauseFunc
+	var runDone chan struct{}
+	stopRun := func() {
+		if cancelRun != nil {
+			// the jobs of a superseded run clean up their containers even though they failed
+			cancelRun(common.ErrRunSuperseded)
+			<-runDone
+			cancelRun = nil
+		}
+	}
+	defer stopRun()
+
+	startRun := func(changedFiles []string) {
+		executor, err := newExecutor(changedFiles)
+		if err != nil {
+			log.Errorf("Failed to plan the run: %v", err)
+			return
+		}
+		if executor == nil {
+			log.Infof("No workflow is triggered by the changed files")
+			return
+		}
+
+		var runCtx context.Context
+		runCtx, cancelRun = context.WithCancelCause(ctx)
+		runDone = make(chan struct{})
+		go func(done chan struct{}) {
+			defer close(done)
+			err := executor(runCtx)
+			switch {
+			case runCtx.Err() != nil && ctx.Err() == nil:
+				log.Infof("Cancelled the run because of new changes")
+			case err != nil:
+				log.Errorf("Run failed: %v", err)
+			default:
+				log.Infof("Run succeeded")
+			}
+			if ctx.Err() == nil {
+				log.Infof("Watching %s for changes", dir)
+			}
+		}(runDone)
+	}
+
+	// run once before watching
+	startRun(nil)
+
+	for folderWatcher.IsRunning() {
+		select {
+		case <-ctx.Done():
+			return nil
+		case changes := <-folderWatcher.ChangeDetails():
+			changedFiles := watchChangedFiles(dir, changes.New(), changes.Modified(), changes.Moved())
+			if len(changedFiles) == 0 {
+				continue
+			}
+			log.Infof("Detected changes in %s", strings.Join(changedFiles, ", "))
+			for _, file := range changedFiles {
+				for _, name := range ignoreFiles {
+					if filepath.Base(file) == name {
+						if err := loadWatchIgnorer(dir, ignorer); err != nil {
+							log.Errorf("Failed to reload %s: %v", file, err)
+						}
+					}
+				}
+			}
+			stopRun()
+			startRun(changedFiles)
+		}
+	}
+
+	return nil
+}
+
+// watchChangedFiles returns the sorted paths, relative to dir and separated by slashes, of new, modified and removed files
+func watchChangedFiles(dir string, changes ...[]string) []string {
+	seen := map[string]bool{}
+	files := make([]string, 0)
+	for _, items := range changes {
+		for _, item := range items {
+			rel, err := filepath.Rel(dir, item)
+			if err != nil {
+				continue
+			}
+			rel = filepath.ToSlash(rel)
+			if !seen[rel] {
+				seen[rel] = true
+				files = append(files, rel)
+			}
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+func isIgnoredByWatch(dir string, ignorer gitignore.Matcher, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	if parts[0] == ".git" {
+		return true
+	}
+	return ignorer.Match(parts, false)
+}
+
+func loadWatchIgnorer(dir string, ignorer *atomic.Pointer[gitignore.Matcher]) error {
+	ps, err := readWatchIgnorePatterns(dir, nil, nil)
+	if err != nil {
+		return err
+	}
+	matcher := gitignore.NewMatcher(ps)
+	ignorer.Store(&matcher)
+	return nil
+}
+
+// readWatchIgnorePatterns reads the patterns of the ignore files in the directory at domain and its sub directories,
+// the directories ignored by the patterns of their parents aren't read
+func readWatchIgnorePatterns(dir string, domain []string, parents []gitignore.Pattern) ([]gitignore.Pattern, error) {
+	path := filepath.Join(append([]string{dir}, domain...)...)
+
+	var ps []gitignore.Pattern
+	for _, name := range ignoreFiles {
+		filePatterns, err := readIgnoreFile(filepath.Join(path, name), domain)
+		if err != nil {
+			return nil, err
+		}
+		ps = append(ps, filePatterns...)
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	matcher := gitignore.NewMatcher(append(append([]gitignore.Pattern{}, parents...), ps...))
+	for _, entry := range entries {
+		if !entry.IsDir() || entry.Name() == ".git" {
+			continue
+		}
+		subdomain := append(append([]string{}, domain...), entry.Name())
+		if matcher.Match(subdomain, true) {
+			continue
+		}
+		subPatterns, err := readWatchIgnorePatterns(dir, subdomain, append(append([]gitignore.Pattern{}, parents...), ps...))
+		if err != nil {
+			return nil, err
+		}
+		ps = append(ps, subPatterns...)
+	}
+	return ps, nil
+}
+
+func readIgnoreFile(path string, domain []string) ([]gitignore.Pattern, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var ps []gitignore.Pattern
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.HasPrefix(line, "#") || len(strings.TrimSpace(line)) == 0 {
+			continue
+		}
+		ps = append(ps, gitignore.ParsePattern(line, domain))
+	}
+	return ps, scanner.Err()
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsIgnoredByWatch(t *testing.T) {
+	dir := t.TempDir()
+	for path, content := range map[string]string{
+		".gitignore":               "*.log\n/build/\n# comment\n\nnode_modules\n",
+		".actignore":               "docs/\n!important.log\n",
+		"src/.gitignore":           "generated/\n*.tmp\n",
+		"src/sub/.actignore":       "fixtures\n",
+		"build/.gitignore":         "!keep.txt\n",
+		"vendor/lib/.actignore":    "*.go\n",
+		"src/main.go":              "",
+		"src/generated/code.go":    "",
+		"src/sub/fixtures/data.go": "",
+		"build/keep.txt":           "",
+		"vendor/lib/lib.go":        "",
+	} {
+		path = filepath.Join(dir, filepath.FromSlash(path))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+	ignorer := &atomic.Pointer[gitignore.Matcher]{}
+	assert.NoError(t, loadWatchIgnorer(dir, ignorer))
+
+	for _, tt := range []struct {
+		path    string
+		ignored bool
+	}{
+		{"src/main.go", false},
+		{"README.md", false},
+		{".git/index", true},
+		{".git", true},
+		{"app.log", true},
+		{"src/debug.log", true},
+		{"important.log", false},
+		{"node_modules/pkg/index.js", true},
+		{"src/node_modules", true},
+		{"docs/index.md", true},
+		{"src/docs/index.md", true},
+		{"build/out.bin", true},
+		{"src/build/out.bin", false},
+		// the patterns of a directory only apply below it
+		{"src/generated/code.go", true},
+		{"generated/code.go", false},
+		{"src/file.tmp", true},
+		{"file.tmp", false},
+		{"src/sub/fixtures/data.go", true},
+		{"src/fixtures/data.go", false},
+		{"vendor/lib/lib.go", true},
+		{"vendor/lib/README.md", false},
+		// the ignore files of ignored directories aren't read
+		{"build/keep.txt", true},
+	} {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.ignored, isIgnoredByWatch(dir, *ignorer.Load(), filepath.Join(dir, filepath.FromSlash(tt.path))))
+		})
+	}
+}
+
+func TestWatchChangedFiles(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "repo")
+	files := watchChangedFiles(dir,
+		[]string{filepath.Join(dir, "src", "new.go")},
+		[]string{filepath.Join(dir, "README.md"), filepath.Join(dir, "src", "new.go")},
+		[]string{filepath.Join(dir, "old.txt")},
+	)
+	assert.Equal(t, []string{"README.md", "old.txt", "src/new.go"}, files)
+	assert.Empty(t, watchChangedFiles(dir))
+}
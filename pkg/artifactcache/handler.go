@@ -38,6 +38,8 @@ type Handler struct {
 	gcing atomic.Bool
 	gcAt  time.Time
 
+	onFind atomic.Pointer[FindListener]
+
 	outboundIP string
 }
 
@@ -110,6 +112,20 @@ func StartHandler(dir, outboundIP string, port uint16, logger logrus.FieldLogger
 	return h, nil
 }
 
+// FindListener is called when a cache has been looked up, with the cache that was found or nil on a miss
+type FindListener func(keys []string, version string, cache *Cache)
+
+// OnFind sets the listener called on every lookup of a cache
+func (h *Handler) OnFind(listener FindListener) {
+	h.onFind.Store(&listener)
+}
+
+func (h *Handler) notifyFind(keys []string, version string, cache *Cache) {
+	if listener := h.onFind.Load(); listener != nil && *listener != nil {
+		(*listener)(keys, version, cache)
+	}
+}
+
 func (h *Handler) ExternalURL() string {
 	// TODO: make the external url configurable if necessary
 	return fmt.Sprintf("http://%s:%d",
@@ -176,6 +192,7 @@ func (h *Handler) find(w http.ResponseWriter, r *http.Request, _ httprouter.Para
 		return
 	}
 	if cache == nil {
+		h.notifyFind(keys, version, nil)
 		h.responseJSON(w, r, 204)
 		return
 	}
@@ -185,9 +202,11 @@ func (h *Handler) find(w http.ResponseWriter, r *http.Request, _ httprouter.Para
 		return
 	} else if !ok {
 		_ = db.Delete(cache.ID, cache)
+		h.notifyFind(keys, version, nil)
 		h.responseJSON(w, r, 204)
 		return
 	}
+	h.notifyFind(keys, version, cache)
 	h.responseJSON(w, r, 200, map[string]any{
 		"result":          "hit",
 		"archiveLocation": fmt.Sprintf("%s%s/artifacts/%d", h.ExternalURL(), urlBase, cache.ID),
@@ -54,6 +54,22 @@ func TestHandler(t *testing.T) {
 		require.Equal(t, 204, resp.StatusCode)
 	})
 
+	t.Run("find listener", func(t *testing.T) {
+		key := strings.ToLower(t.Name())
+		version := "c19da02a2bd7e77277f1ac29ab45c09b7d46a4ee758284e26bb3045ad11d9d20"
+		var found []string
+		handler.OnFind(func(keys []string, v string, cache *Cache) {
+			assert.Equal(t, version, v)
+			assert.Nil(t, cache)
+			found = keys
+		})
+		defer handler.OnFind(nil)
+		resp, err := http.Get(fmt.Sprintf("%s/cache?keys=%s&version=%s", base, key, version))
+		require.NoError(t, err)
+		require.Equal(t, 204, resp.StatusCode)
+		assert.Equal(t, []string{key}, found)
+	})
+
 	t.Run("reserve and upload", func(t *testing.T) {
 		key := strings.ToLower(t.Name())
 		version := "c19da02a2bd7e77277f1ac29ab45c09b7d46a4ee758284e26bb3045ad11d9d20"
@@ -92,7 +92,10 @@ func safeResolve(baseDir string, relPath string) string {
 	return filepath.Join(baseDir, filepath.Clean(filepath.Join(string(os.PathSeparator), relPath)))
 }
 
-func uploads(router *httprouter.Router, baseDir string, fsys WriteFS) {
+// UploadListener is called when the artifact name of the workflow run runID has been uploaded
+type UploadListener func(runID, name string)
+
+func uploads(router *httprouter.Router, baseDir string, fsys WriteFS, onUpload UploadListener) {
 	router.POST("/_apis/pipelines/workflows/:runId/artifacts", func(w http.ResponseWriter, req *http.Request, params httprouter.Params) {
 		runID := params.ByName("runId")
 
@@ -161,6 +164,11 @@ func uploads(router *httprouter.Router, baseDir string, fsys WriteFS) {
 	})
 
 	router.PATCH("/_apis/pipelines/workflows/:runId/artifacts", func(w http.ResponseWriter, req *http.Request, params httprouter.Params) {
+		// the upload of an artifact is finalized by updating its size
+		if name := req.URL.Query().Get("artifactName"); onUpload != nil && name != "" {
+			onUpload(params.ByName("runId"), name)
+		}
+
 		json, err := json.Marshal(ResponseMessage{
 			Message: "success",
 		})
@@ -276,6 +284,11 @@ func downloads(router *httprouter.Router, baseDir string, fsys fs.FS) {
 }
 
 func Serve(ctx context.Context, artifactPath string, addr string, port string) context.CancelFunc {
+	return ServeWithListener(ctx, artifactPath, addr, port, nil)
+}
+
+// ServeWithListener serves the artifacts like Serve, and calls onUpload when an artifact has been uploaded
+func ServeWithListener(ctx context.Context, artifactPath string, addr string, port string, onUpload UploadListener) context.CancelFunc {
 	serverContext, cancel := context.WithCancel(ctx)
 	logger := common.Logger(serverContext)
 
@@ -287,7 +300,7 @@ func Serve(ctx context.Context, artifactPath string, addr string, port string) c
 
 	logger.Debugf("Artifacts base path '%s'", artifactPath)
 	fsys := readWriteFSImpl{}
-	uploads(router, artifactPath, fsys)
+	uploads(router, artifactPath, fsys, onUpload)
 	downloads(router, artifactPath, fsys)
 
 	server := &http.Server{
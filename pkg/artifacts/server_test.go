@@ -66,7 +66,7 @@ func TestNewArtifactUploadPrepare(t *testing.T) {
 	var memfs = fstest.MapFS(map[string]*fstest.MapFile{})
 
 	router := httprouter.New()
-	uploads(router, "artifact/server/path", writeMapFS{memfs})
+	uploads(router, "artifact/server/path", writeMapFS{memfs}, nil)
 
 	req, _ := http.NewRequest("POST", "http://localhost/_apis/pipelines/workflows/1/artifacts", nil)
 	rr := httptest.NewRecorder()
@@ -92,7 +92,7 @@ func TestArtifactUploadBlob(t *testing.T) {
 	var memfs = fstest.MapFS(map[string]*fstest.MapFile{})
 
 	router := httprouter.New()
-	uploads(router, "artifact/server/path", writeMapFS{memfs})
+	uploads(router, "artifact/server/path", writeMapFS{memfs}, nil)
 
 	req, _ := http.NewRequest("PUT", "http://localhost/upload/1?itemPath=some/file", strings.NewReader("content"))
 	rr := httptest.NewRecorder()
@@ -119,7 +119,7 @@ func TestFinalizeArtifactUpload(t *testing.T) {
 	var memfs = fstest.MapFS(map[string]*fstest.MapFile{})
 
 	router := httprouter.New()
-	uploads(router, "artifact/server/path", writeMapFS{memfs})
+	uploads(router, "artifact/server/path", writeMapFS{memfs}, nil)
 
 	req, _ := http.NewRequest("PATCH", "http://localhost/_apis/pipelines/workflows/1/artifacts", nil)
 	rr := httptest.NewRecorder()
@@ -139,6 +139,26 @@ func TestFinalizeArtifactUpload(t *testing.T) {
 	assert.Equal("success", response.Message)
 }
 
+func TestFinalizeArtifactUploadListener(t *testing.T) {
+	assert := assert.New(t)
+
+	var memfs = fstest.MapFS(map[string]*fstest.MapFile{})
+
+	uploaded := []string{}
+	router := httprouter.New()
+	uploads(router, "artifact/server/path", writeMapFS{memfs}, func(runID, name string) {
+		uploaded = append(uploaded, runID+"/"+name)
+	})
+
+	req, _ := http.NewRequest("PATCH", "http://localhost/_apis/pipelines/workflows/1/artifacts?artifactName=my-artifact", nil)
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(http.StatusOK, rr.Code)
+	assert.Equal([]string{"1/my-artifact"}, uploaded)
+}
+
 func TestListArtifacts(t *testing.T) {
 	assert := assert.New(t)
 
@@ -373,7 +393,7 @@ func TestArtifactUploadBlobUnsafePath(t *testing.T) {
 	var memfs = fstest.MapFS(map[string]*fstest.MapFile{})
 
 	router := httprouter.New()
-	uploads(router, "artifact/server/path", writeMapFS{memfs})
+	uploads(router, "artifact/server/path", writeMapFS{memfs}, nil)
 
 	req, _ := http.NewRequest("PUT", "http://localhost/upload/1?itemPath=../../some/file", strings.NewReader("content"))
 	rr := httptest.NewRecorder()
@@ -1,12 +1,35 @@
 package common
 
+import "sort"
+
 // CartesianProduct takes map of lists and returns list of unique tuples
 func CartesianProduct(mapOfLists map[string][]interface{}) []map[string]interface{} {
-	listNames := make([]string, 0)
-	lists := make([][]interface{}, 0)
-	for k, v := range mapOfLists {
-		listNames = append(listNames, k)
-		lists = append(lists, v)
+	return OrderedCartesianProduct(nil, mapOfLists)
+}
+
+// OrderedCartesianProduct is like CartesianProduct, but the tuples are ordered with the first of keys varying slowest.
+// Lists missing from keys are ordered by name after them.
+func OrderedCartesianProduct(keys []string, mapOfLists map[string][]interface{}) []map[string]interface{} {
+	listNames := make([]string, 0, len(mapOfLists))
+	seen := make(map[string]bool, len(mapOfLists))
+	for _, k := range keys {
+		if _, ok := mapOfLists[k]; ok && !seen[k] {
+			listNames = append(listNames, k)
+			seen[k] = true
+		}
+	}
+	rest := make([]string, 0)
+	for k := range mapOfLists {
+		if !seen[k] {
+			rest = append(rest, k)
+		}
+	}
+	sort.Strings(rest)
+	listNames = append(listNames, rest...)
+
+	lists := make([][]interface{}, 0, len(listNames))
+	for _, k := range listNames {
+		lists = append(lists, mapOfLists[k])
 	}
 
 	listCart := cartN(lists...)
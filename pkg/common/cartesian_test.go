@@ -37,3 +37,19 @@ func TestCartesianProduct(t *testing.T) {
 	output = CartesianProduct(input)
 	assert.Len(output, 0)
 }
+
+func TestOrderedCartesianProduct(t *testing.T) {
+	input := map[string][]interface{}{
+		"os":   {"ubuntu", "windows"},
+		"node": {10, 12},
+		"arch": {"x64"},
+	}
+
+	output := OrderedCartesianProduct([]string{"os", "node"}, input)
+	assert.Equal(t, []map[string]interface{}{
+		{"os": "ubuntu", "node": 10, "arch": "x64"},
+		{"os": "ubuntu", "node": 12, "arch": "x64"},
+		{"os": "windows", "node": 10, "arch": "x64"},
+		{"os": "windows", "node": 12, "arch": "x64"},
+	}, output)
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/storer"
 	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/storage/memory"
 	"github.com/mattn/go-isatty"
 	log "github.com/sirupsen/logrus"
 
@@ -417,3 +418,45 @@ func NewGitCloneExecutor(input NewGitCloneExecutorInput) common.Executor {
 		return nil
 	}
 }
+
+var fullSHARegex = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// ResolveRemoteRef returns the commit SHA of a branch, tag or SHA of the remote repository, without cloning it
+func ResolveRemoteRef(ctx context.Context, url, ref, token string) (string, error) {
+	if fullSHARegex.MatchString(ref) {
+		return ref, nil
+	}
+
+	remote := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{
+		Name: "origin",
+		URLs: []string{url},
+	})
+	listOptions := &git.ListOptions{PeelingOption: git.AppendPeeled}
+	if token != "" {
+		listOptions.Auth = &http.BasicAuth{
+			Username: "token",
+			Password: token,
+		}
+	}
+	refs, err := remote.ListContext(ctx, listOptions)
+	if err != nil {
+		return "", err
+	}
+
+	var sha string
+	for _, r := range refs {
+		switch r.Name().String() {
+		case "refs/tags/" + ref + "^{}":
+			// the commit of an annotated tag
+			return r.Hash().String(), nil
+		case "refs/tags/" + ref, "refs/heads/" + ref:
+			if sha == "" || r.Name().IsTag() {
+				sha = r.Hash().String()
+			}
+		}
+	}
+	if sha == "" {
+		return "", fmt.Errorf("ref '%s' not found in %s", ref, url)
+	}
+	return sha, nil
+}
@@ -221,6 +221,36 @@ func TestGitCloneExecutor(t *testing.T) {
 	}
 }
 
+func TestResolveRemoteRef(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, gitCmd("-C", dir, "init", "--initial-branch=main"))
+	require.NoError(t, cleanGitHooks(dir))
+	commit := func(msg string) string {
+		require.NoError(t, gitCmd("-C", dir, "-c", "user.name=test", "-c", "user.email=test@test.com", "commit", "--allow-empty", "-m", msg))
+		_, sha, err := FindGitRevision(context.Background(), dir)
+		require.NoError(t, err)
+		return sha
+	}
+	first := commit("first")
+	require.NoError(t, gitCmd("-C", dir, "tag", "v1"))
+	require.NoError(t, gitCmd("-C", dir, "-c", "user.name=test", "-c", "user.email=test@test.com", "tag", "-a", "v1.0.0", "-m", "release"))
+	head := commit("second")
+
+	for ref, expected := range map[string]string{
+		"main":   head,
+		"v1":     first,
+		"v1.0.0": first,
+		first:    first,
+	} {
+		sha, err := ResolveRemoteRef(context.Background(), dir, ref, "")
+		assert.NoError(t, err, ref)
+		assert.Equal(t, expected, sha, ref)
+	}
+
+	_, err := ResolveRemoteRef(context.Background(), dir, "v2", "")
+	assert.EqualError(t, err, fmt.Sprintf("ref 'v2' not found in %s", dir))
+}
+
 func gitConfig() {
 	if os.Getenv("GITHUB_ACTIONS") == "true" {
 		var err error
@@ -2,8 +2,13 @@ package common
 
 import (
 	"context"
+	"errors"
 )
 
+// ErrRunSuperseded is the cause of the cancellation of a run replaced by a newer one, its jobs remove their containers
+// even when they failed, since nobody will look into them
+var ErrRunSuperseded = errors.New("the run was superseded by a newer run")
+
 type jobErrorContextKey string
 
 const jobErrorContextKeyVal = jobErrorContextKey("job.error")
@@ -8,6 +8,12 @@ import (
 	"github.com/nektos/act/pkg/common"
 )
 
+// The container engines running the job containers
+const (
+	EngineDocker = "docker"
+	EnginePodman = "podman"
+)
+
 // NewContainerInput the input for the New function
 type NewContainerInput struct {
 	Image          string
@@ -30,6 +36,10 @@ type NewContainerInput struct {
 	NetworkAliases []string
 	ExposedPorts   nat.PortSet
 	PortBindings   nat.PortMap
+	Resources      *ResourceLimits // limits of the resources of the container, applied over the options
+	WorkspaceSync  bool            // keep the manifest of the directories copied to the container, to copy only the changed files the next time
+	Definition     string          // the hash of the definition of the job, a container found by name is only reused with the same definition and image
+	Pod            string          // the Podman pod the container is created in, sharing its network instead of NetworkMode
 
 	// Gitea specific
 	AutoRemove   bool
@@ -54,6 +64,7 @@ type Container interface {
 	Pull(forcePull bool) common.Executor
 	Start(attach bool) common.Executor
 	Exec(command []string, env map[string]string, user, workdir string) common.Executor
+	Shell(env map[string]string, user, workdir string) common.Executor
 	UpdateFromEnv(srcPath string, env *map[string]string) common.Executor
 	UpdateFromImageEnv(env *map[string]string) common.Executor
 	Remove() common.Executor
@@ -0,0 +1,81 @@
+//go:build !(WITHOUT_DOCKER || !(linux || darwin || windows || netbsd))
+
+package container
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/client"
+
+	"github.com/nektos/act/pkg/common"
+)
+
+// healthPollInterval is the interval the health of a container is inspected at
+var healthPollInterval = time.Second
+
+// NewContainerHealthyExecutor waits until the healthcheck of a container passes, it returns right away
+// if the container has no healthcheck and fails if the container becomes unhealthy or stops
+func NewContainerHealthyExecutor(name string) common.Executor {
+	return func(ctx context.Context) error {
+		logger := common.Logger(ctx)
+		logger.Debugf("%sdocker wait healthy %s", logPrefix, name)
+
+		cli, err := GetDockerClient(ctx)
+		if err != nil {
+			return err
+		}
+		defer cli.Close()
+
+		waiting := false
+		for {
+			resp, err := cli.ContainerInspect(ctx, name, client.ContainerInspectOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to inspect container %s: %w", name, err)
+			}
+			healthy, err := containerHealthy(name, resp.Container.State)
+			if healthy || err != nil {
+				return err
+			}
+			if !waiting {
+				logger.Infof("Waiting for the healthcheck of container %s to pass", name)
+				waiting = true
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(healthPollInterval):
+			}
+		}
+	}
+}
+
+// containerHealthy returns true if a container is healthy or has no healthcheck, and an error if it never will be
+func containerHealthy(name string, state *container.State) (bool, error) {
+	if state == nil {
+		return false, nil
+	}
+	if !state.Running && !state.Restarting {
+		return false, fmt.Errorf("container %s exited with code %d before its healthcheck passed", name, state.ExitCode)
+	}
+	if state.Health == nil {
+		return true, nil
+	}
+	switch state.Health.Status {
+	case container.Healthy, container.NoHealthcheck:
+		return true, nil
+	case container.Unhealthy:
+		var output string
+		if n := len(state.Health.Log); n > 0 {
+			output = strings.TrimSpace(state.Health.Log[n-1].Output)
+		}
+		if output != "" {
+			return false, fmt.Errorf("container %s is unhealthy: %s", name, output)
+		}
+		return false, fmt.Errorf("container %s is unhealthy", name)
+	}
+	return false, nil
+}
@@ -0,0 +1,31 @@
+package container
+
+import (
+	"testing"
+
+	"github.com/moby/moby/api/types/container"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContainerHealthy(t *testing.T) {
+	healthy, err := containerHealthy("db", &container.State{Running: true})
+	assert.True(t, healthy)
+	assert.NoError(t, err)
+
+	healthy, err = containerHealthy("db", &container.State{Running: true, Health: &container.Health{Status: container.Starting}})
+	assert.False(t, healthy)
+	assert.NoError(t, err)
+
+	healthy, err = containerHealthy("db", &container.State{Running: true, Health: &container.Health{Status: container.Healthy}})
+	assert.True(t, healthy)
+	assert.NoError(t, err)
+
+	_, err = containerHealthy("db", &container.State{Running: true, Health: &container.Health{
+		Status: container.Unhealthy,
+		Log:    []*container.HealthcheckResult{{ExitCode: 1, Output: "no response\n"}},
+	}})
+	assert.EqualError(t, err, "container db is unhealthy: no response")
+
+	_, err = containerHealthy("db", &container.State{ExitCode: 3, Health: &container.Health{Status: container.Starting}})
+	assert.EqualError(t, err, "container db exited with code 3 before its healthcheck passed")
+}
@@ -5,8 +5,11 @@ package container
 import (
 	"context"
 	"fmt"
+	"io"
 
 	cerrdefs "github.com/containerd/errdefs"
+	"github.com/distribution/reference"
+	"github.com/moby/moby/api/types/container"
 	"github.com/moby/moby/client"
 	"github.com/nektos/act/pkg/common"
 )
@@ -64,3 +67,144 @@ func RemoveImage(ctx context.Context, imageName string, force bool, pruneChildre
 
 	return true, nil
 }
+
+// ImageDigest returns the digest of the repository of an image in the local docker image store,
+// empty if the image hasn't been pulled from a registry
+func ImageDigest(ctx context.Context, imageName string) (string, error) {
+	named, err := reference.ParseNormalizedNamed(imageName)
+	if err != nil {
+		return "", err
+	}
+
+	cli, err := GetDockerClient(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer cli.Close()
+
+	inspectImage, err := cli.ImageInspect(ctx, imageName)
+	if err != nil {
+		return "", err
+	}
+
+	for _, repoDigest := range inspectImage.RepoDigests {
+		digested, err := reference.ParseNormalizedNamed(repoDigest)
+		if err != nil {
+			continue
+		}
+		if canonical, ok := digested.(reference.Canonical); ok && canonical.Name() == named.Name() {
+			return canonical.Digest().String(), nil
+		}
+	}
+	return "", nil
+}
+
+// ImageID returns the ID of an image in the local docker image store
+func ImageID(ctx context.Context, imageName string) (string, error) {
+	cli, err := GetDockerClient(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer cli.Close()
+
+	inspectImage, err := cli.ImageInspect(ctx, imageName)
+	if err != nil {
+		return "", err
+	}
+	return inspectImage.ID, nil
+}
+
+// ImageLabels returns the labels of an image in the local docker image store, false if there is no such image
+func ImageLabels(ctx context.Context, imageName string) (map[string]string, bool, error) {
+	cli, err := GetDockerClient(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	defer cli.Close()
+
+	inspectImage, err := cli.ImageInspect(ctx, imageName)
+	if cerrdefs.IsNotFound(err) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+	if inspectImage.Config == nil {
+		return map[string]string{}, true, nil
+	}
+	return inspectImage.Config.Labels, true, nil
+}
+
+// SaveImages writes the archive of images of the local docker image store to w, as docker save does
+func SaveImages(ctx context.Context, images []string, w io.Writer) error {
+	cli, err := GetDockerClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer cli.Close()
+
+	archive, err := cli.ImageSave(ctx, images)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+	_, err = io.Copy(w, archive)
+	return err
+}
+
+// RemoveLabeledImages removes the tags of the images whose label is set to value, but the keep tag,
+// an image being removed with its last tag. It returns the removed tags, those of the images used by a container are kept.
+func RemoveLabeledImages(ctx context.Context, label, value, keep string) ([]string, error) {
+	cli, err := GetDockerClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cli.Close()
+
+	images, err := cli.ImageList(ctx, client.ImageListOptions{
+		Filters: make(client.Filters).Add("label", label+"="+value),
+	})
+	if err != nil {
+		return nil, err
+	}
+	var removed []string
+	for _, img := range images.Items {
+		for _, tag := range img.RepoTags {
+			if tag == keep {
+				continue
+			}
+			if _, err := cli.ImageRemove(ctx, tag, client.ImageRemoveOptions{PruneChildren: true}); err != nil {
+				common.Logger(ctx).Debugf("Failed to remove image %s: %v", tag, err)
+				continue
+			}
+			removed = append(removed, tag)
+		}
+	}
+	return removed, nil
+}
+
+// NewDockerCommitExecutor commits a container to an image with labels
+func NewDockerCommitExecutor(containerName, imageName string, labels map[string]string) common.Executor {
+	return func(ctx context.Context) error {
+		logger := common.Logger(ctx)
+		logger.Infof("%sdocker commit %s %s", logPrefix, containerName, imageName)
+		if common.Dryrun(ctx) {
+			return nil
+		}
+
+		cli, err := GetDockerClient(ctx)
+		if err != nil {
+			return err
+		}
+		defer cli.Close()
+
+		_, err = cli.ContainerCommit(ctx, containerName, client.ContainerCommitOptions{
+			Reference: imageName,
+			Comment:   "act warm snapshot",
+			Config:    &container.Config{Labels: labels},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to commit container %s: %w", containerName, err)
+		}
+		return nil
+	}
+}
@@ -79,3 +79,20 @@ func NewDockerNetworkRemoveExecutor(name string) common.Executor {
 		return err
 	}
 }
+
+// NewDockerNetworkDisconnectExecutor disconnects a container from a network
+func NewDockerNetworkDisconnectExecutor(name, containerName string) common.Executor {
+	return func(ctx context.Context) error {
+		cli, err := GetDockerClient(ctx)
+		if err != nil {
+			return err
+		}
+		defer cli.Close()
+
+		_, err = cli.NetworkDisconnect(ctx, name, client.NetworkDisconnectOptions{
+			Container: containerName,
+			Force:     true,
+		})
+		return err
+	}
+}
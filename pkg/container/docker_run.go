@@ -6,6 +6,8 @@ import (
 	"archive/tar"
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
@@ -21,9 +23,6 @@ import (
 	"github.com/docker/cli/cli/compose/loader"
 	"github.com/docker/cli/cli/connhelper"
 	"github.com/docker/go-connections/nat"
-	"github.com/go-git/go-billy/v5/helper/polyfill"
-	"github.com/go-git/go-billy/v5/osfs"
-	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
 	"github.com/gobwas/glob"
 	"github.com/imdario/mergo"
 	"github.com/joho/godotenv"
@@ -96,6 +95,7 @@ func (cr *containerReference) Create(capAdd []string, capDrop []string) common.E
 			common.NewPipelineExecutor(
 				cr.connect(),
 				cr.find(),
+				cr.replaceOutdated(),
 				cr.create(capAdd, capDrop),
 			).IfNot(common.Dryrun),
 		)
@@ -114,10 +114,7 @@ func (cr *containerReference) Start(attach bool) common.Executor {
 				cr.tryReadUID(),
 				cr.tryReadGID(),
 				func(ctx context.Context) error {
-					// If this fails, then folders have wrong permissions on non root container
-					if cr.UID != 0 || cr.GID != 0 {
-						_ = cr.Exec([]string{"chown", "-R", fmt.Sprintf("%d:%d", cr.UID, cr.GID), cr.input.WorkingDir}, nil, "0", "")(ctx)
-					}
+					cr.chownDir(ctx, cr.input.WorkingDir)
 					return nil
 				},
 			).IfNot(common.Dryrun),
@@ -147,9 +144,15 @@ func (cr *containerReference) Copy(destPath string, files ...*FileEntry) common.
 }
 
 func (cr *containerReference) CopyDir(destPath string, srcPath string, useGitIgnore bool) common.Executor {
+	if cr.input.WorkspaceSync {
+		return common.NewPipelineExecutor(
+			common.NewInfoExecutor("%sdocker cp src=%s dst=%s (changed files)", logPrefix, srcPath, destPath),
+			cr.syncDir(destPath, srcPath, useGitIgnore),
+		).IfNot(common.Dryrun)
+	}
 	return common.NewPipelineExecutor(
 		common.NewInfoExecutor("%sdocker cp src=%s dst=%s", logPrefix, srcPath, destPath),
-		cr.copyDir(destPath, srcPath, useGitIgnore),
+		cr.copyDir(destPath, srcPath, useGitIgnore, nil),
 		func(ctx context.Context) error {
 			// If this fails, then folders have wrong permissions on non root container
 			if cr.UID != 0 || cr.GID != 0 {
@@ -185,6 +188,16 @@ func (cr *containerReference) Exec(command []string, env map[string]string, user
 	).IfNot(common.Dryrun)
 }
 
+// Shell opens an interactive shell in the container, attached to the terminal of act
+func (cr *containerReference) Shell(env map[string]string, user, workdir string) common.Executor {
+	return common.NewPipelineExecutor(
+		common.NewInfoExecutor("%sdocker exec shell user=%s workdir=%s", logPrefix, user, workdir),
+		cr.connect(),
+		cr.find(),
+		cr.shell(env, user, workdir),
+	).IfNot(common.Dryrun)
+}
+
 func (cr *containerReference) Remove() common.Executor {
 	return common.NewPipelineExecutor(
 		cr.connect(),
@@ -210,6 +223,8 @@ type containerReference struct {
 	input *NewContainerInput
 	UID   int
 	GID   int
+	// oomKillsSeen is the oom_kill counter of the container when it was last read, the kills up to it are reported
+	oomKillsSeen int64
 	LinuxContainerEnvironmentExtensions
 }
 
@@ -262,17 +277,16 @@ func RunnerArch(ctx context.Context) string {
 		return ""
 	}
 
-	archMapper := map[string]string{
-		"x86_64":  "X64",
-		"amd64":   "X64",
-		"386":     "X86",
-		"aarch64": "ARM64",
-		"arm64":   "ARM64",
-	}
-	if arch, ok := archMapper[info.Architecture]; ok {
-		return arch
+	return toRunnerArch(info.Architecture)
+}
+
+// GetRunnerContext returns the runner context of the container, whose arch is the one of its platform
+func (cr *containerReference) GetRunnerContext(ctx context.Context) map[string]interface{} {
+	runnerContext := cr.LinuxContainerEnvironmentExtensions.GetRunnerContext(ctx)
+	if arch := PlatformArch(cr.input.Platform); arch != "" {
+		runnerContext["arch"] = arch
 	}
-	return info.Architecture
+	return runnerContext
 }
 
 func (cr *containerReference) connect() common.Executor {
@@ -328,6 +342,47 @@ func (cr *containerReference) find() common.Executor {
 	}
 }
 
+// definitionLabel is the label of the hash of the definition of the job of a container and of its image
+const definitionLabel = "act.definition"
+
+// replaceOutdated removes the container found by name if the definition of its job or its image has changed since it was created
+func (cr *containerReference) replaceOutdated() common.Executor {
+	return func(ctx context.Context) error {
+		if cr.id == "" || cr.input.Definition == "" {
+			return nil
+		}
+		logger := common.Logger(ctx)
+		resp, err := cr.cli.ContainerInspect(ctx, cr.id, client.ContainerInspectOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to inspect container: %w", err)
+		}
+		var labels map[string]string
+		if resp.Container.Config != nil {
+			labels = resp.Container.Config.Labels
+		}
+		hash, err := cr.definitionHash(ctx)
+		if err != nil {
+			return err
+		}
+		if labels[definitionLabel] == hash {
+			logger.Infof("Reusing container %s, its definition hasn't changed", cr.input.Name)
+			return nil
+		}
+		logger.Infof("Replacing container %s, the definition of its job or its image has changed", cr.input.Name)
+		return cr.remove()(ctx)
+	}
+}
+
+// definitionHash returns the hash of the definition of the job of the container and of the ID of its image
+func (cr *containerReference) definitionHash(ctx context.Context) (string, error) {
+	inspectImage, err := cr.cli.ImageInspect(ctx, cr.input.Image)
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect image %s: %w", cr.input.Image, err)
+	}
+	hash := sha256.Sum256([]byte(cr.input.Definition + "\n" + inspectImage.ID))
+	return hex.EncodeToString(hash[:]), nil
+}
+
 func (cr *containerReference) remove() common.Executor {
 	return func(ctx context.Context) error {
 		if cr.id == "" {
@@ -354,6 +409,9 @@ func (cr *containerReference) mergeContainerConfigs(ctx context.Context, config
 	input := cr.input
 
 	if input.Options == "" {
+		if err := cr.applyResourceLimits(ctx, hostConfig); err != nil {
+			return nil, nil, err
+		}
 		return config, hostConfig, nil
 	}
 
@@ -450,116 +508,212 @@ func (cr *containerReference) mergeContainerConfigs(ctx context.Context, config
 		hostConfig.PublishAllPorts = false
 	}
 
+	if err := cr.applyResourceLimits(ctx, hostConfig); err != nil {
+		return nil, nil, err
+	}
+
 	logger.Debugf("Merged container.HostConfig ==> %+v", hostConfig)
 
 	return config, hostConfig, nil
 }
 
+func (cr *containerReference) applyResourceLimits(ctx context.Context, hostConfig *container.HostConfig) error {
+	if cr.input.Resources == nil {
+		return nil
+	}
+	if err := cr.input.Resources.apply(hostConfig); err != nil {
+		return fmt.Errorf("invalid resource limits of container %s: %w", cr.input.Name, err)
+	}
+	common.Logger(ctx).Debugf("Limited the resources of container %s to %s", cr.input.Name, cr.input.Resources)
+	return nil
+}
+
+// oomKilled returns ErrOutOfMemory if the container has been killed because it ran out of memory,
+// the state is reset when the container starts so it only tells about the command of the container
+func (cr *containerReference) oomKilled(ctx context.Context) error {
+	resp, err := cr.cli.ContainerInspect(ctx, cr.id, client.ContainerInspectOptions{})
+	if err != nil {
+		common.Logger(ctx).Debugf("Failed to inspect container %s: %v", cr.id, err)
+		return nil
+	}
+	if resp.Container.State == nil || !resp.Container.State.OOMKilled {
+		return nil
+	}
+	var memory int64
+	if resp.Container.HostConfig != nil {
+		memory = resp.Container.HostConfig.Memory
+	}
+	return outOfMemoryError(memory)
+}
+
+// execOOMKilled returns ErrOutOfMemory if a process of a container with a memory limit has been killed because it ran out of memory,
+// which the oom_kill counter of the container tells since it was last read, the OOMKilled state of the container being kept once set by an exec
+func (cr *containerReference) execOOMKilled(ctx context.Context) error {
+	resp, err := cr.cli.ContainerInspect(ctx, cr.id, client.ContainerInspectOptions{})
+	if err != nil {
+		common.Logger(ctx).Debugf("Failed to inspect container %s: %v", cr.id, err)
+		return nil
+	}
+	if resp.Container.HostConfig == nil || resp.Container.HostConfig.Memory <= 0 {
+		return nil
+	}
+	oomKills := cr.oomKills(ctx)
+	if oomKills <= cr.oomKillsSeen {
+		return nil
+	}
+	cr.oomKillsSeen = oomKills
+	return outOfMemoryError(resp.Container.HostConfig.Memory)
+}
+
+// oomKills returns the oom_kill counter of the memory cgroup of the container, -1 if it can't be read
+func (cr *containerReference) oomKills(ctx context.Context) int64 {
+	// memory.events of cgroup v2, else memory.oom_control of cgroup v1
+	output, err := cr.execOutput(ctx, []string{"cat", "/sys/fs/cgroup/memory.events"})
+	if err != nil {
+		output, err = cr.execOutput(ctx, []string{"cat", "/sys/fs/cgroup/memory/memory.oom_control"})
+	}
+	if err != nil {
+		common.Logger(ctx).Debugf("Failed to read the oom_kill counter of container %s: %v", cr.id, err)
+		return -1
+	}
+	return parseOOMKills(output)
+}
+
+func parseOOMKills(output string) int64 {
+	for _, line := range strings.Split(output, "\n") {
+		if value, ok := strings.CutPrefix(line, "oom_kill "); ok {
+			if count, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64); err == nil {
+				return count
+			}
+		}
+	}
+	return -1
+}
+
 func (cr *containerReference) create(capAdd []string, capDrop []string) common.Executor {
 	return func(ctx context.Context) error {
 		if cr.id != "" {
 			return nil
 		}
 		logger := common.Logger(ctx)
-		isTerminal := term.IsTerminal(int(os.Stdout.Fd()))
 		input := cr.input
 
-		config := &container.Config{
-			Image:        input.Image,
-			WorkingDir:   input.WorkingDir,
-			Env:          input.Env,
-			ExposedPorts: convertPortSet(input.ExposedPorts),
-			Tty:          isTerminal,
+		options, err := cr.createOptions(ctx, capAdd, capDrop)
+		if err != nil {
+			return err
 		}
-		// For Gitea, reduce log noise
-		// logger.Debugf("Common container.Config ==> %+v", config)
-
-		if len(input.Cmd) != 0 {
-			config.Cmd = input.Cmd
+		resp, err := cr.cli.ContainerCreate(ctx, options)
+		if err != nil {
+			return fmt.Errorf("failed to create container: '%w'", err)
 		}
 
-		if len(input.Entrypoint) != 0 {
-			config.Entrypoint = input.Entrypoint
-		}
+		logger.Debugf("Created container name=%s id=%v from image %v (platform: %s)", input.Name, resp.ID, input.Image, input.Platform)
+		logger.Debugf("ENV ==> %v", input.Env)
 
-		mounts := make([]mount.Mount, 0)
-		for mountSource, mountTarget := range input.Mounts {
-			mounts = append(mounts, mount.Mount{
-				Type:   mount.TypeVolume,
-				Source: mountSource,
-				Target: mountTarget,
-			})
-		}
+		cr.id = resp.ID
+		return nil
+	}
+}
 
-		var platSpecs *specs.Platform
-		if supportsContainerImagePlatform(ctx, cr.cli) && cr.input.Platform != "" {
-			desiredPlatform := strings.SplitN(cr.input.Platform, `/`, 2)
+// createOptions returns the config of the container, with the options and the resource limits of the input applied
+func (cr *containerReference) createOptions(ctx context.Context, capAdd []string, capDrop []string) (client.ContainerCreateOptions, error) {
+	isTerminal := term.IsTerminal(int(os.Stdout.Fd()))
+	input := cr.input
 
-			if len(desiredPlatform) != 2 {
-				return fmt.Errorf("incorrect container platform option '%s'", cr.input.Platform)
-			}
+	config := &container.Config{
+		Image:        input.Image,
+		WorkingDir:   input.WorkingDir,
+		Env:          input.Env,
+		ExposedPorts: convertPortSet(input.ExposedPorts),
+		Tty:          isTerminal,
+	}
+	// For Gitea, reduce log noise
+	// logger.Debugf("Common container.Config ==> %+v", config)
 
-			platSpecs = &specs.Platform{
-				Architecture: desiredPlatform[1],
-				OS:           desiredPlatform[0],
-			}
-		}
+	if len(input.Cmd) != 0 {
+		config.Cmd = input.Cmd
+	}
 
-		hostConfig := &container.HostConfig{
-			CapAdd:       capAdd,
-			CapDrop:      capDrop,
-			Binds:        input.Binds,
-			Mounts:       mounts,
-			NetworkMode:  container.NetworkMode(input.NetworkMode),
-			Privileged:   input.Privileged,
-			UsernsMode:   container.UsernsMode(input.UsernsMode),
-			PortBindings: convertPortMap(input.PortBindings),
-			AutoRemove:   input.AutoRemove,
-		}
-		// For Gitea, reduce log noise
-		// logger.Debugf("Common container.HostConfig ==> %+v", hostConfig)
+	if len(input.Entrypoint) != 0 {
+		config.Entrypoint = input.Entrypoint
+	}
 
-		config, hostConfig, err := cr.mergeContainerConfigs(ctx, config, hostConfig)
+	if input.Definition != "" {
+		hash, err := cr.definitionHash(ctx)
 		if err != nil {
-			return err
+			return client.ContainerCreateOptions{}, err
 		}
+		config.Labels = map[string]string{definitionLabel: hash}
+	}
 
-		// For Gitea
-		config, hostConfig = cr.sanitizeConfig(ctx, config, hostConfig)
+	mounts := make([]mount.Mount, 0)
+	for mountSource, mountTarget := range input.Mounts {
+		mounts = append(mounts, mount.Mount{
+			Type:   mount.TypeVolume,
+			Source: mountSource,
+			Target: mountTarget,
+		})
+	}
 
-		var networkingConfig *network.NetworkingConfig
-		// For Gitea, reduce log noise
-		// logger.Debugf("input.NetworkAliases ==> %v", input.NetworkAliases)
-		n := hostConfig.NetworkMode
-		// IsUserDefined and IsHost are broken on windows
-		if n.IsUserDefined() && n != "host" && len(input.NetworkAliases) > 0 {
-			endpointConfig := &network.EndpointSettings{
-				Aliases: input.NetworkAliases,
-			}
-			networkingConfig = &network.NetworkingConfig{
-				EndpointsConfig: map[string]*network.EndpointSettings{
-					input.NetworkMode: endpointConfig,
-				},
-			}
+	var platSpecs *specs.Platform
+	if supportsContainerImagePlatform(ctx, cr.cli) && cr.input.Platform != "" {
+		desiredPlatform := strings.SplitN(cr.input.Platform, `/`, 2)
+
+		if len(desiredPlatform) != 2 {
+			return client.ContainerCreateOptions{}, fmt.Errorf("incorrect container platform option '%s'", cr.input.Platform)
 		}
 
-		resp, err := cr.cli.ContainerCreate(ctx, client.ContainerCreateOptions{
-			Config:           config,
-			HostConfig:       hostConfig,
-			NetworkingConfig: networkingConfig,
-			Platform:         platSpecs,
-			Name:             input.Name,
-		})
-		if err != nil {
-			return fmt.Errorf("failed to create container: '%w'", err)
+		platSpecs = &specs.Platform{
+			Architecture: desiredPlatform[1],
+			OS:           desiredPlatform[0],
 		}
+	}
 
-		logger.Debugf("Created container name=%s id=%v from image %v (platform: %s)", input.Name, resp.ID, input.Image, input.Platform)
-		logger.Debugf("ENV ==> %v", input.Env)
+	hostConfig := &container.HostConfig{
+		CapAdd:       capAdd,
+		CapDrop:      capDrop,
+		Binds:        input.Binds,
+		Mounts:       mounts,
+		NetworkMode:  container.NetworkMode(input.NetworkMode),
+		Privileged:   input.Privileged,
+		UsernsMode:   container.UsernsMode(input.UsernsMode),
+		PortBindings: convertPortMap(input.PortBindings),
+		AutoRemove:   input.AutoRemove,
+	}
+	// For Gitea, reduce log noise
+	// logger.Debugf("Common container.HostConfig ==> %+v", hostConfig)
 
-		cr.id = resp.ID
-		return nil
+	config, hostConfig, err := cr.mergeContainerConfigs(ctx, config, hostConfig)
+	if err != nil {
+		return client.ContainerCreateOptions{}, err
 	}
+
+	// For Gitea
+	config, hostConfig = cr.sanitizeConfig(ctx, config, hostConfig)
+
+	var networkingConfig *network.NetworkingConfig
+	// For Gitea, reduce log noise
+	// logger.Debugf("input.NetworkAliases ==> %v", input.NetworkAliases)
+	n := hostConfig.NetworkMode
+	// IsUserDefined and IsHost are broken on windows
+	if n.IsUserDefined() && n != "host" && len(input.NetworkAliases) > 0 {
+		endpointConfig := &network.EndpointSettings{
+			Aliases: input.NetworkAliases,
+		}
+		networkingConfig = &network.NetworkingConfig{
+			EndpointsConfig: map[string]*network.EndpointSettings{
+				input.NetworkMode: endpointConfig,
+			},
+		}
+	}
+
+	return client.ContainerCreateOptions{
+		Config:           config,
+		HostConfig:       hostConfig,
+		NetworkingConfig: networkingConfig,
+		Platform:         platSpecs,
+		Name:             input.Name,
+	}, nil
 }
 
 func convertPortSet(ps nat.PortSet) network.PortSet {
@@ -660,16 +814,7 @@ func (cr *containerReference) exec(cmd []string, env map[string]string, user, wo
 			envList = append(envList, fmt.Sprintf("%s=%s", k, v))
 		}
 
-		var wd string
-		if workdir != "" {
-			if strings.HasPrefix(workdir, "/") {
-				wd = workdir
-			} else {
-				wd = fmt.Sprintf("%s/%s", cr.input.WorkingDir, workdir)
-			}
-		} else {
-			wd = cr.input.WorkingDir
-		}
+		wd := cr.execWorkingDir(workdir)
 		logger.Debugf("Working directory '%s'", wd)
 
 		idResp, err := cr.cli.ExecCreate(ctx, cr.id, client.ExecCreateOptions{
@@ -685,15 +830,18 @@ func (cr *containerReference) exec(cmd []string, env map[string]string, user, wo
 			return fmt.Errorf("failed to create exec: %w", err)
 		}
 
+		stopSampling := cr.sampleStats(ctx)
 		resp, err := cr.cli.ExecAttach(ctx, idResp.ID, client.ExecAttachOptions{
 			TTY: isTerminal,
 		})
 		if err != nil {
+			stopSampling()
 			return fmt.Errorf("failed to attach to exec: %w", err)
 		}
 		defer resp.Close()
 
 		err = cr.waitForCommand(ctx, isTerminal, resp, idResp, user, workdir)
+		stopSampling()
 		if err != nil {
 			return err
 		}
@@ -708,12 +856,95 @@ func (cr *containerReference) exec(cmd []string, env map[string]string, user, wo
 			return nil
 		case 127:
 			return fmt.Errorf("exitcode '%d': command not found, please refer to https://github.com/nektos/act/issues/107 for more information", inspectResp.ExitCode)
+		case 137:
+			if err := cr.execOOMKilled(ctx); err != nil {
+				return fmt.Errorf("exitcode '%d': %w", inspectResp.ExitCode, err)
+			}
+			return fmt.Errorf("exitcode '%d': failure", inspectResp.ExitCode)
 		default:
 			return fmt.Errorf("exitcode '%d': failure", inspectResp.ExitCode)
 		}
 	}
 }
 
+func (cr *containerReference) execWorkingDir(workdir string) string {
+	if workdir == "" {
+		return cr.input.WorkingDir
+	}
+	if strings.HasPrefix(workdir, "/") {
+		return workdir
+	}
+	return fmt.Sprintf("%s/%s", cr.input.WorkingDir, workdir)
+}
+
+func (cr *containerReference) shell(env map[string]string, user, workdir string) common.Executor {
+	return func(ctx context.Context) error {
+		isTerminal := term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
+		envList := make([]string, 0)
+		for k, v := range env {
+			envList = append(envList, fmt.Sprintf("%s=%s", k, v))
+		}
+
+		options := client.ExecCreateOptions{
+			User:         user,
+			Cmd:          []string{"/bin/sh", "-c", "if command -v bash >/dev/null 2>&1; then exec bash; else exec sh; fi"},
+			WorkingDir:   cr.execWorkingDir(workdir),
+			Env:          envList,
+			TTY:          isTerminal,
+			AttachStdin:  true,
+			AttachStderr: true,
+			AttachStdout: true,
+		}
+		if isTerminal {
+			if width, height, err := term.GetSize(int(os.Stdout.Fd())); err == nil {
+				options.ConsoleSize = client.ConsoleSize{Height: uint(height), Width: uint(width)}
+			}
+		}
+		idResp, err := cr.cli.ExecCreate(ctx, cr.id, options)
+		if err != nil {
+			return fmt.Errorf("failed to create exec: %w", err)
+		}
+
+		resp, err := cr.cli.ExecAttach(ctx, idResp.ID, client.ExecAttachOptions{
+			TTY: isTerminal,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to attach to exec: %w", err)
+		}
+		defer resp.Close()
+
+		if isTerminal {
+			state, err := term.MakeRaw(int(os.Stdin.Fd()))
+			if err != nil {
+				return fmt.Errorf("failed to set the terminal to raw mode: %w", err)
+			}
+			defer func() {
+				_ = term.Restore(int(os.Stdin.Fd()), state)
+			}()
+		}
+
+		// stop reading stdin once the shell has exited, the input after it is for the next prompt
+		done := make(chan struct{})
+		stopped := make(chan struct{})
+		go func() {
+			defer close(stopped)
+			_ = copyStdin(resp.Conn, done)
+			_ = resp.CloseWrite()
+		}()
+		defer func() {
+			close(done)
+			<-stopped
+		}()
+
+		if isTerminal {
+			_, err = io.Copy(os.Stdout, resp.Reader)
+		} else {
+			_, err = stdcopy.StdCopy(os.Stdout, os.Stderr, resp.Reader)
+		}
+		return err
+	}
+}
+
 func (cr *containerReference) tryReadID(opt string, cbk func(id int)) common.Executor {
 	return func(ctx context.Context) error {
 		idResp, err := cr.cli.ExecCreate(ctx, cr.id, client.ExecCreateOptions{
@@ -826,7 +1057,8 @@ func (cr *containerReference) CopyTarStream(ctx context.Context, destPath string
 	return nil
 }
 
-func (cr *containerReference) copyDir(dstPath string, srcPath string, useGitIgnore bool) common.Executor {
+// copyDir copies the files of srcPath to dstPath, only the files of paths unless it's nil
+func (cr *containerReference) copyDir(dstPath string, srcPath string, useGitIgnore bool, paths map[string]bool) common.Executor {
 	return func(ctx context.Context) error {
 		logger := common.Logger(ctx)
 		tarFile, err := os.CreateTemp("", "act")
@@ -847,34 +1079,16 @@ func (cr *containerReference) copyDir(dstPath string, srcPath string, useGitIgno
 		}(tarFile)
 		tw := tar.NewWriter(tarFile)
 
-		srcPrefix := filepath.Dir(srcPath)
-		if !strings.HasSuffix(srcPrefix, string(filepath.Separator)) {
-			srcPrefix += string(filepath.Separator)
-		}
-		logger.Debugf("Stripping prefix:%s src:%s", srcPrefix, srcPath)
-
-		var ignorer gitignore.Matcher
-		if useGitIgnore {
-			ps, err := gitignore.ReadPatterns(polyfill.New(osfs.New(srcPath)), nil)
-			if err != nil {
-				logger.Debugf("Error loading .gitignore: %v", err)
-			}
-
-			ignorer = gitignore.NewMatcher(ps)
+		var handler filecollector.Handler = &filecollector.TarCollector{
+			TarWriter: tw,
+			UID:       cr.UID,
+			GID:       cr.GID,
+			DstDir:    dstPath[1:],
 		}
-
-		fc := &filecollector.FileCollector{
-			Fs:        &filecollector.DefaultFs{},
-			Ignorer:   ignorer,
-			SrcPath:   srcPath,
-			SrcPrefix: srcPrefix,
-			Handler: &filecollector.TarCollector{
-				TarWriter: tw,
-				UID:       cr.UID,
-				GID:       cr.GID,
-				DstDir:    dstPath[1:],
-			},
+		if paths != nil {
+			handler = &filecollector.FilterCollector{Paths: paths, Handler: handler}
 		}
+		fc := newFileCollector(ctx, srcPath, useGitIgnore, handler)
 
 		err = filepath.Walk(srcPath, fc.CollectFiles(ctx, []string{}))
 		if err != nil {
@@ -983,17 +1197,20 @@ func (cr *containerReference) start() common.Executor {
 func (cr *containerReference) wait() common.Executor {
 	return func(ctx context.Context) error {
 		logger := common.Logger(ctx)
+		stopSampling := cr.sampleStats(ctx)
 		waitResult := cr.cli.ContainerWait(ctx, cr.id, client.ContainerWaitOptions{Condition: container.WaitConditionNotRunning})
 		statusCh, errCh := waitResult.Result, waitResult.Error
 		var statusCode int64
 		select {
 		case err := <-errCh:
 			if err != nil {
+				stopSampling()
 				return fmt.Errorf("failed to wait for container: %w", err)
 			}
 		case status := <-statusCh:
 			statusCode = status.StatusCode
 		}
+		stopSampling()
 
 		logger.Debugf("Return status: %v", statusCode)
 
@@ -1001,6 +1218,10 @@ func (cr *containerReference) wait() common.Executor {
 			return nil
 		}
 
+		if err := cr.oomKilled(ctx); err != nil {
+			return fmt.Errorf("exit with `FAILURE`: %v: %w", statusCode, err)
+		}
+
 		return fmt.Errorf("exit with `FAILURE`: %v", statusCode)
 	}
 }
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -80,11 +81,31 @@ func (m *mockDockerClient) ExecInspect(ctx context.Context, execID string, opts
 	return args.Get(0).(client.ExecInspectResult), args.Error(1)
 }
 
+func (m *mockDockerClient) ContainerInspect(ctx context.Context, id string, opts client.ContainerInspectOptions) (client.ContainerInspectResult, error) {
+	args := m.Called(ctx, id, opts)
+	return args.Get(0).(client.ContainerInspectResult), args.Error(1)
+}
+
 func (m *mockDockerClient) CopyToContainer(ctx context.Context, id string, options client.CopyToContainerOptions) (client.CopyToContainerResult, error) {
 	args := m.Called(ctx, id, options.DestinationPath, options.Content, options)
 	return client.CopyToContainerResult{}, args.Error(0)
 }
 
+// mockOOMKills mocks the read of the oom_kill counter of the memory cgroup
+func mockOOMKills(ctx context.Context, cli *mockDockerClient, count int) {
+	isCat := mock.MatchedBy(func(opts client.ExecCreateOptions) bool { return len(opts.Cmd) > 0 && opts.Cmd[0] == "cat" })
+	output := fmt.Sprintf("low 0\nhigh 0\nmax 3\noom 1\noom_kill %d\n", count)
+	frame := append([]byte{1, 0, 0, 0, 0, 0, 0, byte(len(output))}, output...)
+	cli.On("ExecCreate", ctx, "123", isCat).Return(client.ExecCreateResult{ID: "oom"}, nil).Once()
+	cli.On("ExecAttach", ctx, "oom", mock.AnythingOfType("client.ExecAttachOptions")).Return(client.ExecAttachResult{
+		HijackedResponse: client.HijackedResponse{
+			Conn:   &mockConn{},
+			Reader: bufio.NewReader(bytes.NewReader(frame)),
+		},
+	}, nil).Once()
+	cli.On("ExecInspect", ctx, "oom", mock.AnythingOfType("client.ExecInspectOptions")).Return(client.ExecInspectResult{}, nil).Once()
+}
+
 type endlessReader struct {
 	io.Reader
 }
@@ -179,6 +200,68 @@ func TestDockerExecFailure(t *testing.T) {
 	cli.AssertExpectations(t)
 }
 
+func TestDockerExecOutOfMemory(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		memory   int64
+		seen     int64
+		oomKills int
+		oom      bool
+	}{
+		{"killed", 1 << 30, 0, 1, true},
+		{"killed after an earlier oom", 1 << 30, 1, 2, true},
+		{"not killed after an earlier oom", 1 << 30, 1, 1, false},
+		{"no memory limit", 0, 0, -1, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cli := &mockDockerClient{}
+			if tt.oomKills >= 0 {
+				mockOOMKills(ctx, cli, tt.oomKills)
+			}
+			cli.On("ExecCreate", ctx, "123", mock.AnythingOfType("client.ExecCreateOptions")).Return(client.ExecCreateResult{ID: "id"}, nil)
+			cli.On("ExecAttach", ctx, "id", mock.AnythingOfType("client.ExecAttachOptions")).Return(client.ExecAttachResult{
+				HijackedResponse: client.HijackedResponse{
+					Conn:   &mockConn{},
+					Reader: bufio.NewReader(strings.NewReader("")),
+				},
+			}, nil)
+			cli.On("ExecInspect", ctx, "id", mock.AnythingOfType("client.ExecInspectOptions")).Return(client.ExecInspectResult{
+				ExitCode: 137,
+			}, nil)
+			// the state of the container keeps the earlier oom
+			cli.On("ContainerInspect", ctx, "123", mock.AnythingOfType("client.ContainerInspectOptions")).Return(client.ContainerInspectResult{
+				Container: container.InspectResponse{
+					State:      &container.State{OOMKilled: true},
+					HostConfig: &container.HostConfig{Resources: container.Resources{Memory: tt.memory}},
+				},
+			}, nil)
+
+			cr := &containerReference{
+				id:  "123",
+				cli: cli,
+				input: &NewContainerInput{
+					Image: "image",
+				},
+				oomKillsSeen: tt.seen,
+			}
+			err := cr.exec([]string{"make"}, map[string]string{}, "user", "workdir")(ctx)
+			assert.Error(t, err)
+			assert.Equal(t, tt.oom, errors.Is(err, ErrOutOfMemory), err)
+			if tt.oomKills >= 0 {
+				assert.Equal(t, int64(tt.oomKills), cr.oomKillsSeen)
+			}
+			cli.AssertExpectations(t)
+		})
+	}
+}
+
+func TestParseOOMKills(t *testing.T) {
+	assert.Equal(t, int64(2), parseOOMKills("low 0\nhigh 0\nmax 5\noom 2\noom_kill 2\noom_group_kill 0\n"))
+	assert.Equal(t, int64(1), parseOOMKills("oom_kill_disable 0\nunder_oom 0\noom_kill 1\n"))
+	assert.Equal(t, int64(-1), parseOOMKills("cat: can't open '/sys/fs/cgroup/memory.events'\n"))
+}
+
 func TestDockerCopyTarStream(t *testing.T) {
 	ctx := context.Background()
 
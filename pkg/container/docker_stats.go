@@ -0,0 +1,178 @@
+//go:build !(WITHOUT_DOCKER || !(linux || darwin || windows || netbsd))
+
+package container
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/client"
+
+	"github.com/nektos/act/pkg/common"
+)
+
+// statsSampler computes the usage of the resources of a container from the stats sampled while its commands run
+type statsSampler struct {
+	first     *container.StatsResponse
+	last      *container.StatsResponse
+	samples   int
+	cpuPeak   float64
+	memPeak   uint64
+	memTotal  uint64
+	startedAt time.Time
+}
+
+// sampleStats samples the stats of the container until the returned function is called, which adds the usage
+// of the resources to the usage of the context. Nothing is sampled if the context doesn't record the usage.
+func (cr *containerReference) sampleStats(ctx context.Context) func() {
+	usage := resourceUsage(ctx)
+	if usage == nil || cr.id == "" {
+		return func() {}
+	}
+	logger := common.Logger(ctx)
+	sampler := &statsSampler{startedAt: time.Now()}
+	// the counters of the container are cumulative, the first sample is the baseline of the commands
+	sampler.add(cr.statsSnapshot(ctx))
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		resp, err := cr.cli.ContainerStats(streamCtx, cr.id, client.ContainerStatsOptions{Stream: true})
+		if err != nil {
+			logger.Debugf("Failed to sample the stats of container %s: %v", cr.id, err)
+			return
+		}
+		defer resp.Body.Close()
+		decoder := json.NewDecoder(resp.Body)
+		for {
+			stats := &container.StatsResponse{}
+			if err := decoder.Decode(stats); err != nil {
+				return
+			}
+			sampler.add(stats)
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+		// the commands may have finished before the stream sent a sample
+		sampler.add(cr.statsSnapshot(ctx))
+		usage.Add(sampler.usage(time.Since(sampler.startedAt)))
+	}
+}
+
+// statsSnapshot returns the current stats of the container without waiting for a second sample, nil if they can't be read
+func (cr *containerReference) statsSnapshot(ctx context.Context) *container.StatsResponse {
+	resp, err := cr.cli.ContainerStats(ctx, cr.id, client.ContainerStatsOptions{})
+	if err != nil {
+		common.Logger(ctx).Debugf("Failed to read the stats of container %s: %v", cr.id, err)
+		return nil
+	}
+	defer resp.Body.Close()
+	stats := &container.StatsResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(stats); err != nil {
+		return nil
+	}
+	return stats
+}
+
+func (s *statsSampler) add(stats *container.StatsResponse) {
+	// a stopped container has no stats, and its counters are reset
+	if stats == nil || stats.Read.IsZero() {
+		return
+	}
+	if s.first == nil {
+		s.first = stats
+	}
+	s.last = stats
+
+	memory := memoryUsage(&stats.MemoryStats)
+	s.samples++
+	s.memTotal += memory
+	s.memPeak = max(s.memPeak, memory)
+	s.cpuPeak = max(s.cpuPeak, cpuPercent(stats))
+}
+
+func (s *statsSampler) usage(duration time.Duration) ResourceUsage {
+	usage := ResourceUsage{Duration: duration}
+	if s.first == nil {
+		return usage
+	}
+	usage.Samples = s.samples
+	usage.CPUPeak = s.cpuPeak
+	usage.MemoryPeak = s.memPeak
+	usage.MemoryAvg = s.memTotal / uint64(s.samples)
+	usage.CPUTime = time.Duration(counterDelta(s.first.CPUStats.CPUUsage.TotalUsage, s.last.CPUStats.CPUUsage.TotalUsage))
+	if duration > 0 {
+		usage.CPUAvg = usage.CPUTime.Seconds() / duration.Seconds() * 100
+	}
+	firstRx, firstTx := networkBytes(s.first)
+	lastRx, lastTx := networkBytes(s.last)
+	usage.NetworkRx = counterDelta(firstRx, lastRx)
+	usage.NetworkTx = counterDelta(firstTx, lastTx)
+	firstRead, firstWrite := blockBytes(s.first)
+	lastRead, lastWrite := blockBytes(s.last)
+	usage.BlockRead = counterDelta(firstRead, lastRead)
+	usage.BlockWrite = counterDelta(firstWrite, lastWrite)
+	return usage
+}
+
+func counterDelta(first, last uint64) uint64 {
+	if last < first {
+		return 0
+	}
+	return last - first
+}
+
+// cpuPercent returns the CPU usage since the previous sample, like docker stats
+func cpuPercent(stats *container.StatsResponse) float64 {
+	if stats.PreCPUStats.SystemUsage == 0 {
+		return 0
+	}
+	cpuDelta := float64(counterDelta(stats.PreCPUStats.CPUUsage.TotalUsage, stats.CPUStats.CPUUsage.TotalUsage))
+	systemDelta := float64(counterDelta(stats.PreCPUStats.SystemUsage, stats.CPUStats.SystemUsage))
+	cpus := float64(stats.CPUStats.OnlineCPUs)
+	if cpus == 0 {
+		cpus = float64(len(stats.CPUStats.CPUUsage.PercpuUsage))
+	}
+	if systemDelta == 0 || cpuDelta == 0 {
+		return 0
+	}
+	return cpuDelta / systemDelta * cpus * 100
+}
+
+// memoryUsage returns the memory used by the container without its page cache, like docker stats
+func memoryUsage(stats *container.MemoryStats) uint64 {
+	// cgroup v1 and v2 name the inactive page cache differently
+	for _, key := range []string{"total_inactive_file", "inactive_file"} {
+		if inactive, ok := stats.Stats[key]; ok && inactive < stats.Usage {
+			return stats.Usage - inactive
+		}
+	}
+	return stats.Usage
+}
+
+func networkBytes(stats *container.StatsResponse) (rx, tx uint64) {
+	for _, network := range stats.Networks {
+		rx += network.RxBytes
+		tx += network.TxBytes
+	}
+	return rx, tx
+}
+
+func blockBytes(stats *container.StatsResponse) (read, write uint64) {
+	for _, entry := range stats.BlkioStats.IoServiceBytesRecursive {
+		switch strings.ToLower(entry.Op) {
+		case "read":
+			read += entry.Value
+		case "write":
+			write += entry.Value
+		}
+	}
+	return read, write
+}
@@ -0,0 +1,58 @@
+package container
+
+import (
+	"testing"
+	"time"
+
+	"github.com/moby/moby/api/types/container"
+	"github.com/stretchr/testify/assert"
+)
+
+func newStats(read time.Time, cpu, preCPU, system, preSystem, memory, rx, written uint64) *container.StatsResponse {
+	return &container.StatsResponse{
+		Read: read,
+		CPUStats: container.CPUStats{
+			CPUUsage:    container.CPUUsage{TotalUsage: cpu},
+			SystemUsage: system,
+			OnlineCPUs:  2,
+		},
+		PreCPUStats: container.CPUStats{
+			CPUUsage:    container.CPUUsage{TotalUsage: preCPU},
+			SystemUsage: preSystem,
+		},
+		MemoryStats: container.MemoryStats{
+			Usage: memory,
+			Stats: map[string]uint64{"inactive_file": 100},
+		},
+		Networks: map[string]container.NetworkStats{"eth0": {RxBytes: rx, TxBytes: rx / 2}},
+		BlkioStats: container.BlkioStats{
+			IoServiceBytesRecursive: []container.BlkioStatEntry{{Op: "Write", Value: written}, {Op: "read", Value: 7}},
+		},
+	}
+}
+
+func TestStatsSampler(t *testing.T) {
+	now := time.Now()
+	sampler := &statsSampler{}
+	sampler.add(nil)
+	assert.Equal(t, ResourceUsage{Duration: time.Second}, sampler.usage(time.Second))
+
+	sampler.add(newStats(now, 1e9, 0, 0, 0, 1100, 1000, 10))
+	// half of the time of the 2 CPUs of the system
+	sampler.add(newStats(now.Add(time.Second), 2e9, 1e9, 4e9, 2e9, 2100, 1500, 30))
+	sampler.add(newStats(now.Add(2*time.Second), 2.5e9, 2e9, 6e9, 4e9, 600, 2000, 50))
+	// the container has stopped
+	sampler.add(&container.StatsResponse{})
+
+	usage := sampler.usage(2 * time.Second)
+	assert.Equal(t, 3, usage.Samples)
+	assert.Equal(t, 1500*time.Millisecond, usage.CPUTime)
+	assert.InDelta(t, 75, usage.CPUAvg, 0.001)
+	assert.InDelta(t, 100, usage.CPUPeak, 0.001)
+	assert.Equal(t, uint64(2000), usage.MemoryPeak)
+	assert.Equal(t, uint64(1166), usage.MemoryAvg)
+	assert.Equal(t, uint64(1000), usage.NetworkRx)
+	assert.Equal(t, uint64(500), usage.NetworkTx)
+	assert.Equal(t, uint64(0), usage.BlockRead)
+	assert.Equal(t, uint64(40), usage.BlockWrite)
+}
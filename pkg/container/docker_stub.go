@@ -4,8 +4,10 @@ package container
 
 import (
 	"context"
+	"io"
 	"runtime"
 
+	"github.com/docker/go-connections/nat"
 	"github.com/moby/moby/api/types/system"
 	"github.com/nektos/act/pkg/common"
 	"github.com/pkg/errors"
@@ -23,6 +25,39 @@ func RemoveImage(ctx context.Context, imageName string, force bool, pruneChildre
 	return false, errors.New("Unsupported Operation")
 }
 
+// ImageDigest returns the digest of the repository of an image in the local docker image store,
+// empty if the image hasn't been pulled from a registry
+func ImageDigest(ctx context.Context, imageName string) (string, error) {
+	return "", errors.New("Unsupported Operation")
+}
+
+// ImageID returns the ID of an image in the local docker image store
+func ImageID(ctx context.Context, imageName string) (string, error) {
+	return "", errors.New("Unsupported Operation")
+}
+
+// ImageLabels returns the labels of an image in the local docker image store, false if there is no such image
+func ImageLabels(ctx context.Context, imageName string) (map[string]string, bool, error) {
+	return nil, false, errors.New("Unsupported Operation")
+}
+
+// SaveImages writes the archive of images of the local docker image store to w
+func SaveImages(ctx context.Context, images []string, w io.Writer) error {
+	return errors.New("Unsupported Operation")
+}
+
+// RemoveLabeledImages removes the tags of the images whose label is set to value, but the keep tag
+func RemoveLabeledImages(ctx context.Context, label, value, keep string) ([]string, error) {
+	return nil, errors.New("Unsupported Operation")
+}
+
+// NewDockerCommitExecutor commits a container to an image with labels
+func NewDockerCommitExecutor(containerName, imageName string, labels map[string]string) common.Executor {
+	return func(ctx context.Context) error {
+		return errors.New("Unsupported Operation")
+	}
+}
+
 // NewDockerBuildExecutor function to create a run executor for the container
 func NewDockerBuildExecutor(input NewDockerBuildExecutorInput) common.Executor {
 	return func(ctx context.Context) error {
@@ -56,6 +91,16 @@ func NewDockerVolumeRemoveExecutor(volume string, force bool) common.Executor {
 	}
 }
 
+func VolumeInUse(ctx context.Context, volumeName string) (bool, error) {
+	return false, nil
+}
+
+func NewContainerHealthyExecutor(name string) common.Executor {
+	return func(ctx context.Context) error {
+		return nil
+	}
+}
+
 func NewDockerNetworkCreateExecutor(name string) common.Executor {
 	return func(ctx context.Context) error {
 		return nil
@@ -67,3 +112,40 @@ func NewDockerNetworkRemoveExecutor(name string) common.Executor {
 		return nil
 	}
 }
+
+// NewDockerNetworkDisconnectExecutor disconnects a container from a network
+func NewDockerNetworkDisconnectExecutor(name, containerName string) common.Executor {
+	return func(ctx context.Context) error {
+		return nil
+	}
+}
+
+// NewPodmanContainer creates a reference to a container of Podman
+func NewPodmanContainer(input *NewContainerInput) ExecutionsEnvironment {
+	return nil
+}
+
+// PodmanPodInput the input for the NewPodmanPodCreateExecutor function
+type PodmanPodInput struct {
+	Name         string
+	Hosts        []string
+	PortBindings nat.PortMap
+}
+
+func NewPodmanPodCreateExecutor(input PodmanPodInput) common.Executor {
+	return func(ctx context.Context) error {
+		return nil
+	}
+}
+
+func NewPodmanPodRemoveExecutor(name string) common.Executor {
+	return func(ctx context.Context) error {
+		return nil
+	}
+}
+
+func NewWorkspaceVolumeExecutor(input WorkspaceVolumeInput) common.Executor {
+	return func(ctx context.Context) error {
+		return nil
+	}
+}
@@ -33,6 +33,23 @@ func NewDockerVolumeRemoveExecutor(volumeName string, force bool) common.Executo
 	}
 }
 
+// VolumeInUse returns true if a running container mounts the volume
+func VolumeInUse(ctx context.Context, volumeName string) (bool, error) {
+	cli, err := GetDockerClient(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer cli.Close()
+
+	result, err := cli.ContainerList(ctx, client.ContainerListOptions{
+		Filters: make(client.Filters).Add("volume", volumeName),
+	})
+	if err != nil {
+		return false, err
+	}
+	return len(result.Items) > 0, nil
+}
+
 func removeExecutor(volume string, force bool) common.Executor {
 	return func(ctx context.Context) error {
 		logger := common.Logger(ctx)
@@ -0,0 +1,607 @@
+//go:build !(WITHOUT_DOCKER || !(linux || darwin || windows || netbsd))
+
+package container
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/kballard/go-shellquote"
+	"github.com/moby/moby/api/pkg/stdcopy"
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/api/types/mount"
+	"github.com/moby/moby/client"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/spf13/pflag"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/filecollector"
+)
+
+// The labels of the volumes of the workspace snapshots
+const (
+	workspaceSnapshotLabel = "act.workspace-snapshot" // the working directory of the snapshot
+	workspaceDigestLabel   = "act.workspace-digest"   // the digest of the files of the snapshot
+	workspaceOverlayLabel  = "act.workspace-overlay"  // the volume of the snapshot the workspace of a job overlays
+)
+
+// workspaceRemoveChunk is the number of files removed from the workspace by a single rm
+const workspaceRemoveChunk = 500
+
+var snapshotVolumeLocks sync.Map
+
+// NewWorkspaceVolumeExecutor creates the volume of the workspace of a job from the snapshot of the working directory:
+// the files are copied once per snapshot to a volume shared by the jobs, which the volume of the job overlays copy-on-write.
+// The volume of an existing container is kept with --reuse, the copy of the workspace only sends the files which have changed since.
+// If the daemon can't mount an overlay, e.g. rootless Docker, the working directory is copied to the workspace as usual.
+func NewWorkspaceVolumeExecutor(input WorkspaceVolumeInput) common.Executor {
+	return func(ctx context.Context) error {
+		logger := common.Logger(ctx)
+		cli, err := GetDockerClient(ctx)
+		if err != nil {
+			return err
+		}
+		defer cli.Close()
+
+		if _, err := cli.VolumeInspect(ctx, input.Name, client.VolumeInspectOptions{}); err == nil {
+			logger.Debugf("Reusing the volume %s of the workspace", input.Name)
+			return nil
+		}
+
+		snapshot, err := GetWorkspaceSnapshot(ctx, input.SrcPath, input.UseGitIgnore)
+		if err != nil {
+			return err
+		}
+		// the files are given to the user of the job container, chowning them in the job container would copy all of them to the upper layer
+		owner, err := workspaceOwner(ctx, cli, input)
+		if err != nil {
+			logger.Debugf("Failed to read the user of the job container, the workspace snapshot is owned by root: %v", err)
+			owner = fileOwner{}
+		}
+		snapshotVolume := "act-workspace-" + snapshot.Digest[:16]
+		if owner != (fileOwner{}) {
+			snapshotVolume += fmt.Sprintf("-%d-%d", owner.UID, owner.GID)
+		}
+		if err := populateSnapshotVolume(ctx, cli, input, snapshot, snapshotVolume, owner); err != nil {
+			return fmt.Errorf("failed to populate the volume %s of the workspace snapshot: %w", snapshotVolume, err)
+		}
+		if err := createOverlayVolume(ctx, cli, input, snapshot, snapshotVolume, owner); err != nil {
+			logger.Warnf("Failed to overlay the workspace snapshot, copying the working directory instead: %v", err)
+			_, _ = cli.VolumeRemove(ctx, input.Name, client.VolumeRemoveOptions{Force: true})
+			_, _ = cli.VolumeRemove(ctx, input.Name+"-upper", client.VolumeRemoveOptions{Force: true})
+			return nil
+		}
+		logger.Infof("Workspace %s overlays the snapshot %s of %d files", input.Name, snapshotVolume, len(snapshot.Manifest))
+		return nil
+	}
+}
+
+// fileOwner is the user and group owning the files copied to a volume
+type fileOwner struct {
+	UID int
+	GID int
+}
+
+// workspaceOwner returns the user and group of the job container, from its --user option or else the user of its image
+func workspaceOwner(ctx context.Context, cli client.APIClient, input WorkspaceVolumeInput) (fileOwner, error) {
+	user, err := optionsUser(input.Options)
+	if err != nil {
+		return fileOwner{}, err
+	}
+	if user == "" {
+		inspect, err := cli.ImageInspect(ctx, input.Image)
+		if err != nil {
+			return fileOwner{}, err
+		}
+		if inspect.Config != nil {
+			user = inspect.Config.User
+		}
+	}
+	if user == "" {
+		return fileOwner{}, nil
+	}
+	if uid, gid, ok := strings.Cut(user, ":"); ok {
+		owner := fileOwner{}
+		var uidErr, gidErr error
+		owner.UID, uidErr = strconv.Atoi(uid)
+		owner.GID, gidErr = strconv.Atoi(gid)
+		if uidErr == nil && gidErr == nil {
+			return owner, nil
+		}
+	}
+	// the names and the primary group of the user are resolved by the image
+	return readHelperOwner(ctx, cli, input, user)
+}
+
+// optionsUser returns the --user of the container options
+func optionsUser(options string) (string, error) {
+	if options == "" {
+		return "", nil
+	}
+	flags := pflag.NewFlagSet("container_flags", pflag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	copts := addFlags(flags)
+	optionsArgs, err := shellquote.Split(options)
+	if err != nil {
+		return "", fmt.Errorf("Cannot split container options: '%s': '%w'", options, err)
+	}
+	if err := flags.Parse(optionsArgs); err != nil {
+		return "", fmt.Errorf("Cannot parse container options: '%s': '%w'", options, err)
+	}
+	return copts.user, nil
+}
+
+// readHelperOwner runs id as the user in a container of the image of the job
+func readHelperOwner(ctx context.Context, cli client.APIClient, input WorkspaceVolumeInput, user string) (fileOwner, error) {
+	resp, err := cli.ContainerCreate(ctx, client.ContainerCreateOptions{
+		Config:   &container.Config{Image: input.Image, User: user, Entrypoint: []string{"/bin/sh", "-c", "id -u && id -g"}},
+		Platform: helperPlatform(ctx, cli, input),
+	})
+	if err != nil {
+		return fileOwner{}, fmt.Errorf("failed to create container: '%w'", err)
+	}
+	defer removeHelperContainer(ctx, cli, resp.ID)
+	if _, err := cli.ContainerStart(ctx, resp.ID, client.ContainerStartOptions{}); err != nil {
+		return fileOwner{}, fmt.Errorf("failed to start container: '%w'", err)
+	}
+	wait := cli.ContainerWait(ctx, resp.ID, client.ContainerWaitOptions{Condition: container.WaitConditionNotRunning})
+	select {
+	case err := <-wait.Error:
+		return fileOwner{}, err
+	case status := <-wait.Result:
+		if status.StatusCode != 0 {
+			return fileOwner{}, fmt.Errorf("id exited with %d for the user %s", status.StatusCode, user)
+		}
+	}
+	logs, err := cli.ContainerLogs(ctx, resp.ID, client.ContainerLogsOptions{ShowStdout: true})
+	if err != nil {
+		return fileOwner{}, err
+	}
+	defer logs.Close()
+	var stdout bytes.Buffer
+	if _, err := stdcopy.StdCopy(&stdout, io.Discard, logs); err != nil {
+		return fileOwner{}, err
+	}
+	return parseOwner(stdout.String())
+}
+
+// parseOwner parses the output of id -u && id -g
+func parseOwner(output string) (fileOwner, error) {
+	ids := strings.Fields(output)
+	if len(ids) != 2 {
+		return fileOwner{}, fmt.Errorf("unexpected output of id: %q", output)
+	}
+	uid, err := strconv.Atoi(ids[0])
+	if err != nil {
+		return fileOwner{}, err
+	}
+	gid, err := strconv.Atoi(ids[1])
+	if err != nil {
+		return fileOwner{}, err
+	}
+	return fileOwner{UID: uid, GID: gid}, nil
+}
+
+// populateSnapshotVolume copies the files of the snapshot to its volume, unless a job already did,
+// and removes the unused volumes of the previous snapshots of the working directory
+func populateSnapshotVolume(ctx context.Context, cli client.APIClient, input WorkspaceVolumeInput, snapshot *WorkspaceSnapshot, snapshotVolume string, owner fileOwner) error {
+	logger := common.Logger(ctx)
+	lock, _ := snapshotVolumeLocks.LoadOrStore(snapshotVolume, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	if _, err := cli.VolumeCreate(ctx, client.VolumeCreateOptions{
+		Name: snapshotVolume,
+		Labels: map[string]string{
+			workspaceSnapshotLabel: filepath.Clean(input.SrcPath),
+			workspaceDigestLabel:   snapshot.Digest,
+		},
+	}); err != nil {
+		return err
+	}
+	id, err := createHelperContainer(ctx, cli, input, map[string]string{snapshotVolume: "/snapshot"})
+	if err != nil {
+		return err
+	}
+	defer removeHelperContainer(ctx, cli, id)
+
+	// the marker is written once all the files have been copied
+	if _, err := cli.ContainerStatPath(ctx, id, client.ContainerStatPathOptions{Path: "/snapshot/complete"}); err == nil {
+		logger.Debugf("Volume %s of the workspace snapshot exists", snapshotVolume)
+		return nil
+	} else if !cerrdefs.IsNotFound(err) {
+		return err
+	}
+
+	logger.Infof("Copying %d files of %s to the volume %s of the workspace snapshot", len(snapshot.Manifest), input.SrcPath, snapshotVolume)
+	tarFile, err := os.CreateTemp("", "act")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tarFile.Name())
+	defer tarFile.Close()
+	tw := tar.NewWriter(tarFile)
+	fc := newFileCollector(ctx, input.SrcPath, input.UseGitIgnore, &filecollector.TarCollector{
+		TarWriter: tw,
+		UID:       owner.UID,
+		GID:       owner.GID,
+		DstDir:    "snapshot/workspace",
+	})
+	if err := filepath.Walk(input.SrcPath, fc.CollectFiles(ctx, []string{})); err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if _, err := tarFile.Seek(0, 0); err != nil {
+		return fmt.Errorf("failed to seek tar archive: %w", err)
+	}
+	if _, err := cli.CopyToContainer(ctx, id, client.CopyToContainerOptions{DestinationPath: "/", Content: tarFile}); err != nil {
+		return fmt.Errorf("failed to copy content to container: %w", err)
+	}
+	// the directories aren't in the manifest, they are created by the daemon as root
+	dirs := []*FileEntry{{Name: "snapshot/workspace/", Mode: 0o755}}
+	for _, dir := range snapshotDirs(snapshot.Manifest) {
+		dirs = append(dirs, &FileEntry{Name: "snapshot/workspace/" + dir + "/", Mode: 0o755})
+	}
+	if err := copyToHelperContainer(ctx, cli, id, owner, dirs...); err != nil {
+		return err
+	}
+	if err := copyToHelperContainer(ctx, cli, id, fileOwner{}, &FileEntry{Name: "snapshot/complete", Mode: 0o644}); err != nil {
+		return err
+	}
+	removePreviousSnapshotVolumes(ctx, cli, input.SrcPath, snapshotVolume, snapshot.Digest)
+	return nil
+}
+
+// snapshotDirs returns the sorted directories of the files of the manifest
+func snapshotDirs(manifest filecollector.Manifest) []string {
+	seen := map[string]bool{}
+	for p := range manifest {
+		for dir := path.Dir(p); dir != "." && dir != "/" && !seen[dir]; dir = path.Dir(dir) {
+			seen[dir] = true
+		}
+	}
+	dirs := make([]string, 0, len(seen))
+	for dir := range seen {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	return dirs
+}
+
+// removePreviousSnapshotVolumes removes the volumes of the previous snapshots of the working directory which no workspace overlays anymore,
+// the volumes of the snapshot owned by other users are kept for the jobs of the run
+func removePreviousSnapshotVolumes(ctx context.Context, cli client.APIClient, srcPath string, snapshotVolume string, digest string) {
+	logger := common.Logger(ctx)
+	previous, err := cli.VolumeList(ctx, client.VolumeListOptions{
+		Filters: make(client.Filters).Add("label", workspaceSnapshotLabel+"="+filepath.Clean(srcPath)),
+	})
+	if err != nil {
+		logger.Debugf("Failed to list the volumes of the previous workspace snapshots: %v", err)
+		return
+	}
+	for _, vol := range previous.Items {
+		if vol.Name == snapshotVolume || vol.Labels[workspaceDigestLabel] == digest {
+			continue
+		}
+		overlays, err := cli.VolumeList(ctx, client.VolumeListOptions{
+			Filters: make(client.Filters).Add("label", workspaceOverlayLabel+"="+vol.Name),
+		})
+		if err != nil || len(overlays.Items) > 0 {
+			continue
+		}
+		if _, err := cli.VolumeRemove(ctx, vol.Name, client.VolumeRemoveOptions{}); err == nil {
+			logger.Debugf("Removed the volume %s of a previous workspace snapshot", vol.Name)
+		}
+	}
+}
+
+// createOverlayVolume creates the volume of the workspace of the job, an overlay of the volume of the snapshot with the upper layer in another volume,
+// and records the snapshot as the content of the workspace in the volume of the act path
+func createOverlayVolume(ctx context.Context, cli client.APIClient, input WorkspaceVolumeInput, snapshot *WorkspaceSnapshot, snapshotVolume string, owner fileOwner) error {
+	upperVolume := input.Name + "-upper"
+	id, err := createHelperContainer(ctx, cli, input, map[string]string{upperVolume: "/upper"})
+	if err != nil {
+		return err
+	}
+	// the upper directory is the root of the overlay
+	err = copyToHelperContainer(ctx, cli, id, owner,
+		&FileEntry{Name: "upper/upper/", Mode: 0o755},
+		&FileEntry{Name: "upper/work/", Mode: 0o755},
+	)
+	removeHelperContainer(ctx, cli, id)
+	if err != nil {
+		return err
+	}
+
+	lower, err := cli.VolumeInspect(ctx, snapshotVolume, client.VolumeInspectOptions{})
+	if err != nil {
+		return err
+	}
+	upper, err := cli.VolumeInspect(ctx, upperVolume, client.VolumeInspectOptions{})
+	if err != nil {
+		return err
+	}
+	if _, err := cli.VolumeCreate(ctx, client.VolumeCreateOptions{
+		Name:   input.Name,
+		Driver: "local",
+		Labels: map[string]string{workspaceOverlayLabel: snapshotVolume},
+		DriverOpts: map[string]string{
+			"type":   "overlay",
+			"device": "overlay",
+			"o": fmt.Sprintf("lowerdir=%s/workspace,upperdir=%s/upper,workdir=%s/work",
+				lower.Volume.Mountpoint, upper.Volume.Mountpoint, upper.Volume.Mountpoint),
+		},
+	}); err != nil {
+		return err
+	}
+
+	// copying to the helper container mounts the overlay, which fails early if the daemon can't mount it
+	id, err = createHelperContainer(ctx, cli, input, map[string]string{input.Name: "/workspace", input.ActVolume: "/act"})
+	if err != nil {
+		return err
+	}
+	defer removeHelperContainer(ctx, cli, id)
+	if _, err := cli.ContainerStatPath(ctx, id, client.ContainerStatPathOptions{Path: "/workspace"}); err != nil {
+		return err
+	}
+	manifest, err := json.Marshal(&workspaceManifest{UID: owner.UID, GID: owner.GID, Files: snapshot.Manifest})
+	if err != nil {
+		return err
+	}
+	return copyToHelperContainer(ctx, cli, id, fileOwner{}, &FileEntry{
+		Name: path.Join("act", workspaceManifestPath(input.WorkingDir)),
+		Mode: 0o644,
+		Body: string(manifest),
+	})
+}
+
+// createHelperContainer creates a container mounting the volumes, to copy files to them
+func createHelperContainer(ctx context.Context, cli client.APIClient, input WorkspaceVolumeInput, volumes map[string]string) (string, error) {
+	mounts := make([]mount.Mount, 0, len(volumes))
+	for source, target := range volumes {
+		mounts = append(mounts, mount.Mount{Type: mount.TypeVolume, Source: source, Target: target})
+	}
+	resp, err := cli.ContainerCreate(ctx, client.ContainerCreateOptions{
+		Config:     &container.Config{Image: input.Image, Entrypoint: []string{"/bin/true"}},
+		HostConfig: &container.HostConfig{Mounts: mounts},
+		Platform:   helperPlatform(ctx, cli, input),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create container: '%w'", err)
+	}
+	return resp.ID, nil
+}
+
+func helperPlatform(ctx context.Context, cli client.APIClient, input WorkspaceVolumeInput) *specs.Platform {
+	if goos, arch, ok := strings.Cut(input.Platform, "/"); ok && supportsContainerImagePlatform(ctx, cli) {
+		return &specs.Platform{OS: goos, Architecture: arch}
+	}
+	return nil
+}
+
+func removeHelperContainer(ctx context.Context, cli client.APIClient, id string) {
+	if _, err := cli.ContainerRemove(ctx, id, client.ContainerRemoveOptions{Force: true}); err != nil {
+		common.Logger(ctx).Debugf("Failed to remove the container %s: %v", id, err)
+	}
+}
+
+// copyToHelperContainer copies the files owned by owner to the root of the container, the names ending with a slash are directories
+func copyToHelperContainer(ctx context.Context, cli client.APIClient, id string, owner fileOwner, files ...*FileEntry) error {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, file := range files {
+		hdr := &tar.Header{Name: file.Name, Mode: file.Mode, Size: int64(len(file.Body)), Typeflag: tar.TypeReg, Uid: owner.UID, Gid: owner.GID}
+		if strings.HasSuffix(file.Name, "/") {
+			hdr.Typeflag = tar.TypeDir
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write([]byte(file.Body)); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if _, err := cli.CopyToContainer(ctx, id, client.CopyToContainerOptions{DestinationPath: "/", Content: &buf}); err != nil {
+		return fmt.Errorf("failed to copy content to container: %w", err)
+	}
+	return nil
+}
+
+// syncDir copies the files of srcPath to dstPath which have changed since the last copy, according to the manifest of dstPath kept in the container,
+// and removes the files which have been removed since. The files of the manifest modified or removed in the container since, e.g. by the steps
+// of a reused container, are copied again. Without a manifest, all the files are copied.
+func (cr *containerReference) syncDir(dstPath string, srcPath string, useGitIgnore bool) common.Executor {
+	return func(ctx context.Context) error {
+		logger := common.Logger(ctx)
+		snapshot, err := GetWorkspaceSnapshot(ctx, srcPath, useGitIgnore)
+		if err != nil {
+			return err
+		}
+		previous, err := cr.readWorkspaceManifest(ctx, dstPath)
+		if err != nil {
+			logger.Debugf("No manifest of %s in the container, copying all the files: %v", dstPath, err)
+			if err := cr.copyDir(dstPath, srcPath, useGitIgnore, nil)(ctx); err != nil {
+				return err
+			}
+			cr.chownDir(ctx, dstPath)
+			return cr.writeWorkspaceManifest(ctx, dstPath, snapshot)
+		}
+
+		changed, removed := snapshot.Manifest.Diff(previous.Files)
+		modified, err := cr.modifiedInContainer(ctx, dstPath, previous.Files)
+		if err != nil {
+			logger.Debugf("Failed to list the files modified in %s, copying the files changed in %s only: %v", dstPath, srcPath, err)
+		}
+		changed = mergeModified(changed, snapshot.Manifest, modified)
+		logger.Infof("%d files changed and %d files removed in %s since the last copy to %s", len(changed), len(removed), srcPath, dstPath)
+		for i := 0; i < len(removed); i += workspaceRemoveChunk {
+			chunk := removed[i:min(i+workspaceRemoveChunk, len(removed))]
+			args := []string{"rm", "-rf", "--"}
+			for _, p := range chunk {
+				args = append(args, path.Join(dstPath, p))
+			}
+			if err := cr.Exec(args, nil, "0", "")(ctx); err != nil {
+				return fmt.Errorf("failed to remove the files removed from %s: %w", srcPath, err)
+			}
+		}
+		if len(changed) > 0 {
+			paths := make(map[string]bool, len(changed))
+			for _, p := range changed {
+				paths[p] = true
+			}
+			if err := cr.copyDir(dstPath, srcPath, useGitIgnore, paths)(ctx); err != nil {
+				return err
+			}
+		}
+		if previous.UID != cr.UID || previous.GID != cr.GID {
+			cr.chownDir(ctx, dstPath)
+		}
+		if len(changed) == 0 && len(removed) == 0 && previous.UID == cr.UID && previous.GID == cr.GID {
+			return nil
+		}
+		return cr.writeWorkspaceManifest(ctx, dstPath, snapshot)
+	}
+}
+
+// modifiedInContainer returns the files of the manifest of dstPath which are newer than the manifest or missing in the container
+func (cr *containerReference) modifiedInContainer(ctx context.Context, dstPath string, files filecollector.Manifest) ([]string, error) {
+	// the files copied keep the modification time of the host, older than the manifest written after them
+	const script = `cd "$1" && find . ! -type d -newer "$2" && echo / && find . ! -type d`
+	output, err := cr.execOutput(ctx, []string{"sh", "-c", script, "sh", dstPath, path.Join(cr.GetActPath(), workspaceManifestPath(dstPath))})
+	if err != nil {
+		return nil, err
+	}
+	newer, existing, ok := strings.Cut(output, "/\n")
+	if !ok {
+		return nil, fmt.Errorf("unexpected output of find: %q", output)
+	}
+	return modifiedFiles(files, strings.Split(newer, "\n"), strings.Split(existing, "\n")), nil
+}
+
+// modifiedFiles returns the sorted files of the manifest which are newer or not existing, the paths found being prefixed with ./
+func modifiedFiles(files filecollector.Manifest, newer []string, existing []string) []string {
+	exists := make(map[string]bool, len(existing))
+	for _, p := range existing {
+		exists[strings.TrimPrefix(p, "./")] = true
+	}
+	modified := []string{}
+	for _, p := range newer {
+		if p = strings.TrimPrefix(p, "./"); p != "" {
+			if _, ok := files[p]; ok {
+				modified = append(modified, p)
+			}
+		}
+	}
+	for p := range files {
+		if !exists[p] {
+			modified = append(modified, p)
+		}
+	}
+	sort.Strings(modified)
+	return modified
+}
+
+// mergeModified adds to the files changed on the host the files modified in the container which are still in the snapshot
+func mergeModified(changed []string, snapshot filecollector.Manifest, modified []string) []string {
+	seen := make(map[string]bool, len(changed))
+	for _, p := range changed {
+		seen[p] = true
+	}
+	for _, p := range modified {
+		if _, ok := snapshot[p]; ok && !seen[p] {
+			seen[p] = true
+			changed = append(changed, p)
+		}
+	}
+	return changed
+}
+
+// chownDir hands dstPath over to the user of the container
+func (cr *containerReference) chownDir(ctx context.Context, dstPath string) {
+	if cr.UID == 0 && cr.GID == 0 {
+		return
+	}
+	// only the files of another owner are chowned, chowning a file of the lower layer of an overlay copies it to the upper layer
+	uid, gid := strconv.Itoa(cr.UID), strconv.Itoa(cr.GID)
+	const script = `find "$1" \( ! -user "$2" -o ! -group "$3" \) -exec chown -h "$2:$3" {} +`
+	if err := cr.Exec([]string{"sh", "-c", script, "sh", dstPath, uid, gid}, nil, "0", "")(ctx); err != nil {
+		// If this fails, then folders have wrong permissions on non root container
+		_ = cr.Exec([]string{"chown", "-R", uid + ":" + gid, dstPath}, nil, "0", "")(ctx)
+	}
+}
+
+// execOutput runs cmd as root in the container and returns its output
+func (cr *containerReference) execOutput(ctx context.Context, cmd []string) (string, error) {
+	idResp, err := cr.cli.ExecCreate(ctx, cr.id, client.ExecCreateOptions{
+		User:         "0",
+		Cmd:          cmd,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create exec: %w", err)
+	}
+	resp, err := cr.cli.ExecAttach(ctx, idResp.ID, client.ExecAttachOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to attach to exec: %w", err)
+	}
+	defer resp.Close()
+	var stdout, stderr bytes.Buffer
+	if _, err := stdcopy.StdCopy(&stdout, &stderr, resp.Reader); err != nil {
+		return "", err
+	}
+	inspectResp, err := cr.cli.ExecInspect(ctx, idResp.ID, client.ExecInspectOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect exec: %w", err)
+	}
+	if inspectResp.ExitCode != 0 {
+		return "", fmt.Errorf("exitcode '%d': %s", inspectResp.ExitCode, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+func (cr *containerReference) readWorkspaceManifest(ctx context.Context, dstPath string) (*workspaceManifest, error) {
+	archive, err := cr.GetContainerArchive(ctx, path.Join(cr.GetActPath(), workspaceManifestPath(dstPath)))
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+	reader := tar.NewReader(archive)
+	if _, err := reader.Next(); err != nil {
+		return nil, err
+	}
+	manifest := &workspaceManifest{}
+	if err := json.NewDecoder(reader).Decode(manifest); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+func (cr *containerReference) writeWorkspaceManifest(ctx context.Context, dstPath string, snapshot *WorkspaceSnapshot) error {
+	manifest, err := json.Marshal(&workspaceManifest{UID: cr.UID, GID: cr.GID, Files: snapshot.Manifest})
+	if err != nil {
+		return err
+	}
+	return cr.copyContent(cr.GetActPath()+"/", &FileEntry{
+		Name: workspaceManifestPath(dstPath),
+		Mode: 0o644,
+		Body: string(manifest),
+	})(ctx)
+}
@@ -0,0 +1,58 @@
+package container
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nektos/act/pkg/filecollector"
+)
+
+func TestWorkspaceOwner(t *testing.T) {
+	user, err := optionsUser("--cpus 2 --user 1001:1002")
+	assert.NoError(t, err)
+	assert.Equal(t, "1001:1002", user)
+	user, err = optionsUser("")
+	assert.NoError(t, err)
+	assert.Empty(t, user)
+	_, err = optionsUser("--unknown")
+	assert.Error(t, err)
+
+	owner, err := parseOwner("1001\n1002\n")
+	assert.NoError(t, err)
+	assert.Equal(t, fileOwner{UID: 1001, GID: 1002}, owner)
+	_, err = parseOwner("id: unknown user\n")
+	assert.Error(t, err)
+}
+
+func TestSnapshotDirs(t *testing.T) {
+	manifest := filecollector.Manifest{
+		"README.md":         {},
+		"src/main.go":       {},
+		"src/pkg/lib/a.go":  {},
+		"src/pkg/lib/b.go":  {},
+		"docs/index.md":     {},
+		"docs/img/logo.png": {},
+	}
+	assert.Equal(t, []string{"docs", "docs/img", "src", "src/pkg", "src/pkg/lib"}, snapshotDirs(manifest))
+}
+
+func TestModifiedInContainer(t *testing.T) {
+	files := filecollector.Manifest{
+		"README.md":   {},
+		"main.go":     {},
+		"src/lib.go":  {},
+		"src/util.go": {},
+	}
+	// main.go is modified, src/util.go is removed and build/out is created in the container
+	modified := modifiedFiles(files,
+		[]string{"./main.go", "./build/out", ""},
+		[]string{"./README.md", "./main.go", "./src/lib.go", "./build/out", ""},
+	)
+	assert.Equal(t, []string{"main.go", "src/util.go"}, modified)
+
+	// the files modified in the container are copied again, unless they have been removed from the host
+	snapshot := filecollector.Manifest{"README.md": {}, "main.go": {}, "src/lib.go": {}, "new.go": {}}
+	assert.Equal(t, []string{"new.go", "main.go"}, mergeModified([]string{"new.go"}, snapshot, modified))
+	assert.Equal(t, []string{"main.go"}, mergeModified(nil, snapshot, []string{"main.go", "main.go"}))
+}
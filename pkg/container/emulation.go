@@ -0,0 +1,119 @@
+package container
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// binfmtMiscPath is where the binfmt_misc handlers of the kernel are registered
+const binfmtMiscPath = "/proc/sys/fs/binfmt_misc"
+
+// runnerArchs maps the architectures of go, docker and uname to the ones of runner.arch
+var runnerArchs = map[string]string{
+	"x86_64":  "X64",
+	"amd64":   "X64",
+	"386":     "X86",
+	"i386":    "X86",
+	"aarch64": "ARM64",
+	"arm64":   "ARM64",
+	"arm":     "ARM",
+}
+
+// toRunnerArch returns the architecture of runner.arch of an architecture of go, docker or uname, the architecture itself if it is unknown
+func toRunnerArch(arch string) string {
+	if runnerArch, ok := runnerArchs[arch]; ok {
+		return runnerArch
+	}
+	return arch
+}
+
+// PlatformArch returns the architecture of runner.arch of a platform of docker, e.g. ARM64 for linux/arm64/v8, empty if it has none
+func PlatformArch(platform string) string {
+	parts := strings.Split(platform, "/")
+	if len(parts) < 2 || parts[1] == "" {
+		return ""
+	}
+	return toRunnerArch(strings.ToLower(parts[1]))
+}
+
+// ArchPlatform returns the platform of docker of an OS and an architecture of runner.os and runner.arch,
+// e.g. linux/arm64 for Linux and ARM64
+func ArchPlatform(os, arch string) string {
+	goos := "linux"
+	if strings.EqualFold(os, "Windows") {
+		goos = "windows"
+	}
+	switch strings.ToUpper(arch) {
+	case "X64":
+		return goos + "/amd64"
+	case "X86":
+		return goos + "/386"
+	case "ARM64":
+		return goos + "/arm64"
+	case "ARM":
+		return goos + "/arm"
+	}
+	return goos + "/" + strings.ToLower(arch)
+}
+
+// qemuArchs maps the architectures of docker to the names of their QEMU binfmt_misc handlers
+var qemuArchs = map[string]string{
+	"amd64":    "x86_64",
+	"386":      "i386",
+	"arm64":    "aarch64",
+	"arm":      "arm",
+	"riscv64":  "riscv64",
+	"ppc64le":  "ppc64le",
+	"s390x":    "s390x",
+	"mips64le": "mips64el",
+	"mips64":   "mips64",
+}
+
+// CheckEmulation returns an error explaining how to register the emulator when the linux host, whose architecture is
+// hostArch, cannot run the containers of platform, e.g. linux/arm64 on an amd64 host without a binfmt_misc handler for aarch64
+func CheckEmulation(platform, hostArch string) error {
+	return checkEmulation(binfmtMiscPath, platform, hostArch)
+}
+
+func checkEmulation(binfmtMisc, platform, hostArch string) error {
+	parts := strings.Split(platform, "/")
+	if len(parts) < 2 || parts[0] != "linux" {
+		return nil
+	}
+	arch := strings.ToLower(parts[1])
+	host := strings.ToLower(hostArch)
+	if toRunnerArch(arch) == toRunnerArch(host) {
+		return nil
+	}
+	// the 64-bit hosts run the 32-bit binaries of their architecture natively
+	if (arch == "386" && toRunnerArch(host) == "X64") || (arch == "arm" && toRunnerArch(host) == "ARM64") {
+		return nil
+	}
+
+	install := fmt.Sprintf("register the QEMU emulator with `docker run --privileged --rm tonistiigi/binfmt --install %s`, or install the qemu-user-static package of the host", arch)
+	if _, err := os.Stat(filepath.Join(binfmtMisc, "register")); err != nil {
+		return fmt.Errorf("the host cannot run %s containers: binfmt_misc isn't mounted at %s, mount it with `sudo mount -t binfmt_misc binfmt_misc %s` and %s", platform, binfmtMisc, binfmtMisc, install)
+	}
+	if status, err := os.ReadFile(filepath.Join(binfmtMisc, "status")); err == nil && strings.TrimSpace(string(status)) == "disabled" {
+		return fmt.Errorf("the host cannot run %s containers: binfmt_misc is disabled, enable it with `echo 1 | sudo tee %s`", platform, filepath.Join(binfmtMisc, "status"))
+	}
+
+	qemuArch, ok := qemuArchs[arch]
+	if !ok {
+		qemuArch = arch
+	}
+	handler := filepath.Join(binfmtMisc, "qemu-"+qemuArch)
+	content, err := os.ReadFile(handler)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("the host cannot run %s containers: no binfmt_misc handler for %s is registered in %s, %s", platform, qemuArch, binfmtMisc, install)
+	} else if err != nil {
+		return fmt.Errorf("the host cannot run %s containers: failed to read the binfmt_misc handler %s: %w", platform, handler, err)
+	}
+	if !strings.HasPrefix(string(content), "enabled") {
+		return fmt.Errorf("the host cannot run %s containers: the binfmt_misc handler %s is disabled, enable it with `echo 1 | sudo tee %s`", platform, handler, handler)
+	}
+	return nil
+}
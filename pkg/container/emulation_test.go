@@ -0,0 +1,51 @@
+package container
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlatformArch(t *testing.T) {
+	assert.Equal(t, "ARM64", PlatformArch("linux/arm64/v8"))
+	assert.Equal(t, "X64", PlatformArch("linux/amd64"))
+	assert.Equal(t, "X86", PlatformArch("linux/386"))
+	assert.Equal(t, "riscv64", PlatformArch("linux/riscv64"))
+	assert.Empty(t, PlatformArch("linux"))
+	assert.Empty(t, PlatformArch(""))
+
+	assert.Equal(t, "linux/arm64", ArchPlatform("Linux", "ARM64"))
+	assert.Equal(t, "linux/amd64", ArchPlatform("", "X64"))
+	assert.Equal(t, "windows/386", ArchPlatform("Windows", "X86"))
+}
+
+func TestCheckEmulation(t *testing.T) {
+	binfmtMisc := t.TempDir()
+
+	// nothing to emulate
+	assert.NoError(t, checkEmulation(binfmtMisc, "linux/amd64", "x86_64"))
+	assert.NoError(t, checkEmulation(binfmtMisc, "linux/386", "x86_64"))
+	assert.NoError(t, checkEmulation(binfmtMisc, "linux/arm/v7", "aarch64"))
+	assert.NoError(t, checkEmulation(binfmtMisc, "windows/arm64", "x86_64"))
+	assert.NoError(t, checkEmulation(binfmtMisc, "", "x86_64"))
+
+	assert.ErrorContains(t, checkEmulation(binfmtMisc, "linux/arm64", "x86_64"), "binfmt_misc isn't mounted")
+
+	assert.NoError(t, os.WriteFile(filepath.Join(binfmtMisc, "register"), nil, 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(binfmtMisc, "status"), []byte("disabled\n"), 0o600))
+	assert.ErrorContains(t, checkEmulation(binfmtMisc, "linux/arm64", "x86_64"), "binfmt_misc is disabled")
+
+	assert.NoError(t, os.WriteFile(filepath.Join(binfmtMisc, "status"), []byte("enabled\n"), 0o600))
+	err := checkEmulation(binfmtMisc, "linux/arm64", "x86_64")
+	assert.ErrorContains(t, err, "no binfmt_misc handler for aarch64")
+	assert.ErrorContains(t, err, "tonistiigi/binfmt --install arm64")
+
+	assert.NoError(t, os.WriteFile(filepath.Join(binfmtMisc, "qemu-aarch64"), []byte("disabled\ninterpreter /usr/bin/qemu-aarch64-static\n"), 0o600))
+	assert.ErrorContains(t, checkEmulation(binfmtMisc, "linux/arm64", "x86_64"), "qemu-aarch64 is disabled")
+
+	assert.NoError(t, os.WriteFile(filepath.Join(binfmtMisc, "qemu-aarch64"), []byte("enabled\ninterpreter /usr/bin/qemu-aarch64-static\n"), 0o600))
+	assert.NoError(t, checkEmulation(binfmtMisc, "linux/arm64", "x86_64"))
+	assert.ErrorContains(t, checkEmulation(binfmtMisc, "linux/amd64", "aarch64"), "no binfmt_misc handler for x86_64")
+}
@@ -33,6 +33,10 @@ type HostEnvironment struct {
 	ActPath   string
 	CleanUp   func()
 	StdOut    io.Writer
+
+	// wrapCommand wraps the commands run on the host, e.g. to run them in a sandbox,
+	// terminal tells whether the command is attached to a pseudo terminal as its controlling terminal
+	wrapCommand func(path string, args []string, workdir string, terminal bool) (string, []string)
 }
 
 func (e *HostEnvironment) Create(_ []string, _ []string) common.Executor {
@@ -290,16 +294,7 @@ func getEnvListFromMap(env map[string]string) []string {
 
 func (e *HostEnvironment) exec(ctx context.Context, command []string, cmdline string, env map[string]string, _, workdir string) error {
 	envList := getEnvListFromMap(env)
-	var wd string
-	if workdir != "" {
-		if filepath.IsAbs(workdir) {
-			wd = workdir
-		} else {
-			wd = filepath.Join(e.Path, workdir)
-		}
-	} else {
-		wd = e.Path
-	}
+	wd := e.workingDir(workdir)
 	f, err := lookupPathHost(command[0], env, e.StdOut)
 	if err != nil {
 		return err
@@ -330,6 +325,10 @@ func (e *HostEnvironment) exec(ctx context.Context, command []string, cmdline st
 			common.Logger(ctx).Debugf("Failed to setup Pty %v\n", err.Error())
 		}
 	}
+	if e.wrapCommand != nil {
+		// without a pseudo terminal, the command would share the controlling terminal of act
+		cmd.Path, cmd.Args = e.wrapCommand(f, command, wd, tty != nil)
+	}
 	writer := &ptyWriter{Out: e.StdOut}
 	logctx, finishLog := context.WithCancel(context.Background())
 	if ppty != nil {
@@ -340,7 +339,11 @@ func (e *HostEnvironment) exec(ctx context.Context, command []string, cmdline st
 	if ppty != nil {
 		go writeKeepAlive(ppty)
 	}
+	startedAt := time.Now()
 	err = cmd.Run()
+	if usage := resourceUsage(ctx); usage != nil && cmd.ProcessState != nil {
+		usage.Add(hostResourceUsage(cmd.ProcessState, time.Since(startedAt)))
+	}
 	if err != nil {
 		return err
 	}
@@ -359,6 +362,46 @@ func (e *HostEnvironment) exec(ctx context.Context, command []string, cmdline st
 	return err
 }
 
+func (e *HostEnvironment) workingDir(workdir string) string {
+	if workdir == "" {
+		return e.Path
+	}
+	if filepath.IsAbs(workdir) {
+		return workdir
+	}
+	return filepath.Join(e.Path, workdir)
+}
+
+// Shell opens an interactive shell on the host, attached to the terminal of act
+func (e *HostEnvironment) Shell(env map[string]string, _, workdir string) common.Executor {
+	return func(ctx context.Context) error {
+		shell := "sh"
+		if runtime.GOOS == "windows" {
+			shell = "cmd"
+		} else if s := os.Getenv("SHELL"); s != "" {
+			shell = s
+		}
+		f, err := lookupPathHost(shell, env, e.StdOut)
+		if err != nil {
+			return err
+		}
+		wd := e.workingDir(workdir)
+		args := []string{shell}
+		if e.wrapCommand != nil {
+			// the interactive shell shares the terminal of act with the user
+			f, args = e.wrapCommand(f, args, wd, true)
+		}
+		cmd := exec.CommandContext(ctx, f)
+		cmd.Args = args
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		cmd.Env = getEnvListFromMap(env)
+		cmd.Dir = wd
+		return cmd.Run()
+	}
+}
+
 func (e *HostEnvironment) Exec(command []string /*cmdline string, */, env map[string]string, user, workdir string) common.Executor {
 	return e.ExecWithCmdLine(command, "", env, user, workdir)
 }
@@ -428,15 +471,7 @@ func (*HostEnvironment) JoinPathVariable(paths ...string) string {
 // Reference for Arch values for runner.arch
 // https://docs.github.com/en/actions/learn-github-actions/contexts#runner-context
 func goArchToActionArch(arch string) string {
-	archMapper := map[string]string{
-		"x86_64":  "X64",
-		"386":     "X86",
-		"aarch64": "ARM64",
-	}
-	if arch, ok := archMapper[arch]; ok {
-		return arch
-	}
-	return arch
+	return toRunnerArch(arch)
 }
 
 func goOsToActionOs(os string) string {
@@ -467,3 +502,16 @@ func (e *HostEnvironment) ReplaceLogWriter(stdout io.Writer, _ io.Writer) (io.Wr
 func (*HostEnvironment) IsEnvironmentCaseInsensitive() bool {
 	return runtime.GOOS == "windows"
 }
+
+// hostResourceUsage returns the usage of the resources of a command from the rusage of its process tree
+func hostResourceUsage(state *os.ProcessState, duration time.Duration) ResourceUsage {
+	usage := ResourceUsage{
+		Duration: duration,
+		CPUTime:  state.UserTime() + state.SystemTime(),
+	}
+	if duration > 0 {
+		usage.CPUAvg = usage.CPUTime.Seconds() / duration.Seconds() * 100
+	}
+	usage.MemoryPeak, usage.BlockRead, usage.BlockWrite = processUsage(state)
+	return usage
+}
@@ -0,0 +1,565 @@
+//go:build !(WITHOUT_DOCKER || !(linux || darwin || windows || netbsd))
+
+package container
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/api/types/mount"
+	"github.com/moby/moby/client"
+
+	"github.com/nektos/act/pkg/common"
+)
+
+// podmanAPIVersion is the version of the libpod API used for the pods, supported since Podman 4.0
+const podmanAPIVersion = "v4.0.0"
+
+// NewPodmanContainer creates a reference to a container of Podman, through the Docker compatible API of the Podman service.
+// With rootless Podman, the bind mounted workspace is mapped to the user of the image in the user namespace of the container,
+// instead of being chowned to the subordinate IDs of the user.
+func NewPodmanContainer(input *NewContainerInput) ExecutionsEnvironment {
+	return &podmanContainerReference{containerReference: &containerReference{input: input}}
+}
+
+type podmanContainerReference struct {
+	*containerReference
+	rootless bool
+}
+
+func (cr *podmanContainerReference) Create(capAdd []string, capDrop []string) common.Executor {
+	if cr.input.Pod == "" {
+		return common.NewPipelineExecutor(
+			common.NewPipelineExecutor(
+				cr.connect(),
+				cr.mapUserNamespace(),
+			).IfNot(common.Dryrun),
+			cr.containerReference.Create(capAdd, capDrop),
+		)
+	}
+	return common.
+		NewInfoExecutor("%spodman create image=%s platform=%s entrypoint=%+q cmd=%+q pod=%+q", logPrefix, cr.input.Image, cr.input.Platform, cr.input.Entrypoint, cr.input.Cmd, cr.input.Pod).
+		Then(
+			common.NewPipelineExecutor(
+				cr.connect(),
+				cr.mapUserNamespace(),
+				cr.find(),
+				cr.replaceOutdated(),
+				cr.createInPod(capAdd, capDrop),
+			).IfNot(common.Dryrun),
+		)
+}
+
+// createInPod creates the container in the pod of the input with the libpod API, the Docker compatible API lacking pods.
+// The container shares the network of the pod, which publishes its ports.
+func (cr *podmanContainerReference) createInPod(capAdd []string, capDrop []string) common.Executor {
+	return func(ctx context.Context) error {
+		if cr.id != "" {
+			return nil
+		}
+		options, err := cr.createOptions(ctx, capAdd, capDrop)
+		if err != nil {
+			return err
+		}
+		var resp struct {
+			ID string `json:"Id"`
+		}
+		spec, err := podmanContainerSpec(options, cr.input.Pod)
+		if err != nil {
+			return err
+		}
+		if err := podmanRequest(ctx, http.MethodPost, "/containers/create", spec, &resp); err != nil {
+			return fmt.Errorf("failed to create container: '%w'", err)
+		}
+		common.Logger(ctx).Debugf("Created container name=%s id=%v from image %v in pod %s", cr.input.Name, resp.ID, cr.input.Image, cr.input.Pod)
+		cr.id = resp.ID
+		return nil
+	}
+}
+
+type podmanMount struct {
+	Destination string   `json:"destination"`
+	Type        string   `json:"type"`
+	Source      string   `json:"source,omitempty"`
+	Options     []string `json:"options,omitempty"`
+}
+
+type podmanNamedVolume struct {
+	Name    string   `json:"Name"`
+	Dest    string   `json:"Dest"`
+	Options []string `json:"Options,omitempty"`
+}
+
+type podmanNamespace struct {
+	NSMode string `json:"nsmode"`
+	Value  string `json:"value,omitempty"`
+}
+
+type podmanDevice struct {
+	// the device of the host, followed by the path in the container and the cgroup permissions separated by ':'
+	Path string `json:"path"`
+}
+
+type podmanRlimit struct {
+	Type string `json:"type"`
+	Hard uint64 `json:"hard"`
+	Soft uint64 `json:"soft"`
+}
+
+// podmanContainerSpec converts the config of a container for the Docker compatible API to the spec of a container
+// of the libpod API, as a member of pod
+func podmanContainerSpec(options client.ContainerCreateOptions, pod string) (map[string]interface{}, error) {
+	config, hostConfig := options.Config, options.HostConfig
+	if err := podmanUnsupportedOptions(hostConfig); err != nil {
+		return nil, err
+	}
+	env := make(map[string]string, len(config.Env))
+	for _, kv := range config.Env {
+		k, v, _ := strings.Cut(kv, "=")
+		env[k] = v
+	}
+	spec := map[string]interface{}{
+		"name":       options.Name,
+		"pod":        pod,
+		"image":      config.Image,
+		"command":    []string(config.Cmd),
+		"entrypoint": []string(config.Entrypoint),
+		"env":        env,
+		"work_dir":   config.WorkingDir,
+		"user":       config.User,
+		"labels":     config.Labels,
+		"terminal":   config.Tty,
+		"stdin":      config.OpenStdin,
+		"privileged": hostConfig.Privileged,
+		"cap_add":    hostConfig.CapAdd,
+		"cap_drop":   hostConfig.CapDrop,
+		"remove":     hostConfig.AutoRemove,
+	}
+	if config.Healthcheck != nil {
+		// the health config of the libpod API has the same fields
+		spec["healthconfig"] = config.Healthcheck
+	}
+	if options.Platform != nil {
+		spec["image_os"] = options.Platform.OS
+		spec["image_arch"] = options.Platform.Architecture
+	}
+	if mode := string(hostConfig.UsernsMode); mode != "" {
+		nsMode, value, _ := strings.Cut(mode, ":")
+		spec["userns"] = podmanNamespace{NSMode: nsMode, Value: value}
+	}
+
+	mounts := []podmanMount{}
+	volumes := []podmanNamedVolume{}
+	for _, bind := range hostConfig.Binds {
+		parts := strings.Split(bind, ":")
+		var bindOptions []string
+		if len(parts) > 2 {
+			bindOptions = strings.Split(parts[2], ",")
+		}
+		switch {
+		case len(parts) == 1:
+			// an anonymous volume
+			volumes = append(volumes, podmanNamedVolume{Dest: parts[0]})
+		case strings.HasPrefix(parts[0], "/"):
+			mounts = append(mounts, podmanMount{Destination: parts[1], Type: "bind", Source: parts[0], Options: append(bindOptions, "rbind")})
+		default:
+			volumes = append(volumes, podmanNamedVolume{Name: parts[0], Dest: parts[1], Options: bindOptions})
+		}
+	}
+	for _, m := range hostConfig.Mounts {
+		var mountOptions []string
+		if m.ReadOnly {
+			mountOptions = []string{"ro"}
+		}
+		switch m.Type {
+		case mount.TypeVolume:
+			volumes = append(volumes, podmanNamedVolume{Name: m.Source, Dest: m.Target, Options: mountOptions})
+		default:
+			mounts = append(mounts, podmanMount{Destination: m.Target, Type: string(m.Type), Source: m.Source, Options: mountOptions})
+		}
+	}
+	for target, tmpfsOptions := range hostConfig.Tmpfs {
+		var mountOptions []string
+		if tmpfsOptions != "" {
+			mountOptions = strings.Split(tmpfsOptions, ",")
+		}
+		mounts = append(mounts, podmanMount{Destination: target, Type: "tmpfs", Source: "tmpfs", Options: mountOptions})
+	}
+	spec["mounts"] = mounts
+	spec["volumes"] = volumes
+
+	// the limits of the resources, as in the OCI runtime spec
+	limits := map[string]interface{}{}
+	if hostConfig.Memory > 0 {
+		limits["memory"] = map[string]int64{"limit": hostConfig.Memory}
+	}
+	if hostConfig.NanoCPUs > 0 {
+		const period = 100000
+		limits["cpu"] = map[string]int64{"quota": hostConfig.NanoCPUs * period / 1e9, "period": period}
+	}
+	if hostConfig.PidsLimit != nil && *hostConfig.PidsLimit > 0 {
+		limits["pids"] = map[string]int64{"limit": *hostConfig.PidsLimit}
+	}
+	if len(limits) > 0 {
+		spec["resource_limits"] = limits
+	}
+	if hostConfig.ShmSize > 0 {
+		spec["shm_size"] = hostConfig.ShmSize
+	}
+
+	if len(hostConfig.Devices) > 0 {
+		devices := make([]podmanDevice, 0, len(hostConfig.Devices))
+		for _, d := range hostConfig.Devices {
+			path := d.PathOnHost
+			if d.PathInContainer != "" {
+				path += ":" + d.PathInContainer
+				if d.CgroupPermissions != "" {
+					path += ":" + d.CgroupPermissions
+				}
+			}
+			devices = append(devices, podmanDevice{Path: path})
+		}
+		spec["devices"] = devices
+	}
+	if len(hostConfig.Ulimits) > 0 {
+		rlimits := make([]podmanRlimit, 0, len(hostConfig.Ulimits))
+		for _, u := range hostConfig.Ulimits {
+			rlimits = append(rlimits, podmanRlimit{Type: "RLIMIT_" + strings.ToUpper(u.Name), Hard: uint64(u.Hard), Soft: uint64(u.Soft)})
+		}
+		spec["r_limits"] = rlimits
+	}
+	for _, opt := range hostConfig.SecurityOpt {
+		if err := podmanSecurityOpt(spec, opt); err != nil {
+			return nil, err
+		}
+	}
+	if len(hostConfig.GroupAdd) > 0 {
+		spec["groups"] = hostConfig.GroupAdd
+	}
+	if len(hostConfig.Sysctls) > 0 {
+		spec["sysctl"] = hostConfig.Sysctls
+	}
+	if hostConfig.Init != nil {
+		spec["init"] = *hostConfig.Init
+	}
+	if hostConfig.ReadonlyRootfs {
+		spec["read_only_filesystem"] = true
+	}
+	return spec, nil
+}
+
+// podmanUnsupportedOptions fails on the options which can't be set on a container of a pod, rather than dropping them
+func podmanUnsupportedOptions(hostConfig *container.HostConfig) error {
+	for _, option := range []struct {
+		name string
+		set  bool
+	}{
+		// the network options are those of the pod, which the containers of the job share
+		{"--publish", len(hostConfig.PortBindings) > 0},
+		{"--publish-all", hostConfig.PublishAllPorts},
+		{"--add-host", len(hostConfig.ExtraHosts) > 0},
+		{"--dns", len(hostConfig.DNS) > 0},
+		{"--dns-option", len(hostConfig.DNSOptions) > 0},
+		{"--dns-search", len(hostConfig.DNSSearch) > 0},
+		{"--link", len(hostConfig.Links) > 0},
+		{"--gpus", len(hostConfig.DeviceRequests) > 0},
+		{"--device-cgroup-rule", len(hostConfig.DeviceCgroupRules) > 0},
+		{"--volumes-from", len(hostConfig.VolumesFrom) > 0},
+		{"--ipc", hostConfig.IpcMode != ""},
+		{"--pid", hostConfig.PidMode != ""},
+		{"--uts", hostConfig.UTSMode != ""},
+		{"--runtime", hostConfig.Runtime != ""},
+		{"--cgroup-parent", hostConfig.CgroupParent != ""},
+		{"--cpuset-cpus", hostConfig.CpusetCpus != ""},
+		{"--cpuset-mems", hostConfig.CpusetMems != ""},
+		{"--cpu-shares", hostConfig.CPUShares != 0},
+		{"--memory-reservation", hostConfig.MemoryReservation != 0},
+		{"--memory-swap", hostConfig.MemorySwap != 0},
+		{"--oom-kill-disable", hostConfig.OomKillDisable != nil && *hostConfig.OomKillDisable},
+		{"--oom-score-adj", hostConfig.OomScoreAdj != 0},
+		{"--blkio-weight", hostConfig.BlkioWeight != 0},
+		{"--storage-opt", len(hostConfig.StorageOpt) > 0},
+	} {
+		if option.set {
+			return fmt.Errorf("the container option %s is not supported with podman, the containers of a job are created in its pod", option.name)
+		}
+	}
+	return nil
+}
+
+// podmanSecurityOpt sets a --security-opt option on the spec of a container
+func podmanSecurityOpt(spec map[string]interface{}, opt string) error {
+	key, value, ok := strings.Cut(opt, "=")
+	if !ok {
+		// the deprecated key:value form
+		key, value, _ = strings.Cut(opt, ":")
+	}
+	switch key {
+	case "no-new-privileges":
+		spec["no_new_privileges"] = value == "" || value == "true"
+	case "label":
+		labels, _ := spec["selinux_opts"].([]string)
+		spec["selinux_opts"] = append(labels, value)
+	case "apparmor":
+		spec["apparmor_profile"] = value
+	case "seccomp":
+		// the Docker client passes the content of a profile, the libpod API takes the path of the profile
+		if value != "unconfined" {
+			return fmt.Errorf("the container option --security-opt seccomp=<profile> is not supported with podman, only seccomp=unconfined is")
+		}
+		spec["seccomp_profile_path"] = value
+	default:
+		return fmt.Errorf("the container option --security-opt %s is not supported with podman", opt)
+	}
+	return nil
+}
+
+func (cr *podmanContainerReference) Start(attach bool) common.Executor {
+	return common.
+		NewInfoExecutor("%spodman run image=%s platform=%s entrypoint=%+q cmd=%+q network=%+q", logPrefix, cr.input.Image, cr.input.Platform, cr.input.Entrypoint, cr.input.Cmd, cr.input.NetworkMode).
+		Then(
+			common.NewPipelineExecutor(
+				cr.connect(),
+				cr.find(),
+				cr.attach().IfBool(attach),
+				cr.start(),
+				cr.wait().IfBool(attach),
+				cr.tryReadUID(),
+				cr.tryReadGID(),
+				func(ctx context.Context) error {
+					if cr.UID == 0 && cr.GID == 0 {
+						return nil
+					}
+					if cr.rootless && isBindMounted(cr.input.Binds, cr.input.WorkingDir) {
+						// chown would hand the files of the host over to the subordinate IDs of the user,
+						// the user namespace maps them to the user of the container instead
+						return nil
+					}
+					cr.chownDir(ctx, cr.input.WorkingDir)
+					return nil
+				},
+			).IfNot(common.Dryrun),
+		)
+}
+
+// mapUserNamespace maps the user running rootless Podman to the user of the image,
+// so that the bind mounted workspace is owned by the user of the container
+func (cr *podmanContainerReference) mapUserNamespace() common.Executor {
+	return func(ctx context.Context) error {
+		logger := common.Logger(ctx)
+		info, err := cr.cli.Info(ctx, client.InfoOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get podman info: %w", err)
+		}
+		cr.rootless = slices.Contains(info.Info.SecurityOptions, "name=rootless")
+		if !cr.rootless || cr.input.UsernsMode != "" || !isBindMounted(cr.input.Binds, cr.input.WorkingDir) {
+			return nil
+		}
+
+		inspect, err := cr.cli.ImageInspect(ctx, cr.input.Image)
+		if err != nil {
+			return fmt.Errorf("inspect image: %w", err)
+		}
+		if inspect.Config == nil || inspect.Config.User == "" {
+			return nil
+		}
+		uid, gid, ok := parseNumericUser(inspect.Config.User)
+		if !ok {
+			logger.Warnf("The user '%s' of image %s isn't numeric, the workspace is owned by root in the container", inspect.Config.User, cr.input.Image)
+			return nil
+		}
+		if uid != 0 {
+			cr.input.UsernsMode = fmt.Sprintf("keep-id:uid=%d,gid=%d", uid, gid)
+			logger.Debugf("Mapping the workspace to user %d:%d with --userns=%s", uid, gid, cr.input.UsernsMode)
+		}
+		return nil
+	}
+}
+
+// parseNumericUser parses a user of the form uid or uid:gid, the group defaults to the user
+func parseNumericUser(user string) (int, int, bool) {
+	u, g, hasGroup := strings.Cut(user, ":")
+	uid, err := strconv.Atoi(u)
+	if err != nil {
+		return 0, 0, false
+	}
+	if !hasGroup {
+		return uid, uid, true
+	}
+	gid, err := strconv.Atoi(g)
+	if err != nil {
+		return 0, 0, false
+	}
+	return uid, gid, true
+}
+
+// isBindMounted returns true if a host path is bind mounted at path
+func isBindMounted(binds []string, path string) bool {
+	for _, bind := range binds {
+		parts := strings.Split(bind, ":")
+		if len(parts) >= 2 && parts[1] == path {
+			return true
+		}
+	}
+	return false
+}
+
+// PodmanPodInput the input for the NewPodmanPodCreateExecutor function
+type PodmanPodInput struct {
+	Name         string
+	Hosts        []string    // the host names resolving to the pod, e.g. the IDs of the services
+	PortBindings nat.PortMap // the ports of the containers to publish, on the pod
+}
+
+func podmanInfraName(name string) string {
+	return name + "-infra"
+}
+
+type podmanPortMapping struct {
+	ContainerPort uint16 `json:"container_port"`
+	HostPort      uint16 `json:"host_port,omitempty"`
+	HostIP        string `json:"host_ip,omitempty"`
+	Protocol      string `json:"protocol,omitempty"`
+}
+
+// NewPodmanPodCreateExecutor creates the pod of a job, the equivalent of the network of the job for Podman:
+// the containers created in the pod share its network namespace and reach each other on localhost
+func NewPodmanPodCreateExecutor(input PodmanPodInput) common.Executor {
+	return func(ctx context.Context) error {
+		hosts := make([]string, 0, len(input.Hosts))
+		for _, host := range input.Hosts {
+			hosts = append(hosts, host+":127.0.0.1")
+		}
+		portMappings := []podmanPortMapping{}
+		for port, bindings := range input.PortBindings {
+			for _, binding := range bindings {
+				hostPort, _ := strconv.ParseUint(binding.HostPort, 10, 16)
+				portMappings = append(portMappings, podmanPortMapping{
+					ContainerPort: uint16(port.Int()),
+					HostPort:      uint16(hostPort),
+					HostIP:        binding.HostIP,
+					Protocol:      port.Proto(),
+				})
+			}
+		}
+		spec := map[string]interface{}{
+			"name":         input.Name,
+			"infra_name":   podmanInfraName(input.Name),
+			"hostadd":      hosts,
+			"portmappings": portMappings,
+		}
+
+		err := podmanRequest(ctx, http.MethodPost, "/pods/create", spec, nil)
+		var apiErr *podmanAPIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict {
+			common.Logger(ctx).Debugf("Pod %v exists", input.Name)
+			return nil
+		}
+		return err
+	}
+}
+
+// NewPodmanPodRemoveExecutor removes the pod of a job with its infra container
+func NewPodmanPodRemoveExecutor(name string) common.Executor {
+	return func(ctx context.Context) error {
+		err := podmanRequest(ctx, http.MethodDelete, "/pods/"+url.PathEscape(name)+"?force=true", nil, nil)
+		var apiErr *podmanAPIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			return nil
+		}
+		return err
+	}
+}
+
+type podmanAPIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *podmanAPIError) Error() string {
+	return fmt.Sprintf("podman API: %d %s", e.StatusCode, e.Message)
+}
+
+// podmanHost returns the URI of the Podman API service, DOCKER_HOST which act sets to the Podman service, or CONTAINER_HOST and the usual locations
+func podmanHost() (string, error) {
+	if host := os.Getenv("DOCKER_HOST"); host != "" {
+		return host, nil
+	}
+	if host, found := podmanSocketLocation(); found {
+		return host, nil
+	}
+	return "", fmt.Errorf("the podman socket couldn't be found")
+}
+
+// podmanRequest sends a request to the libpod API of the Podman service, which the Docker compatible API lacks pods for
+func podmanRequest(ctx context.Context, method, path string, body, out interface{}) error {
+	host, err := podmanHost()
+	if err != nil {
+		return err
+	}
+	u, err := url.Parse(host)
+	if err != nil {
+		return fmt.Errorf("invalid podman host '%s': %w", host, err)
+	}
+	httpClient := &http.Client{}
+	var baseURL string
+	switch u.Scheme {
+	case "unix":
+		httpClient.Transport = &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", u.Path)
+			},
+		}
+		baseURL = "http://d"
+	case "tcp", "http":
+		baseURL = "http://" + u.Host
+	default:
+		return fmt.Errorf("unsupported podman host '%s', pods require a unix or tcp socket", host)
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		content, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(content)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, baseURL+"/"+podmanAPIVersion+"/libpod"+path, reqBody)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to connect to the podman service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&apiErr)
+		return &podmanAPIError{StatusCode: resp.StatusCode, Message: apiErr.Message}
+	}
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package container
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// PodmanSocketLocations are the usual locations of the socket of the Podman API service,
+// the sockets of rootless Podman first
+var PodmanSocketLocations = []string{
+	"$XDG_RUNTIME_DIR/podman/podman.sock",
+	"/run/user/$UID/podman/podman.sock",
+	"/run/podman/podman.sock",
+	"/var/run/podman/podman.sock",
+}
+
+// returns the socket URI of the Podman API service or false if not found any
+func podmanSocketLocation() (string, bool) {
+	if containerHost, exists := os.LookupEnv("CONTAINER_HOST"); exists && containerHost != "" {
+		return containerHost, true
+	}
+
+	uid := strconv.Itoa(os.Getuid())
+	for _, p := range PodmanSocketLocations {
+		p = os.Expand(p, func(name string) string {
+			if name == "UID" {
+				return uid
+			}
+			return os.Getenv(name)
+		})
+		if _, err := os.Lstat(p); err == nil {
+			return "unix://" + filepath.ToSlash(p), true
+		}
+	}
+
+	return "", false
+}
+
+// GetPodmanSocketAndHost is GetSocketAndHost for Podman: the host is the Podman API service found with CONTAINER_HOST
+// or in the usual locations, and the socket to mount in the containers defaults to it
+func GetPodmanSocketAndHost(containerSocket string) (SocketAndHost, error) {
+	log.Debugf("Handling podman host and socket")
+
+	host, found := podmanSocketLocation()
+	if !found {
+		if !isDockerHostURI(containerSocket) {
+			return SocketAndHost{}, fmt.Errorf("CONTAINER_HOST was not set and the podman socket couldn't be found in the usual locations, start it with `systemctl --user start podman.socket`")
+		}
+		host = containerSocket
+	}
+
+	socketHost := SocketAndHost{Socket: containerSocket, Host: host}
+	if socketHost.Socket == "" {
+		log.Debugf("Defaulting container socket to the podman host")
+		socketHost.Socket = host
+	}
+	return socketHost, nil
+}
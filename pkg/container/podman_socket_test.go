@@ -0,0 +1,45 @@
+package container
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestGetPodmanSocketAndHost(t *testing.T) {
+	runtimeDir := t.TempDir()
+	socket := filepath.Join(runtimeDir, "podman", "podman.sock")
+	assert.NoError(t, os.MkdirAll(filepath.Dir(socket), 0o755))
+	assert.NoError(t, os.WriteFile(socket, nil, 0o600))
+	t.Setenv("XDG_RUNTIME_DIR", runtimeDir)
+	t.Setenv("CONTAINER_HOST", "")
+
+	ret, err := GetPodmanSocketAndHost("")
+	assert.NoError(t, err)
+	assert.Equal(t, SocketAndHost{Socket: "unix://" + socket, Host: "unix://" + socket}, ret)
+
+	ret, err = GetPodmanSocketAndHost("-")
+	assert.NoError(t, err)
+	assert.Equal(t, SocketAndHost{Socket: "-", Host: "unix://" + socket}, ret)
+
+	t.Setenv("CONTAINER_HOST", "tcp://127.0.0.1:8888")
+	ret, err = GetPodmanSocketAndHost("")
+	assert.NoError(t, err)
+	assert.Equal(t, SocketAndHost{Socket: "tcp://127.0.0.1:8888", Host: "tcp://127.0.0.1:8888"}, ret)
+}
+
+func TestGetPodmanSocketAndHostNotFound(t *testing.T) {
+	locations := PodmanSocketLocations
+	defer func() { PodmanSocketLocations = locations }()
+	PodmanSocketLocations = []string{filepath.Join(t.TempDir(), "podman.sock")}
+	t.Setenv("CONTAINER_HOST", "")
+
+	_, err := GetPodmanSocketAndHost("")
+	assert.ErrorContains(t, err, "the podman socket couldn't be found")
+
+	ret, err := GetPodmanSocketAndHost("unix:///run/podman.sock")
+	assert.NoError(t, err)
+	assert.Equal(t, SocketAndHost{Socket: "unix:///run/podman.sock", Host: "unix:///run/podman.sock"}, ret)
+}
@@ -0,0 +1,185 @@
+package container
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/docker/go-connections/nat"
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/api/types/mount"
+	"github.com/moby/moby/api/types/network"
+	"github.com/moby/moby/client"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseNumericUser(t *testing.T) {
+	for user, expected := range map[string][]int{
+		"1001":      {1001, 1001},
+		"1001:121":  {1001, 121},
+		"0":         {0, 0},
+		"runner":    nil,
+		"1001:user": nil,
+	} {
+		uid, gid, ok := parseNumericUser(user)
+		if expected == nil {
+			assert.False(t, ok, user)
+			continue
+		}
+		assert.True(t, ok, user)
+		assert.Equal(t, expected, []int{uid, gid}, user)
+	}
+}
+
+func TestIsBindMounted(t *testing.T) {
+	binds := []string{"/var/run/docker.sock:/var/run/docker.sock", "/home/user/repo:/home/user/repo:z"}
+	assert.True(t, isBindMounted(binds, "/home/user/repo"))
+	assert.False(t, isBindMounted(binds, "/home/user"))
+	assert.False(t, isBindMounted(nil, "/home/user/repo"))
+}
+
+func TestPodmanPod(t *testing.T) {
+	var requests []string
+	var spec map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.RequestURI())
+		switch {
+		case r.Method == http.MethodPost && spec == nil:
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&spec))
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"Id": "abc"}`))
+		case r.Method == http.MethodPost:
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte(`{"message": "pod already exists"}`))
+		case strings.Contains(r.URL.Path, "missing"):
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "no such pod"}`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`{"message": "container is running"}`))
+		}
+	}))
+	defer server.Close()
+	t.Setenv("DOCKER_HOST", "tcp://"+strings.TrimPrefix(server.URL, "http://"))
+	ctx := context.Background()
+
+	_, bindings, err := nat.ParsePortSpecs([]string{"5432", "8080:80"})
+	assert.NoError(t, err)
+	input := PodmanPodInput{Name: "job-network", Hosts: []string{"db", "web"}, PortBindings: bindings}
+	assert.NoError(t, NewPodmanPodCreateExecutor(input)(ctx))
+	assert.Equal(t, "job-network", spec["name"])
+	assert.Equal(t, "job-network-infra", spec["infra_name"])
+	assert.Equal(t, []interface{}{"db:127.0.0.1", "web:127.0.0.1"}, spec["hostadd"])
+	assert.ElementsMatch(t, []interface{}{
+		map[string]interface{}{"container_port": float64(5432), "protocol": "tcp"},
+		map[string]interface{}{"container_port": float64(80), "host_port": float64(8080), "protocol": "tcp"},
+	}, spec["portmappings"])
+
+	assert.NoError(t, NewPodmanPodCreateExecutor(input)(ctx), "the pod of a reused job exists")
+	assert.NoError(t, NewPodmanPodRemoveExecutor("missing")(ctx))
+	assert.EqualError(t, NewPodmanPodRemoveExecutor("job-network")(ctx), "podman API: 500 container is running")
+	assert.Equal(t, []string{
+		"POST /v4.0.0/libpod/pods/create",
+		"POST /v4.0.0/libpod/pods/create",
+		"DELETE /v4.0.0/libpod/pods/missing?force=true",
+		"DELETE /v4.0.0/libpod/pods/job-network?force=true",
+	}, requests)
+}
+
+func TestPodmanContainerSpec(t *testing.T) {
+	pids := int64(100)
+	spec, err := podmanContainerSpec(client.ContainerCreateOptions{
+		Name: "job-service",
+		Config: &container.Config{
+			Image:       "postgres:16",
+			Cmd:         []string{"postgres"},
+			Env:         []string{"POSTGRES_PASSWORD=postgres", "EMPTY="},
+			WorkingDir:  "/home/user/repo",
+			Healthcheck: &container.HealthConfig{Test: []string{"CMD-SHELL", "pg_isready"}, Retries: 5},
+		},
+		HostConfig: &container.HostConfig{
+			Binds:      []string{"/home/user/repo:/home/user/repo:z", "cache:/cache:ro", "/anonymous"},
+			Mounts:     []mount.Mount{{Type: mount.TypeVolume, Source: "job-env", Target: "/var/run/act"}},
+			Tmpfs:      map[string]string{"/tmp": "size=64m"},
+			CapAdd:     []string{"SYS_PTRACE"},
+			UsernsMode: "keep-id:uid=1001,gid=121",
+			Resources:  container.Resources{Memory: 1 << 30, NanoCPUs: 1500000000, PidsLimit: &pids},
+		},
+		Platform: &specs.Platform{OS: "linux", Architecture: "arm64"},
+	}, "job-network")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "job-network", spec["pod"])
+	assert.Equal(t, "postgres:16", spec["image"])
+	assert.Equal(t, map[string]string{"POSTGRES_PASSWORD": "postgres", "EMPTY": ""}, spec["env"])
+	assert.Equal(t, "arm64", spec["image_arch"])
+	assert.Equal(t, podmanNamespace{NSMode: "keep-id", Value: "uid=1001,gid=121"}, spec["userns"])
+	assert.Equal(t, &container.HealthConfig{Test: []string{"CMD-SHELL", "pg_isready"}, Retries: 5}, spec["healthconfig"])
+	assert.Equal(t, []podmanMount{
+		{Destination: "/home/user/repo", Type: "bind", Source: "/home/user/repo", Options: []string{"z", "rbind"}},
+		{Destination: "/tmp", Type: "tmpfs", Source: "tmpfs", Options: []string{"size=64m"}},
+	}, spec["mounts"])
+	assert.Equal(t, []podmanNamedVolume{
+		{Name: "cache", Dest: "/cache", Options: []string{"ro"}},
+		{Dest: "/anonymous"},
+		{Name: "job-env", Dest: "/var/run/act"},
+	}, spec["volumes"])
+	assert.Equal(t, map[string]interface{}{
+		"memory": map[string]int64{"limit": 1 << 30},
+		"cpu":    map[string]int64{"quota": 150000, "period": 100000},
+		"pids":   map[string]int64{"limit": 100},
+	}, spec["resource_limits"])
+	assert.NotContains(t, spec, "shm_size")
+}
+
+func TestPodmanContainerSpecHostOptions(t *testing.T) {
+	init := true
+	options := client.ContainerCreateOptions{
+		Name:   "job",
+		Config: &container.Config{Image: "node:20"},
+		HostConfig: &container.HostConfig{
+			Resources: container.Resources{
+				Devices: []container.DeviceMapping{
+					{PathOnHost: "/dev/fuse", PathInContainer: "/dev/fuse", CgroupPermissions: "rwm"},
+					{PathOnHost: "/dev/kvm"},
+				},
+				Ulimits: []*container.Ulimit{{Name: "nofile", Hard: 2048, Soft: 1024}},
+			},
+			SecurityOpt:    []string{"no-new-privileges", "label=disable", "apparmor=unconfined", "seccomp=unconfined"},
+			GroupAdd:       []string{"video"},
+			Sysctls:        map[string]string{"net.ipv4.ip_unprivileged_port_start": "0"},
+			Init:           &init,
+			ReadonlyRootfs: true,
+		},
+	}
+	spec, err := podmanContainerSpec(options, "job-network")
+	assert.NoError(t, err)
+	assert.Equal(t, []podmanDevice{{Path: "/dev/fuse:/dev/fuse:rwm"}, {Path: "/dev/kvm"}}, spec["devices"])
+	assert.Equal(t, []podmanRlimit{{Type: "RLIMIT_NOFILE", Hard: 2048, Soft: 1024}}, spec["r_limits"])
+	assert.Equal(t, true, spec["no_new_privileges"])
+	assert.Equal(t, []string{"disable"}, spec["selinux_opts"])
+	assert.Equal(t, "unconfined", spec["apparmor_profile"])
+	assert.Equal(t, "unconfined", spec["seccomp_profile_path"])
+	assert.Equal(t, []string{"video"}, spec["groups"])
+	assert.Equal(t, map[string]string{"net.ipv4.ip_unprivileged_port_start": "0"}, spec["sysctl"])
+	assert.Equal(t, true, spec["init"])
+	assert.Equal(t, true, spec["read_only_filesystem"])
+
+	// the options which can't be set on a container of the pod fail instead of being dropped
+	for name, hostConfig := range map[string]*container.HostConfig{
+		"--add-host":              {ExtraHosts: []string{"db:10.0.0.2"}},
+		"--dns":                   {DNS: []netip.Addr{netip.MustParseAddr("1.1.1.1")}},
+		"--publish":               {PortBindings: network.PortMap{network.MustParsePort("80/tcp"): {{HostPort: "8080"}}}},
+		"--ipc":                   {IpcMode: "host"},
+		"--security-opt seccomp=": {SecurityOpt: []string{`seccomp={"defaultAction":"SCMP_ACT_ERRNO"}`}},
+	} {
+		options.HostConfig = hostConfig
+		_, err := podmanContainerSpec(options, "job-network")
+		assert.ErrorContains(t, err, name)
+	}
+}
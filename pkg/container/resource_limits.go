@@ -0,0 +1,141 @@
+package container
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/docker/cli/opts"
+	"github.com/moby/moby/api/types/container"
+)
+
+// ErrOutOfMemory is the error of a command killed because its container ran out of memory
+var ErrOutOfMemory = errors.New("killed because the container ran out of memory")
+
+// ResourceLimits are the limits of the resources of a container, in the units of docker run
+type ResourceLimits struct {
+	CPUs    string            `yaml:"cpus,omitempty"`     // number of CPUs, e.g. 4 or 1.5
+	Memory  string            `yaml:"memory,omitempty"`   // memory limit, e.g. 8g
+	Pids    int64             `yaml:"pids,omitempty"`     // maximum number of processes
+	ShmSize string            `yaml:"shm-size,omitempty"` // size of /dev/shm, e.g. 1g
+	Tmpfs   map[string]string `yaml:"tmpfs,omitempty"`    // tmpfs mounts by path, with their mount options, e.g. size=2g
+}
+
+// Validate checks the values of the limits
+func (l *ResourceLimits) Validate() error {
+	_, err := l.hostConfig()
+	return err
+}
+
+func (l *ResourceLimits) hostConfig() (*container.HostConfig, error) {
+	hostConfig := &container.HostConfig{Tmpfs: map[string]string{}}
+	if l.CPUs != "" {
+		var cpus opts.NanoCPUs
+		if err := cpus.Set(l.CPUs); err != nil {
+			return nil, fmt.Errorf("invalid cpus '%s': %w", l.CPUs, err)
+		}
+		hostConfig.NanoCPUs = cpus.Value()
+	}
+	if l.Memory != "" {
+		var memory opts.MemBytes
+		if err := memory.Set(l.Memory); err != nil {
+			return nil, fmt.Errorf("invalid memory '%s': %w", l.Memory, err)
+		}
+		hostConfig.Memory = memory.Value()
+	}
+	if l.Pids < 0 {
+		return nil, fmt.Errorf("invalid pids '%d': must be positive", l.Pids)
+	}
+	if l.Pids > 0 {
+		pids := l.Pids
+		hostConfig.PidsLimit = &pids
+	}
+	if l.ShmSize != "" {
+		var shmSize opts.MemBytes
+		if err := shmSize.Set(l.ShmSize); err != nil {
+			return nil, fmt.Errorf("invalid shm-size '%s': %w", l.ShmSize, err)
+		}
+		hostConfig.ShmSize = shmSize.Value()
+	}
+	for target, options := range l.Tmpfs {
+		if !path.IsAbs(target) {
+			return nil, fmt.Errorf("invalid tmpfs '%s': must be an absolute path", target)
+		}
+		hostConfig.Tmpfs[target] = options
+	}
+	return hostConfig, nil
+}
+
+// apply applies the limits to the host config of a container, where the options of the container ask for more of a resource,
+// the limit wins: the CPUs, memory and processes of the options can only be lower. The shm size and tmpfs mounts of the options win.
+func (l *ResourceLimits) apply(hostConfig *container.HostConfig) error {
+	limits, err := l.hostConfig()
+	if err != nil {
+		return err
+	}
+	if limits.NanoCPUs > 0 && (hostConfig.NanoCPUs == 0 || hostConfig.NanoCPUs > limits.NanoCPUs) {
+		hostConfig.NanoCPUs = limits.NanoCPUs
+	}
+	if limits.Memory > 0 && (hostConfig.Memory == 0 || hostConfig.Memory > limits.Memory) {
+		hostConfig.Memory = limits.Memory
+		// without swap beyond the limit, like the runners of GitHub
+		if hostConfig.MemorySwap == 0 || hostConfig.MemorySwap > limits.Memory {
+			hostConfig.MemorySwap = limits.Memory
+		}
+	}
+	if limits.PidsLimit != nil && (hostConfig.PidsLimit == nil || *hostConfig.PidsLimit <= 0 || *hostConfig.PidsLimit > *limits.PidsLimit) {
+		hostConfig.PidsLimit = limits.PidsLimit
+	}
+	if limits.ShmSize > 0 && hostConfig.ShmSize == 0 {
+		hostConfig.ShmSize = limits.ShmSize
+	}
+	for target, options := range limits.Tmpfs {
+		if hostConfig.Tmpfs == nil {
+			hostConfig.Tmpfs = map[string]string{}
+		}
+		if _, ok := hostConfig.Tmpfs[target]; !ok {
+			hostConfig.Tmpfs[target] = options
+		}
+	}
+	return nil
+}
+
+// String returns the limits as the options of docker run
+func (l *ResourceLimits) String() string {
+	var options []string
+	if l.CPUs != "" {
+		options = append(options, "--cpus "+l.CPUs)
+	}
+	if l.Memory != "" {
+		options = append(options, "--memory "+l.Memory)
+	}
+	if l.Pids > 0 {
+		options = append(options, fmt.Sprintf("--pids-limit %d", l.Pids))
+	}
+	if l.ShmSize != "" {
+		options = append(options, "--shm-size "+l.ShmSize)
+	}
+	targets := make([]string, 0, len(l.Tmpfs))
+	for target := range l.Tmpfs {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	for _, target := range targets {
+		if options := l.Tmpfs[target]; options != "" {
+			target += ":" + options
+		}
+		options = append(options, "--tmpfs "+target)
+	}
+	return strings.Join(options, " ")
+}
+
+// outOfMemoryError returns ErrOutOfMemory with the memory limit of the container
+func outOfMemoryError(memory int64) error {
+	if memory <= 0 {
+		return ErrOutOfMemory
+	}
+	limit := opts.MemBytes(memory)
+	return fmt.Errorf("%w, its memory is limited to %s", ErrOutOfMemory, limit.String())
+}
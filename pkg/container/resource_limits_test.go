@@ -0,0 +1,59 @@
+package container
+
+import (
+	"testing"
+
+	"github.com/moby/moby/api/types/container"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResourceLimitsApply(t *testing.T) {
+	limits := &ResourceLimits{
+		CPUs:    "2",
+		Memory:  "1g",
+		Pids:    512,
+		ShmSize: "256m",
+		Tmpfs:   map[string]string{"/tmp": "size=1g", "/run": ""},
+	}
+	assert.NoError(t, limits.Validate())
+	assert.Equal(t, "--cpus 2 --memory 1g --pids-limit 512 --shm-size 256m --tmpfs /run --tmpfs /tmp:size=1g", limits.String())
+
+	hostConfig := &container.HostConfig{}
+	assert.NoError(t, limits.apply(hostConfig))
+	assert.Equal(t, int64(2e9), hostConfig.NanoCPUs)
+	assert.Equal(t, int64(1<<30), hostConfig.Memory)
+	assert.Equal(t, int64(1<<30), hostConfig.MemorySwap)
+	assert.Equal(t, int64(512), *hostConfig.PidsLimit)
+	assert.Equal(t, int64(256<<20), hostConfig.ShmSize)
+	assert.Equal(t, map[string]string{"/tmp": "size=1g", "/run": ""}, hostConfig.Tmpfs)
+
+	// the options can ask for less, not more
+	pids := int64(4096)
+	hostConfig = &container.HostConfig{
+		Resources: container.Resources{NanoCPUs: 1e9, Memory: 4 << 30, PidsLimit: &pids},
+		ShmSize:   1 << 30,
+		Tmpfs:     map[string]string{"/tmp": "size=4g"},
+	}
+	assert.NoError(t, limits.apply(hostConfig))
+	assert.Equal(t, int64(1e9), hostConfig.NanoCPUs)
+	assert.Equal(t, int64(1<<30), hostConfig.Memory)
+	assert.Equal(t, int64(512), *hostConfig.PidsLimit)
+	assert.Equal(t, int64(1<<30), hostConfig.ShmSize)
+	assert.Equal(t, "size=4g", hostConfig.Tmpfs["/tmp"])
+}
+
+func TestResourceLimitsValidate(t *testing.T) {
+	assert.NoError(t, (&ResourceLimits{}).Validate())
+	assert.ErrorContains(t, (&ResourceLimits{CPUs: "many"}).Validate(), "invalid cpus")
+	assert.ErrorContains(t, (&ResourceLimits{Memory: "8 gigs"}).Validate(), "invalid memory")
+	assert.ErrorContains(t, (&ResourceLimits{Pids: -1}).Validate(), "invalid pids")
+	assert.ErrorContains(t, (&ResourceLimits{ShmSize: "big"}).Validate(), "invalid shm-size")
+	assert.ErrorContains(t, (&ResourceLimits{Tmpfs: map[string]string{"tmp": ""}}).Validate(), "invalid tmpfs")
+}
+
+func TestOutOfMemoryError(t *testing.T) {
+	err := outOfMemoryError(2 << 30)
+	assert.ErrorIs(t, err, ErrOutOfMemory)
+	assert.Equal(t, "killed because the container ran out of memory, its memory is limited to 2GiB", err.Error())
+	assert.Equal(t, ErrOutOfMemory, outOfMemoryError(0))
+}
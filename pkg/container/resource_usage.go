@@ -0,0 +1,108 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/docker/cli/opts"
+)
+
+// ResourceUsage is the usage of the resources of the commands of a step or a job. The CPU and memory of a container
+// are sampled while its commands run, those of the host are the rusage of the process tree of its commands.
+type ResourceUsage struct {
+	Duration   time.Duration `json:"duration_ns"`                 // the time the commands ran
+	Samples    int           `json:"samples,omitempty"`           // the number of stats sampled from the container
+	CPUTime    time.Duration `json:"cpu_time_ns,omitempty"`       // the CPU time of the commands, summed over the CPUs
+	CPUPeak    float64       `json:"cpu_peak_percent,omitempty"`  // the peak CPU usage, 100% per CPU
+	CPUAvg     float64       `json:"cpu_avg_percent,omitempty"`   // the average CPU usage over the duration, 100% per CPU
+	MemoryPeak uint64        `json:"memory_peak_bytes,omitempty"` // the peak memory usage
+	MemoryAvg  uint64        `json:"memory_avg_bytes,omitempty"`  // the average memory usage of the samples
+	NetworkRx  uint64        `json:"network_rx_bytes,omitempty"`  // the bytes received
+	NetworkTx  uint64        `json:"network_tx_bytes,omitempty"`  // the bytes sent
+	BlockRead  uint64        `json:"block_read_bytes,omitempty"`  // the bytes read from the block devices
+	BlockWrite uint64        `json:"block_write_bytes,omitempty"` // the bytes written to the block devices
+}
+
+// Add adds the usage of commands which ran after the ones of u: the peaks are the highest of both,
+// the averages are weighted by the durations and the counters are summed
+func (u *ResourceUsage) Add(other ResourceUsage) {
+	duration := u.Duration + other.Duration
+	if duration > 0 {
+		u.CPUAvg = (u.CPUAvg*u.Duration.Seconds() + other.CPUAvg*other.Duration.Seconds()) / duration.Seconds()
+	}
+	if samples := u.Samples + other.Samples; samples > 0 {
+		u.MemoryAvg = (u.MemoryAvg*uint64(u.Samples) + other.MemoryAvg*uint64(other.Samples)) / uint64(samples)
+	}
+	u.Duration = duration
+	u.Samples += other.Samples
+	u.CPUTime += other.CPUTime
+	u.CPUPeak = max(u.CPUPeak, other.CPUPeak)
+	u.MemoryPeak = max(u.MemoryPeak, other.MemoryPeak)
+	u.NetworkRx += other.NetworkRx
+	u.NetworkTx += other.NetworkTx
+	u.BlockRead += other.BlockRead
+	u.BlockWrite += other.BlockWrite
+}
+
+// IsZero returns true if no usage has been recorded
+func (u *ResourceUsage) IsZero() bool {
+	return *u == ResourceUsage{}
+}
+
+// String returns a summary of the usage, e.g. "cpu 35.2% avg, 198.0% peak, memory 1.2GiB peak, network 12MiB in, 1KiB out"
+func (u *ResourceUsage) String() string {
+	var parts []string
+	if u.CPUPeak > 0 {
+		parts = append(parts, fmt.Sprintf("cpu %.1f%% avg, %.1f%% peak", u.CPUAvg, u.CPUPeak))
+	} else if u.CPUTime > 0 {
+		parts = append(parts, fmt.Sprintf("cpu %.1f%% avg, %s", u.CPUAvg, u.CPUTime.Round(time.Millisecond)))
+	}
+	if u.MemoryPeak > 0 {
+		parts = append(parts, "memory "+formatBytes(u.MemoryPeak)+" peak")
+	}
+	if u.NetworkRx > 0 || u.NetworkTx > 0 {
+		parts = append(parts, fmt.Sprintf("network %s in, %s out", formatBytes(u.NetworkRx), formatBytes(u.NetworkTx)))
+	}
+	if u.BlockRead > 0 || u.BlockWrite > 0 {
+		parts = append(parts, fmt.Sprintf("disk %s read, %s written", formatBytes(u.BlockRead), formatBytes(u.BlockWrite)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func formatBytes(n uint64) string {
+	if n == 0 {
+		return "0B"
+	}
+	bytes := opts.MemBytes(n)
+	return bytes.String()
+}
+
+type resourceUsageContextKey string
+
+const resourceUsageContextKeyVal = resourceUsageContextKey("resourceUsage")
+
+// resourceUsageRecorder adds the usage of the commands to the usage of the context and to those of its parents,
+// so that the commands of a nested step count for the step running it too
+type resourceUsageRecorder struct {
+	usage  *ResourceUsage
+	parent *resourceUsageRecorder
+}
+
+func (r *resourceUsageRecorder) Add(usage ResourceUsage) {
+	for ; r != nil; r = r.parent {
+		r.usage.Add(usage)
+	}
+}
+
+// WithResourceUsage adds to the context the usage the commands executed with it add their usage to
+func WithResourceUsage(ctx context.Context, usage *ResourceUsage) context.Context {
+	return context.WithValue(ctx, resourceUsageContextKeyVal, &resourceUsageRecorder{usage: usage, parent: resourceUsage(ctx)})
+}
+
+// resourceUsage returns the recorder of the usage of the context, nil if the usage isn't recorded
+func resourceUsage(ctx context.Context) *resourceUsageRecorder {
+	recorder, _ := ctx.Value(resourceUsageContextKeyVal).(*resourceUsageRecorder)
+	return recorder
+}
@@ -0,0 +1,68 @@
+package container
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResourceUsageAdd(t *testing.T) {
+	usage := ResourceUsage{}
+	usage.Add(ResourceUsage{Duration: time.Second, Samples: 1, CPUTime: time.Second, CPUPeak: 150, CPUAvg: 100, MemoryPeak: 300, MemoryAvg: 200, NetworkRx: 10, BlockWrite: 5})
+	usage.Add(ResourceUsage{Duration: 3 * time.Second, Samples: 3, CPUTime: 600 * time.Millisecond, CPUPeak: 90, CPUAvg: 20, MemoryPeak: 100, MemoryAvg: 100, NetworkRx: 20, BlockWrite: 5})
+	assert.Equal(t, ResourceUsage{
+		Duration:   4 * time.Second,
+		Samples:    4,
+		CPUTime:    1600 * time.Millisecond,
+		CPUPeak:    150,
+		CPUAvg:     40,
+		MemoryPeak: 300,
+		MemoryAvg:  125,
+		NetworkRx:  30,
+		BlockWrite: 10,
+	}, usage)
+	assert.Equal(t, "cpu 40.0% avg, 150.0% peak, memory 300B peak, network 30B in, 0B out, disk 0B read, 10B written", usage.String())
+
+	assert.Equal(t, "cpu 50.0% avg, 500ms, memory 2MiB peak", (&ResourceUsage{Duration: time.Second, CPUTime: 500 * time.Millisecond, CPUAvg: 50, MemoryPeak: 2 << 20}).String())
+	assert.True(t, (&ResourceUsage{}).IsZero())
+}
+
+func TestWithResourceUsage(t *testing.T) {
+	assert.Nil(t, resourceUsage(context.Background()))
+
+	step := &ResourceUsage{}
+	nested := &ResourceUsage{}
+	ctx := WithResourceUsage(WithResourceUsage(context.Background(), step), nested)
+	resourceUsage(ctx).Add(ResourceUsage{Duration: time.Second, NetworkTx: 1})
+	assert.Equal(t, ResourceUsage{Duration: time.Second, NetworkTx: 1}, *step)
+	assert.Equal(t, *step, *nested)
+}
+
+func TestHostEnvironmentResourceUsage(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test runs sh")
+	}
+	dir := t.TempDir()
+	e := &HostEnvironment{
+		Path:      filepath.Join(dir, "path"),
+		TmpDir:    filepath.Join(dir, "tmp"),
+		ToolCache: filepath.Join(dir, "tool_cache"),
+		ActPath:   filepath.Join(dir, "act_path"),
+		StdOut:    os.Stdout,
+		Workdir:   dir,
+	}
+	assert.NoError(t, os.MkdirAll(e.Path, 0o700))
+	usage := &ResourceUsage{}
+	ctx := WithResourceUsage(context.Background(), usage)
+	assert.NoError(t, e.Exec([]string{"sh", "-c", "i=0; while [ $i -lt 100000 ]; do i=$((i+1)); done"}, map[string]string{"PATH": os.Getenv("PATH")}, "", "")(ctx))
+	assert.Positive(t, usage.Duration)
+	assert.Positive(t, usage.CPUTime)
+	assert.Positive(t, usage.CPUAvg)
+	assert.Positive(t, usage.MemoryPeak)
+	assert.Zero(t, usage.Samples)
+}
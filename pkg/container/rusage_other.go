@@ -0,0 +1,12 @@
+//go:build !unix
+
+package container
+
+import (
+	"os"
+)
+
+// processUsage returns nothing, the peak memory and the block I/O of a process are only known on unix
+func processUsage(_ *os.ProcessState) (memoryPeak, blockRead, blockWrite uint64) {
+	return 0, 0, 0
+}
@@ -0,0 +1,24 @@
+//go:build unix
+
+package container
+
+import (
+	"os"
+	"runtime"
+	"syscall"
+)
+
+// processUsage returns the peak memory and the block I/O of a process and of the children it has waited for
+func processUsage(state *os.ProcessState) (memoryPeak, blockRead, blockWrite uint64) {
+	rusage, ok := state.SysUsage().(*syscall.Rusage)
+	if !ok || rusage == nil {
+		return 0, 0, 0
+	}
+	memoryPeak = uint64(rusage.Maxrss)
+	// the peak resident set size is in bytes on darwin, in kilobytes elsewhere
+	if runtime.GOOS != "darwin" && runtime.GOOS != "ios" {
+		memoryPeak *= 1024
+	}
+	// the block operations are counted in blocks of 512 bytes
+	return memoryPeak, uint64(rusage.Inblock) * 512, uint64(rusage.Oublock) * 512
+}
@@ -0,0 +1,114 @@
+package container
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// SandboxEnvironment runs the steps on the host like HostEnvironment, in Linux namespaces set up by bubblewrap:
+// the processes of the job get their own mount, PID, IPC and UTS namespaces and, unless the network is shared, network namespace.
+// The root filesystem of the host is read-only, the home directory and the runtime directories are replaced by empty tmpfs,
+// and only the workspace, the temp directory and the tool cache of the job are writable.
+// A read-only bind mount still lets a process connect to the unix sockets in it, like the sockets of the Docker and Podman
+// daemons and of the session of the user, hence the runtime directories being hidden.
+type SandboxEnvironment struct {
+	*HostEnvironment
+	Network       bool     // share the network of the host instead of an isolated network without any interface but loopback
+	ReadOnlyPaths []string // paths kept readable in the sandbox, e.g. in the hidden home directory
+
+	bwrap       string
+	home        string
+	runtimeDirs []string // the directories of the sockets of the daemons and of the user session
+}
+
+// sandboxResolvDir is the directory of the resolv.conf of systemd-resolved in /run, kept readable for the DNS of a shared network
+const sandboxResolvDir = "/run/systemd/resolve"
+
+// sandboxRuntimeDirs returns /run, /var/run unless it links to it, and XDG_RUNTIME_DIR unless it's in /run
+func sandboxRuntimeDirs() []string {
+	dirs := []string{"/run"}
+	if info, err := os.Lstat("/var/run"); err == nil && info.Mode()&os.ModeSymlink == 0 {
+		dirs = append(dirs, "/var/run")
+	}
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" && filepath.IsAbs(dir) {
+		dir = filepath.Clean(dir)
+		if dir != "/run" && !strings.HasPrefix(dir, "/run/") {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// NewSandboxEnvironment makes the host environment run its commands in a sandbox, it requires Linux and bwrap in the PATH
+func NewSandboxEnvironment(host *HostEnvironment, network bool, readOnlyPaths ...string) (*SandboxEnvironment, error) {
+	if runtime.GOOS != "linux" {
+		return nil, fmt.Errorf("the sandbox requires Linux namespaces, use -self-hosted on %s", runtime.GOOS)
+	}
+	bwrap, err := exec.LookPath("bwrap")
+	if err != nil {
+		return nil, fmt.Errorf("the sandbox requires bubblewrap, install the bwrap command: %w", err)
+	}
+	home, _ := os.UserHomeDir()
+	if err := os.MkdirAll(host.ToolCache, 0o777); err != nil {
+		return nil, err
+	}
+	e := &SandboxEnvironment{
+		HostEnvironment: host,
+		Network:         network,
+		ReadOnlyPaths:   readOnlyPaths,
+		bwrap:           bwrap,
+		home:            home,
+		runtimeDirs:     sandboxRuntimeDirs(),
+	}
+	host.wrapCommand = e.wrapCommand
+	return e, nil
+}
+
+func (e *SandboxEnvironment) wrapCommand(path string, args []string, workdir string, terminal bool) (string, []string) {
+	wrapped := append([]string{e.bwrap}, e.bwrapArgs(workdir, terminal)...)
+	wrapped = append(wrapped, path)
+	wrapped = append(wrapped, args[1:]...)
+	return e.bwrap, wrapped
+}
+
+// bwrapArgs returns the options of bwrap, the later mounts are mounted over the earlier ones
+func (e *SandboxEnvironment) bwrapArgs(workdir string, terminal bool) []string {
+	args := []string{
+		"--ro-bind", "/", "/",
+		"--dev", "/dev",
+		"--proc", "/proc",
+		"--tmpfs", "/tmp",
+	}
+	for _, dir := range e.runtimeDirs {
+		args = append(args, "--tmpfs", dir)
+	}
+	if e.Network {
+		args = append(args, "--ro-bind-try", sandboxResolvDir, sandboxResolvDir)
+	}
+	if e.home != "" && e.home != "/" {
+		args = append(args, "--tmpfs", e.home)
+	}
+	for _, p := range e.ReadOnlyPaths {
+		args = append(args, "--ro-bind-try", p, p)
+	}
+	for _, p := range []string{e.Path, e.ActPath, e.TmpDir, e.ToolCache} {
+		if p != "" {
+			args = append(args, "--bind", p, p)
+		}
+	}
+	args = append(args, "--unshare-pid", "--unshare-ipc", "--unshare-uts")
+	if !e.Network {
+		args = append(args, "--unshare-net")
+	}
+	if !terminal {
+		// a command without its own pseudo terminal would otherwise inject input to the terminal of act with TIOCSTI,
+		// with one the command keeps the pseudo terminal as its controlling terminal
+		args = append(args, "--new-session")
+	}
+	args = append(args, "--die-with-parent", "--chdir", filepath.Clean(workdir), "--")
+	return args
+}
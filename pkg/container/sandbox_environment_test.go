@@ -0,0 +1,120 @@
+package container
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Type assert SandboxEnvironment implements ExecutionsEnvironment
+var _ ExecutionsEnvironment = &SandboxEnvironment{}
+
+func TestSandboxEnvironmentArgs(t *testing.T) {
+	e := &SandboxEnvironment{
+		HostEnvironment: &HostEnvironment{
+			Path:      "/cache/act/1/hostexecutor",
+			TmpDir:    "/cache/act/1/tmp",
+			ToolCache: "/cache/act/tool_cache",
+			ActPath:   "/cache/act/1/act",
+		},
+		ReadOnlyPaths: []string{"/cache/act"},
+		bwrap:         "/usr/bin/bwrap",
+		home:          "/home/user",
+		runtimeDirs:   []string{"/run", "/var/lib/user/1000"},
+	}
+
+	path, args := e.wrapCommand("/bin/bash", []string{"bash", "-e", "script.sh"}, "/cache/act/1/hostexecutor/", true)
+	assert.Equal(t, "/usr/bin/bwrap", path)
+	assert.Equal(t, []string{
+		"/usr/bin/bwrap",
+		"--ro-bind", "/", "/",
+		"--dev", "/dev",
+		"--proc", "/proc",
+		"--tmpfs", "/tmp",
+		"--tmpfs", "/run",
+		"--tmpfs", "/var/lib/user/1000",
+		"--tmpfs", "/home/user",
+		"--ro-bind-try", "/cache/act", "/cache/act",
+		"--bind", "/cache/act/1/hostexecutor", "/cache/act/1/hostexecutor",
+		"--bind", "/cache/act/1/act", "/cache/act/1/act",
+		"--bind", "/cache/act/1/tmp", "/cache/act/1/tmp",
+		"--bind", "/cache/act/tool_cache", "/cache/act/tool_cache",
+		"--unshare-pid", "--unshare-ipc", "--unshare-uts", "--unshare-net",
+		"--die-with-parent", "--chdir", "/cache/act/1/hostexecutor", "--",
+		"/bin/bash", "-e", "script.sh",
+	}, args)
+
+	// without a pseudo terminal, the command doesn't share the controlling terminal of act
+	_, args = e.wrapCommand("/bin/bash", []string{"bash", "-e", "script.sh"}, "/cache/act/1/hostexecutor/", false)
+	assert.Equal(t, []string{"--unshare-net", "--new-session", "--die-with-parent"}, args[len(args)-9:len(args)-6])
+
+	e.Network = true
+	_, args = e.wrapCommand("/bin/sh", []string{"sh"}, "/cache/act/1/hostexecutor", true)
+	assert.NotContains(t, args, "--unshare-net")
+	assert.Equal(t, "/bin/sh", args[len(args)-1])
+	// the resolv.conf of systemd-resolved is in the hidden /run
+	assert.Equal(t, []string{"--tmpfs", "/var/lib/user/1000", "--ro-bind-try", "/run/systemd/resolve", "/run/systemd/resolve"}, args[12:17])
+}
+
+func TestSandboxRuntimeDirs(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", "/run/user/1000")
+	assert.Contains(t, sandboxRuntimeDirs(), "/run")
+	assert.NotContains(t, sandboxRuntimeDirs(), "/run/user/1000", "hidden with /run")
+
+	t.Setenv("XDG_RUNTIME_DIR", "/var/lib/user/1000/")
+	assert.Contains(t, sandboxRuntimeDirs(), "/var/lib/user/1000")
+
+	t.Setenv("XDG_RUNTIME_DIR", "")
+	assert.NotContains(t, sandboxRuntimeDirs(), "")
+}
+
+func TestSandboxEnvironmentExec(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the sandbox requires Linux")
+	}
+	if _, err := exec.LookPath("bwrap"); err != nil {
+		t.Skip("bwrap isn't installed")
+	}
+	dir := t.TempDir()
+	out := &bytes.Buffer{}
+	host := &HostEnvironment{
+		Path:      filepath.Join(dir, "path"),
+		TmpDir:    filepath.Join(dir, "tmp"),
+		ToolCache: filepath.Join(dir, "tool_cache"),
+		ActPath:   filepath.Join(dir, "act_path"),
+		StdOut:    out,
+	}
+	for _, p := range []string{host.Path, host.TmpDir, host.ActPath} {
+		assert.NoError(t, os.MkdirAll(p, 0o700))
+	}
+	e, err := NewSandboxEnvironment(host, false)
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	env := map[string]string{"PATH": os.Getenv("PATH")}
+	assert.NoError(t, e.Exec([]string{"sh", "-c", "echo ok > result"}, env, "", "")(ctx))
+	content, err := os.ReadFile(filepath.Join(host.Path, "result"))
+	assert.NoError(t, err)
+	assert.Equal(t, "ok\n", string(content))
+
+	// the files outside of the workspace, the temp directory and the tool cache aren't written on the host
+	_ = e.Exec([]string{"sh", "-c", "touch " + filepath.Join(dir, "outside")}, env, "", "")(ctx)
+	_, err = os.Stat(filepath.Join(dir, "outside"))
+	assert.True(t, os.IsNotExist(err))
+	assert.Error(t, e.Exec([]string{"sh", "-c", "touch /usr/act-sandbox"}, env, "", "")(ctx))
+
+	// the sockets of the daemons and of the session of the user can't be connected to
+	assert.NoError(t, e.Exec([]string{"sh", "-c", "for p in /run/docker.sock /var/run/docker.sock /run/podman/podman.sock /run/user/$(id -u); do if [ -e $p ]; then exit 1; fi; done"}, env, "", "")(ctx))
+
+	// bwrap is the init of the PID namespace
+	out.Reset()
+	assert.NoError(t, e.Exec([]string{"sh", "-c", "echo $$"}, env, "", "")(ctx))
+	assert.Equal(t, "2", strings.TrimSpace(out.String()))
+}
@@ -0,0 +1,26 @@
+//go:build !unix
+
+package container
+
+import (
+	"io"
+	"os"
+)
+
+// copyStdin copies stdin to w until done is closed. Stdin can't be polled here, so the read
+// pending when done is closed isn't stopped. This is a known limitation on these platforms:
+// the next input, e.g. the first key pressed at the prompt following the debug shell, is
+// taken by that read and dropped.
+func copyStdin(w io.Writer, done <-chan struct{}) error {
+	copied := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(w, os.Stdin)
+		copied <- err
+	}()
+	select {
+	case err := <-copied:
+		return err
+	case <-done:
+		return nil
+	}
+}
@@ -0,0 +1,43 @@
+//go:build unix
+
+package container
+
+import (
+	"errors"
+	"io"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// copyStdin copies stdin to w until done is closed. Stdin is only read once it has input,
+// so nothing typed after done is closed is taken from the next reader of stdin.
+func copyStdin(w io.Writer, done <-chan struct{}) error {
+	fds := []unix.PollFd{{Fd: int32(os.Stdin.Fd()), Events: unix.POLLIN}}
+	buf := make([]byte, 32*1024)
+	for {
+		select {
+		case <-done:
+			return nil
+		default:
+		}
+		n, err := unix.Poll(fds, 100)
+		if errors.Is(err, unix.EINTR) || n == 0 {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if fds[0].Revents&unix.POLLNVAL != 0 {
+			return io.EOF
+		}
+		n, err = os.Stdin.Read(buf)
+		if n > 0 {
+			if _, err := w.Write(buf[:n]); err != nil {
+				return err
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
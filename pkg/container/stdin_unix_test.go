@@ -0,0 +1,48 @@
+//go:build unix
+
+package container
+
+import (
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCopyStdinStopsReading(t *testing.T) {
+	r, w, err := os.Pipe()
+	assert.NoError(t, err)
+	defer r.Close()
+	defer w.Close()
+	origStdin := os.Stdin
+	os.Stdin = r
+	defer func() {
+		os.Stdin = origStdin
+	}()
+
+	out, in := io.Pipe()
+	done := make(chan struct{})
+	copied := make(chan error, 1)
+	go func() {
+		copied <- copyStdin(in, done)
+	}()
+
+	_, err = w.Write([]byte("exit\n"))
+	assert.NoError(t, err)
+	buf := make([]byte, 5)
+	_, err = io.ReadFull(out, buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "exit\n", string(buf))
+
+	close(done)
+	assert.NoError(t, <-copied)
+
+	// the input after the shell has exited is left for the next prompt
+	_, err = w.Write([]byte("y"))
+	assert.NoError(t, err)
+	buf = make([]byte, 1)
+	_, err = r.Read(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "y", string(buf))
+}
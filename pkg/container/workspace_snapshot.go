@@ -0,0 +1,142 @@
+package container
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/go-git/go-billy/v5/helper/polyfill"
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/filecollector"
+)
+
+// WorkspaceSnapshot is the content addressed snapshot of a working directory
+type WorkspaceSnapshot struct {
+	SrcPath  string
+	Manifest filecollector.Manifest
+	Digest   string
+}
+
+// WorkspaceVolumeInput the input for the NewWorkspaceVolumeExecutor function
+type WorkspaceVolumeInput struct {
+	Name         string // the volume of the workspace of the job
+	ActVolume    string // the volume of the act path of the job, which keeps the manifest of the workspace
+	WorkingDir   string // the path of the workspace in the job container
+	Image        string // the image of the containers populating the volumes, they are never started
+	Platform     string
+	Options      string // the options of the job container, whose user owns the files of the volumes
+	SrcPath      string
+	UseGitIgnore bool
+}
+
+// workspaceManifest is the manifest of the files copied to a directory of a container, kept in the container
+// to send only the files which have changed on the next copy
+type workspaceManifest struct {
+	UID   int                    `json:"uid"`
+	GID   int                    `json:"gid"`
+	Files filecollector.Manifest `json:"files"`
+}
+
+// workspaceManifestPath returns the path of the manifest of a directory of the container,
+// relative to the act path whose volume is kept as long as the container
+func workspaceManifestPath(dstPath string) string {
+	sum := sha256.Sum256([]byte(path.Clean(dstPath)))
+	return "workspace-manifests/" + hex.EncodeToString(sum[:8]) + ".json"
+}
+
+type workspaceSnapshotsContextKey string
+
+const workspaceSnapshotsContextKeyVal = workspaceSnapshotsContextKey("workspaceSnapshots")
+
+type workspaceSnapshots struct {
+	mu        sync.Mutex
+	snapshots map[string]*workspaceSnapshotOnce
+}
+
+type workspaceSnapshotOnce struct {
+	once     sync.Once
+	snapshot *WorkspaceSnapshot
+	err      error
+}
+
+// WithWorkspaceSnapshots adds to the context a cache of the snapshots of the working directories,
+// so that the jobs of a run share the snapshot hashed by the first of them
+func WithWorkspaceSnapshots(ctx context.Context) context.Context {
+	return context.WithValue(ctx, workspaceSnapshotsContextKeyVal, &workspaceSnapshots{snapshots: map[string]*workspaceSnapshotOnce{}})
+}
+
+// GetWorkspaceSnapshot hashes the files of a directory, once per run if the context has a cache of the snapshots
+func GetWorkspaceSnapshot(ctx context.Context, srcPath string, useGitIgnore bool) (*WorkspaceSnapshot, error) {
+	cache, ok := ctx.Value(workspaceSnapshotsContextKeyVal).(*workspaceSnapshots)
+	if !ok {
+		return newWorkspaceSnapshot(ctx, srcPath, useGitIgnore)
+	}
+	// the paths of the manifest are relative to the parent of srcPath, so dir and dir/. have different snapshots
+	key := srcPath
+	if useGitIgnore {
+		key += "\x00gitignore"
+	}
+	cache.mu.Lock()
+	entry, ok := cache.snapshots[key]
+	if !ok {
+		entry = &workspaceSnapshotOnce{}
+		cache.snapshots[key] = entry
+	}
+	cache.mu.Unlock()
+	entry.once.Do(func() {
+		entry.snapshot, entry.err = newWorkspaceSnapshot(ctx, srcPath, useGitIgnore)
+	})
+	return entry.snapshot, entry.err
+}
+
+func newWorkspaceSnapshot(ctx context.Context, srcPath string, useGitIgnore bool) (*WorkspaceSnapshot, error) {
+	logger := common.Logger(ctx)
+	logger.Debugf("Hashing the files of %s", srcPath)
+	collector := &filecollector.ManifestCollector{Manifest: filecollector.Manifest{}}
+	fc := newFileCollector(ctx, srcPath, useGitIgnore, collector)
+	if err := filepath.Walk(srcPath, fc.CollectFiles(ctx, []string{})); err != nil {
+		return nil, err
+	}
+	snapshot := &WorkspaceSnapshot{
+		SrcPath:  srcPath,
+		Manifest: collector.Manifest,
+		Digest:   collector.Manifest.Digest(),
+	}
+	logger.Debugf("Snapshot %s of %s has %d files", snapshot.Digest, srcPath, len(snapshot.Manifest))
+	return snapshot, nil
+}
+
+// newFileCollector returns a collector of the files of srcPath, ignoring the files of .gitignore if useGitIgnore is true
+func newFileCollector(ctx context.Context, srcPath string, useGitIgnore bool, handler filecollector.Handler) *filecollector.FileCollector {
+	logger := common.Logger(ctx)
+	srcPrefix := filepath.Dir(srcPath)
+	if !strings.HasSuffix(srcPrefix, string(filepath.Separator)) {
+		srcPrefix += string(filepath.Separator)
+	}
+	logger.Debugf("Stripping prefix:%s src:%s", srcPrefix, srcPath)
+
+	var ignorer gitignore.Matcher
+	if useGitIgnore {
+		ps, err := gitignore.ReadPatterns(polyfill.New(osfs.New(srcPath)), nil)
+		if err != nil {
+			logger.Debugf("Error loading .gitignore: %v", err)
+		}
+
+		ignorer = gitignore.NewMatcher(ps)
+	}
+
+	return &filecollector.FileCollector{
+		Fs:        &filecollector.DefaultFs{},
+		Ignorer:   ignorer,
+		SrcPath:   srcPath,
+		SrcPrefix: srcPrefix,
+		Handler:   handler,
+	}
+}
@@ -0,0 +1,46 @@
+package container
+
+import (
+	"context"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetWorkspaceSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("*.log\n"), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "build.log"), []byte("ok\n"), 0o644))
+	srcPath := dir + string(filepath.Separator) + "."
+
+	ctx := WithWorkspaceSnapshots(context.Background())
+	snapshot, err := GetWorkspaceSnapshot(ctx, srcPath, true)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{".gitignore", "main.go"}, slices.Collect(maps.Keys(snapshot.Manifest)))
+	assert.Equal(t, snapshot.Manifest.Digest(), snapshot.Digest)
+
+	// the files are hashed once per run
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package other\n"), 0o644))
+	cached, err := GetWorkspaceSnapshot(ctx, srcPath, true)
+	assert.NoError(t, err)
+	assert.Same(t, snapshot, cached)
+
+	withIgnored, err := GetWorkspaceSnapshot(ctx, srcPath, false)
+	assert.NoError(t, err)
+	assert.Len(t, withIgnored.Manifest, 3)
+
+	uncached, err := GetWorkspaceSnapshot(context.Background(), srcPath, true)
+	assert.NoError(t, err)
+	assert.NotEqual(t, snapshot.Digest, uncached.Digest)
+}
+
+func TestWorkspaceManifestPath(t *testing.T) {
+	assert.Equal(t, workspaceManifestPath("/home/runner/work/repo"), workspaceManifestPath("/home/runner/work/repo/"))
+	assert.NotEqual(t, workspaceManifestPath("/home/runner/work/repo"), workspaceManifestPath("/home/runner/work/repo/sub"))
+	assert.Regexp(t, `^workspace-manifests/[0-9a-f]{16}\.json$`, workspaceManifestPath("/home/runner/work/repo"))
+}
@@ -0,0 +1,89 @@
+package filecollector
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"sort"
+)
+
+// FileDigest is the content address of a file of a Manifest
+type FileDigest struct {
+	Mode     fs.FileMode `json:"mode"`
+	Size     int64       `json:"size"`
+	SHA256   string      `json:"sha256,omitempty"`
+	LinkName string      `json:"link,omitempty"`
+}
+
+// Manifest is the content addressed list of the files of a directory, by their slash separated path
+type Manifest map[string]FileDigest
+
+// Digest returns the digest of the manifest, which is the same for the directories with the same files
+func (m Manifest) Digest() string {
+	paths := make([]string, 0, len(m))
+	for p := range m {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	h := sha256.New()
+	for _, p := range paths {
+		f := m[p]
+		fmt.Fprintf(h, "%s\x00%o\x00%s\x00%s\n", p, f.Mode, f.SHA256, f.LinkName)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Diff returns the paths of the files which are new or have changed since the previous manifest, and of the files removed since
+func (m Manifest) Diff(previous Manifest) (changed []string, removed []string) {
+	for p, f := range m {
+		if prev, ok := previous[p]; !ok || prev != f {
+			changed = append(changed, p)
+		}
+	}
+	for p := range previous {
+		if _, ok := m[p]; !ok {
+			removed = append(removed, p)
+		}
+	}
+	sort.Strings(changed)
+	sort.Strings(removed)
+	return changed, removed
+}
+
+// ManifestCollector collects the digests of the files into a manifest
+type ManifestCollector struct {
+	Manifest Manifest
+}
+
+func (mc *ManifestCollector) WriteFile(fpath string, fi fs.FileInfo, linkName string, f io.Reader) error {
+	digest := FileDigest{Mode: fi.Mode(), LinkName: linkName}
+	if f != nil {
+		h := sha256.New()
+		n, err := io.Copy(h, f)
+		if err != nil {
+			return err
+		}
+		digest.Size = n
+		digest.SHA256 = hex.EncodeToString(h.Sum(nil))
+	}
+	if mc.Manifest == nil {
+		mc.Manifest = Manifest{}
+	}
+	mc.Manifest[fpath] = digest
+	return nil
+}
+
+// FilterCollector passes the files of Paths to Handler and skips the others
+type FilterCollector struct {
+	Paths   map[string]bool
+	Handler Handler
+}
+
+func (fc *FilterCollector) WriteFile(fpath string, fi fs.FileInfo, linkName string, f io.Reader) error {
+	if !fc.Paths[fpath] {
+		return nil
+	}
+	return fc.Handler.WriteFile(fpath, fi, linkName, f)
+}
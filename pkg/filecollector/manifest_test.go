@@ -0,0 +1,77 @@
+package filecollector
+
+import (
+	"archive/tar"
+	"context"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManifestCollector(t *testing.T) {
+	fs := memfs.New()
+	worktree, _ := fs.Chroot("repo")
+	for name, content := range map[string]string{"a.txt": "a\n", "dir/b.txt": "b\n"} {
+		f, err := worktree.Create(name)
+		assert.NoError(t, err)
+		_, _ = f.Write([]byte(content))
+		f.Close()
+	}
+	assert.NoError(t, worktree.Symlink("a.txt", "link"))
+
+	collect := func(handler Handler) {
+		fc := &FileCollector{
+			Fs:        &memoryFs{Filesystem: fs},
+			SrcPath:   "repo",
+			SrcPrefix: "repo" + string(filepath.Separator),
+			Handler:   handler,
+		}
+		assert.NoError(t, fc.Fs.Walk("repo", fc.CollectFiles(context.Background(), []string{})))
+	}
+
+	mc := &ManifestCollector{}
+	collect(mc)
+	assert.Len(t, mc.Manifest, 3)
+	assert.Equal(t, "87428fc522803d31065e7bce3cf03fe475096631e5e07bbd7a0fde60c4cf25c7", mc.Manifest["a.txt"].SHA256)
+	assert.Equal(t, int64(2), mc.Manifest["dir/b.txt"].Size)
+	assert.Equal(t, "a.txt", mc.Manifest["link"].LinkName)
+	assert.Empty(t, mc.Manifest["link"].SHA256)
+
+	// the digest only depends on the files
+	again := &ManifestCollector{}
+	collect(again)
+	assert.Equal(t, mc.Manifest.Digest(), again.Manifest.Digest())
+
+	f, _ := worktree.Create("a.txt")
+	_, _ = f.Write([]byte("changed\n"))
+	f.Close()
+	_ = worktree.Remove("dir/b.txt")
+	_ = worktree.Remove("dir")
+	f, _ = worktree.Create("c.txt")
+	f.Close()
+	changedManifest := &ManifestCollector{}
+	collect(changedManifest)
+	assert.NotEqual(t, mc.Manifest.Digest(), changedManifest.Manifest.Digest())
+
+	changed, removed := changedManifest.Manifest.Diff(mc.Manifest)
+	assert.Equal(t, []string{"a.txt", "c.txt"}, changed)
+	assert.Equal(t, []string{"dir/b.txt"}, removed)
+
+	tmpTar, _ := fs.Create("temp.tar")
+	tw := tar.NewWriter(tmpTar)
+	collect(&FilterCollector{
+		Paths:   map[string]bool{"c.txt": true},
+		Handler: &TarCollector{TarWriter: tw},
+	})
+	tw.Close()
+	_, _ = tmpTar.Seek(0, io.SeekStart)
+	tr := tar.NewReader(tmpTar)
+	h, err := tr.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, "c.txt", h.Name)
+	_, err = tr.Next()
+	assert.ErrorIs(t, err, io.EOF, "tar must only contain the filtered file")
+}
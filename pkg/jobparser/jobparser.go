@@ -32,7 +32,7 @@ func Parse(content []byte, options ...ParseOption) ([]*SingleWorkflow, error) {
 		results[id] = &JobResult{
 			Needs:   job.Needs(),
 			Result:  pc.jobResults[id],
-			Outputs: nil, // not supported yet
+			Outputs: pc.jobOutputs[id],
 		}
 	}
 
@@ -47,23 +47,38 @@ func Parse(content []byte, options ...ParseOption) ([]*SingleWorkflow, error) {
 
 	for i, id := range ids {
 		job := jobs[i]
-		matricxes, err := getMatrixes(origin.GetJob(id))
-		if err != nil {
-			return nil, fmt.Errorf("getMatrixes: %w", err)
+		var matricxes []map[string]interface{}
+		if origin.GetJob(id).MatrixReferencesNeeds() && !hasResults(origin.GetJob(id).Needs(), results) {
+			// the matrix can't be expanded before the jobs it needs have finished,
+			// keep it as is so that the job can be parsed again with WithJobResults and WithJobOutputs
+			matricxes = []map[string]interface{}{nil}
+		} else {
+			if origin.GetJob(id).MatrixReferencesNeeds() {
+				evaluator := NewExpressionEvaluator(NewInterpeter(id, origin.GetJob(id), nil, pc.gitContext, results, pc.vars, pc.inputs))
+				if err := evaluator.EvaluateYamlNode(&origin.GetJob(id).Strategy.RawMatrix); err != nil {
+					return nil, fmt.Errorf("failed to evaluate matrix: %w", err)
+				}
+			}
+			matricxes, err = getMatrixes(origin.GetJob(id))
+			if err != nil {
+				return nil, fmt.Errorf("getMatrixes: %w", err)
+			}
 		}
 		for _, matrix := range matricxes {
 			job := job.Clone()
 			if job.Name == "" {
 				job.Name = id
 			}
-			job.Strategy.RawMatrix = encodeMatrix(matrix)
-			evaluator := NewExpressionEvaluator(NewInterpeter(id, origin.GetJob(id), matrix, pc.gitContext, results, pc.vars, pc.inputs))
-			job.Name = nameWithMatrix(job.Name, matrix, evaluator)
-			runsOn := origin.GetJob(id).RunsOn()
-			for i, v := range runsOn {
-				runsOn[i] = evaluator.Interpolate(v)
+			if matrix != nil {
+				job.Strategy.RawMatrix = encodeMatrix(matrix)
+				evaluator := NewExpressionEvaluator(NewInterpeter(id, origin.GetJob(id), matrix, pc.gitContext, results, pc.vars, pc.inputs))
+				job.Name = nameWithMatrix(job.Name, matrix, evaluator)
+				runsOn := origin.GetJob(id).RunsOn()
+				for i, v := range runsOn {
+					runsOn[i] = evaluator.Interpolate(v)
+				}
+				job.RawRunsOn = encodeRunsOn(runsOn)
 			}
-			job.RawRunsOn = encodeRunsOn(runsOn)
 			swf := &SingleWorkflow{
 				Name:           workflow.Name,
 				RawOn:          workflow.RawOn,
@@ -87,6 +102,13 @@ func WithJobResults(results map[string]string) ParseOption {
 	}
 }
 
+// WithJobOutputs sets the outputs of the finished jobs, which are used to expand matrices referencing the needs context
+func WithJobOutputs(outputs map[string]map[string]string) ParseOption {
+	return func(c *parseContext) {
+		c.jobOutputs = outputs
+	}
+}
+
 func WithGitContext(context *model.GithubContext) ParseOption {
 	return func(c *parseContext) {
 		c.gitContext = context
@@ -107,6 +129,7 @@ func WithInputs(inputs map[string]any) ParseOption {
 
 type parseContext struct {
 	jobResults map[string]string
+	jobOutputs map[string]map[string]string
 	gitContext *model.GithubContext
 	vars       map[string]string
 	inputs     map[string]any
@@ -114,13 +137,22 @@ type parseContext struct {
 
 type ParseOption func(c *parseContext)
 
+func hasResults(jobIDs []string, results map[string]*JobResult) bool {
+	for _, id := range jobIDs {
+		if r, ok := results[id]; !ok || r.Result == "" {
+			return false
+		}
+	}
+	return true
+}
+
 func getMatrixes(job *model.Job) ([]map[string]interface{}, error) {
 	ret, err := job.GetMatrixes()
 	if err != nil {
 		return nil, fmt.Errorf("GetMatrixes: %w", err)
 	}
 	sort.Slice(ret, func(i, j int) bool {
-		return matrixName(ret[i]) < matrixName(ret[j])
+		return MatrixName(ret[i]) < MatrixName(ret[j])
 	})
 	return ret, nil
 }
@@ -154,13 +186,14 @@ func nameWithMatrix(name string, m map[string]interface{}, evaluator *Expression
 	}
 
 	if !strings.Contains(name, "${{") || !strings.Contains(name, "}}") {
-		return name + " " + matrixName(m)
+		return name + " " + MatrixName(m)
 	}
 
 	return evaluator.Interpolate(name)
 }
 
-func matrixName(m map[string]interface{}) string {
+// MatrixName returns the values of the matrix combination ordered by key, like GitHub suffixes the name of a matrix job (e.g. `(ubuntu-latest, 18)`)
+func MatrixName(m map[string]interface{}) string {
 	ks := make([]string, 0, len(m))
 	for k := range m {
 		ks = append(ks, k)
@@ -57,6 +57,19 @@ func TestParse(t *testing.T) {
 			options: nil,
 			wantErr: false,
 		},
+		{
+			name:    "dynamic_matrix",
+			options: nil,
+			wantErr: false,
+		},
+		{
+			name: "dynamic_matrix_with_outputs",
+			options: []ParseOption{
+				WithJobResults(map[string]string{"job1": "success"}),
+				WithJobOutputs(map[string]map[string]string{"job1": {"matrix": `{"os":["ubuntu-22.04","ubuntu-20.04"]}`}}),
+			},
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
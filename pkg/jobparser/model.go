@@ -80,6 +80,7 @@ type Job struct {
 	Steps          []*Step                   `yaml:"steps,omitempty"`
 	TimeoutMinutes string                    `yaml:"timeout-minutes,omitempty"`
 	Services       map[string]*ContainerSpec `yaml:"services,omitempty"`
+	Compose        *model.ComposeSpec        `yaml:"compose,omitempty"`
 	Strategy       Strategy                  `yaml:"strategy,omitempty"`
 	RawContainer   yaml.Node                 `yaml:"container,omitempty"`
 	Defaults       Defaults                  `yaml:"defaults,omitempty"`
@@ -104,6 +105,7 @@ func (j *Job) Clone() *Job {
 		Steps:          j.Steps,
 		TimeoutMinutes: j.TimeoutMinutes,
 		Services:       j.Services,
+		Compose:        j.Compose,
 		Strategy:       j.Strategy,
 		RawContainer:   j.RawContainer,
 		Defaults:       j.Defaults,
@@ -157,13 +159,17 @@ func (s *Step) String() string {
 }
 
 type ContainerSpec struct {
-	Image       string            `yaml:"image,omitempty"`
-	Env         map[string]string `yaml:"env,omitempty"`
-	Ports       []string          `yaml:"ports,omitempty"`
-	Volumes     []string          `yaml:"volumes,omitempty"`
-	Options     string            `yaml:"options,omitempty"`
-	Credentials map[string]string `yaml:"credentials,omitempty"`
-	Cmd         []string          `yaml:"cmd,omitempty"`
+	Image       string                    `yaml:"image,omitempty"`
+	Env         map[string]string         `yaml:"env,omitempty"`
+	Ports       []string                  `yaml:"ports,omitempty"`
+	Volumes     []string                  `yaml:"volumes,omitempty"`
+	Options     string                    `yaml:"options,omitempty"`
+	Credentials map[string]string         `yaml:"credentials,omitempty"`
+	Cmd         []string                  `yaml:"cmd,omitempty"`
+	Entrypoint  string                    `yaml:"entrypoint,omitempty"`
+	Aliases     []string                  `yaml:"aliases,omitempty"`
+	DependsOn   model.ServiceDependencies `yaml:"depends-on,omitempty"`
+	OneShot     bool                      `yaml:"one-shot,omitempty"`
 }
 
 type Strategy struct {
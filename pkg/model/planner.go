@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"sort"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -50,6 +51,34 @@ func (r *Run) Job() *Job {
 	return r.Workflow.GetJob(r.JobID)
 }
 
+// QualifiedJobID returns the job ID prefixed with the workflow file (e.g. `ci.yml:build`),
+// which identifies the job among all the workflows of a plan
+func (r *Run) QualifiedJobID() string {
+	if r.Workflow == nil || r.Workflow.File == "" {
+		return r.JobID
+	}
+	return fmt.Sprintf("%s:%s", r.Workflow.File, r.JobID)
+}
+
+// MatchesJobID returns true if the run is the job with the given ID,
+// the ID may be qualified with the workflow file (e.g. `ci.yml:build`)
+func (r *Run) MatchesJobID(jobID string) bool {
+	workflowFile, id := SplitQualifiedJobID(jobID)
+	if id != r.JobID {
+		return false
+	}
+	return workflowFile == "" || (r.Workflow != nil && r.Workflow.matchesFile(workflowFile))
+}
+
+// SplitQualifiedJobID splits a job ID of the form `workflow.yml:job` into the workflow file and the job ID,
+// the workflow file is empty if the job ID isn't qualified
+func SplitQualifiedJobID(jobID string) (string, string) {
+	if i := strings.LastIndex(jobID, ":"); i >= 0 {
+		return jobID[:i], jobID[i+1:]
+	}
+	return "", jobID
+}
+
 type WorkflowFiles struct {
 	workflowDirEntry os.DirEntry
 	dirPath          string
@@ -71,6 +100,12 @@ func NewWorkflowPlanner(path string, noWorkflowRecurse bool) (WorkflowPlanner, e
 
 	var workflows []WorkflowFiles
 
+	// the files of the workflows are identified by their path relative to the directory they are loaded from
+	rootDir := path
+	if !fi.IsDir() {
+		rootDir = filepath.Dir(path)
+	}
+
 	if fi.IsDir() {
 		log.Debugf("Loading workflows from '%s'", path)
 		if noWorkflowRecurse {
@@ -144,6 +179,9 @@ func NewWorkflowPlanner(path string, noWorkflowRecurse bool) (WorkflowPlanner, e
 			}
 
 			workflow.File = wf.workflowDirEntry.Name()
+			if rel, err := filepath.Rel(rootDir, filepath.Join(wf.dirPath, wf.workflowDirEntry.Name())); err == nil {
+				workflow.File = filepath.ToSlash(rel)
+			}
 			if workflow.Name == "" {
 				workflow.Name = wf.workflowDirEntry.Name()
 			}
@@ -158,12 +196,49 @@ func NewWorkflowPlanner(path string, noWorkflowRecurse bool) (WorkflowPlanner, e
 			_ = f.Close()
 		}
 	}
+	markSharedBaseNames(wp.workflows)
 
 	return wp, nil
 }
 
+// markSharedBaseNames marks the workflows whose file has the same base name as the file of another workflow,
+// they can only be selected by their path
+func markSharedBaseNames(workflows []*Workflow) {
+	count := map[string]int{}
+	for _, w := range workflows {
+		count[filepath.Base(w.File)]++
+	}
+	for _, w := range workflows {
+		w.sharedBaseName = count[filepath.Base(w.File)] > 1
+	}
+}
+
+// checkWorkflowFile returns an error if no workflow was read from the file of a qualified job ID, or if the file
+// is the base name of the files of several workflows
+func checkWorkflowFile(workflows []*Workflow, file, jobID string) error {
+	file = strings.TrimPrefix(filepath.ToSlash(file), "./")
+	matches := []string{}
+	for _, w := range workflows {
+		if w.File == file {
+			return nil
+		}
+		if !strings.Contains(file, "/") && filepath.Base(w.File) == file {
+			matches = append(matches, w.File)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return fmt.Errorf("workflow '%s' of job '%s' not found", file, jobID)
+	case 1:
+		return nil
+	}
+	sort.Strings(matches)
+	return fmt.Errorf("workflow '%s' of job '%s' is ambiguous, it may be %s", file, jobID, strings.Join(matches, " or "))
+}
+
 // CombineWorkflowPlanner combines workflows to a WorkflowPlanner
 func CombineWorkflowPlanner(workflows ...*Workflow) WorkflowPlanner {
+	markSharedBaseNames(workflows)
 	return &workflowPlanner{
 		workflows: workflows,
 	}
@@ -240,7 +315,8 @@ func (wp *workflowPlanner) PlanEvent(eventName string) (*Plan, error) {
 	return plan, lastErr
 }
 
-// PlanJob builds a new run to execute in parallel for a job name
+// PlanJob builds a new run to execute in parallel for a job name,
+// the job name may be qualified with the workflow file (e.g. `ci.yml:build`) to select it from a single workflow
 func (wp *workflowPlanner) PlanJob(jobName string) (*Plan, error) {
 	plan := new(Plan)
 	if len(wp.workflows) == 0 {
@@ -248,8 +324,18 @@ func (wp *workflowPlanner) PlanJob(jobName string) (*Plan, error) {
 	}
 	var lastErr error
 
+	workflowFile, jobID := SplitQualifiedJobID(jobName)
+	if workflowFile != "" {
+		if err := checkWorkflowFile(wp.workflows, workflowFile, jobName); err != nil {
+			return nil, err
+		}
+	}
+
 	for _, w := range wp.workflows {
-		stages, err := createStages(w, jobName)
+		if workflowFile != "" && !w.matchesFile(workflowFile) {
+			continue
+		}
+		stages, err := createStages(w, jobID)
 		if err != nil {
 			log.Warn(err)
 			lastErr = err
@@ -326,6 +412,74 @@ func (p *Plan) MaxRunNameLen() int {
 	return maxRunNameLen
 }
 
+// DuplicateJobIDs returns the job IDs used by runs of more than one workflow in the plan,
+// these runs are only identified by their QualifiedJobID
+func (p *Plan) DuplicateJobIDs() map[string]bool {
+	seen := map[string]string{}
+	duplicates := map[string]bool{}
+	for _, stage := range p.Stages {
+		for _, run := range stage.Runs {
+			if qualifiedJobID, ok := seen[run.JobID]; ok && qualifiedJobID != run.QualifiedJobID() {
+				duplicates[run.JobID] = true
+			}
+			seen[run.JobID] = run.QualifiedJobID()
+		}
+	}
+	return duplicates
+}
+
+// CheckJobID returns an error if the workflow file of a qualified job ID matches none or several of the workflows of the plan
+func (p *Plan) CheckJobID(jobID string) error {
+	file, _ := SplitQualifiedJobID(jobID)
+	if file == "" {
+		return nil
+	}
+	workflows := []*Workflow{}
+	seen := map[*Workflow]bool{}
+	for _, stage := range p.Stages {
+		for _, run := range stage.Runs {
+			if !seen[run.Workflow] {
+				seen[run.Workflow] = true
+				workflows = append(workflows, run.Workflow)
+			}
+		}
+	}
+	return checkWorkflowFile(workflows, file, jobID)
+}
+
+// FilterWorkflows returns a plan with only the runs of the workflows for which keep returns true
+func (p *Plan) FilterWorkflows(keep func(*Workflow) bool) *Plan {
+	kept := map[*Workflow]bool{}
+	plan := &Plan{}
+	for _, stage := range p.Stages {
+		runs := make([]*Run, 0, len(stage.Runs))
+		for _, run := range stage.Runs {
+			k, ok := kept[run.Workflow]
+			if !ok {
+				k = keep(run.Workflow)
+				kept[run.Workflow] = k
+			}
+			if k {
+				runs = append(runs, run)
+			}
+		}
+		if len(runs) > 0 {
+			plan.Stages = append(plan.Stages, &Stage{Runs: runs})
+		}
+	}
+	return plan
+}
+
+// Workflow returns the workflow of the first run in the plan, or nil if the plan is empty
+func (p *Plan) Workflow() *Workflow {
+	for _, stage := range p.Stages {
+		for _, run := range stage.Runs {
+			return run.Workflow
+		}
+	}
+	return nil
+}
+
 // GetJobIDs will get all the job names in the stage
 func (s *Stage) GetJobIDs() []string {
 	names := make([]string, 0)
@@ -2,6 +2,7 @@ package model
 
 import (
 	"path/filepath"
+	"sort"
 	"testing"
 
 	log "github.com/sirupsen/logrus"
@@ -61,3 +62,104 @@ func TestWorkflow(t *testing.T) {
 	assert.Nil(t, err)
 	assert.NotNil(t, result)
 }
+
+func TestPlanJobQualifiedJobID(t *testing.T) {
+	planner, err := NewWorkflowPlanner("testdata/duplicate-job-ids", true)
+	assert.NoError(t, err)
+
+	jobIDs := func(plan *Plan) [][]string {
+		ids := [][]string{}
+		for _, stage := range plan.Stages {
+			stageIDs := []string{}
+			for _, run := range stage.Runs {
+				stageIDs = append(stageIDs, run.QualifiedJobID())
+			}
+			sort.Strings(stageIDs)
+			ids = append(ids, stageIDs)
+		}
+		return ids
+	}
+
+	plan, err := planner.PlanJob("ci.yml:build")
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{"ci.yml:setup"}, {"ci.yml:build"}}, jobIDs(plan))
+	assert.Empty(t, plan.DuplicateJobIDs())
+
+	plan, err = planner.PlanJob("./release.yml:build")
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{"release.yml:build"}}, jobIDs(plan))
+
+	plan, err = planner.PlanJob("build")
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{"ci.yml:setup", "release.yml:build"}, {"ci.yml:build"}}, jobIDs(plan))
+
+	plan, err = planner.PlanAll()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{"build": true}, plan.DuplicateJobIDs())
+	for _, stage := range plan.Stages {
+		for _, run := range stage.Runs {
+			assert.True(t, run.MatchesJobID(run.JobID))
+			assert.True(t, run.MatchesJobID(run.QualifiedJobID()))
+			assert.False(t, run.MatchesJobID("other.yml:"+run.JobID))
+		}
+	}
+
+	plan = plan.FilterWorkflows(func(w *Workflow) bool {
+		return w.File == "release.yml"
+	})
+	assert.Equal(t, [][]string{{"release.yml:build"}, {"release.yml:publish"}}, jobIDs(plan))
+
+	_, err = planner.PlanJob("other.yml:build")
+	assert.EqualError(t, err, "workflow 'other.yml' of job 'other.yml:build' not found")
+}
+
+func TestPlanJobWorkflowSubdirectories(t *testing.T) {
+	planner, err := NewWorkflowPlanner("testdata/workflow-subdirectories", false)
+	assert.NoError(t, err)
+
+	jobIDs := func(plan *Plan) []string {
+		ids := []string{}
+		for _, stage := range plan.Stages {
+			for _, run := range stage.Runs {
+				ids = append(ids, run.QualifiedJobID())
+			}
+		}
+		sort.Strings(ids)
+		return ids
+	}
+
+	plan, err := planner.PlanAll()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ci.yml:build", "other/lint.yml:lint", "sub/ci.yml:build", "sub/lint.yml:lint", "sub/release.yml:publish"}, jobIDs(plan))
+
+	// the path relative to the workflows directory selects a single workflow
+	plan, err = planner.PlanJob("ci.yml:build")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ci.yml:build"}, jobIDs(plan))
+	plan, err = planner.PlanJob("sub/ci.yml:build")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"sub/ci.yml:build"}, jobIDs(plan))
+
+	// the base name only if it is unique
+	plan, err = planner.PlanJob("release.yml:publish")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"sub/release.yml:publish"}, jobIDs(plan))
+	_, err = planner.PlanJob("lint.yml:lint")
+	assert.EqualError(t, err, "workflow 'lint.yml' of job 'lint.yml:lint' is ambiguous, it may be other/lint.yml or sub/lint.yml")
+	_, err = planner.PlanJob("other/ci.yml:build")
+	assert.EqualError(t, err, "workflow 'other/ci.yml' of job 'other/ci.yml:build' not found")
+
+	plan, err = planner.PlanAll()
+	assert.NoError(t, err)
+	assert.EqualError(t, plan.CheckJobID("lint.yml:lint"), "workflow 'lint.yml' of job 'lint.yml:lint' is ambiguous, it may be other/lint.yml or sub/lint.yml")
+	assert.NoError(t, plan.CheckJobID("sub/lint.yml:lint"))
+	assert.NoError(t, plan.CheckJobID("lint"))
+	for _, stage := range plan.Stages {
+		for _, run := range stage.Runs {
+			assert.True(t, run.MatchesJobID(run.QualifiedJobID()))
+			assert.False(t, run.MatchesJobID("lint.yml:"+run.JobID))
+			assert.Equal(t, run.Workflow.File == "ci.yml", run.MatchesJobID("ci.yml:"+run.JobID))
+			assert.Equal(t, run.Workflow.File == "sub/release.yml", run.MatchesJobID("release.yml:"+run.JobID))
+		}
+	}
+}
@@ -0,0 +1,430 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/kballard/go-shellquote"
+	"go.yaml.in/yaml/v4"
+)
+
+// The conditions a service waits for the services it depends on to meet, like the ones of docker compose
+const (
+	ServiceStarted               = "service_started"                // the container has started
+	ServiceHealthy               = "service_healthy"                // the healthcheck of the container has passed, or it has started if it has none
+	ServiceCompletedSuccessfully = "service_completed_successfully" // the container of a one-shot service has exited with code 0
+)
+
+// ServiceDependencies maps the services a service depends on to their condition. It is written as a list
+// of services, which must have started, or as a mapping of the services to their condition:
+//
+//	depends-on: [migrate]
+//	depends-on:
+//	  db:
+//	    condition: service_healthy
+type ServiceDependencies map[string]string
+
+func (d *ServiceDependencies) UnmarshalYAML(n *yaml.Node) error {
+	var services []string
+	if err := n.Decode(&services); err == nil {
+		*d = make(ServiceDependencies, len(services))
+		for _, service := range services {
+			(*d)[service] = ServiceStarted
+		}
+		return nil
+	}
+	var conditions map[string]yaml.Node
+	if err := n.Decode(&conditions); err != nil {
+		return fmt.Errorf("depends-on must be a list of services or a mapping of services to their condition: %w", err)
+	}
+	*d = make(ServiceDependencies, len(conditions))
+	for service, node := range conditions {
+		var condition struct {
+			Condition string `yaml:"condition"`
+		}
+		if node.Kind == yaml.ScalarNode {
+			if err := node.Decode(&condition.Condition); err != nil {
+				return err
+			}
+		} else if err := node.Decode(&condition); err != nil {
+			return err
+		}
+		if condition.Condition == "" {
+			condition.Condition = ServiceStarted
+		}
+		(*d)[service] = condition.Condition
+	}
+	return nil
+}
+
+// CheckServiceDependencies checks that the services depend on services which exist with a known condition,
+// that only one-shot services are waited for to complete, and that no service depends on itself
+func CheckServiceDependencies(services map[string]*ContainerSpec) error {
+	ids := make([]string, 0, len(services))
+	for id := range services {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		for dependency, condition := range services[id].DependsOn {
+			spec, ok := services[dependency]
+			if !ok {
+				return fmt.Errorf("service %s depends on service %s, which doesn't exist", id, dependency)
+			}
+			switch condition {
+			case ServiceStarted, ServiceHealthy:
+			case ServiceCompletedSuccessfully:
+				if !spec.OneShot {
+					return fmt.Errorf("service %s waits for service %s to complete, which isn't one-shot", id, dependency)
+				}
+			default:
+				return fmt.Errorf("service %s depends on service %s with the unknown condition '%s'", id, dependency, condition)
+			}
+		}
+	}
+
+	// depth-first search of a cycle
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := map[string]int{}
+	var visit func(id string, path []string) error
+	visit = func(id string, path []string) error {
+		switch state[id] {
+		case visiting:
+			return fmt.Errorf("the services depend on each other: %s", strings.Join(append(path, id), " -> "))
+		case visited:
+			return nil
+		}
+		state[id] = visiting
+		dependencies := make([]string, 0, len(services[id].DependsOn))
+		for dependency := range services[id].DependsOn {
+			dependencies = append(dependencies, dependency)
+		}
+		sort.Strings(dependencies)
+		for _, dependency := range dependencies {
+			if err := visit(dependency, append(path, id)); err != nil {
+				return err
+			}
+		}
+		state[id] = visited
+		return nil
+	}
+	for _, id := range ids {
+		if err := visit(id, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ComposeSpec references the docker-compose.yml whose services are started with the services of a job,
+// it is written as the path of the file or as a mapping:
+//
+//	compose:
+//	  file: docker-compose.yml
+//	  services: [api]
+type ComposeSpec struct {
+	File     string   `yaml:"file"`
+	Services []string `yaml:"services,omitempty"` // the services to start with the services they depend on, all of them if empty
+}
+
+type objectComposeSpec ComposeSpec
+
+func (c *ComposeSpec) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind == yaml.ScalarNode {
+		return n.Decode(&c.File)
+	}
+	return n.Decode((*objectComposeSpec)(c))
+}
+
+// ComposeProject is the services and the named volumes of a docker-compose.yml
+type ComposeProject struct {
+	Services map[string]*ContainerSpec
+	Volumes  []string // the named volumes declared by the file, which only the containers of the job share
+}
+
+type composeFile struct {
+	Services map[string]*composeService `yaml:"services"`
+	Volumes  map[string]*composeVolume  `yaml:"volumes"`
+}
+
+type composeService struct {
+	Image       string              `yaml:"image"`
+	Build       yaml.Node           `yaml:"build"`
+	Environment yaml.Node           `yaml:"environment"`
+	Ports       []string            `yaml:"ports"`
+	Volumes     []string            `yaml:"volumes"`
+	Command     yaml.Node           `yaml:"command"`
+	Entrypoint  yaml.Node           `yaml:"entrypoint"`
+	DependsOn   ServiceDependencies `yaml:"depends_on"`
+	Healthcheck *composeHealthcheck `yaml:"healthcheck"`
+	Networks    yaml.Node           `yaml:"networks"`
+	User        string              `yaml:"user"`
+	WorkingDir  string              `yaml:"working_dir"`
+}
+
+type composeHealthcheck struct {
+	Test        yaml.Node `yaml:"test"`
+	Interval    string    `yaml:"interval"`
+	Timeout     string    `yaml:"timeout"`
+	Retries     int       `yaml:"retries"`
+	StartPeriod string    `yaml:"start_period"`
+	Disable     bool      `yaml:"disable"`
+}
+
+type composeVolume struct {
+	External bool `yaml:"external"`
+}
+
+// ReadCompose reads the services of a docker-compose.yml, only the given services and the services they depend on if any.
+// The relative paths of the bind mounts are relative to the directory of the file.
+func ReadCompose(path string, services []string) (*ComposeProject, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	file := &composeFile{}
+	if err := yaml.Unmarshal(content, file); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	project := &ComposeProject{Services: map[string]*ContainerSpec{}}
+	for name, volume := range file.Volumes {
+		if volume == nil || !volume.External {
+			project.Volumes = append(project.Volumes, name)
+		}
+	}
+	sort.Strings(project.Volumes)
+
+	dir := filepath.Dir(path)
+	for id, service := range file.Services {
+		if service == nil {
+			return nil, fmt.Errorf("service %s of %s is empty", id, path)
+		}
+		spec, err := service.containerSpec(dir)
+		if err != nil {
+			return nil, fmt.Errorf("service %s of %s: %w", id, path, err)
+		}
+		project.Services[id] = spec
+	}
+	// the services other services wait for to complete run to completion
+	for _, spec := range project.Services {
+		for dependency, condition := range spec.DependsOn {
+			if dependent, ok := project.Services[dependency]; ok && condition == ServiceCompletedSuccessfully {
+				dependent.OneShot = true
+			}
+		}
+	}
+	if err := CheckServiceDependencies(project.Services); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if len(services) == 0 {
+		return project, nil
+	}
+	selected := map[string]*ContainerSpec{}
+	var add func(id string) error
+	add = func(id string) error {
+		if _, ok := selected[id]; ok {
+			return nil
+		}
+		spec, ok := project.Services[id]
+		if !ok {
+			return fmt.Errorf("service %s doesn't exist in %s", id, path)
+		}
+		selected[id] = spec
+		for dependency := range spec.DependsOn {
+			if err := add(dependency); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, id := range services {
+		if err := add(id); err != nil {
+			return nil, err
+		}
+	}
+	project.Services = selected
+	return project, nil
+}
+
+func (s *composeService) containerSpec(dir string) (*ContainerSpec, error) {
+	if !s.Build.IsZero() {
+		return nil, errors.New("build isn't supported, use an image")
+	}
+	if s.Image == "" {
+		return nil, errors.New("image is required")
+	}
+	spec := &ContainerSpec{
+		Image:     s.Image,
+		Ports:     s.Ports,
+		DependsOn: s.DependsOn,
+	}
+
+	env, err := composeEnvironment(s.Environment)
+	if err != nil {
+		return nil, err
+	}
+	spec.Env = env
+
+	for _, volume := range s.Volumes {
+		if strings.HasPrefix(volume, ".") {
+			// a relative path of the host
+			source, target, _ := strings.Cut(volume, ":")
+			volume = filepath.Join(dir, source) + ":" + target
+		}
+		spec.Volumes = append(spec.Volumes, volume)
+	}
+
+	if spec.Cmd, err = composeCommand(s.Command); err != nil {
+		return nil, fmt.Errorf("command: %w", err)
+	}
+	entrypoint, err := composeCommand(s.Entrypoint)
+	if err != nil {
+		return nil, fmt.Errorf("entrypoint: %w", err)
+	}
+	if len(entrypoint) > 0 {
+		spec.Entrypoint = shellquote.Join(entrypoint...)
+	}
+
+	if s.Networks.Kind == yaml.MappingNode {
+		var networks map[string]*struct {
+			Aliases []string `yaml:"aliases"`
+		}
+		if err := s.Networks.Decode(&networks); err != nil {
+			return nil, fmt.Errorf("networks: %w", err)
+		}
+		// the services share the network of the job
+		for _, network := range networks {
+			if network != nil {
+				for _, alias := range network.Aliases {
+					if !slices.Contains(spec.Aliases, alias) {
+						spec.Aliases = append(spec.Aliases, alias)
+					}
+				}
+			}
+		}
+		sort.Strings(spec.Aliases)
+	}
+
+	var options []string
+	if s.User != "" {
+		options = append(options, "--user", s.User)
+	}
+	if s.WorkingDir != "" {
+		options = append(options, "--workdir", s.WorkingDir)
+	}
+	if s.Healthcheck != nil {
+		healthOptions, err := s.Healthcheck.options()
+		if err != nil {
+			return nil, fmt.Errorf("healthcheck: %w", err)
+		}
+		options = append(options, healthOptions...)
+	}
+	spec.Options = shellquote.Join(options...)
+	return spec, nil
+}
+
+// composeEnvironment returns the environment of a service, a mapping or a list of KEY=VALUE. The variables
+// without a value, which docker compose reads from the environment of the host, are left out.
+func composeEnvironment(node yaml.Node) (map[string]string, error) {
+	env := map[string]string{}
+	switch node.Kind {
+	case 0:
+		return env, nil
+	case yaml.SequenceNode:
+		var list []string
+		if err := node.Decode(&list); err != nil {
+			return nil, fmt.Errorf("environment: %w", err)
+		}
+		for _, variable := range list {
+			if k, v, ok := strings.Cut(variable, "="); ok {
+				env[k] = v
+			}
+		}
+	default:
+		var mapping map[string]*string
+		if err := node.Decode(&mapping); err != nil {
+			return nil, fmt.Errorf("environment: %w", err)
+		}
+		for k, v := range mapping {
+			if v != nil {
+				env[k] = *v
+			}
+		}
+	}
+	return env, nil
+}
+
+// composeCommand returns the arguments of a command, a string split like a shell does or a list
+func composeCommand(node yaml.Node) ([]string, error) {
+	switch node.Kind {
+	case 0:
+		return nil, nil
+	case yaml.ScalarNode:
+		var command string
+		if err := node.Decode(&command); err != nil {
+			return nil, err
+		}
+		return shellquote.Split(command)
+	}
+	var command []string
+	if err := node.Decode(&command); err != nil {
+		return nil, err
+	}
+	return command, nil
+}
+
+// options returns the options of docker run of a healthcheck
+func (h *composeHealthcheck) options() ([]string, error) {
+	var test []string
+	switch h.Test.Kind {
+	case 0:
+	case yaml.ScalarNode:
+		var command string
+		if err := h.Test.Decode(&command); err != nil {
+			return nil, err
+		}
+		test = []string{"CMD-SHELL", command}
+	default:
+		if err := h.Test.Decode(&test); err != nil {
+			return nil, err
+		}
+	}
+	if h.Disable || (len(test) > 0 && test[0] == "NONE") {
+		return []string{"--no-healthcheck"}, nil
+	}
+
+	var options []string
+	if len(test) > 0 {
+		switch test[0] {
+		case "CMD-SHELL":
+			options = append(options, "--health-cmd", strings.Join(test[1:], " "))
+		case "CMD":
+			options = append(options, "--health-cmd", shellquote.Join(test[1:]...))
+		default:
+			return nil, fmt.Errorf("test must start with NONE, CMD or CMD-SHELL, not %s", test[0])
+		}
+	}
+	if h.Interval != "" {
+		options = append(options, "--health-interval", h.Interval)
+	}
+	if h.Timeout != "" {
+		options = append(options, "--health-timeout", h.Timeout)
+	}
+	if h.Retries > 0 {
+		options = append(options, "--health-retries", fmt.Sprint(h.Retries))
+	}
+	if h.StartPeriod != "" {
+		options = append(options, "--health-start-period", h.StartPeriod)
+	}
+	return options, nil
+}
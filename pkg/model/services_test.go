@@ -0,0 +1,182 @@
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.yaml.in/yaml/v4"
+)
+
+func TestServiceDependencies(t *testing.T) {
+	content := `
+name: services
+on: push
+jobs:
+  test:
+    runs-on: ubuntu-latest
+    compose: docker-compose.yml
+    services:
+      db:
+        image: postgres:16
+        aliases: [postgres]
+      migrate:
+        image: migrate/migrate
+        one-shot: true
+        entrypoint: /migrate.sh
+        depends-on:
+          db:
+            condition: service_healthy
+      api:
+        image: api
+        depends-on: [db]
+      worker:
+        image: worker
+        depends-on:
+          migrate: service_completed_successfully
+          api:
+    steps:
+      - run: echo
+`
+	workflow, err := ReadWorkflow(strings.NewReader(content))
+	assert.NoError(t, err)
+	job := workflow.GetJob("test")
+	assert.Equal(t, &ComposeSpec{File: "docker-compose.yml"}, job.Compose)
+	services := job.Services
+	assert.Equal(t, []string{"postgres"}, services["db"].Aliases)
+	assert.Equal(t, "/migrate.sh", services["migrate"].Entrypoint)
+	assert.True(t, services["migrate"].OneShot)
+	assert.Equal(t, ServiceDependencies{"db": ServiceHealthy}, services["migrate"].DependsOn)
+	assert.Equal(t, ServiceDependencies{"db": ServiceStarted}, services["api"].DependsOn)
+	assert.Equal(t, ServiceDependencies{"migrate": ServiceCompletedSuccessfully, "api": ServiceStarted}, services["worker"].DependsOn)
+	assert.NoError(t, CheckServiceDependencies(services))
+}
+
+func TestCheckServiceDependencies(t *testing.T) {
+	for message, services := range map[string]map[string]*ContainerSpec{
+		"service a depends on service b, which doesn't exist": {
+			"a": {DependsOn: ServiceDependencies{"b": ServiceStarted}},
+		},
+		"service a waits for service b to complete, which isn't one-shot": {
+			"a": {DependsOn: ServiceDependencies{"b": ServiceCompletedSuccessfully}},
+			"b": {},
+		},
+		"service a depends on service b with the unknown condition 'service_ready'": {
+			"a": {DependsOn: ServiceDependencies{"b": "service_ready"}},
+			"b": {},
+		},
+		"the services depend on each other: a -> b -> c -> a": {
+			"a": {DependsOn: ServiceDependencies{"b": ServiceStarted}},
+			"b": {DependsOn: ServiceDependencies{"c": ServiceHealthy}},
+			"c": {DependsOn: ServiceDependencies{"a": ServiceStarted}},
+		},
+	} {
+		assert.EqualError(t, CheckServiceDependencies(services), message)
+	}
+}
+
+func TestComposeSpecMapping(t *testing.T) {
+	spec := &ComposeSpec{}
+	assert.NoError(t, yaml.Unmarshal([]byte("file: compose.yml\nservices: [api]\n"), spec))
+	assert.Equal(t, &ComposeSpec{File: "compose.yml", Services: []string{"api"}}, spec)
+}
+
+const testCompose = `
+services:
+  db:
+    image: postgres:16
+    environment:
+      POSTGRES_PASSWORD: secret
+      FROM_HOST:
+    volumes:
+      - pgdata:/var/lib/postgresql/data
+      - ./init:/docker-entrypoint-initdb.d
+    ports:
+      - 5432
+    healthcheck:
+      test: ["CMD", "pg_isready", "-U", "postgres"]
+      interval: 2s
+      retries: 10
+    networks:
+      default:
+        aliases: [postgres]
+  migrate:
+    image: migrate/migrate
+    command: -path /migrations -database "postgres://db/app" up
+    entrypoint: ["/bin/migrate"]
+    environment:
+      - MODE=up
+      - FROM_HOST
+    depends_on:
+      db:
+        condition: service_healthy
+  api:
+    image: api
+    user: "1000"
+    healthcheck:
+      test: curl -f http://localhost/health
+    depends_on:
+      migrate:
+        condition: service_completed_successfully
+  docs:
+    image: docs
+    networks: [default]
+volumes:
+  pgdata:
+  shared:
+    external: true
+`
+
+func TestReadCompose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "docker-compose.yml")
+	assert.NoError(t, os.WriteFile(path, []byte(testCompose), 0o600))
+
+	project, err := ReadCompose(path, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"pgdata"}, project.Volumes)
+	assert.Len(t, project.Services, 4)
+
+	db := project.Services["db"]
+	assert.Equal(t, "postgres:16", db.Image)
+	assert.Equal(t, map[string]string{"POSTGRES_PASSWORD": "secret"}, db.Env)
+	assert.Equal(t, []string{"pgdata:/var/lib/postgresql/data", filepath.Join(filepath.Dir(path), "init") + ":/docker-entrypoint-initdb.d"}, db.Volumes)
+	assert.Equal(t, []string{"5432"}, db.Ports)
+	assert.Equal(t, "--health-cmd 'pg_isready -U postgres' --health-interval 2s --health-retries 10", db.Options)
+	assert.Equal(t, []string{"postgres"}, db.Aliases)
+	assert.False(t, db.OneShot)
+
+	migrate := project.Services["migrate"]
+	assert.Equal(t, []string{"-path", "/migrations", "-database", "postgres://db/app", "up"}, migrate.Cmd)
+	assert.Equal(t, "/bin/migrate", migrate.Entrypoint)
+	assert.Equal(t, map[string]string{"MODE": "up"}, migrate.Env)
+	assert.Equal(t, ServiceDependencies{"db": ServiceHealthy}, migrate.DependsOn)
+	assert.True(t, migrate.OneShot)
+
+	assert.Equal(t, "--user 1000 --health-cmd 'curl -f http://localhost/health'", project.Services["api"].Options)
+	assert.Empty(t, project.Services["docs"].Aliases)
+
+	// the selected services with the services they depend on
+	project, err = ReadCompose(path, []string{"api"})
+	assert.NoError(t, err)
+	assert.Len(t, project.Services, 3)
+	assert.NotContains(t, project.Services, "docs")
+
+	_, err = ReadCompose(path, []string{"web"})
+	assert.ErrorContains(t, err, "service web doesn't exist")
+}
+
+func TestReadComposeErrors(t *testing.T) {
+	for content, message := range map[string]string{
+		"services:\n  api:\n    build: .\n":                                        "build isn't supported",
+		"services:\n  api:\n    environment: [A=1]\n":                              "image is required",
+		"services:\n  api:\n    image: api\n    healthcheck:\n      test: [RUN]\n": "test must start with NONE, CMD or CMD-SHELL",
+		"services:\n  api:\n    image: api\n    depends_on: [db]\n":                "service api depends on service db, which doesn't exist",
+	} {
+		path := filepath.Join(t.TempDir(), "docker-compose.yml")
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		_, err := ReadCompose(path, nil)
+		assert.ErrorContains(t, err, message)
+	}
+}
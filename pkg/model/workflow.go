@@ -4,6 +4,8 @@ import (
 	"crypto/sha256"
 	"fmt"
 	"io"
+	"path"
+	"path/filepath"
 	"reflect"
 	"regexp"
 	"strconv"
@@ -13,11 +15,12 @@ import (
 	"go.yaml.in/yaml/v4"
 
 	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/workflowpattern"
 )
 
 // Workflow is the structure of the files in .github/workflows
 type Workflow struct {
-	File           string
+	File           string            // the path of the workflow file, relative to the directory the workflows were loaded from
 	Name           string            `yaml:"name"`
 	RawOn          yaml.Node         `yaml:"on"`
 	Env            map[string]string `yaml:"env"`
@@ -25,6 +28,8 @@ type Workflow struct {
 	Defaults       Defaults          `yaml:"defaults"`
 	RawConcurrency *RawConcurrency   `yaml:"concurrency"`
 	RawPermissions yaml.Node         `yaml:"permissions"`
+
+	sharedBaseName bool // another workflow of the planner has a file with the same base name
 }
 
 // On events for the workflow
@@ -70,6 +75,45 @@ func (w *Workflow) OnEvent(event string) interface{} {
 	return nil
 }
 
+// MatchesPaths reports whether the paths and paths-ignore filters of the event let the changed files trigger the workflow,
+// a workflow without these filters always matches
+func (w *Workflow) MatchesPaths(event string, files []string) (bool, error) {
+	if w.RawOn.Kind != yaml.MappingNode {
+		return true, nil
+	}
+	var events map[string]yaml.Node
+	if !decodeNode(w.RawOn, &events) {
+		return true, nil
+	}
+	node, ok := events[event]
+	if !ok || node.Kind != yaml.MappingNode {
+		return true, nil
+	}
+	var filters struct {
+		Paths       []string `yaml:"paths"`
+		PathsIgnore []string `yaml:"paths-ignore"`
+	}
+	if err := node.Decode(&filters); err != nil {
+		return false, fmt.Errorf("invalid paths filter of event '%s': %w", event, err)
+	}
+
+	if len(filters.Paths) > 0 {
+		patterns, err := workflowpattern.CompilePatterns(filters.Paths...)
+		if err != nil {
+			return false, err
+		}
+		return !workflowpattern.Skip(patterns, files, &workflowpattern.EmptyTraceWriter{}), nil
+	}
+	if len(filters.PathsIgnore) > 0 {
+		patterns, err := workflowpattern.CompilePatterns(filters.PathsIgnore...)
+		if err != nil {
+			return false, err
+		}
+		return !workflowpattern.Filter(patterns, files, &workflowpattern.EmptyTraceWriter{}), nil
+	}
+	return true, nil
+}
+
 func (w *Workflow) OnSchedule() []string {
 	schedules := w.OnEvent("schedule")
 	if schedules == nil {
@@ -150,6 +194,11 @@ type WorkflowCallInput struct {
 	Type        string `yaml:"type"`
 }
 
+type WorkflowCallSecret struct {
+	Description string `yaml:"description"`
+	Required    bool   `yaml:"required"`
+}
+
 type WorkflowCallOutput struct {
 	Description string `yaml:"description"`
 	Value       string `yaml:"value"`
@@ -157,6 +206,7 @@ type WorkflowCallOutput struct {
 
 type WorkflowCall struct {
 	Inputs  map[string]WorkflowCallInput  `yaml:"inputs"`
+	Secrets map[string]WorkflowCallSecret `yaml:"secrets"`
 	Outputs map[string]WorkflowCallOutput `yaml:"outputs"`
 }
 
@@ -194,6 +244,7 @@ type Job struct {
 	Steps          []*Step                   `yaml:"steps"`
 	TimeoutMinutes string                    `yaml:"timeout-minutes"`
 	Services       map[string]*ContainerSpec `yaml:"services"`
+	Compose        *ComposeSpec              `yaml:"compose"` // the docker-compose.yml whose services are added to the services
 	Strategy       *Strategy                 `yaml:"strategy"`
 	RawContainer   yaml.Node                 `yaml:"container"`
 	Defaults       Defaults                  `yaml:"defaults"`
@@ -389,6 +440,41 @@ func (j *Job) Matrix() map[string][]interface{} {
 	return nil
 }
 
+var matrixNeedsPattern = regexp.MustCompile(`\$\{\{[^}]*\bneeds\s*[.\[]`)
+
+// MatrixReferencesNeeds returns true if the matrix contains expressions using the `needs` context,
+// such a matrix can only be expanded once the jobs it needs have finished
+func (j *Job) MatrixReferencesNeeds() bool {
+	if j.Strategy == nil {
+		return false
+	}
+	return nodeReferencesNeeds(&j.Strategy.RawMatrix)
+}
+
+func nodeReferencesNeeds(node *yaml.Node) bool {
+	if node.Kind == yaml.ScalarNode {
+		return matrixNeedsPattern.MatchString(node.Value)
+	}
+	for _, n := range node.Content {
+		if nodeReferencesNeeds(n) {
+			return true
+		}
+	}
+	return false
+}
+
+// matrixKeys returns the keys of the matrix in the order they are declared in the workflow
+func (j *Job) matrixKeys() []string {
+	if j.Strategy == nil || j.Strategy.RawMatrix.Kind != yaml.MappingNode {
+		return nil
+	}
+	keys := make([]string, 0, len(j.Strategy.RawMatrix.Content)/2)
+	for i := 0; i+1 < len(j.Strategy.RawMatrix.Content); i += 2 {
+		keys = append(keys, j.Strategy.RawMatrix.Content[i].Value)
+	}
+	return keys
+}
+
 // GetMatrixes returns the matrix cross product
 // It skips includes and hard fails excludes for non-existing keys
 //
@@ -451,7 +537,7 @@ func (j *Job) GetMatrixes() ([]map[string]interface{}, error) {
 			}
 			delete(m, "exclude")
 
-			matrixProduct := common.CartesianProduct(m)
+			matrixProduct := common.OrderedCartesianProduct(j.matrixKeys(), m)
 		MATRIX:
 			for _, matrix := range matrixProduct {
 				for _, exclude := range excludes {
@@ -579,6 +665,9 @@ type ContainerSpec struct {
 	Args        string
 	Name        string
 	Reuse       bool
+	Aliases     []string            `yaml:"aliases"`    // the network aliases of a service, besides its ID
+	DependsOn   ServiceDependencies `yaml:"depends-on"` // the services a service starts after
+	OneShot     bool                `yaml:"one-shot"`   // the service runs to completion before the job starts, e.g. a database migration
 
 	// Gitea specific
 	Cmd []string `yaml:"cmd"`
@@ -751,6 +840,19 @@ func (w *Workflow) GetJob(jobID string) *Job {
 	return nil
 }
 
+// matchesFile returns true if the workflow was read from the given file, its path relative to the workflows
+// directory or its base name if no other workflow has a file with the same base name
+func (w *Workflow) matchesFile(file string) bool {
+	if w.File == "" {
+		return false
+	}
+	file = strings.TrimPrefix(filepath.ToSlash(file), "./")
+	if file == w.File {
+		return true
+	}
+	return !w.sharedBaseName && !strings.Contains(file, "/") && path.Base(w.File) == file
+}
+
 // GetJobIDs will get all the job names in the workflow
 func (w *Workflow) GetJobIDs() []string {
 	ids := make([]string, 0)
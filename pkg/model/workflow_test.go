@@ -483,6 +483,91 @@ func TestReadWorkflow_Strategy(t *testing.T) {
 	assert.Equal(t, job.Strategy.FailFast, false)
 }
 
+func TestReadWorkflow_MatrixReferencesNeeds(t *testing.T) {
+	yaml := `
+name: dynamic-matrix
+on: push
+
+jobs:
+  static:
+    runs-on: ubuntu-latest
+    strategy:
+      matrix:
+        os: [ubuntu-latest]
+        name: [needs]
+    steps:
+    - run: echo
+  dynamic:
+    runs-on: ubuntu-latest
+    strategy:
+      matrix: ${{ fromJSON(needs.setup.outputs.matrix) }}
+    steps:
+    - run: echo
+  dynamic-value:
+    runs-on: ubuntu-latest
+    strategy:
+      matrix:
+        os: ${{ fromJSON(needs['setup'].outputs.os) }}
+    steps:
+    - run: echo
+  no-strategy:
+    runs-on: ubuntu-latest
+    steps:
+    - run: echo
+`
+
+	workflow, err := ReadWorkflow(strings.NewReader(yaml))
+	assert.NoError(t, err, "read workflow should succeed")
+
+	assert.False(t, workflow.GetJob("static").MatrixReferencesNeeds())
+	assert.True(t, workflow.GetJob("dynamic").MatrixReferencesNeeds())
+	assert.True(t, workflow.GetJob("dynamic-value").MatrixReferencesNeeds())
+	assert.False(t, workflow.GetJob("no-strategy").MatrixReferencesNeeds())
+}
+
+func TestReadWorkflow_MatchesPaths(t *testing.T) {
+	yaml := `
+name: paths
+on:
+  push:
+    paths:
+      - 'src/**'
+      - '!src/docs/**'
+  pull_request:
+    paths-ignore:
+      - '**.md'
+  workflow_dispatch:
+
+jobs:
+  test:
+    runs-on: ubuntu-latest
+    steps:
+    - run: echo
+`
+
+	workflow, err := ReadWorkflow(strings.NewReader(yaml))
+	assert.NoError(t, err, "read workflow should succeed")
+
+	for _, tt := range []struct {
+		event   string
+		files   []string
+		matches bool
+	}{
+		{"push", []string{"src/main.go"}, true},
+		{"push", []string{"src/docs/index.md"}, false},
+		{"push", []string{"README.md", "src/docs/index.md", "src/lib/lib.go"}, true},
+		{"push", []string{"README.md"}, false},
+		{"pull_request", []string{"README.md", "docs/index.md"}, false},
+		{"pull_request", []string{"README.md", "main.go"}, true},
+		{"workflow_dispatch", []string{"README.md"}, true},
+		{"release", []string{"README.md"}, true},
+	} {
+		matches, err := workflow.MatchesPaths(tt.event, tt.files)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.matches, matches, "%s %v", tt.event, tt.files)
+	}
+}
+
 func TestStep_ShellCommand(t *testing.T) {
 	tests := []struct {
 		shell string
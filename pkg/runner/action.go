@@ -287,7 +287,7 @@ func execAsDocker(ctx context.Context, step actionStep, actionName string, based
 			return err
 		}
 
-		correctArchExists, err := container.ImageExistsLocally(ctx, image, rc.Config.ContainerArchitecture)
+		correctArchExists, err := container.ImageExistsLocally(ctx, image, rc.containerArchitecture(ctx))
 		if err != nil {
 			return err
 		}
@@ -303,7 +303,7 @@ func execAsDocker(ctx context.Context, step actionStep, actionName string, based
 		}
 
 		if !correctArchExists || rc.Config.ForceRebuild {
-			logger.Debugf("image '%s' for architecture '%s' will be built from context '%s", image, rc.Config.ContainerArchitecture, contextDir)
+			logger.Debugf("image '%s' for architecture '%s' will be built from context '%s", image, rc.containerArchitecture(ctx), contextDir)
 			var buildContext io.ReadCloser
 			if localAction {
 				buildContext, err = rc.JobContainer.GetContainerArchive(ctx, contextDir+"/.")
@@ -324,10 +324,10 @@ func execAsDocker(ctx context.Context, step actionStep, actionName string, based
 				Dockerfile:   fileName,
 				ImageTag:     image,
 				BuildContext: buildContext,
-				Platform:     rc.Config.ContainerArchitecture,
+				Platform:     rc.containerArchitecture(ctx),
 			})
 		} else {
-			logger.Debugf("image '%s' for architecture '%s' already exists", image, rc.Config.ContainerArchitecture)
+			logger.Debugf("image '%s' for architecture '%s' already exists", image, rc.containerArchitecture(ctx))
 		}
 	}
 	eval := rc.NewStepExpressionEvaluator(ctx, step)
@@ -410,7 +410,7 @@ func newStepContainer(ctx context.Context, step step, image string, cmd []string
 
 	envList = append(envList, fmt.Sprintf("%s=%s", "RUNNER_TOOL_CACHE", "/opt/hostedtoolcache"))
 	envList = append(envList, fmt.Sprintf("%s=%s", "RUNNER_OS", "Linux"))
-	envList = append(envList, fmt.Sprintf("%s=%s", "RUNNER_ARCH", container.RunnerArch(ctx)))
+	envList = append(envList, fmt.Sprintf("%s=%s", "RUNNER_ARCH", rc.runnerArch(ctx)))
 	envList = append(envList, fmt.Sprintf("%s=%s", "RUNNER_TEMP", "/tmp"))
 
 	binds, mounts := rc.GetBindsAndMounts()
@@ -418,7 +418,7 @@ func newStepContainer(ctx context.Context, step step, image string, cmd []string
 	if rc.IsHostEnv(ctx) {
 		networkMode = "default"
 	}
-	stepContainer := container.NewContainer(&container.NewContainerInput{
+	stepContainer := rc.newContainer(&container.NewContainerInput{
 		Cmd:          cmd,
 		Entrypoint:   entrypoint,
 		WorkingDir:   rc.JobContainer.ToContainerPath(rc.Config.Workdir),
@@ -434,8 +434,9 @@ func newStepContainer(ctx context.Context, step step, image string, cmd []string
 		Stderr:       logWriter,
 		Privileged:   rc.Config.Privileged,
 		UsernsMode:   rc.Config.UsernsMode,
-		Platform:     rc.Config.ContainerArchitecture,
+		Platform:     rc.containerArchitecture(ctx),
 		Options:      rc.Config.ContainerOptions,
+		Resources:    rc.resourceLimits(ctx),
 		AutoRemove:   rc.Config.AutoRemove,
 		ValidVolumes: rc.Config.ValidVolumes,
 	})
@@ -60,8 +60,13 @@ func (rc *RunContext) commandHandler(ctx context.Context) common.LineHandler {
 			logger.Infof("%s", line)
 		case "warning":
 			logger.Infof("%s", line)
+			rc.emitAnnotation(ctx, command, kvPairs, arg)
 		case "error":
 			logger.Infof("%s", line)
+			rc.emitAnnotation(ctx, command, kvPairs, arg)
+		case "notice":
+			logger.Infof("%s", line)
+			rc.emitAnnotation(ctx, command, kvPairs, arg)
 		case "add-mask":
 			rc.AddMask(arg)
 			logger.Infof("%s", "***")
@@ -65,6 +65,11 @@ func (cm *containerMock) Exec(command []string, env map[string]string, user, wor
 	return args.Get(0).(func(context.Context) error)
 }
 
+func (cm *containerMock) Shell(env map[string]string, user, workdir string) common.Executor {
+	args := cm.Called(env, user, workdir)
+	return args.Get(0).(func(context.Context) error)
+}
+
 func (cm *containerMock) GetContainerArchive(ctx context.Context, srcPath string) (io.ReadCloser, error) {
 	args := cm.Called(ctx, srcPath)
 	err, hasErr := args.Get(1).(error)
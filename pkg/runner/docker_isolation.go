@@ -0,0 +1,307 @@
+package runner
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/container"
+	"github.com/nektos/act/pkg/model"
+)
+
+// DockerIsolationDind gives each run a Docker-in-Docker sidecar, which DOCKER_HOST of its jobs points to, instead of the host docker socket
+const DockerIsolationDind = "dind"
+
+const (
+	dindServiceID = "docker" // the host name of the sidecar on the network of each job
+	dindHost      = "tcp://docker:2375"
+	dindImage     = "docker:dind"
+	dindImagesDir = "/tmp/act-images" // where the images of the host are copied to before the sidecar loads them
+
+	// maxDindCacheVolumes is the number of numbered copies of the cache volume tried before giving up
+	maxDindCacheVolumes = 16
+)
+
+// dindCacheVolumes are the cache volumes claimed by the sidecars of the runs of this process
+var dindCacheVolumes = struct {
+	sync.Mutex
+	claimed map[string]bool
+}{claimed: map[string]bool{}}
+
+// dindSidecars are the sidecars of the runs of this process, by the config of the run, which the runners of its reusable workflows share
+var dindSidecars = struct {
+	sync.Mutex
+	byConfig map[*Config]*dindSidecar
+	count    int
+}{byConfig: map[*Config]*dindSidecar{}}
+
+// dindSidecar is the Docker-in-Docker sidecar of a run, the jobs of the run share it: it joins the network of each job
+// while the job runs, as docker
+type dindSidecar struct {
+	mu          sync.Mutex
+	name        string
+	network     string // the network of the sidecar between the jobs
+	cacheVolume string
+	container   container.ExecutionsEnvironment
+	started     bool
+	err         error           // the error of the start of the sidecar, the next jobs fail with it too
+	images      map[string]bool // the images of the host known to be in the sidecar
+}
+
+// dockerIsolated returns true if the containers of the job use a Docker-in-Docker sidecar instead of the host docker socket
+func (rc *RunContext) dockerIsolated() bool {
+	return rc.Config.DockerIsolation == DockerIsolationDind
+}
+
+// dindSidecar returns the sidecar of the run of the job, which is started by the first job joining it
+func (rc *RunContext) dindSidecar() *dindSidecar {
+	dindSidecars.Lock()
+	defer dindSidecars.Unlock()
+	if s, ok := dindSidecars.byConfig[rc.Config]; ok {
+		return s
+	}
+	dindSidecars.count++
+	name := createContainerName(rc.Config.ContainerNamePrefix, "dind", fmt.Sprintf("%d-%d", os.Getpid(), dindSidecars.count))
+	s := &dindSidecar{
+		name:    name,
+		network: name + "-network",
+		images:  map[string]bool{},
+	}
+	dindSidecars.byConfig[rc.Config] = s
+	return s
+}
+
+// claimDindCacheVolume returns the cache volume of the sidecar of the run: the first of the cache volume and its numbered
+// copies which no running container mounts, as a docker daemon can't share its data with another one
+func claimDindCacheVolume(ctx context.Context, base string) (string, error) {
+	if base == "" || base == "-" || common.Dryrun(ctx) {
+		return "", nil
+	}
+	dindCacheVolumes.Lock()
+	defer dindCacheVolumes.Unlock()
+	for i := 0; i < maxDindCacheVolumes; i++ {
+		volume := base
+		if i > 0 {
+			volume = fmt.Sprintf("%s-%d", base, i)
+		}
+		if dindCacheVolumes.claimed[volume] {
+			continue
+		}
+		inUse, err := container.VolumeInUse(ctx, volume)
+		if err != nil {
+			return "", fmt.Errorf("failed to find a free Docker-in-Docker cache volume: %w", err)
+		}
+		if !inUse {
+			dindCacheVolumes.claimed[volume] = true
+			return volume, nil
+		}
+	}
+	return "", fmt.Errorf("the Docker-in-Docker cache volume %s and its %d numbered copies are all in use", base, maxDindCacheVolumes-1)
+}
+
+// newDindSidecar creates the Docker-in-Docker sidecar of the run. Its /var/lib/docker is the cache volume, which keeps the images
+// pulled or built by the previous sidecars mounting it, the images of the host the jobs reference are loaded into it.
+// It mounts the working directory at the same path as the job containers, for the bind mounts of the workspace to work, read-only
+// unless the working directory is bind mounted in the job containers as the sidecar doesn't see the copies of the jobs
+func (rc *RunContext) newDindSidecar(s *dindSidecar) container.ExecutionsEnvironment {
+	ext := container.LinuxContainerEnvironmentExtensions{}
+	workdir := ext.ToContainerPath(rc.Config.Workdir)
+	bind := fmt.Sprintf("%s:%s", rc.Config.Workdir, workdir)
+	if !rc.Config.BindWorkdir {
+		bind += ":ro"
+	}
+	mounts := map[string]string{}
+	if s.cacheVolume != "" {
+		mounts[s.cacheVolume] = "/var/lib/docker"
+	}
+	image := rc.Config.DindImage
+	if image == "" {
+		image = dindImage
+	}
+	return rc.newContainer(&container.NewContainerInput{
+		Name:           s.name,
+		Image:          image,
+		Env:            []string{"DOCKER_TLS_CERTDIR="}, // listen on tcp://0.0.0.0:2375, only the networks of the jobs reach it
+		Mounts:         mounts,
+		Binds:          []string{bind},
+		Stdout:         io.Discard,
+		Stderr:         io.Discard,
+		Privileged:     true,
+		NetworkMode:    s.network,
+		NetworkAliases: []string{dindServiceID},
+		AutoRemove:     rc.Config.AutoRemove,
+		Options:        "--health-cmd 'docker info' --health-interval 1s --health-timeout 5s --health-retries 60",
+	})
+}
+
+// joinDindSidecar starts the sidecar of the run if it isn't yet, connects it to the network of the job and loads the images
+// of the host the job references which the sidecar lacks
+func (rc *RunContext) joinDindSidecar(network string, images []string) common.Executor {
+	return func(ctx context.Context) error {
+		s := rc.dindSidecar()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if !s.started {
+			s.started = true
+			s.err = rc.startDindSidecar(s)(ctx)
+		}
+		if s.err != nil {
+			return fmt.Errorf("failed to start the Docker-in-Docker sidecar of the run: %w", s.err)
+		}
+		if err := s.container.ConnectToNetwork(network)(ctx); err != nil {
+			return fmt.Errorf("failed to connect the Docker-in-Docker sidecar to network %s: %w", network, err)
+		}
+		if err := s.loadImages(ctx, images); err != nil {
+			common.Logger(ctx).Warnf("Failed to load the images of the host into the Docker-in-Docker sidecar, they are pulled again: %v", err)
+		}
+		return nil
+	}
+}
+
+func (rc *RunContext) startDindSidecar(s *dindSidecar) common.Executor {
+	return func(ctx context.Context) error {
+		var err error
+		if s.cacheVolume, err = claimDindCacheVolume(ctx, rc.Config.DindCacheVolume); err != nil {
+			return err
+		}
+		common.Logger(ctx).Infof("Starting the Docker-in-Docker sidecar %s of the run", s.name)
+		s.container = rc.newDindSidecar(s)
+		return common.NewPipelineExecutor(
+			container.NewDockerNetworkCreateExecutor(s.network),
+			s.container.Pull(false),
+			s.container.Create(nil, nil),
+			s.container.Start(false),
+			container.NewContainerHealthyExecutor(s.name),
+		)(ctx)
+	}
+}
+
+// leaveDindSidecar disconnects the sidecar of the run from the network of the job, for the network to be removed
+func (rc *RunContext) leaveDindSidecar(network string) common.Executor {
+	return func(ctx context.Context) error {
+		s := rc.dindSidecar()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.container == nil || s.err != nil {
+			return nil
+		}
+		return container.NewDockerNetworkDisconnectExecutor(network, s.name)(ctx)
+	}
+}
+
+// removeDindSidecar removes the sidecar of the run once all its jobs have finished, with its network, and releases its cache volume
+func removeDindSidecar(config *Config) common.Executor {
+	return func(ctx context.Context) error {
+		dindSidecars.Lock()
+		s, ok := dindSidecars.byConfig[config]
+		delete(dindSidecars.byConfig, config)
+		dindSidecars.Unlock()
+		if !ok || s.container == nil {
+			return nil
+		}
+		err := s.container.Remove().Then(container.NewDockerNetworkRemoveExecutor(s.network))(ctx)
+		if s.cacheVolume != "" {
+			dindCacheVolumes.Lock()
+			delete(dindCacheVolumes.claimed, s.cacheVolume)
+			dindCacheVolumes.Unlock()
+		}
+		return err
+	}
+}
+
+// dindImages returns the images the job references: those of its container, of its services and of its docker steps,
+// the images its steps run with docker are often the same
+func (rc *RunContext) dindImages(ctx context.Context, image string, services map[string]*model.ContainerSpec) []string {
+	images := []string{}
+	seen := map[string]bool{}
+	add := func(image string) {
+		if image != "" && !seen[image] {
+			seen[image] = true
+			images = append(images, image)
+		}
+	}
+	add(image)
+	for _, spec := range services {
+		add(rc.ExprEval.Interpolate(ctx, spec.Image))
+	}
+	for _, step := range rc.Run.Job().Steps {
+		if step != nil && step.Type() == model.StepTypeUsesDockerURL {
+			add(strings.TrimPrefix(step.Uses, "docker://"))
+		}
+	}
+	return images
+}
+
+// loadImages loads the images of the host into the sidecar unless it has them already, e.g. from its cache volume,
+// for the jobs not to pull again the layers the host has
+func (s *dindSidecar) loadImages(ctx context.Context, images []string) error {
+	missing := []string{}
+	for _, image := range images {
+		if s.images[image] {
+			continue
+		}
+		if exists, err := container.ImageExistsLocally(ctx, image, ""); err != nil || !exists {
+			continue
+		}
+		if err := s.container.Exec([]string{"docker", "image", "inspect", "--format", "{{.Id}}", image}, nil, "", "")(ctx); err == nil {
+			s.images[image] = true
+			continue
+		}
+		missing = append(missing, image)
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	common.Logger(ctx).Infof("Loading the images %s of the host into the Docker-in-Docker sidecar", strings.Join(missing, ", "))
+
+	archive, err := os.CreateTemp("", "act-dind-images")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		archive.Close()
+		os.Remove(archive.Name())
+	}()
+	if err := container.SaveImages(ctx, missing, archive); err != nil {
+		return err
+	}
+	info, err := archive.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err := archive.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	// the archive of the images is copied in a tar stream, as a file of the directory of the images
+	reader, writer := io.Pipe()
+	go func() {
+		tw := tar.NewWriter(writer)
+		err := tw.WriteHeader(&tar.Header{Name: "images.tar", Mode: 0o644, Size: info.Size()})
+		if err == nil {
+			_, err = io.Copy(tw, archive)
+		}
+		if err == nil {
+			err = tw.Close()
+		}
+		writer.CloseWithError(err)
+	}()
+	err = s.container.CopyTarStream(ctx, dindImagesDir, reader)
+	reader.Close()
+	if err != nil {
+		return err
+	}
+	file := path.Join(dindImagesDir, "images.tar")
+	if err := s.container.Exec([]string{"sh", "-c", `docker load -i "$0"; status=$?; rm -f "$0"; exit $status`, file}, nil, "", "")(ctx); err != nil {
+		return err
+	}
+	for _, image := range missing {
+		s.images[image] = true
+	}
+	return nil
+}
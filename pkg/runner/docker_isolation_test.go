@@ -0,0 +1,201 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/container"
+	"github.com/nektos/act/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDockerIsolationBindsAndMounts(t *testing.T) {
+	newRunContext := func(isolation string) *RunContext {
+		return &RunContext{
+			Name: "TestRCName",
+			Run: &model.Run{
+				Workflow: &model.Workflow{
+					Name: "TestWorkflowName",
+				},
+			},
+			Config: &Config{
+				Workdir:               "/mnt/linux",
+				ContainerDaemonSocket: "/var/run/docker.sock",
+				DockerIsolation:       isolation,
+			},
+		}
+	}
+
+	rc := newRunContext("")
+	binds, _ := rc.GetBindsAndMounts()
+	assert.Contains(t, binds, "/var/run/docker.sock:/var/run/docker.sock")
+	assert.Contains(t, rc.Config.ValidVolumes, "/var/run/docker.sock")
+	binds, _ = rc.GetServiceBindsAndMounts(nil)
+	assert.Contains(t, binds, "/var/run/docker.sock:/var/run/docker.sock")
+
+	rc = newRunContext(DockerIsolationDind)
+	binds, _ = rc.GetBindsAndMounts()
+	assert.NotContains(t, binds, "/var/run/docker.sock:/var/run/docker.sock")
+	assert.NotContains(t, rc.Config.ValidVolumes, "/var/run/docker.sock")
+	binds, _ = rc.GetServiceBindsAndMounts(nil)
+	assert.Empty(t, binds)
+}
+
+func TestDaemonSocket(t *testing.T) {
+	t.Setenv("CONTAINER_HOST", "unix:///run/user/1000/podman/podman.sock")
+	config := &Config{Workdir: "/mnt/linux"}
+	docker := &RunContext{Name: "docker", Run: &model.Run{Workflow: &model.Workflow{Name: "TestWorkflowName"}}, Config: config}
+	podman := &RunContext{Name: "podman", Run: &model.Run{Workflow: &model.Workflow{Name: "TestWorkflowName"}}, Config: &Config{Workdir: "/mnt/linux", ContainerEngine: container.EnginePodman}}
+
+	binds, _ := docker.GetBindsAndMounts()
+	assert.Contains(t, binds, "/var/run/docker.sock:/var/run/docker.sock")
+	binds, _ = podman.GetServiceBindsAndMounts(nil)
+	assert.Contains(t, binds, "/run/user/1000/podman/podman.sock:/var/run/docker.sock")
+
+	// the config shared by the jobs keeps the socket unset, to be resolved by each of them
+	assert.Empty(t, config.ContainerDaemonSocket)
+	assert.Empty(t, podman.Config.ContainerDaemonSocket)
+}
+
+func TestDockerIsolationNetwork(t *testing.T) {
+	rc := &RunContext{
+		Name:   "TestRCName",
+		Run:    &model.Run{JobID: "test", Workflow: &model.Workflow{Name: "TestWorkflowName"}},
+		Config: &Config{ContainerNetworkMode: "host"},
+	}
+	network, create := rc.networkNameForGitea()
+	assert.Equal(t, "host", network)
+	assert.False(t, create)
+
+	rc.Config.DockerIsolation = DockerIsolationDind
+	network, create = rc.networkNameForGitea()
+	assert.Equal(t, rc.jobContainerName()+"-test-network", network)
+	assert.True(t, create)
+}
+
+func TestDindSidecar(t *testing.T) {
+	var input *container.NewContainerInput
+	origContainerNewContainer := ContainerNewContainer
+	ContainerNewContainer = func(containerInput *container.NewContainerInput) container.ExecutionsEnvironment {
+		input = containerInput
+		return &containerMock{}
+	}
+	defer (func() {
+		ContainerNewContainer = origContainerNewContainer
+	})()
+
+	rc := &RunContext{
+		Name: "TestRCName",
+		Run: &model.Run{
+			Workflow: &model.Workflow{
+				Name: "TestWorkflowName",
+			},
+		},
+		Config: &Config{
+			Workdir:         "/mnt/linux",
+			DockerIsolation: DockerIsolationDind,
+			DindCacheVolume: "act-dind-cache",
+		},
+	}
+	s := &dindSidecar{name: "act-dind", network: "act-dind-network", cacheVolume: "act-dind-cache-1"}
+	rc.newDindSidecar(s)
+	assert.Equal(t, dindImage, input.Image)
+	assert.True(t, input.Privileged)
+	assert.Equal(t, "act-dind-network", input.NetworkMode)
+	assert.Equal(t, []string{dindServiceID}, input.NetworkAliases)
+	// the copies of the workspace of the jobs are in their volumes, the sidecar sees the working directory read-only
+	assert.Equal(t, []string{"/mnt/linux:/mnt/linux:ro"}, input.Binds)
+	assert.Equal(t, map[string]string{"act-dind-cache-1": "/var/lib/docker"}, input.Mounts)
+	assert.Contains(t, input.Options, "--health-cmd 'docker info'")
+
+	rc.Config.BindWorkdir = true
+	rc.Config.DindImage = "registry.local/docker:dind"
+	rc.newDindSidecar(s)
+	assert.Equal(t, "registry.local/docker:dind", input.Image)
+	assert.Equal(t, []string{"/mnt/linux:/mnt/linux"}, input.Binds)
+}
+
+func TestDindSidecarPerRun(t *testing.T) {
+	config := &Config{DockerIsolation: DockerIsolationDind}
+	workflow := &model.Workflow{Name: "TestWorkflowName"}
+	build := &RunContext{Name: "build", Run: &model.Run{Workflow: workflow}, Config: config}
+	test := &RunContext{Name: "test", Run: &model.Run{Workflow: workflow}, Config: config}
+	other := &RunContext{Name: "build", Run: &model.Run{Workflow: workflow}, Config: &Config{DockerIsolation: DockerIsolationDind}}
+
+	// the jobs of a run share its sidecar
+	s := build.dindSidecar()
+	assert.Same(t, s, test.dindSidecar())
+	assert.NotSame(t, s, other.dindSidecar())
+	assert.NotEqual(t, s.name, other.dindSidecar().name)
+	assert.Equal(t, s.name+"-network", s.network)
+
+	// the sidecar is forgotten once the run has finished
+	assert.NoError(t, removeDindSidecar(config)(context.Background()))
+	assert.NotSame(t, s, build.dindSidecar())
+	assert.NoError(t, removeDindSidecar(config)(context.Background()))
+	assert.NoError(t, removeDindSidecar(other.Config)(context.Background()))
+}
+
+func TestDindImages(t *testing.T) {
+	workflow, err := model.ReadWorkflow(strings.NewReader(`
+on: push
+jobs:
+  test:
+    runs-on: ubuntu-latest
+    services:
+      db:
+        image: postgres:${{ matrix.postgres }}
+      cache:
+        image: node:20
+    steps:
+      - uses: docker://alpine:3.20
+      - run: docker run --rm alpine:3.20 true
+`))
+	assert.NoError(t, err)
+	rc := &RunContext{
+		Config: &Config{},
+		Run:    &model.Run{Workflow: workflow, JobID: "test"},
+		Matrix: map[string]interface{}{"postgres": "16"},
+	}
+	ctx := context.Background()
+	rc.ExprEval = rc.NewExpressionEvaluator(ctx)
+	services, _, err := rc.jobServices(ctx)
+	assert.NoError(t, err)
+	images := rc.dindImages(ctx, "node:20", services)
+	assert.ElementsMatch(t, []string{"node:20", "postgres:16", "alpine:3.20"}, images)
+}
+
+func TestClaimDindCacheVolume(t *testing.T) {
+	volume, err := claimDindCacheVolume(context.Background(), "-")
+	assert.NoError(t, err)
+	assert.Empty(t, volume)
+
+	volume, err = claimDindCacheVolume(common.WithDryrun(context.Background(), true), "act-dind-cache")
+	assert.NoError(t, err)
+	assert.Empty(t, volume)
+
+	// the numbered copies are bounded
+	dindCacheVolumes.Lock()
+	for i := 0; i < maxDindCacheVolumes; i++ {
+		volume := "act-dind-full"
+		if i > 0 {
+			volume = fmt.Sprintf("%s-%d", volume, i)
+		}
+		dindCacheVolumes.claimed[volume] = true
+	}
+	dindCacheVolumes.Unlock()
+	defer func() {
+		dindCacheVolumes.Lock()
+		defer dindCacheVolumes.Unlock()
+		for volume := range dindCacheVolumes.claimed {
+			if strings.HasPrefix(volume, "act-dind-full") {
+				delete(dindCacheVolumes.claimed, volume)
+			}
+		}
+	}()
+	_, err = claimDindCacheVolume(context.Background(), "act-dind-full")
+	assert.ErrorContains(t, err, "are all in use")
+}
@@ -0,0 +1,217 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/nektos/act/pkg/model"
+)
+
+// EventsVersion is the version of the event protocol, it is increased when events change incompatibly
+const EventsVersion = 1
+
+// EventType is the type of an event of the run
+type EventType string
+
+const (
+	EventRunStarted       EventType = "run.started"
+	EventRunFinished      EventType = "run.finished"
+	EventJobQueued        EventType = "job.queued"
+	EventJobStarted       EventType = "job.started"
+	EventJobFinished      EventType = "job.finished"
+	EventStepStarted      EventType = "step.started"
+	EventStepFinished     EventType = "step.finished"
+	EventAnnotation       EventType = "annotation"
+	EventArtifactUploaded EventType = "artifact.uploaded"
+	EventCacheHit         EventType = "cache.hit"
+	EventCacheMiss        EventType = "cache.miss"
+)
+
+// Event is an event of the run, written as a line of JSON to the event stream
+type Event struct {
+	Version    int                    `json:"version"`
+	Type       EventType              `json:"type"`
+	Time       time.Time              `json:"time"`
+	RunID      string                 `json:"run_id,omitempty"`
+	Workflow   string                 `json:"workflow,omitempty"` // the workflow file
+	JobID      string                 `json:"job_id,omitempty"`   // the qualified job ID
+	Job        string                 `json:"job,omitempty"`      // the name of the job, with the values of its matrix combination
+	Matrix     map[string]interface{} `json:"matrix,omitempty"`
+	StepID     string                 `json:"step_id,omitempty"`
+	Step       string                 `json:"step,omitempty"`
+	Stage      string                 `json:"stage,omitempty"`
+	Result     string                 `json:"result,omitempty"`  // the conclusion of a step
+	Outcome    string                 `json:"outcome,omitempty"` // the outcome of a step, before continue-on-error
+	Outputs    map[string]string      `json:"outputs,omitempty"`
+	Error      string                 `json:"error,omitempty"`
+	Plan       [][]string             `json:"plan,omitempty"` // the qualified job IDs of each stage of a started run
+	Annotation *AnnotationEvent       `json:"annotation,omitempty"`
+	Artifact   *ArtifactEvent         `json:"artifact,omitempty"`
+	Cache      *CacheEvent            `json:"cache,omitempty"`
+}
+
+// AnnotationEvent is an error, warning or notice workflow command
+type AnnotationEvent struct {
+	Level     string `json:"level"`
+	Message   string `json:"message"`
+	Title     string `json:"title,omitempty"`
+	File      string `json:"file,omitempty"`
+	Line      string `json:"line,omitempty"`
+	EndLine   string `json:"end_line,omitempty"`
+	Column    string `json:"column,omitempty"`
+	EndColumn string `json:"end_column,omitempty"`
+}
+
+// ArtifactEvent is an artifact uploaded to the artifact server
+type ArtifactEvent struct {
+	Name  string `json:"name"`
+	RunID string `json:"run_id"` // the run ID of the artifact server
+}
+
+// CacheEvent is a lookup of the cache server
+type CacheEvent struct {
+	Keys    []string `json:"keys"`
+	Version string   `json:"version"`
+	Key     string   `json:"key,omitempty"` // the key of the cache that was hit
+}
+
+// EventWriter writes the events of the run as NDJSON, it is safe for concurrent use
+type EventWriter struct {
+	w   io.Writer
+	mu  sync.Mutex
+	err error
+}
+
+// NewEventWriter creates an EventWriter writing to w
+func NewEventWriter(w io.Writer) *EventWriter {
+	return &EventWriter{w: w}
+}
+
+// Emit writes the event, with the version of the protocol and the current time, once the reader has gone the events are dropped
+func (ew *EventWriter) Emit(event Event) {
+	if ew == nil {
+		return
+	}
+	event.Version = EventsVersion
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	line, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	ew.mu.Lock()
+	defer ew.mu.Unlock()
+	if ew.err != nil {
+		return
+	}
+	_, ew.err = ew.w.Write(append(line, '\n'))
+}
+
+// Err returns the error that stopped writing the events
+func (ew *EventWriter) Err() error {
+	ew.mu.Lock()
+	defer ew.mu.Unlock()
+	return ew.err
+}
+
+// planStages returns the qualified job IDs of each stage of the plan
+func planStages(plan *model.Plan) [][]string {
+	stages := make([][]string, 0, len(plan.Stages))
+	for _, stage := range plan.Stages {
+		ids := make([]string, 0, len(stage.Runs))
+		for _, run := range stage.Runs {
+			ids = append(ids, run.QualifiedJobID())
+		}
+		stages = append(stages, ids)
+	}
+	return stages
+}
+
+// runID returns the ID of the recorded run, if any
+func (c *Config) runID() string {
+	if c.RunState == nil {
+		return ""
+	}
+	return c.RunState.ID
+}
+
+// emitJobEvent emits an event of the job
+func (rc *RunContext) emitJobEvent(ctx context.Context, event Event) {
+	if rc.Config.Events == nil || rc.Run == nil {
+		return
+	}
+	event.RunID = rc.Config.runID()
+	if rc.Run.Workflow != nil {
+		event.Workflow = rc.Run.Workflow.File
+	}
+	event.JobID = rc.Run.QualifiedJobID()
+	event.Job = rc.nameWithMatrix()
+	event.Matrix = rc.Matrix
+	if event.Step != "" {
+		event.Step = maskValue(ctx, rc.Config, event.Step)
+	}
+	if event.Error != "" {
+		event.Error = maskValue(ctx, rc.Config, event.Error)
+	}
+	if len(event.Outputs) > 0 {
+		outputs := make(map[string]string, len(event.Outputs))
+		for k, v := range event.Outputs {
+			outputs[k] = maskValue(ctx, rc.Config, v)
+		}
+		event.Outputs = outputs
+	}
+	rc.Config.Events.Emit(event)
+}
+
+// emitStepEvent emits an event of a step of the job, the steps of composite actions are part of their step
+func (rc *RunContext) emitStepEvent(ctx context.Context, eventType EventType, stepModel *model.Step, stage stepStage, stepResult *model.StepResult, err error) {
+	if rc.Config.Events == nil || rc.Parent != nil {
+		return
+	}
+	event := Event{
+		Type:   eventType,
+		StepID: stepModel.ID,
+		Step:   rc.ExprEval.Interpolate(ctx, stepModel.String()),
+		Stage:  stage.String(),
+	}
+	if stepResult != nil {
+		event.Result = stepResult.Conclusion.String()
+		event.Outcome = stepResult.Outcome.String()
+		if stage == stepStageMain {
+			event.Outputs = stepResult.Outputs
+		}
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	rc.emitJobEvent(ctx, event)
+}
+
+// emitAnnotation emits an error, warning or notice workflow command of the current step
+func (rc *RunContext) emitAnnotation(ctx context.Context, level string, kvPairs map[string]string, message string) {
+	if rc.Config.Events == nil {
+		return
+	}
+	for rc.Parent != nil {
+		// the commands of composite actions are annotations of their step
+		rc = rc.Parent
+	}
+	rc.emitJobEvent(ctx, Event{
+		Type:   EventAnnotation,
+		StepID: rc.CurrentStep,
+		Annotation: &AnnotationEvent{
+			Level:     level,
+			Message:   maskValue(ctx, rc.Config, message),
+			Title:     maskValue(ctx, rc.Config, kvPairs["title"]),
+			File:      kvPairs["file"],
+			Line:      kvPairs["line"],
+			EndLine:   kvPairs["endLine"],
+			Column:    kvPairs["col"],
+			EndColumn: kvPairs["endColumn"],
+		},
+	})
+}
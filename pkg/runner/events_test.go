@@ -0,0 +1,129 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/nektos/act/pkg/model"
+)
+
+type failingWriter struct {
+	writes int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return 0, errors.New("broken pipe")
+}
+
+func readEvents(t *testing.T, buf *bytes.Buffer) []Event {
+	events := []Event{}
+	decoder := json.NewDecoder(buf)
+	for {
+		event := Event{}
+		if err := decoder.Decode(&event); errors.Is(err, io.EOF) {
+			return events
+		} else if !assert.NoError(t, err) {
+			return events
+		}
+		events = append(events, event)
+	}
+}
+
+func TestEventWriter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	ew := NewEventWriter(buf)
+	ew.Emit(Event{Type: EventRunStarted, RunID: "1", Plan: [][]string{{"ci.yml:build"}}})
+	ew.Emit(Event{Type: EventRunFinished, RunID: "1", Result: "success"})
+
+	events := readEvents(t, buf)
+	assert.Len(t, events, 2)
+	assert.Equal(t, EventsVersion, events[0].Version)
+	assert.Equal(t, EventRunStarted, events[0].Type)
+	assert.False(t, events[0].Time.IsZero())
+	assert.Equal(t, [][]string{{"ci.yml:build"}}, events[0].Plan)
+	assert.Equal(t, "success", events[1].Result)
+
+	fw := &failingWriter{}
+	ew = NewEventWriter(fw)
+	ew.Emit(Event{Type: EventRunStarted})
+	ew.Emit(Event{Type: EventRunFinished})
+	assert.Equal(t, 1, fw.writes)
+	assert.Error(t, ew.Err())
+
+	var nilWriter *EventWriter
+	nilWriter.Emit(Event{Type: EventRunStarted})
+}
+
+func TestStepEvents(t *testing.T) {
+	buf := &bytes.Buffer{}
+	cm := &containerMock{}
+	rc := &RunContext{
+		Name:        "build",
+		JobName:     "build",
+		StepResults: map[string]*model.StepResult{},
+		ExprEval:    &expressionEvaluator{},
+		Config: &Config{
+			Events:  NewEventWriter(buf),
+			Secrets: map[string]string{"TOKEN": "s3cr3t"},
+		},
+		Run: &model.Run{
+			JobID: "build",
+			Workflow: &model.Workflow{
+				File: "ci.yml",
+				Jobs: map[string]*model.Job{
+					"build": {},
+				},
+			},
+		},
+		JobContainer: cm,
+	}
+	sr := &stepRun{
+		RunContext: rc,
+		Step: &model.Step{
+			ID:    "compile",
+			Run:   "make TOKEN=s3cr3t",
+			Shell: "bash",
+		},
+	}
+
+	cm.On("Copy", "/var/run/act", mock.AnythingOfType("[]*container.FileEntry")).Return(func(_ context.Context) error {
+		return nil
+	})
+	cm.On("Exec", mock.AnythingOfType("[]string"), mock.AnythingOfType("map[string]string"), "", "").Return(func(ctx context.Context) error {
+		rc.commandHandler(ctx)("::error file=main.go,line=3,title=Build::failed with s3cr3t\n")
+		return errors.New("exit status 2")
+	})
+	cm.On("UpdateFromEnv", mock.AnythingOfType("string"), mock.AnythingOfType("*map[string]string")).Return(func(_ context.Context) error {
+		return nil
+	})
+	cm.On("GetContainerArchive", mock.Anything, "/var/run/act/workflow/pathcmd.txt").Return(io.NopCloser(&bytes.Buffer{}), nil)
+
+	err := sr.main()(context.Background())
+	assert.EqualError(t, err, "exit status 2")
+
+	events := readEvents(t, buf)
+	assert.Len(t, events, 3)
+	for _, event := range events {
+		assert.Equal(t, "ci.yml:build", event.JobID)
+		assert.Equal(t, "build", event.Job)
+		assert.Equal(t, "ci.yml", event.Workflow)
+		assert.Equal(t, "compile", event.StepID)
+	}
+	assert.Equal(t, EventStepStarted, events[0].Type)
+	assert.Equal(t, "make TOKEN=***", events[0].Step)
+	assert.Equal(t, "Main", events[0].Stage)
+	assert.Equal(t, EventAnnotation, events[1].Type)
+	assert.Equal(t, &AnnotationEvent{Level: "error", Message: "failed with ***", Title: "Build", File: "main.go", Line: "3"}, events[1].Annotation)
+	assert.Equal(t, EventStepFinished, events[2].Type)
+	assert.Equal(t, "failure", events[2].Result)
+	assert.Equal(t, "failure", events[2].Outcome)
+	assert.Equal(t, "exit status 2", events[2].Error)
+}
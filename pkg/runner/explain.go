@@ -0,0 +1,395 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/common/git"
+	"github.com/nektos/act/pkg/exprparser"
+	"github.com/nektos/act/pkg/model"
+)
+
+// explainRefTimeout is how long resolving the ref of a remote action may take
+const explainRefTimeout = 30 * time.Second
+
+const (
+	ConditionTrue    = "true"
+	ConditionFalse   = "false"
+	ConditionRuntime = "runtime" // the condition depends on the results, outputs or environment of the run
+)
+
+var (
+	// runtimeContextRegex matches the expressions using contexts which are only known once the jobs run
+	runtimeContextRegex = regexp.MustCompile(`\b(steps|needs|job|jobs|env|runner)\.`)
+	// stepStatusRegex matches the expressions of steps depending on the status of the previous steps or on the files of the workspace
+	stepStatusRegex   = regexp.MustCompile(`\b(success|failure|always|cancelled|hashFiles)\s*\(`)
+	secretNameRegexes = []*regexp.Regexp{
+		regexp.MustCompile(`\bsecrets\.([A-Za-z_][A-Za-z0-9_-]*)`),
+		regexp.MustCompile(`\bsecrets\[\s*['"]([^'"]+)['"]\s*\]`),
+	}
+)
+
+// PlanExplanation describes what the jobs of a plan would do, without running them
+type PlanExplanation struct {
+	Event string            `json:"event"`
+	Jobs  []*JobExplanation `json:"jobs"`
+}
+
+// JobExplanation describes a job or a combination of its matrix
+type JobExplanation struct {
+	Stage            int                    `json:"stage"`
+	Workflow         string                 `json:"workflow"`
+	JobID            string                 `json:"job_id"`
+	Name             string                 `json:"name"`
+	Matrix           map[string]interface{} `json:"matrix,omitempty"`
+	Needs            []string               `json:"needs,omitempty"`
+	If               *ConditionExplanation  `json:"if,omitempty"`
+	RunsOn           []string               `json:"runs_on,omitempty"`
+	Platform         string                 `json:"platform,omitempty"` // the runner of the platform registry matching runs-on
+	Image            string                 `json:"image,omitempty"`
+	Architecture     string                 `json:"architecture,omitempty"` // the platform of the containers, e.g. linux/arm64
+	Host             bool                   `json:"host,omitempty"`         // the job runs on the host instead of a container
+	ContainerOptions string                 `json:"container_options,omitempty"`
+	ResourceLimits   string                 `json:"resource_limits,omitempty"` // the limits of the runner profile of the job, as docker run options
+	Services         []*ServiceExplanation  `json:"services,omitempty"`
+	Uses             string                 `json:"uses,omitempty"` // the reusable workflow called by the job
+	Steps            []*StepExplanation     `json:"steps,omitempty"`
+	Error            string                 `json:"error,omitempty"`
+}
+
+// ConditionExplanation is an if condition and its result, when it can be decided before the run
+type ConditionExplanation struct {
+	Expression string `json:"expression"`
+	Result     string `json:"result"` // one of ConditionTrue, ConditionFalse or ConditionRuntime
+	Error      string `json:"error,omitempty"`
+}
+
+// ServiceExplanation describes a service container of a job
+type ServiceExplanation struct {
+	Name      string            `json:"name"`
+	Image     string            `json:"image"`
+	Ports     []string          `json:"ports,omitempty"`
+	Options   string            `json:"options,omitempty"`
+	DependsOn map[string]string `json:"depends_on,omitempty"` // the services it starts after, with their condition
+	OneShot   bool              `json:"one_shot,omitempty"`
+}
+
+// StepExplanation describes a step of a job
+type StepExplanation struct {
+	ID      string                `json:"id"`
+	Name    string                `json:"name"`
+	If      *ConditionExplanation `json:"if,omitempty"`
+	Uses    string                `json:"uses,omitempty"`
+	SHA     string                `json:"sha,omitempty"` // the commit the ref of a remote action resolves to
+	Env     []string              `json:"env,omitempty"`
+	Secrets []string              `json:"secrets,omitempty"`
+}
+
+// ExplainPlan explains, for every job of the plan and every selected combination of its matrix, the image it runs on,
+// its services, the conditions which can be decided before the run and the env and secrets its steps receive.
+// With resolveRefs, the refs of the remote actions are resolved to the SHAs of their commits.
+func ExplainPlan(ctx context.Context, runnerConfig *Config, plan *model.Plan, resolveRefs bool) (*PlanExplanation, error) {
+	r, err := New(runnerConfig)
+	if err != nil {
+		return nil, err
+	}
+	runner := r.(*runnerImpl)
+
+	explanation := &PlanExplanation{Event: runnerConfig.EventName, Jobs: []*JobExplanation{}}
+	shas := map[string]string{}
+	for i, stage := range plan.Stages {
+		for _, run := range stage.Runs {
+			matrixes, err := runner.expandMatrix(ctx, run)
+			if err != nil {
+				rc := runner.newRunContext(ctx, run, nil)
+				explanation.Jobs = append(explanation.Jobs, &JobExplanation{
+					Stage:    i + 1,
+					Workflow: run.Workflow.File,
+					JobID:    run.JobID,
+					Name:     rc.Name,
+					Error:    err.Error(),
+				})
+				continue
+			}
+			for _, m := range selectMatrixes(matrixes, runnerConfig.Matrix, runnerConfig.MatrixIndexes) {
+				rc := runner.newRunContext(ctx, run, matrixes[m])
+				rc.JobName = rc.Name
+				job := rc.explainJob(ctx, resolveRefs, shas)
+				job.Stage = i + 1
+				explanation.Jobs = append(explanation.Jobs, job)
+			}
+		}
+	}
+	return explanation, nil
+}
+
+func (rc *RunContext) explainJob(ctx context.Context, resolveRefs bool, shas map[string]string) *JobExplanation {
+	job := rc.Run.Job()
+	explanation := &JobExplanation{
+		Workflow: rc.Run.Workflow.File,
+		JobID:    rc.Run.JobID,
+		Name:     maskValue(ctx, rc.Config, rc.nameWithMatrix()),
+		Matrix:   rc.Matrix,
+		Needs:    job.Needs(),
+		Uses:     job.Uses,
+	}
+	if job.If.Value != "" {
+		// the status functions of a job depend on the results of the jobs it needs
+		explanation.If = rc.explainCondition(ctx, job.If.Value, len(job.Needs()) > 0)
+	}
+	if job.Uses != "" {
+		return explanation
+	}
+
+	explanation.RunsOn = rc.runsOnPlatformNames(ctx)
+	if platform := rc.Config.PlatformRegistry.Match(explanation.RunsOn); platform != nil {
+		explanation.Platform = platform.Name
+	}
+	explanation.Host = rc.IsHostEnv(ctx)
+	if !explanation.Host {
+		explanation.Image = maskValue(ctx, rc.Config, rc.platformImage(ctx))
+		explanation.Architecture = rc.containerArchitecture(ctx)
+		explanation.ContainerOptions = maskValue(ctx, rc.Config, strings.TrimSpace(rc.options(ctx)))
+		if limits := rc.resourceLimits(ctx); limits != nil {
+			explanation.ResourceLimits = limits.String()
+		}
+	}
+
+	services, _, err := rc.jobServices(ctx)
+	if err != nil {
+		common.Logger(ctx).Warnf("%v", err)
+		services = job.Services
+	}
+	serviceNames := make([]string, 0, len(services))
+	for name := range services {
+		serviceNames = append(serviceNames, name)
+	}
+	sort.Strings(serviceNames)
+	for _, name := range serviceNames {
+		spec := services[name]
+		service := &ServiceExplanation{
+			Name:      name,
+			Image:     maskValue(ctx, rc.Config, rc.ExprEval.Interpolate(ctx, spec.Image)),
+			Options:   maskValue(ctx, rc.Config, rc.ExprEval.Interpolate(ctx, spec.Options)),
+			DependsOn: spec.DependsOn,
+			OneShot:   spec.OneShot,
+		}
+		for _, port := range spec.Ports {
+			service.Ports = append(service.Ports, maskValue(ctx, rc.Config, rc.ExprEval.Interpolate(ctx, port)))
+		}
+		explanation.Services = append(explanation.Services, service)
+	}
+
+	jobEnv := mergeMaps(rc.Run.Workflow.Env, job.Environment())
+	for i, step := range job.Steps {
+		if step == nil {
+			continue
+		}
+		stepExplanation := rc.explainStep(ctx, step, jobEnv, resolveRefs, shas)
+		if stepExplanation.ID == "" {
+			// the same default as the job executor
+			stepExplanation.ID = fmt.Sprintf("%d", i)
+		}
+		explanation.Steps = append(explanation.Steps, stepExplanation)
+	}
+	return explanation
+}
+
+func (rc *RunContext) explainStep(ctx context.Context, step *model.Step, jobEnv map[string]string, resolveRefs bool, shas map[string]string) *StepExplanation {
+	explanation := &StepExplanation{
+		ID:   step.ID,
+		Name: step.String(),
+		Uses: step.Uses,
+	}
+	if step.Name != "" {
+		explanation.Name = maskValue(ctx, rc.Config, rc.ExprEval.Interpolate(ctx, step.Name))
+	}
+	if step.If.Value != "" {
+		explanation.If = rc.explainCondition(ctx, step.If.Value, stepStatusRegex.MatchString(step.If.Value))
+	}
+
+	env := mergeMaps(jobEnv, step.GetEnv())
+	for name := range env {
+		explanation.Env = append(explanation.Env, name)
+	}
+	sort.Strings(explanation.Env)
+
+	values := []string{step.Run, step.If.Value}
+	for _, m := range []map[string]string{env, step.With} {
+		for _, value := range m {
+			values = append(values, value)
+		}
+	}
+	explanation.Secrets = secretNames(values...)
+
+	if stepType := step.Type(); stepType == model.StepTypeUsesActionRemote && resolveRefs {
+		if action := newRemoteAction(step.Uses); action != nil {
+			instance := rc.Config.DefaultActionInstance
+			if instance == "" {
+				instance = rc.Config.GitHubInstance
+			}
+			url := action.CloneURL(instance)
+			key := url + "@" + action.Ref
+			sha, ok := shas[key]
+			if !ok {
+				refCtx, cancel := context.WithTimeout(ctx, explainRefTimeout)
+				var err error
+				sha, err = git.ResolveRemoteRef(refCtx, url, action.Ref, getGitCloneToken(rc.Config, url))
+				cancel()
+				if err != nil {
+					common.Logger(ctx).Warnf("Unable to resolve %s: %v", step.Uses, err)
+				}
+				shas[key] = sha
+			}
+			explanation.SHA = sha
+		}
+	}
+	return explanation
+}
+
+// explainCondition evaluates an if condition when its result doesn't depend on the run
+func (rc *RunContext) explainCondition(ctx context.Context, expression string, runtime bool) *ConditionExplanation {
+	condition := &ConditionExplanation{Expression: expression, Result: ConditionRuntime}
+	if runtime || runtimeContextRegex.MatchString(expression) {
+		return condition
+	}
+	result, err := EvalBool(ctx, rc.ExprEval, expression, exprparser.DefaultStatusCheckSuccess)
+	if err != nil {
+		condition.Error = err.Error()
+		return condition
+	}
+	condition.Result = fmt.Sprintf("%t", result)
+	return condition
+}
+
+// secretNames returns the sorted names of the secrets referenced by the values
+func secretNames(values ...string) []string {
+	names := map[string]bool{}
+	for _, value := range values {
+		for _, re := range secretNameRegexes {
+			for _, match := range re.FindAllStringSubmatch(value, -1) {
+				names[strings.ToUpper(match[1])] = true
+			}
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// WriteJSON writes the explanation as JSON
+func (e *PlanExplanation) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(e)
+}
+
+// WriteText writes the explanation in a human readable form
+func (e *PlanExplanation) WriteText(w io.Writer) error {
+	b := &strings.Builder{}
+	stage := 0
+	for _, job := range e.Jobs {
+		if job.Stage != stage {
+			stage = job.Stage
+			fmt.Fprintf(b, "Stage %d\n", stage)
+		}
+		fmt.Fprintf(b, "  Job %s (%s, %s)\n", job.Name, job.JobID, job.Workflow)
+		if job.Error != "" {
+			fmt.Fprintf(b, "    error: %s\n", job.Error)
+			continue
+		}
+		if len(job.Needs) > 0 {
+			fmt.Fprintf(b, "    needs: %s\n", strings.Join(job.Needs, ", "))
+		}
+		if job.If != nil {
+			fmt.Fprintf(b, "    if: %s\n", job.If)
+		}
+		if job.Uses != "" {
+			fmt.Fprintf(b, "    uses: %s\n", job.Uses)
+			continue
+		}
+		fmt.Fprintf(b, "    runs-on: %s\n", strings.Join(job.RunsOn, ", "))
+		if job.Platform != "" {
+			fmt.Fprintf(b, "    platform: %s\n", job.Platform)
+		}
+		switch {
+		case job.Host:
+			fmt.Fprintf(b, "    host: the job runs on the host\n")
+		case job.Image == "":
+			fmt.Fprintf(b, "    image: none, the platform is skipped -- try running with `-P %s=...`\n", strings.Join(job.RunsOn, ","))
+		default:
+			fmt.Fprintf(b, "    image: %s\n", job.Image)
+		}
+		if job.Architecture != "" {
+			fmt.Fprintf(b, "    architecture: %s\n", job.Architecture)
+		}
+		if job.ContainerOptions != "" {
+			fmt.Fprintf(b, "    container options: %s\n", job.ContainerOptions)
+		}
+		if job.ResourceLimits != "" {
+			fmt.Fprintf(b, "    resource limits: %s\n", job.ResourceLimits)
+		}
+		for _, service := range job.Services {
+			fmt.Fprintf(b, "    service %s: %s", service.Name, service.Image)
+			if len(service.Ports) > 0 {
+				fmt.Fprintf(b, ", ports %s", strings.Join(service.Ports, " "))
+			}
+			if service.Options != "" {
+				fmt.Fprintf(b, ", options %s", service.Options)
+			}
+			if service.OneShot {
+				fmt.Fprintf(b, ", one-shot")
+			}
+			if len(service.DependsOn) > 0 {
+				dependencies := make([]string, 0, len(service.DependsOn))
+				for dependency, condition := range service.DependsOn {
+					dependencies = append(dependencies, fmt.Sprintf("%s (%s)", dependency, condition))
+				}
+				sort.Strings(dependencies)
+				fmt.Fprintf(b, ", depends on %s", strings.Join(dependencies, ", "))
+			}
+			fmt.Fprintln(b)
+		}
+		for _, step := range job.Steps {
+			fmt.Fprintf(b, "    Step %s: %s\n", step.ID, step.Name)
+			if step.Uses != "" {
+				if step.SHA != "" {
+					fmt.Fprintf(b, "      uses: %s (%s)\n", step.Uses, step.SHA)
+				} else {
+					fmt.Fprintf(b, "      uses: %s\n", step.Uses)
+				}
+			}
+			if step.If != nil {
+				fmt.Fprintf(b, "      if: %s\n", step.If)
+			}
+			if len(step.Env) > 0 {
+				fmt.Fprintf(b, "      env: %s\n", strings.Join(step.Env, ", "))
+			}
+			if len(step.Secrets) > 0 {
+				fmt.Fprintf(b, "      secrets: %s\n", strings.Join(step.Secrets, ", "))
+			}
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (c *ConditionExplanation) String() string {
+	if c.Error != "" {
+		return fmt.Sprintf("%s => %s (%s)", c.Expression, c.Result, c.Error)
+	}
+	return fmt.Sprintf("%s => %s", c.Expression, c.Result)
+}
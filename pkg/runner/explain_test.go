@@ -0,0 +1,137 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nektos/act/pkg/model"
+)
+
+func TestExplainPlan(t *testing.T) {
+	planner, err := model.NewSingleWorkflowPlanner("ci.yml", strings.NewReader(`
+name: CI
+on: push
+env:
+  CI: "true"
+jobs:
+  build:
+    runs-on: ${{ matrix.os }}
+    if: github.event_name == 'push'
+    strategy:
+      matrix:
+        os: [ubuntu-latest, windows-latest]
+    container:
+      image: golang:1.21
+      options: --cpus 2
+    services:
+      db:
+        image: postgres:${{ matrix.os == 'ubuntu-latest' && '16' || '15' }}
+        ports: ["5432"]
+    steps:
+      - uses: ./.github/actions/setup
+        with:
+          token: ${{ secrets.GITHUB_TOKEN }}
+      - id: test
+        if: github.ref == 'refs/heads/main'
+        run: make test DB_PASSWORD=${{ secrets['DB_PASSWORD'] }}
+        env:
+          GOFLAGS: -mod=mod
+      - if: failure()
+        run: echo failed
+  deploy:
+    needs: build
+    if: github.ref == 'refs/heads/main'
+    uses: ./.github/workflows/deploy.yml
+`))
+	assert.NoError(t, err)
+	plan, err := planner.PlanEvent("push")
+	assert.NoError(t, err)
+
+	config := &Config{
+		Workdir:   t.TempDir(),
+		EventName: "push",
+		Platforms: map[string]string{"ubuntu-latest": "node:16-bullseye"},
+		Matrix:    map[string]map[string]bool{"os": {"windows-*": false}},
+	}
+	explanation, err := ExplainPlan(context.Background(), config, plan, false)
+	assert.NoError(t, err)
+	assert.Len(t, explanation.Jobs, 2)
+
+	build := explanation.Jobs[0]
+	assert.Equal(t, 1, build.Stage)
+	assert.Equal(t, "build (ubuntu-latest)", build.Name)
+	assert.Equal(t, &ConditionExplanation{Expression: "github.event_name == 'push'", Result: ConditionTrue}, build.If)
+	assert.Equal(t, []string{"ubuntu-latest"}, build.RunsOn)
+	assert.Equal(t, "golang:1.21", build.Image)
+	assert.Equal(t, "--cpus 2", build.ContainerOptions)
+	assert.Equal(t, []*ServiceExplanation{{Name: "db", Image: "postgres:16", Ports: []string{"5432"}}}, build.Services)
+	assert.Len(t, build.Steps, 3)
+	assert.Equal(t, &StepExplanation{
+		ID:      "0",
+		Name:    "./.github/actions/setup",
+		Uses:    "./.github/actions/setup",
+		Env:     []string{"CI", "INPUT_TOKEN"},
+		Secrets: []string{"GITHUB_TOKEN"},
+	}, build.Steps[0])
+	assert.Equal(t, &StepExplanation{
+		ID:      "test",
+		Name:    "make test DB_PASSWORD=${{ secrets['DB_PASSWORD'] }}",
+		If:      &ConditionExplanation{Expression: "github.ref == 'refs/heads/main'", Result: ConditionFalse},
+		Env:     []string{"CI", "GOFLAGS"},
+		Secrets: []string{"DB_PASSWORD"},
+	}, build.Steps[1])
+	assert.Equal(t, ConditionRuntime, build.Steps[2].If.Result)
+
+	deploy := explanation.Jobs[1]
+	assert.Equal(t, 2, deploy.Stage)
+	assert.Equal(t, []string{"build"}, deploy.Needs)
+	assert.Equal(t, ConditionRuntime, deploy.If.Result)
+	assert.Equal(t, "./.github/workflows/deploy.yml", deploy.Uses)
+	assert.Empty(t, deploy.Steps)
+
+	out := &bytes.Buffer{}
+	assert.NoError(t, explanation.WriteText(out))
+	assert.Contains(t, out.String(), "  Job build (ubuntu-latest) (build, ci.yml)\n")
+	assert.Contains(t, out.String(), "    service db: postgres:16, ports 5432\n")
+	assert.Contains(t, out.String(), "      if: github.ref == 'refs/heads/main' => false\n")
+}
+
+func TestExplainPlanMasksSecrets(t *testing.T) {
+	planner, err := model.NewSingleWorkflowPlanner("ci.yml", strings.NewReader(`
+name: CI
+on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    container:
+      image: golang:1.21
+      options: --env TOKEN=${{ secrets.TOKEN }}
+    services:
+      db:
+        image: registry.example.com/${{ secrets.TOKEN }}/postgres:16
+        options: --env PASSWORD=${{ secrets.TOKEN }}
+    steps:
+      - name: Login with ${{ secrets.TOKEN }}
+        run: echo login
+`))
+	assert.NoError(t, err)
+	plan, err := planner.PlanEvent("push")
+	assert.NoError(t, err)
+
+	config := &Config{
+		Workdir:   t.TempDir(),
+		EventName: "push",
+		Platforms: map[string]string{"ubuntu-latest": "node:16-bullseye"},
+		Secrets:   map[string]string{"TOKEN": "s3cr3t-t0ken"},
+	}
+	explanation, err := ExplainPlan(context.Background(), config, plan, false)
+	assert.NoError(t, err)
+	build := explanation.Jobs[0]
+	assert.Equal(t, "--env TOKEN=***", build.ContainerOptions)
+	assert.Equal(t, []*ServiceExplanation{{Name: "db", Image: "registry.example.com/***/postgres:16", Options: "--env PASSWORD=***"}}, build.Services)
+	assert.Equal(t, "Login with ***", build.Steps[0].Name)
+}
@@ -7,6 +7,7 @@ import (
 	"path"
 	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -44,15 +45,7 @@ func (rc *RunContext) NewExpressionEvaluatorWithEnv(ctx context.Context, env map
 			strategy["max-parallel"] = job.Strategy.MaxParallel
 		}
 
-		jobs := rc.Run.Workflow.Jobs
-		jobNeeds := rc.Run.Job().Needs()
-
-		for _, needs := range jobNeeds {
-			using[needs] = exprparser.Needs{
-				Outputs: jobs[needs].Outputs,
-				Result:  jobs[needs].Result,
-			}
-		}
+		using = getNeedsContext(rc.Run)
 
 		// only setup jobs context in case of workflow_call
 		// and existing expression evaluator (this means, jobs are at
@@ -60,7 +53,7 @@ func (rc *RunContext) NewExpressionEvaluatorWithEnv(ctx context.Context, env map
 		if rc.caller != nil && rc.ExprEval != nil {
 			workflowCallResult = map[string]*model.WorkflowCallResult{}
 
-			for jobName, job := range jobs {
+			for jobName, job := range rc.Run.Workflow.Jobs {
 				result := model.WorkflowCallResult{
 					Outputs: map[string]string{},
 				}
@@ -106,6 +99,21 @@ func (rc *RunContext) NewExpressionEvaluatorWithEnv(ctx context.Context, env map
 //go:embed hashfiles/index.js
 var hashfiles string
 
+// getNeedsContext returns the needs context of the run. The needed jobs are looked up in the workflow of the run only,
+// so jobs with the same ID in the other workflows of a plan don't interfere.
+func getNeedsContext(run *model.Run) map[string]exprparser.Needs {
+	using := make(map[string]exprparser.Needs)
+	for _, needs := range run.Job().Needs() {
+		if job := run.Workflow.GetJob(needs); job != nil {
+			using[needs] = exprparser.Needs{
+				Outputs: job.Outputs,
+				Result:  job.Result,
+			}
+		}
+	}
+	return using
+}
+
 // NewStepExpressionEvaluator creates a new evaluator
 func (rc *RunContext) NewStepExpressionEvaluator(ctx context.Context, step step) ExpressionEvaluator {
 	// todo: cleanup EvaluationEnvironment creation
@@ -116,16 +124,7 @@ func (rc *RunContext) NewStepExpressionEvaluator(ctx context.Context, step step)
 		strategy["max-parallel"] = job.Strategy.MaxParallel
 	}
 
-	jobs := rc.Run.Workflow.Jobs
-	jobNeeds := rc.Run.Job().Needs()
-
-	using := make(map[string]exprparser.Needs)
-	for _, needs := range jobNeeds {
-		using[needs] = exprparser.Needs{
-			Outputs: jobs[needs].Outputs,
-			Result:  jobs[needs].Result,
-		}
-	}
+	using := getNeedsContext(rc.Run)
 
 	ghc := rc.getGithubContext(ctx)
 	inputs := getEvaluatorInputs(ctx, rc, step, ghc)
@@ -514,11 +513,12 @@ func getEvaluatorInputs(ctx context.Context, rc *RunContext, step step, ghc *mod
 				if value == nil {
 					value = v.Default
 				}
-				if v.Type == "boolean" {
-					inputs[k] = value == "true"
-				} else {
-					inputs[k] = value
+				converted, err := convertWorkflowCallInput(k, v, value)
+				if err != nil {
+					common.Logger(ctx).Warnf("Invalid input of the workflow_call event, it is passed unconverted: %v", err)
+					converted = value
 				}
+				inputs[k] = converted
 			}
 		}
 	}
@@ -538,7 +538,7 @@ func setupWorkflowInputs(ctx context.Context, inputs *map[string]interface{}, rc
 				}
 			}
 
-			if value == nil && config != nil && config.Inputs != nil {
+			if value == nil {
 				value = input.Default
 				if rc.ExprEval != nil {
 					if str, ok := value.(string); ok {
@@ -548,26 +548,95 @@ func setupWorkflowInputs(ctx context.Context, inputs *map[string]interface{}, rc
 				}
 			}
 
+			// inputs are typed, an omitted optional input gets the zero value of its type
+			if converted, err := convertWorkflowCallInput(name, input, value); err == nil {
+				value = converted
+			} else if value == "" {
+				value = workflowCallInputZeroValue(input)
+			}
+
 			(*inputs)[name] = value
 		}
 	}
 }
 
+// convertWorkflowCallInput converts the value of an input to the type declared in `on.workflow_call.inputs`
+func convertWorkflowCallInput(name string, input model.WorkflowCallInput, value interface{}) (interface{}, error) {
+	switch input.Type {
+	case "boolean":
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			switch v {
+			case "true":
+				return true, nil
+			case "false":
+				return false, nil
+			}
+		}
+		return nil, fmt.Errorf("input '%s' has type 'boolean', but got '%v'", name, value)
+	case "number":
+		switch v := value.(type) {
+		case int:
+			return float64(v), nil
+		case float64:
+			return v, nil
+		case string:
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				return f, nil
+			}
+		}
+		return nil, fmt.Errorf("input '%s' has type 'number', but got '%v'", name, value)
+	case "", "string":
+		switch v := value.(type) {
+		case string:
+			return v, nil
+		case bool, int, float64:
+			return fmt.Sprint(v), nil
+		}
+		return nil, fmt.Errorf("input '%s' has type 'string', but got '%v'", name, value)
+	}
+	return nil, fmt.Errorf("input '%s' has unsupported type '%s', must be one of 'boolean', 'number' or 'string'", name, input.Type)
+}
+
+func workflowCallInputZeroValue(input model.WorkflowCallInput) interface{} {
+	switch input.Type {
+	case "boolean":
+		return false
+	case "number":
+		return float64(0)
+	}
+	return ""
+}
+
 func getWorkflowSecrets(ctx context.Context, rc *RunContext) map[string]string {
 	if rc.caller != nil {
 		job := rc.caller.runContext.Run.Job()
-		secrets := job.Secrets()
-
-		if secrets == nil && job.InheritSecrets() {
-			secrets = rc.caller.runContext.Config.Secrets
-		}
+		callerSecrets := getWorkflowSecrets(ctx, rc.caller.runContext)
+		secrets := map[string]string{}
 
-		if secrets == nil {
-			secrets = map[string]string{}
+		if job.InheritSecrets() {
+			for k, v := range callerSecrets {
+				secrets[k] = v
+			}
+		} else {
+			// only secrets declared by the called workflow are passed
+			declared := rc.Run.Workflow.WorkflowCallConfig().Secrets
+			for k, v := range job.Secrets() {
+				if name, ok := lookupWorkflowCallSecret(declared, k); ok {
+					secrets[name] = rc.caller.runContext.ExprEval.Interpolate(ctx, v)
+				}
+			}
 		}
 
-		for k, v := range secrets {
-			secrets[k] = rc.caller.runContext.ExprEval.Interpolate(ctx, v)
+		// the token is always available to the called workflow
+		for _, k := range []string{"GITHUB_TOKEN", "GITEA_TOKEN"} {
+			if v, ok := callerSecrets[k]; ok {
+				if _, ok := secrets[k]; !ok {
+					secrets[k] = v
+				}
+			}
 		}
 
 		return secrets
@@ -576,6 +645,16 @@ func getWorkflowSecrets(ctx context.Context, rc *RunContext) map[string]string {
 	return rc.Config.Secrets
 }
 
+// lookupWorkflowCallSecret finds a secret declared in `on.workflow_call.secrets`, secret names are case insensitive
+func lookupWorkflowCallSecret(declared map[string]model.WorkflowCallSecret, name string) (string, bool) {
+	for k := range declared {
+		if strings.EqualFold(k, name) {
+			return k, true
+		}
+	}
+	return "", false
+}
+
 func getWorkflowVars(_ context.Context, rc *RunContext) map[string]string {
 	return rc.Config.Vars
 }
@@ -6,10 +6,14 @@ import (
 	"os"
 	"regexp"
 	"sort"
+	"strings"
 	"testing"
 
+	"github.com/nektos/act/pkg/common"
 	"github.com/nektos/act/pkg/exprparser"
 	"github.com/nektos/act/pkg/model"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
 	assert "github.com/stretchr/testify/assert"
 	yaml "go.yaml.in/yaml/v4"
 )
@@ -374,3 +378,71 @@ func TestRewriteSubExpressionForceFormat(t *testing.T) {
 		})
 	}
 }
+
+func TestConvertWorkflowCallInput(t *testing.T) {
+	table := []struct {
+		inputType string
+		in        interface{}
+		out       interface{}
+		errMesg   string
+	}{
+		{"boolean", true, true, ""},
+		{"boolean", "false", false, ""},
+		{"boolean", "yes", nil, "input 'in' has type 'boolean', but got 'yes'"},
+		{"number", 1, float64(1), ""},
+		{"number", 1.5, 1.5, ""},
+		{"number", " 42 ", float64(42), ""},
+		{"number", "two", nil, "input 'in' has type 'number', but got 'two'"},
+		{"string", "value", "value", ""},
+		{"string", true, "true", ""},
+		{"", 3, "3", ""},
+		{"string", []interface{}{"a"}, nil, "input 'in' has type 'string', but got '[a]'"},
+		{"choice", "a", nil, "input 'in' has unsupported type 'choice'"},
+	}
+
+	for _, tt := range table {
+		test := tt
+		t.Run(fmt.Sprintf("%s/%v", test.inputType, test.in), func(t *testing.T) {
+			out, err := convertWorkflowCallInput("in", model.WorkflowCallInput{Type: test.inputType}, test.in)
+			if test.errMesg != "" {
+				assert.ErrorContains(t, err, test.errMesg)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.out, out)
+		})
+	}
+}
+
+func TestGetEvaluatorInputsWorkflowCall(t *testing.T) {
+	workflow, err := model.ReadWorkflow(strings.NewReader(`
+on:
+  workflow_call:
+    inputs:
+      count:
+        type: number
+      debug:
+        type: boolean
+jobs:
+  test:
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo
+`))
+	assert.NoError(t, err)
+	rc := &RunContext{Config: &Config{}, Run: &model.Run{Workflow: workflow, JobID: "test"}}
+	logger, hook := test.NewNullLogger()
+	ctx := common.WithLogger(context.Background(), logger)
+	ghc := &model.GithubContext{EventName: "workflow_call", Event: map[string]interface{}{
+		"inputs": map[string]interface{}{"count": "two", "debug": "true"},
+	}}
+
+	inputs := getEvaluatorInputs(ctx, rc, nil, ghc)
+	assert.Equal(t, true, inputs["debug"])
+	// the invalid input is passed unconverted, with a warning
+	assert.Equal(t, "two", inputs["count"])
+	if assert.Len(t, hook.AllEntries(), 1) {
+		assert.Equal(t, logrus.WarnLevel, hook.LastEntry().Level)
+		assert.Contains(t, hook.LastEntry().Message, "input 'count' has type 'number', but got 'two'")
+	}
+}
@@ -2,6 +2,7 @@ package runner
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -9,6 +10,9 @@ import (
 	"github.com/nektos/act/pkg/model"
 )
 
+// supersededContextKey marks the context of the post steps of a job whose run was superseded by a newer run
+type supersededContextKey struct{}
+
 type jobInfo interface {
 	matrix() map[string]interface{}
 	steps() []*model.Step
@@ -53,6 +57,33 @@ func newJobExecutor(info jobInfo, sf stepFactory, rc *RunContext) common.Executo
 		return nil
 	})
 
+	// the steps before resumeIndex have completed in the resumed run
+	resumeIndex := 0
+	if rc.Run != nil {
+		var err error
+		if resumeIndex, err = rc.resumeStepIndex(context.Background(), infoSteps); err != nil {
+			return common.NewErrorExecutor(err)
+		}
+	}
+
+	preSteps = append(preSteps, func(ctx context.Context) error {
+		if rc.Config.RunState == nil || rc.Run == nil {
+			return nil
+		}
+		if err := rc.Config.RunState.startJob(rc.stateKey(), resumeIndex); err != nil {
+			common.Logger(ctx).Warnf("Failed to record the state of job %s: %v", rc.JobName, err)
+		}
+		rc.restoreStepStates(ctx, resumeIndex)
+		return nil
+	})
+
+	// the steps up to snapshotIndex are restored from the warm snapshot the job container started from, if any
+	snapshotIndex := -1
+	if rc.Run != nil && resumeIndex == 0 {
+		snapshotIndex = rc.warmSnapshotIndex(infoSteps)
+		preSteps = append(preSteps, rc.restoreWarmSnapshot)
+	}
+
 	for i, stepModel := range infoSteps {
 		stepModel := stepModel
 		if stepModel == nil {
@@ -65,14 +96,26 @@ func newJobExecutor(info jobInfo, sf stepFactory, rc *RunContext) common.Executo
 		}
 		stepModel.Number = i
 
+		if i < resumeIndex {
+			continue
+		}
+
 		step, err := sf.newStep(stepModel, rc)
 
 		if err != nil {
 			return common.NewErrorExecutor(err)
 		}
 
+		// the stages of the steps before the warm snapshot are skipped if the job container started from it
+		skipIfWarmSnapshot := func(executor common.Executor) common.Executor {
+			if i > snapshotIndex {
+				return executor
+			}
+			return rc.unlessWarmSnapshot(executor)
+		}
+
 		preExec := step.pre()
-		preSteps = append(preSteps, useStepLogger(rc, stepModel, stepStagePre, func(ctx context.Context) error {
+		preSteps = append(preSteps, skipIfWarmSnapshot(useStepLogger(rc, stepModel, stepStagePre, func(ctx context.Context) error {
 			logger := common.Logger(ctx)
 			preErr := preExec(ctx)
 			if preErr != nil {
@@ -83,11 +126,12 @@ func newJobExecutor(info jobInfo, sf stepFactory, rc *RunContext) common.Executo
 				common.SetJobError(ctx, ctx.Err())
 			}
 			return preErr
-		}))
+		})))
 
 		stepExec := step.main()
-		steps = append(steps, useStepLogger(rc, stepModel, stepStageMain, func(ctx context.Context) error {
+		steps = append(steps, skipIfWarmSnapshot(useStepLogger(rc, stepModel, stepStageMain, func(ctx context.Context) error {
 			logger := common.Logger(ctx)
+			startedAt := time.Now()
 			err := stepExec(ctx)
 			if err != nil {
 				logger.Errorf("%v", err)
@@ -96,10 +140,15 @@ func newJobExecutor(info jobInfo, sf stepFactory, rc *RunContext) common.Executo
 				logger.Errorf("%v", ctx.Err())
 				common.SetJobError(ctx, ctx.Err())
 			}
+			rc.finishStepState(ctx, stepModel, startedAt)
+			rc.finishStepReport(ctx, stepModel, err)
 			return nil
-		}))
+		})))
+		if i == snapshotIndex {
+			steps = append(steps, rc.takeWarmSnapshot(infoSteps[:i+1]))
+		}
 
-		postExec := useStepLogger(rc, stepModel, stepStagePost, step.post())
+		postExec := skipIfWarmSnapshot(useStepLogger(rc, stepModel, stepStagePost, step.post()))
 		if postExecutor != nil {
 			// run the post executor in reverse order
 			postExecutor = postExec.Finally(postExecutor)
@@ -110,8 +159,9 @@ func newJobExecutor(info jobInfo, sf stepFactory, rc *RunContext) common.Executo
 
 	postExecutor = postExecutor.Finally(func(ctx context.Context) error {
 		jobError := common.JobError(ctx)
+		superseded, _ := ctx.Value(supersededContextKey{}).(bool)
 		var err error
-		if rc.Config.AutoRemove || jobError == nil {
+		if rc.Config.AutoRemove || jobError == nil || superseded {
 			// always allow 1 min for stopping and removing the runner, even if we were cancelled
 			ctx, cancel := context.WithTimeout(common.WithLogger(context.Background(), common.Logger(ctx)), time.Minute)
 			defer cancel()
@@ -144,7 +194,6 @@ func newJobExecutor(info jobInfo, sf stepFactory, rc *RunContext) common.Executo
 			// }
 		}
 		setJobResult(ctx, info, rc, jobError == nil)
-		setJobOutputs(ctx, rc)
 
 		return err
 	})
@@ -153,19 +202,40 @@ func newJobExecutor(info jobInfo, sf stepFactory, rc *RunContext) common.Executo
 	pipeline = append(pipeline, preSteps...)
 	pipeline = append(pipeline, steps...)
 
-	return common.NewPipelineExecutor(info.startContainer(), common.NewPipelineExecutor(pipeline...).
+	jobExecutor := common.NewPipelineExecutor(info.startContainer(), common.NewPipelineExecutor(pipeline...).
 		Finally(func(ctx context.Context) error { //nolint:contextcheck
 			var cancel context.CancelFunc
 			if ctx.Err() == context.Canceled {
 				// in case of an aborted run, we still should execute the
 				// post steps to allow cleanup.
-				ctx, cancel = context.WithTimeout(common.WithLogger(context.Background(), common.Logger(ctx)), 5*time.Minute)
+				superseded := errors.Is(context.Cause(ctx), common.ErrRunSuperseded)
+				ctx, cancel = context.WithTimeout(context.WithoutCancel(ctx), 5*time.Minute)
 				defer cancel()
+				ctx = context.WithValue(ctx, supersededContextKey{}, superseded)
 			}
 			return postExecutor(ctx)
 		}).
 		Finally(info.interpolateOutputs()).
 		Finally(info.closeContainer()))
+
+	return func(ctx context.Context) error {
+		rc.emitJobEvent(ctx, Event{Type: EventJobStarted})
+		err := jobExecutor(ctx)
+		jobErr := err
+		if jobErr == nil {
+			jobErr = common.JobError(ctx)
+		}
+		event := Event{Type: EventJobFinished, Result: "success"}
+		if jobErr != nil {
+			event.Result = "failure"
+			event.Error = jobErr.Error()
+		}
+		if rc.Run != nil {
+			event.Outputs = rc.Run.Job().Outputs
+		}
+		rc.emitJobEvent(ctx, event)
+		return err
+	}
 }
 
 func setJobResult(ctx context.Context, info jobInfo, rc *RunContext, success bool) {
@@ -194,28 +264,22 @@ func setJobResult(ctx context.Context, info jobInfo, rc *RunContext, success boo
 		jobResultMessage = "failed"
 	}
 
-	logger.WithField("jobResult", jobResult).Infof("\U0001F3C1  Job %s", jobResultMessage)
-}
-
-func setJobOutputs(ctx context.Context, rc *RunContext) {
-	if rc.caller != nil {
-		// map outputs for reusable workflows
-		callerOutputs := make(map[string]string)
-
-		ee := rc.NewExpressionEvaluator(ctx)
-
-		for k, v := range rc.Run.Workflow.WorkflowCallConfig().Outputs {
-			callerOutputs[k] = ee.Interpolate(ctx, ee.Interpolate(ctx, v.Value))
-		}
-
-		rc.caller.runContext.Run.Job().Outputs = callerOutputs
+	if rc.resourceUsage.IsZero() {
+		logger.WithField("jobResult", jobResult).Infof("\U0001F3C1  Job %s", jobResultMessage)
+		return
 	}
+	logger.WithField("jobResult", jobResult).WithField("resourceUsage", rc.resourceUsage).Infof("\U0001F3C1  Job %s (%s)", jobResultMessage, rc.resourceUsage.String())
 }
 
 func useStepLogger(rc *RunContext, stepModel *model.Step, stage stepStage, executor common.Executor) common.Executor {
 	return func(ctx context.Context) error {
 		ctx = withStepLogger(ctx, stepModel.Number, stepModel.ID, rc.ExprEval.Interpolate(ctx, stepModel.String()), stage.String())
 
+		var stepReport *StepReport
+		if stage == stepStageMain {
+			stepReport = rc.startStepReport(ctx, stepModel)
+		}
+
 		rawLogger := common.Logger(ctx).WithField("raw_output", true)
 		logWriter := common.NewLineWriter(rc.commandHandler(ctx), func(s string) bool {
 			if rc.Config.LogOutput {
@@ -223,6 +287,9 @@ func useStepLogger(rc *RunContext, stepModel *model.Step, stage stepStage, execu
 			} else {
 				rawLogger.Debugf("%s", s)
 			}
+			if stepReport != nil {
+				stepReport.addLogLine(ctx, rc.Config, s)
+			}
 			return true
 		})
 
@@ -339,3 +339,59 @@ func TestNewJobExecutor(t *testing.T) {
 		})
 	}
 }
+
+func TestJobExecutorCleanupSuperseded(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		cause   error
+		cleanup bool
+	}{
+		{"cancelled", context.Canceled, false},
+		{"superseded by a newer run", common.ErrRunSuperseded, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancelCause(common.WithJobErrorContainer(context.Background()))
+			defer cancel(nil)
+			jim := &jobInfoMock{}
+			sfm := &stepFactoryMock{}
+			rc := &RunContext{
+				JobContainer: &jobContainerMock{},
+				Run: &model.Run{
+					JobID: "test",
+					Workflow: &model.Workflow{
+						Jobs: map[string]*model.Job{
+							"test": {},
+						},
+					},
+				},
+				Config: &Config{},
+			}
+			rc.ExprEval = rc.NewExpressionEvaluator(ctx)
+
+			stepModel := &model.Step{ID: "1"}
+			sm := &stepMock{}
+			jim.On("steps").Return([]*model.Step{stepModel})
+			jim.On("startContainer").Return(func(_ context.Context) error { return nil })
+			sfm.On("newStep", stepModel, rc).Return(sm, nil)
+			sm.On("pre").Return(func(_ context.Context) error { return nil })
+			sm.On("main").Return(func(ctx context.Context) error {
+				cancel(tt.cause)
+				common.SetJobError(ctx, ctx.Err())
+				return ctx.Err()
+			})
+			sm.On("post").Return(func(_ context.Context) error { return nil })
+			jim.On("matrix").Return(map[string]interface{}{})
+			jim.On("interpolateOutputs").Return(func(_ context.Context) error { return nil })
+			stopped := false
+			jim.On("stopContainer").Return(func(_ context.Context) error {
+				stopped = true
+				return nil
+			}).Maybe()
+			jim.On("result", mock.Anything).Maybe()
+			jim.On("closeContainer").Return(func(_ context.Context) error { return nil })
+
+			_ = newJobExecutor(jim, sfm, rc)(ctx)
+			assert.Equal(t, tt.cleanup, stopped)
+		})
+	}
+}
@@ -3,9 +3,13 @@ package runner
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"io"
+	"net/url"
 	"os"
+	"sort"
 	"strings"
 	"sync"
 
@@ -107,10 +111,21 @@ func WithJobLogger(ctx context.Context, jobID string, jobName string, config *Co
 		}
 	}
 
-	logger.SetFormatter(&maskedFormatter{
-		Formatter: logger.Formatter,
-		masker:    valueMasker(config.InsecureSecrets, config.Secrets),
-	})
+	if config.RunState != nil {
+		logger.AddHook(&runLogHook{
+			runState:  config.RunState,
+			formatter: &logrus.JSONFormatter{},
+		})
+	}
+	// the entries are masked by the first hook
+	masker := valueMasker(config.InsecureSecrets, config.Secrets)
+	ctx = context.WithValue(ctx, maskerContextKeyVal, &jobMasker{config: config, masker: masker})
+	hooks := logrus.LevelHooks{}
+	hooks.Add(&maskHook{masker: masker})
+	for level, levelHooks := range logger.Hooks {
+		hooks[level] = append(hooks[level], levelHooks...)
+	}
+	logger.ReplaceHooks(hooks)
 	rtn := logger.WithFields(logrus.Fields{
 		"job":    jobName,
 		"jobID":  jobID,
@@ -153,25 +168,140 @@ func withStepLogger(ctx context.Context, stepNumber int, stepID, stepName, stage
 	return common.WithLogger(ctx, rtn)
 }
 
+// runLogHook records the log entries of the jobs as JSON to the log of the run, whatever the format of the output
+type runLogHook struct {
+	runState  *RunState
+	formatter logrus.Formatter
+}
+
+func (h *runLogHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *runLogHook) Fire(entry *logrus.Entry) error {
+	line, err := h.formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+	return h.runState.writeLog(line)
+}
+
 type entryProcessor func(entry *logrus.Entry) *logrus.Entry
 
-func valueMasker(insecureSecrets bool, secrets map[string]string) entryProcessor {
-	return func(entry *logrus.Entry) *logrus.Entry {
-		if insecureSecrets {
-			return entry
+// minMaskLength is the minimum length of a secret, and of its lines and encodings, to mask them besides the secret itself, shorter ones would mask too much of the log
+const minMaskLength = 4
+
+// secretMasks returns the masks of a secret: the secret, each line of a multiline secret and its JSON escaped, base64 and URL encoded forms
+func secretMasks(value string) []string {
+	if value == "" {
+		return nil
+	}
+	masks := []string{value}
+	add := func(mask string) {
+		if len(value) < minMaskLength || len(mask) < minMaskLength || mask == value {
+			return
+		}
+		for _, m := range masks {
+			if m == mask {
+				return
+			}
 		}
+		masks = append(masks, mask)
+	}
+	if strings.ContainsAny(value, "\r\n") {
+		for _, line := range strings.FieldsFunc(value, func(r rune) bool { return r == '\r' || r == '\n' }) {
+			add(strings.TrimSpace(line))
+		}
+	}
+	escaped := &bytes.Buffer{}
+	encoder := json.NewEncoder(escaped)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err == nil {
+		add(strings.TrimSuffix(strings.TrimPrefix(strings.TrimSuffix(escaped.String(), "\n"), `"`), `"`))
+	}
+	for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding} {
+		encoded := encoding.EncodeToString([]byte(value))
+		add(encoded)
+		// without the padding, when the secret is encoded with more data
+		add(strings.TrimRight(encoded, "="))
+	}
+	add(url.QueryEscape(value))
+	add(url.PathEscape(value))
 
-		masks := Masks(entry.Context)
+	return masks
+}
 
-		for _, v := range secrets {
-			if v != "" {
-				entry.Message = strings.ReplaceAll(entry.Message, v, "***")
-			}
+// sortMasks sorts the masks by length, the longest first so that a mask containing another one is replaced whole
+func sortMasks(masks []string) {
+	sort.SliceStable(masks, func(i, j int) bool {
+		return len(masks[i]) > len(masks[j])
+	})
+}
+
+// maskString replaces the masks in value, which are sorted by sortMasks
+func maskString(value string, masks []string) string {
+	for _, mask := range masks {
+		value = strings.ReplaceAll(value, mask, "***")
+	}
+	return value
+}
+
+// jobMasks are the sorted masks of the secrets and of the values masked by a job, up to count of them
+type jobMasks struct {
+	count int
+	masks []string
+}
+
+// valueMasker returns a processor masking the secrets and the values masked by the job of the entries,
+// the masks are computed and sorted when the masker is built and again when the job masks a new value
+func valueMasker(insecureSecrets bool, secrets map[string]string) entryProcessor {
+	if insecureSecrets {
+		return func(entry *logrus.Entry) *logrus.Entry {
+			return entry
+		}
+	}
+	secretValues := make([]string, 0, len(secrets))
+	for _, v := range secrets {
+		secretValues = append(secretValues, secretMasks(v)...)
+	}
+	sortMasks(secretValues)
+
+	var mu sync.Mutex
+	// by the masks of the context, those of the job and of each of its composite actions
+	cache := map[*[]string]*jobMasks{}
+	contextMasks := func(ctx context.Context) []string {
+		values := Masks(ctx)
+		mu.Lock()
+		defer mu.Unlock()
+		cached, ok := cache[values]
+		if !ok {
+			cached = &jobMasks{masks: secretValues}
+			cache[values] = cached
+		}
+		if cached.count == len(*values) {
+			return cached.masks
+		}
+		// the values are only appended by the job
+		masks := append([]string{}, cached.masks...)
+		for _, v := range (*values)[cached.count:] {
+			masks = append(masks, secretMasks(v)...)
 		}
+		sortMasks(masks)
+		cached.count, cached.masks = len(*values), masks
+		return masks
+	}
 
-		for _, v := range *masks {
-			if v != "" {
-				entry.Message = strings.ReplaceAll(entry.Message, v, "***")
+	return func(entry *logrus.Entry) *logrus.Entry {
+		ctx := entry.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		masks := contextMasks(ctx)
+		entry.Message = maskString(entry.Message, masks)
+		// the fields may hold interpolated values too, e.g. the run script of the step
+		for k, v := range entry.Data {
+			if str, ok := v.(string); ok {
+				entry.Data[k] = maskString(str, masks)
 			}
 		}
 
@@ -179,13 +309,37 @@ func valueMasker(insecureSecrets bool, secrets map[string]string) entryProcessor
 	}
 }
 
-type maskedFormatter struct {
-	logrus.Formatter
+type maskerContextKey string
+
+const maskerContextKeyVal = maskerContextKey("runner.masker")
+
+// jobMasker is the masker of the job logger, built from the config
+type jobMasker struct {
+	config *Config
 	masker entryProcessor
 }
 
-func (f *maskedFormatter) Format(entry *logrus.Entry) ([]byte, error) {
-	return f.Formatter.Format(f.masker(entry))
+// maskValue masks the secrets and the values masked by the job in a value reported outside of the log,
+// with the masker of the job logger when it is built from the same config
+func maskValue(ctx context.Context, config *Config, value string) string {
+	if job, ok := ctx.Value(maskerContextKeyVal).(*jobMasker); ok && job.config == config {
+		return job.masker(&logrus.Entry{Message: value, Context: ctx}).Message
+	}
+	return valueMasker(config.InsecureSecrets, config.Secrets)(&logrus.Entry{Message: value, Context: ctx}).Message
+}
+
+// maskHook masks the entries before the other hooks and the formatter see them, so that the secrets don't leak to the hooks
+type maskHook struct {
+	masker entryProcessor
+}
+
+func (h *maskHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *maskHook) Fire(entry *logrus.Entry) error {
+	h.masker(entry)
+	return nil
 }
 
 type jobLogFormatter struct {
@@ -0,0 +1,101 @@
+package runner
+
+import (
+	"context"
+	"encoding/base64"
+	"net/url"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nektos/act/pkg/common"
+)
+
+type entriesHook struct {
+	messages []string
+	fields   []logrus.Fields
+}
+
+func (h *entriesHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *entriesHook) Fire(entry *logrus.Entry) error {
+	h.messages = append(h.messages, entry.Message)
+	h.fields = append(h.fields, entry.Data)
+	return nil
+}
+
+func TestSecretMasks(t *testing.T) {
+	key := "-----BEGIN KEY-----\nMIIEowIBAAKCAQEA\r\n-----END KEY-----\n"
+	masks := secretMasks(key)
+	assert.Contains(t, masks, key)
+	assert.Contains(t, masks, "-----BEGIN KEY-----")
+	assert.Contains(t, masks, "MIIEowIBAAKCAQEA")
+	assert.Contains(t, masks, `-----BEGIN KEY-----\nMIIEowIBAAKCAQEA\r\n-----END KEY-----\n`)
+
+	secret := "p@ss/w<rd>&1"
+	masks = secretMasks(secret)
+	assert.Contains(t, masks, "p@ss/w<rd>&1")
+	assert.Contains(t, masks, "cEBzcy93PHJkPiYx")
+	assert.Contains(t, masks, url.QueryEscape(secret))
+	assert.Contains(t, masks, url.PathEscape(secret))
+
+	assert.Equal(t, []string{"abc"}, secretMasks("abc"), "the encodings of short secrets mask too much")
+	assert.Nil(t, secretMasks(""))
+
+	masks = append(secretMasks(key), secretMasks(secret)...)
+	sortMasks(masks)
+	masked := maskString("key: "+key+" url: https://example.com/?p="+url.QueryEscape(secret)+" b64: "+base64.StdEncoding.EncodeToString([]byte(secret)), masks)
+	assert.Equal(t, "key: *** url: https://example.com/?p=*** b64: ***", masked)
+}
+
+func TestJobLoggerMasks(t *testing.T) {
+	hook := &entriesHook{}
+	ctx := common.WithLoggerHook(context.Background(), hook)
+	config := &Config{Secrets: map[string]string{"KEY": "-----BEGIN KEY-----\nMIIEowIBAAKCAQEA\n-----END KEY-----"}}
+	masks := &[]string{"added-mask"}
+	ctx = WithJobLogger(ctx, "build", "build", config, masks, nil)
+
+	logger := common.Logger(ctx)
+	logger.WithField("raw_output", true).Infof("MIIEowIBAAKCAQEA")
+	logger.Infof(`{"key": "-----BEGIN KEY-----\nMIIEowIBAAKCAQEA\n-----END KEY-----"}`)
+	logger.Infof("token=%s", base64.StdEncoding.EncodeToString([]byte("added-mask")))
+	assert.Equal(t, []string{"***", `{"key": "***"}`, "token=***"}, hook.messages)
+
+	common.Logger(withStepLogger(ctx, 1, "deploy", "curl -H 'Authorization: added-mask'", "Main")).Infof("deploying")
+	assert.Equal(t, "curl -H 'Authorization: ***'", hook.fields[3]["step"])
+
+	assert.Equal(t, "failed with ***", maskValue(ctx, config, "failed with YWRkZWQtbWFzaw"))
+
+	// the values masked by the job later on are masked too
+	*masks = append(*masks, "later-mask")
+	logger.Infof("later-mask and added-mask")
+	assert.Equal(t, "*** and ***", hook.messages[len(hook.messages)-1])
+	assert.Equal(t, "*** failed", maskValue(ctx, config, "later-mask failed"))
+	// so are those of a composite action
+	compositeMasks := &[]string{"composite-mask"}
+	common.Logger(WithCompositeLogger(ctx, compositeMasks)).Infof("composite-mask, not later-mask")
+	assert.Equal(t, "***, not later-mask", hook.messages[len(hook.messages)-1])
+	assert.Equal(t, "-----BEGIN KEY-----", maskValue(ctx, &Config{InsecureSecrets: true, Secrets: config.Secrets}, "-----BEGIN KEY-----"))
+}
+
+func TestJobLoggerMasksLineWriter(t *testing.T) {
+	hook := &entriesHook{}
+	ctx := common.WithLoggerHook(context.Background(), hook)
+	config := &Config{Secrets: map[string]string{"KEY": "-----BEGIN KEY-----\nMIIEowIBAAKCAQEA\n-----END KEY-----"}}
+	ctx = WithJobLogger(ctx, "build", "build", config, &[]string{}, nil)
+
+	logger := common.Logger(ctx).WithField("raw_output", true)
+	writer := common.NewLineWriter(func(line string) bool {
+		logger.Infof("%s", line)
+		return true
+	})
+	// the secret is written in chunks not aligned with its lines, each of them is logged on its own
+	for _, chunk := range []string{"key: -----BEGIN K", "EY-----\nMIIEow", "IBAAKCAQEA\n---", "--END KEY-----\n"} {
+		_, err := writer.Write([]byte(chunk))
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, []string{"key: ***\n", "***\n", "***\n"}, hook.messages)
+}
@@ -0,0 +1,109 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/container"
+	"github.com/nektos/act/pkg/model"
+)
+
+const runMetricsFile = "metrics.json"
+
+// RunMetrics is the usage of the resources of the jobs and steps of a run, recorded next to the state of the run
+type RunMetrics struct {
+	Jobs map[string]*JobMetrics `json:"jobs"` // by the qualified job ID, suffixed with the index of the matrix combination
+}
+
+// JobMetrics is the usage of the resources of a job, the sum of the usages of its steps
+type JobMetrics struct {
+	Name  string                  `json:"name,omitempty"`
+	Usage container.ResourceUsage `json:"usage"`
+	Steps []*StepMetrics          `json:"steps,omitempty"`
+}
+
+// StepMetrics is the usage of the resources of a stage of a step
+type StepMetrics struct {
+	ID    string                  `json:"id"`
+	Name  string                  `json:"name,omitempty"`
+	Stage string                  `json:"stage"`
+	Usage container.ResourceUsage `json:"usage"`
+}
+
+// MetricsPath returns the path of the metrics of the run
+func (s *RunState) MetricsPath() string {
+	return filepath.Join(s.dir, runMetricsFile)
+}
+
+// ReadMetrics reads the metrics of the jobs of the run which have finished
+func (s *RunState) ReadMetrics() (*RunMetrics, error) {
+	metrics := &RunMetrics{}
+	content, err := os.ReadFile(s.MetricsPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return &RunMetrics{Jobs: map[string]*JobMetrics{}}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, metrics); err != nil {
+		return nil, err
+	}
+	if metrics.Jobs == nil {
+		metrics.Jobs = map[string]*JobMetrics{}
+	}
+	return metrics, nil
+}
+
+// finishJobMetrics records the metrics of a job, keeping those of the other jobs of the run and of a resumed run
+func (s *RunState) finishJobMetrics(key string, job *JobMetrics) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	metrics, err := s.ReadMetrics()
+	if err != nil {
+		return err
+	}
+	metrics.Jobs[key] = job
+	content, err := json.MarshalIndent(metrics, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(s.dir, runMetricsFile+".tmp")
+	if err := os.WriteFile(tmp, content, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.MetricsPath())
+}
+
+// recordResourceUsage adds the usage of a stage of a step to the usage of the job
+func (rc *RunContext) recordResourceUsage(ctx context.Context, stepModel *model.Step, stage stepStage, usage *container.ResourceUsage) {
+	if usage.IsZero() {
+		return
+	}
+	rc.resourceUsage.Add(*usage)
+	rc.stepMetrics = append(rc.stepMetrics, &StepMetrics{
+		ID:    stepModel.ID,
+		Name:  rc.ExprEval.Interpolate(ctx, stepModel.String()),
+		Stage: stage.String(),
+		Usage: *usage,
+	})
+}
+
+// finishJobMetrics records the usage of the resources of the job and of its steps in the metrics of the run
+func (rc *RunContext) finishJobMetrics(ctx context.Context) {
+	runState := rc.Config.RunState
+	if runState == nil || rc.Run == nil || len(rc.stepMetrics) == 0 {
+		return
+	}
+	job := &JobMetrics{
+		Name:  rc.Name,
+		Usage: rc.resourceUsage,
+		Steps: rc.stepMetrics,
+	}
+	rc.stepMetrics = nil
+	if err := runState.finishJobMetrics(rc.stateKey(), job); err != nil {
+		common.Logger(ctx).Warnf("Failed to record the metrics of job %s: %v", rc.JobName, err)
+	}
+}
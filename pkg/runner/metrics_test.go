@@ -0,0 +1,54 @@
+package runner
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nektos/act/pkg/container"
+	"github.com/nektos/act/pkg/model"
+)
+
+func TestRunContextMetrics(t *testing.T) {
+	ctx := context.Background()
+	runState, err := NewRunState(t.TempDir(), "push")
+	assert.NoError(t, err)
+
+	rc := createIfTestRunContext(map[string]*model.Job{
+		"job1": createJob(t, `runs-on: ubuntu-latest`, ""),
+	})
+	rc.Name = "job1"
+	rc.JobName = "job1"
+	rc.Config.RunState = runState
+
+	build := &model.Step{ID: "build", Run: "make"}
+	test := &model.Step{ID: "test", Run: "make test"}
+	rc.recordResourceUsage(ctx, build, stepStageMain, &container.ResourceUsage{Duration: time.Second, CPUTime: time.Second, CPUAvg: 100, MemoryPeak: 1 << 30})
+	rc.recordResourceUsage(ctx, test, stepStageMain, &container.ResourceUsage{Duration: time.Second, MemoryPeak: 2 << 30})
+	// a step without commands isn't recorded
+	rc.recordResourceUsage(ctx, &model.Step{ID: "skipped"}, stepStageMain, &container.ResourceUsage{})
+	assert.Equal(t, 2*time.Second, rc.resourceUsage.Duration)
+	assert.InDelta(t, 50, rc.resourceUsage.CPUAvg, 0.001)
+	assert.Equal(t, uint64(2<<30), rc.resourceUsage.MemoryPeak)
+
+	rc.finishJobState(ctx, "success", time.Now())
+	metrics, err := runState.ReadMetrics()
+	assert.NoError(t, err)
+	job := metrics.Jobs[rc.stateKey()]
+	if assert.NotNil(t, job) {
+		assert.Equal(t, "job1", job.Name)
+		assert.Equal(t, rc.resourceUsage, job.Usage)
+		assert.Len(t, job.Steps, 2)
+		assert.Equal(t, "build", job.Steps[0].ID)
+		assert.Equal(t, "Main", job.Steps[0].Stage)
+		assert.Equal(t, uint64(2<<30), job.Steps[1].Usage.MemoryPeak)
+	}
+
+	// the metrics of the other jobs of the run are kept
+	assert.NoError(t, runState.finishJobMetrics("ci.yml:other", &JobMetrics{Name: "other"}))
+	metrics, err = runState.ReadMetrics()
+	assert.NoError(t, err)
+	assert.Len(t, metrics.Jobs, 2)
+}
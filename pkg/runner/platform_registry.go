@@ -0,0 +1,189 @@
+package runner
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/distribution/reference"
+	"go.yaml.in/yaml/v4"
+)
+
+// PlatformRegistry is the registry of the runners the jobs run on, a job runs on the first runner which has all the labels of its runs-on
+//
+//	platforms:
+//	  - name: linux-x64
+//	    labels: [self-hosted, ubuntu-latest, ubuntu-22.04]
+//	    os: Linux
+//	    arch: X64
+//	    image: catthehacker/ubuntu:act-22.04@sha256:...
+//	  - name: gpu
+//	    group: gpu-runners
+//	    labels: [self-hosted, gpu]
+//	    os: Linux
+//	    arch: X64
+//	    image: nvidia/cuda:12.4.1-base-ubuntu22.04
+type PlatformRegistry struct {
+	Platforms []*RegisteredPlatform `yaml:"platforms" json:"platforms"`
+}
+
+// RegisteredPlatform is a runner of the registry, it has the labels of its name, its group, its OS and its arch besides its labels
+type RegisteredPlatform struct {
+	Name   string   `yaml:"name" json:"name"`
+	Group  string   `yaml:"group,omitempty" json:"group,omitempty"` // the runner group of runs-on: {group, labels}
+	Labels []string `yaml:"labels,omitempty" json:"labels,omitempty"`
+	OS     string   `yaml:"os,omitempty" json:"os,omitempty"`     // Linux, Windows or macOS, like runner.os
+	Arch   string   `yaml:"arch,omitempty" json:"arch,omitempty"` // X86, X64, ARM or ARM64, like runner.arch
+	Image  string   `yaml:"image" json:"image"`                   // the image of the jobs, pinned by its digest with name:tag@sha256:..., or -self-hosted
+}
+
+var (
+	registryOSes  = []string{"Linux", "Windows", "macOS"}
+	registryArchs = []string{"X86", "X64", "ARM", "ARM64"}
+)
+
+// LoadPlatformRegistry reads the platform registry of a file
+func LoadPlatformRegistry(path string) (*PlatformRegistry, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	registry := &PlatformRegistry{}
+	if err := yaml.Unmarshal(content, registry); err != nil {
+		return nil, fmt.Errorf("failed to read the platform registry %s: %w", path, err)
+	}
+	names := map[string]bool{}
+	for i, platform := range registry.Platforms {
+		if platform == nil || platform.Name == "" {
+			return nil, fmt.Errorf("platform %d of %s has no name", i, path)
+		}
+		if names[strings.ToLower(platform.Name)] {
+			return nil, fmt.Errorf("platform '%s' of %s is defined twice", platform.Name, path)
+		}
+		names[strings.ToLower(platform.Name)] = true
+		if err := platform.validate(); err != nil {
+			return nil, fmt.Errorf("invalid platform '%s' of %s: %w", platform.Name, path, err)
+		}
+	}
+	return registry, nil
+}
+
+func (p *RegisteredPlatform) validate() error {
+	if p.OS != "" {
+		i := slices.IndexFunc(registryOSes, func(os string) bool { return strings.EqualFold(os, p.OS) })
+		if i < 0 {
+			return fmt.Errorf("os '%s' must be one of %s", p.OS, strings.Join(registryOSes, ", "))
+		}
+		p.OS = registryOSes[i]
+	}
+	if p.Arch != "" {
+		i := slices.IndexFunc(registryArchs, func(arch string) bool { return strings.EqualFold(arch, p.Arch) })
+		if i < 0 {
+			return fmt.Errorf("arch '%s' must be one of %s", p.Arch, strings.Join(registryArchs, ", "))
+		}
+		p.Arch = registryArchs[i]
+	}
+	switch {
+	case p.Image == "":
+		return errors.New("image is required")
+	case strings.HasPrefix(p.Image, "-"):
+		// a platform of the host, e.g. -self-hosted
+		return nil
+	}
+	if _, err := reference.ParseNormalizedNamed(p.Image); err != nil {
+		return fmt.Errorf("image '%s': %w", p.Image, err)
+	}
+	return nil
+}
+
+// AllLabels returns the labels the runner has, in lower case
+func (p *RegisteredPlatform) AllLabels() []string {
+	labels := []string{strings.ToLower(p.Name)}
+	for _, label := range append([]string{p.Group, p.OS, p.Arch}, p.Labels...) {
+		if label != "" && !slices.Contains(labels, strings.ToLower(label)) {
+			labels = append(labels, strings.ToLower(label))
+		}
+	}
+	return labels
+}
+
+// Pinned returns true if the image of the runner is pinned by its digest
+func (p *RegisteredPlatform) Pinned() bool {
+	named, err := reference.ParseNormalizedNamed(p.Image)
+	if err != nil {
+		return false
+	}
+	_, ok := named.(reference.Digested)
+	return ok
+}
+
+// Match returns the first runner which has all the labels of runs-on, nil if none has them
+func (r *PlatformRegistry) Match(runsOn []string) *RegisteredPlatform {
+	if r == nil || len(runsOn) == 0 {
+		return nil
+	}
+	for _, platform := range r.Platforms {
+		labels := platform.AllLabels()
+		if !slices.ContainsFunc(runsOn, func(label string) bool { return !slices.Contains(labels, strings.ToLower(label)) }) {
+			return platform
+		}
+	}
+	return nil
+}
+
+// ImageDigests records the digests the tags of the images resolved to, to warn when a tag has moved since the last run
+type ImageDigests struct {
+	path    string
+	mu      sync.Mutex
+	digests map[string]string
+}
+
+// LoadImageDigests reads the digests recorded in path, which is created by the first record
+func LoadImageDigests(path string) (*ImageDigests, error) {
+	d := &ImageDigests{path: path, digests: map[string]string{}}
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return d, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, &d.digests); err != nil {
+		return nil, fmt.Errorf("failed to read the image digests of %s: %w", path, err)
+	}
+	return d, nil
+}
+
+// Get returns the digest recorded for an image, empty if it has none
+func (d *ImageDigests) Get(image string) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.digests[image]
+}
+
+// record records the digest an image resolved to, and returns the digest it resolved to before
+func (d *ImageDigests) record(image, digest string) (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	previous := d.digests[image]
+	if previous == digest {
+		return previous, nil
+	}
+	d.digests[image] = digest
+	content, err := json.MarshalIndent(d.digests, "", "  ")
+	if err != nil {
+		return previous, err
+	}
+	if err := os.MkdirAll(filepath.Dir(d.path), 0o755); err != nil {
+		return previous, err
+	}
+	tmp := d.path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0o600); err != nil {
+		return previous, err
+	}
+	return previous, os.Rename(tmp, d.path)
+}
@@ -0,0 +1,160 @@
+package runner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nektos/act/pkg/model"
+)
+
+const testPlatformRegistry = `
+platforms:
+  - name: gpu
+    group: gpu-runners
+    labels: [self-hosted, gpu]
+    os: linux
+    arch: x64
+    image: nvidia/cuda:12.4.1-base-ubuntu22.04
+  - name: linux-arm64
+    labels: [self-hosted, ubuntu-latest]
+    os: Linux
+    arch: ARM64
+    image: catthehacker/ubuntu:act-22.04@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef
+  - name: linux-x64
+    labels: [self-hosted, ubuntu-latest, gpu-less]
+    os: Linux
+    arch: X64
+    image: catthehacker/ubuntu:act-22.04
+`
+
+func writePlatformRegistry(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "platforms.yml")
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestPlatformRegistryMatch(t *testing.T) {
+	registry, err := LoadPlatformRegistry(writePlatformRegistry(t, testPlatformRegistry))
+	assert.NoError(t, err)
+	assert.Len(t, registry.Platforms, 3)
+	assert.Equal(t, "Linux", registry.Platforms[0].OS)
+	assert.Equal(t, "X64", registry.Platforms[0].Arch)
+	assert.Equal(t, []string{"gpu", "gpu-runners", "linux", "x64", "self-hosted"}, registry.Platforms[0].AllLabels())
+	assert.False(t, registry.Platforms[0].Pinned())
+	assert.True(t, registry.Platforms[1].Pinned())
+
+	for _, tt := range []struct {
+		runsOn   []string
+		platform string
+	}{
+		{[]string{"self-hosted", "linux", "gpu-less", "x64"}, "linux-x64"},
+		{[]string{"ubuntu-latest"}, "linux-arm64"},
+		{[]string{"Ubuntu-Latest", "X64"}, "linux-x64"},
+		{[]string{"gpu", "gpu-runners"}, "gpu"},
+		{[]string{"linux-arm64"}, "linux-arm64"},
+		{[]string{"self-hosted", "windows"}, ""},
+		{[]string{}, ""},
+	} {
+		platform := registry.Match(tt.runsOn)
+		if tt.platform == "" {
+			assert.Nil(t, platform, tt.runsOn)
+		} else if assert.NotNil(t, platform, tt.runsOn) {
+			assert.Equal(t, tt.platform, platform.Name, tt.runsOn)
+		}
+	}
+
+	var none *PlatformRegistry
+	assert.Nil(t, none.Match([]string{"ubuntu-latest"}))
+}
+
+func TestLoadPlatformRegistryErrors(t *testing.T) {
+	for content, message := range map[string]string{
+		"platforms:\n  - image: node:20\n":                                            "has no name",
+		"platforms:\n  - name: a\n    image: node:20\n  - name: A\n    image: node\n": "defined twice",
+		"platforms:\n  - name: a\n":                                                   "image is required",
+		"platforms:\n  - name: a\n    os: solaris\n    image: node:20\n":              "os 'solaris' must be one of",
+		"platforms:\n  - name: a\n    arch: riscv\n    image: node:20\n":              "arch 'riscv' must be one of",
+		"platforms:\n  - name: a\n    image: Node:20\n":                               "image 'Node:20'",
+	} {
+		_, err := LoadPlatformRegistry(writePlatformRegistry(t, content))
+		assert.ErrorContains(t, err, message)
+	}
+
+	registry, err := LoadPlatformRegistry(writePlatformRegistry(t, "platforms:\n  - name: host\n    image: -self-hosted\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "-self-hosted", registry.Match([]string{"host"}).Image)
+}
+
+func TestRunContextPlatformRegistry(t *testing.T) {
+	ctx := context.Background()
+	registry, err := LoadPlatformRegistry(writePlatformRegistry(t, testPlatformRegistry))
+	assert.NoError(t, err)
+
+	rc := createIfTestRunContext(map[string]*model.Job{
+		"job1": createJob(t, "runs-on:\n  group: gpu-runners\n  labels: [gpu]", ""),
+	})
+	rc.Config.PlatformRegistry = registry
+	assert.Equal(t, "nvidia/cuda:12.4.1-base-ubuntu22.04", rc.runsOnImage(ctx))
+
+	// the registry takes precedence over the platforms matched by a single label
+	rc = createIfTestRunContext(map[string]*model.Job{
+		"job1": createJob(t, `runs-on: [self-hosted, linux, gpu-less, x64]`, ""),
+	})
+	rc.Config.Platforms["self-hosted"] = "node:20"
+	rc.Config.PlatformRegistry = registry
+	assert.Equal(t, "catthehacker/ubuntu:act-22.04", rc.runsOnImage(ctx))
+
+	rc.Config.PlatformRegistry = nil
+	assert.Equal(t, "node:20", rc.runsOnImage(ctx))
+}
+
+func TestRunContextContainerArchitecture(t *testing.T) {
+	ctx := context.Background()
+	registry, err := LoadPlatformRegistry(writePlatformRegistry(t, testPlatformRegistry))
+	assert.NoError(t, err)
+
+	rc := createIfTestRunContext(map[string]*model.Job{
+		"job1": createJob(t, `runs-on: ubuntu-latest`, ""),
+	})
+	assert.Empty(t, rc.containerArchitecture(ctx))
+
+	// the arch of the runner of the registry
+	rc.Config.PlatformRegistry = registry
+	assert.Equal(t, "linux/arm64", rc.containerArchitecture(ctx))
+	assert.Equal(t, "ARM64", rc.runnerArch(ctx))
+
+	// --container-architecture takes precedence, and may refer to the matrix
+	rc.Config.ContainerArchitecture = "${{ matrix.platform }}"
+	rc.Matrix = map[string]interface{}{"platform": "linux/386"}
+	rc.ExprEval = rc.NewExpressionEvaluator(ctx)
+	assert.Equal(t, "linux/386", rc.containerArchitecture(ctx))
+	assert.Equal(t, "X86", rc.runnerArch(ctx))
+
+	// a combination without the variable falls back to the registry
+	rc.Matrix = map[string]interface{}{}
+	rc.ExprEval = rc.NewExpressionEvaluator(ctx)
+	assert.Equal(t, "linux/arm64", rc.containerArchitecture(ctx))
+}
+
+func TestImageDigests(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "act", "image-digests.json")
+	digests, err := LoadImageDigests(path)
+	assert.NoError(t, err)
+	assert.Empty(t, digests.Get("node:20"))
+
+	previous, err := digests.record("node:20", "sha256:aaa")
+	assert.NoError(t, err)
+	assert.Empty(t, previous)
+
+	digests, err = LoadImageDigests(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "sha256:aaa", digests.Get("node:20"))
+	previous, err = digests.record("node:20", "sha256:bbb")
+	assert.NoError(t, err)
+	assert.Equal(t, "sha256:aaa", previous)
+	assert.Equal(t, "sha256:bbb", digests.Get("node:20"))
+}
@@ -0,0 +1,289 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nektos/act/pkg/jobparser"
+	"github.com/nektos/act/pkg/model"
+)
+
+// reportLogTailLines is the number of lines at the end of the log of a failed step that are kept in the report
+const reportLogTailLines = 50
+
+// Report collects the results and timings of the workflows, jobs and steps of a run for the test reports
+type Report struct {
+	Workflows []*WorkflowReport `json:"workflows"`
+
+	mu sync.Mutex
+}
+
+// WorkflowReport is the report of a workflow, a test suite in JUnit
+type WorkflowReport struct {
+	Name string       `json:"name"`
+	File string       `json:"file"`
+	Jobs []*JobReport `json:"jobs"`
+}
+
+// JobReport is the report of a job or a combination of its matrix, a test case in JUnit
+type JobReport struct {
+	ID         string                 `json:"id"`
+	Name       string                 `json:"name"`
+	Matrix     map[string]interface{} `json:"matrix,omitempty"`
+	Result     string                 `json:"result"`
+	Error      string                 `json:"error,omitempty"`
+	StartedAt  time.Time              `json:"started_at"`
+	FinishedAt time.Time              `json:"finished_at"`
+	Steps      []*StepReport          `json:"steps,omitempty"`
+}
+
+// StepReport is the report of a main step, a failure of its test case in JUnit when it has failed
+type StepReport struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	Outcome    string    `json:"outcome"`
+	Conclusion string    `json:"conclusion"`
+	Error      string    `json:"error,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	LogTail    []string  `json:"log_tail,omitempty"` // the last lines of the output of a failed step
+}
+
+// NewReport creates an empty report, to be set in Config.Report
+func NewReport() *Report {
+	return &Report{Workflows: []*WorkflowReport{}}
+}
+
+func (r *Report) addJob(workflow *model.Workflow, job *JobReport) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, w := range r.Workflows {
+		if w.File == workflow.File && w.Name == workflow.Name {
+			w.Jobs = append(w.Jobs, job)
+			return
+		}
+	}
+	r.Workflows = append(r.Workflows, &WorkflowReport{
+		Name: workflow.Name,
+		File: workflow.File,
+		Jobs: []*JobReport{job},
+	})
+}
+
+// WriteJSON writes the report as JSON
+func (r *Report) WriteJSON(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr,omitempty"`
+	File      string          `xml:"file,attr,omitempty"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string         `xml:"name,attr"`
+	Classname string         `xml:"classname,attr"`
+	Time      string         `xml:"time,attr"`
+	Skipped   *struct{}      `xml:"skipped"`
+	Failures  []junitFailure `xml:"failure"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func junitTime(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+// WriteJUnit writes the report as JUnit XML, with a test suite per workflow and a test case per job
+func (r *Report) WriteJUnit(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	suites := junitTestSuites{Suites: []junitTestSuite{}}
+	var total time.Duration
+	for _, workflow := range r.Workflows {
+		suite := junitTestSuite{
+			Name:  workflow.Name,
+			File:  workflow.File,
+			Cases: []junitTestCase{},
+		}
+		var first, last time.Time
+		for _, job := range workflow.Jobs {
+			if first.IsZero() || job.StartedAt.Before(first) {
+				first = job.StartedAt
+			}
+			if job.FinishedAt.After(last) {
+				last = job.FinishedAt
+			}
+			testCase := junitTestCase{
+				Name:      job.Name,
+				Classname: workflow.Name,
+				Time:      junitTime(job.FinishedAt.Sub(job.StartedAt)),
+			}
+			switch job.Result {
+			case "skipped":
+				testCase.Skipped = &struct{}{}
+				suite.Skipped++
+			case "failure":
+				for _, step := range job.Steps {
+					if step.Conclusion != model.StepStatusFailure.String() {
+						continue
+					}
+					message := fmt.Sprintf("Step '%s' failed", step.Name)
+					if step.Error != "" {
+						message += ": " + step.Error
+					}
+					testCase.Failures = append(testCase.Failures, junitFailure{
+						Message: message,
+						Type:    "step",
+						Text:    strings.Join(step.LogTail, "\n"),
+					})
+				}
+				if len(testCase.Failures) == 0 {
+					// the job has failed outside of its steps, e.g. while starting its container
+					testCase.Failures = append(testCase.Failures, junitFailure{
+						Message: fmt.Sprintf("Job '%s' failed", job.Name),
+						Type:    "job",
+						Text:    job.Error,
+					})
+				}
+				suite.Failures++
+			}
+			suite.Cases = append(suite.Cases, testCase)
+		}
+		suite.Tests = len(suite.Cases)
+		suite.Time = junitTime(last.Sub(first))
+		if !first.IsZero() {
+			suite.Timestamp = first.Format("2006-01-02T15:04:05")
+		}
+		total += last.Sub(first)
+
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Skipped += suite.Skipped
+		suites.Suites = append(suites.Suites, suite)
+	}
+	suites.Time = junitTime(total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(suites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// nameWithMatrix returns the name of the job suffixed with the values of its matrix combination, like GitHub names matrix jobs,
+// unless the name of the job refers to the matrix
+func (rc *RunContext) nameWithMatrix() string {
+	name := rc.JobName
+	if name == "" {
+		name = rc.Name
+	}
+	if len(rc.Matrix) == 0 || strings.Contains(rc.Run.Job().Name, "${{") {
+		return name
+	}
+	return name + " " + jobparser.MatrixName(rc.Matrix)
+}
+
+// reportJob adds the job and the reports of its steps to Config.Report
+func (rc *RunContext) reportJob(ctx context.Context, result string, startedAt time.Time, err error) {
+	report := rc.Config.Report
+	if report == nil || rc.Run == nil {
+		return
+	}
+	job := &JobReport{
+		ID:         rc.Run.JobID,
+		Name:       rc.nameWithMatrix(),
+		Matrix:     rc.Matrix,
+		Result:     result,
+		StartedAt:  startedAt,
+		FinishedAt: time.Now(),
+		Steps:      rc.stepReports,
+	}
+	if err != nil {
+		job.Error = maskValue(ctx, rc.Config, err.Error())
+	}
+	rc.stepReports = nil
+	report.addJob(rc.Run.Workflow, job)
+}
+
+// startStepReport starts the report of a main step, the lines of its output are kept until it finishes
+func (rc *RunContext) startStepReport(ctx context.Context, stepModel *model.Step) *StepReport {
+	if rc.Config.Report == nil {
+		return nil
+	}
+	step := &StepReport{
+		ID:        stepModel.ID,
+		Name:      rc.ExprEval.Interpolate(ctx, stepModel.String()),
+		StartedAt: time.Now(),
+	}
+	rc.stepReports = append(rc.stepReports, step)
+	return step
+}
+
+// finishStepReport records the result of the step, and keeps the tail of its output if it has failed
+func (rc *RunContext) finishStepReport(ctx context.Context, stepModel *model.Step, err error) {
+	if rc.Config.Report == nil || len(rc.stepReports) == 0 {
+		return
+	}
+	step := rc.stepReports[len(rc.stepReports)-1]
+	if step.ID != stepModel.ID {
+		return
+	}
+	step.FinishedAt = time.Now()
+	step.Outcome = model.StepStatusFailure.String()
+	step.Conclusion = model.StepStatusFailure.String()
+	if result, ok := rc.StepResults[stepModel.ID]; ok {
+		step.Outcome = result.Outcome.String()
+		step.Conclusion = result.Conclusion.String()
+	}
+	if err != nil {
+		step.Error = maskValue(ctx, rc.Config, err.Error())
+	}
+	if step.Conclusion != model.StepStatusFailure.String() {
+		step.LogTail = nil
+	}
+}
+
+// addLogLine keeps a line of the output of the step, masked like in the log
+func (s *StepReport) addLogLine(ctx context.Context, config *Config, line string) {
+	line = maskValue(ctx, config, strings.TrimRight(line, "\r\n"))
+	s.LogTail = append(s.LogTail, line)
+	if len(s.LogTail) > reportLogTailLines {
+		s.LogTail = s.LogTail[len(s.LogTail)-reportLogTailLines:]
+	}
+}
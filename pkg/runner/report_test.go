@@ -0,0 +1,114 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nektos/act/pkg/model"
+)
+
+func TestReport(t *testing.T) {
+	report := NewReport()
+	workflow, err := model.ReadWorkflow(strings.NewReader(`
+name: CI
+on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    strategy:
+      matrix:
+        os: [linux, windows]
+        go: ["1.21"]
+    steps:
+      - id: compile
+        run: make
+  lint:
+    name: Lint ${{ matrix.linter }}
+    runs-on: ubuntu-latest
+    strategy:
+      matrix:
+        linter: [vet]
+    steps:
+      - run: make lint
+  release:
+    runs-on: ubuntu-latest
+    steps:
+      - run: make release
+`))
+	assert.NoError(t, err)
+	workflow.File = "ci.yml"
+	config := &Config{Report: report, Secrets: map[string]string{"TOKEN": "s3cr3t"}}
+
+	runJob := func(jobID, name string, matrix map[string]interface{}, steps map[string]model.StepResult, result string, jobErr error) {
+		rc := &RunContext{
+			Name:        name,
+			JobName:     name,
+			Config:      config,
+			Matrix:      matrix,
+			Run:         &model.Run{Workflow: workflow, JobID: jobID},
+			StepResults: map[string]*model.StepResult{},
+			ExprEval:    &expressionEvaluator{},
+		}
+		startedAt := time.Now()
+		ctx := WithMasks(context.Background(), &[]string{"masked"})
+		for id, stepResult := range steps {
+			stepResult := stepResult
+			stepModel := &model.Step{ID: id, Run: "make " + id}
+			step := rc.startStepReport(ctx, stepModel)
+			for i := 1; i <= reportLogTailLines+5; i++ {
+				step.addLogLine(ctx, config, fmt.Sprintf("line %d of s3cr3t and masked\n", i))
+			}
+			rc.StepResults[id] = &stepResult
+			var stepErr error
+			if stepResult.Conclusion == model.StepStatusFailure {
+				stepErr = errors.New("exitcode '2': failure")
+			}
+			rc.finishStepReport(ctx, stepModel, stepErr)
+		}
+		rc.reportJob(ctx, result, startedAt, jobErr)
+	}
+
+	success := model.StepResult{Conclusion: model.StepStatusSuccess, Outcome: model.StepStatusSuccess}
+	failure := model.StepResult{Conclusion: model.StepStatusFailure, Outcome: model.StepStatusFailure}
+	runJob("build", "build", map[string]interface{}{"os": "linux", "go": "1.21"}, map[string]model.StepResult{"compile": success}, "success", nil)
+	runJob("build", "build", map[string]interface{}{"os": "windows", "go": "1.21"}, map[string]model.StepResult{"compile": failure}, "failure", errors.New("Job 'build' failed"))
+	runJob("lint", "Lint vet", map[string]interface{}{"linter": "vet"}, nil, "skipped", nil)
+	runJob("release", "release", nil, nil, "failure", errors.New("failed to start container"))
+
+	assert.Len(t, report.Workflows, 1)
+	jobs := report.Workflows[0].Jobs
+	assert.Len(t, jobs, 4)
+	assert.Equal(t, "build (1.21, linux)", jobs[0].Name)
+	assert.Equal(t, "build (1.21, windows)", jobs[1].Name)
+	assert.Equal(t, "Lint vet", jobs[2].Name)
+	assert.Equal(t, "release", jobs[3].Name)
+	assert.Nil(t, jobs[0].Steps[0].LogTail)
+	tail := jobs[1].Steps[0].LogTail
+	assert.Len(t, tail, reportLogTailLines)
+	assert.Equal(t, "line 6 of *** and ***", tail[0])
+
+	junit := &bytes.Buffer{}
+	assert.NoError(t, report.WriteJUnit(junit))
+	assert.Contains(t, junit.String(), `<testsuites tests="4" failures="2" skipped="1"`)
+	assert.Contains(t, junit.String(), `<testsuite name="CI" tests="4" failures="2" skipped="1"`)
+	assert.Contains(t, junit.String(), `<testcase name="build (1.21, windows)" classname="CI"`)
+	assert.Contains(t, junit.String(), `<failure message="Step &#39;make compile&#39; failed: exitcode &#39;2&#39;: failure" type="step">line 6 of *** and ***`)
+	assert.Contains(t, junit.String(), `<failure message="Job &#39;release&#39; failed" type="job">failed to start container</failure>`)
+	assert.Contains(t, junit.String(), `<skipped></skipped>`)
+
+	content := &bytes.Buffer{}
+	assert.NoError(t, report.WriteJSON(content))
+	decoded := &Report{}
+	assert.NoError(t, json.Unmarshal(content.Bytes(), decoded))
+	assert.Equal(t, "ci.yml", decoded.Workflows[0].File)
+	assert.Equal(t, "failure", decoded.Workflows[0].Jobs[1].Result)
+	assert.Equal(t, "failure", decoded.Workflows[0].Jobs[1].Steps[0].Conclusion)
+}
@@ -85,13 +85,15 @@ func newRemoteReusableWorkflowExecutor(rc *RunContext) common.Executor {
 
 func newActionCacheReusableWorkflowExecutor(rc *RunContext, filename string, remoteReusableWorkflow *remoteReusableWorkflow) common.Executor {
 	return func(ctx context.Context) error {
-		ghctx := rc.getGithubContext(ctx)
-		remoteReusableWorkflow.URL = ghctx.ServerURL
-		sha, err := rc.Config.ActionCache.Fetch(ctx, filename, remoteReusableWorkflow.CloneURL(), remoteReusableWorkflow.Ref, ghctx.Token)
+		// resolve the repository the same way as cloneIfRequired, so that both cache modes fetch from the same place
+		cloneURL := rc.NewExpressionEvaluator(ctx).Interpolate(ctx, remoteReusableWorkflow.CloneURL())
+		token := getGitCloneToken(rc.Config, remoteReusableWorkflow.CloneURL())
+		sha, err := rc.Config.ActionCache.Fetch(ctx, filename, cloneURL, remoteReusableWorkflow.Ref, token)
 		if err != nil {
 			return err
 		}
-		archive, err := rc.Config.ActionCache.GetTarArchive(ctx, filename, sha, fmt.Sprintf(".github/workflows/%s", remoteReusableWorkflow.Filename))
+		// ./.gitea/workflows/wf.yml -> .gitea/workflows/wf.yml
+		archive, err := rc.Config.ActionCache.GetTarArchive(ctx, filename, sha, strings.TrimPrefix(remoteReusableWorkflow.FilePath(), "./"))
 		if err != nil {
 			return err
 		}
@@ -109,12 +111,7 @@ func newActionCacheReusableWorkflowExecutor(rc *RunContext, filename string, rem
 			return err
 		}
 
-		runner, err := NewReusableWorkflowRunner(rc)
-		if err != nil {
-			return err
-		}
-
-		return runner.NewPlanExecutor(plan)(ctx)
+		return newReusableWorkflowPlanExecutor(rc, plan)(ctx)
 	}
 }
 
@@ -160,16 +157,136 @@ func newReusableWorkflowExecutor(rc *RunContext, directory string, workflow stri
 			return err
 		}
 
+		return newReusableWorkflowPlanExecutor(rc, plan)(ctx)
+	}
+}
+
+// maxReusableWorkflowDepth is the number of workflow levels which can be connected,
+// the top-level caller workflow included
+// https://docs.github.com/en/actions/using-workflows/reusing-workflows#nesting-reusable-workflows
+const maxReusableWorkflowDepth = 4
+
+// reusableWorkflowDepth returns the level of the workflow the job belongs to, the top-level workflow is level 1
+func reusableWorkflowDepth(rc *RunContext) int {
+	depth := 1
+	for c := rc.caller; c != nil; c = c.runContext.caller {
+		depth++
+	}
+	return depth
+}
+
+// newReusableWorkflowPlanExecutor validates the call of a reusable workflow against its `on.workflow_call`
+// definition, runs its plan and maps the declared outputs to the calling job
+func newReusableWorkflowPlanExecutor(rc *RunContext, plan *model.Plan) common.Executor {
+	return func(ctx context.Context) error {
+		err := validateReusableWorkflowCall(ctx, rc, plan)
+		if err != nil {
+			rc.result("failure")
+			if rc.caller != nil {
+				rc.caller.setReusedWorkflowJobResult(rc.JobName, "failure") // For Gitea
+			}
+			return err
+		}
+
 		runner, err := NewReusableWorkflowRunner(rc)
 		if err != nil {
 			return err
 		}
 
-		// return runner.NewPlanExecutor(plan)(ctx)
-		return common.NewPipelineExecutor( // For Gitea
-			runner.NewPlanExecutor(plan),
-			setReusedWorkflowCallerResult(rc, runner),
-		)(ctx)
+		return runner.NewPlanExecutor(plan).
+			Finally(setReusedWorkflowCallerOutputs(rc, runner, plan)).
+			Finally(setReusedWorkflowCallerResult(rc, runner))(ctx) // For Gitea
+	}
+}
+
+func validateReusableWorkflowCall(ctx context.Context, rc *RunContext, plan *model.Plan) error {
+	uses := rc.Run.Job().Uses
+	if reusableWorkflowDepth(rc) >= maxReusableWorkflowDepth {
+		return fmt.Errorf("reusable workflow '%s' exceeds the maximum of %d nested workflow levels", uses, maxReusableWorkflowDepth)
+	}
+
+	workflow := plan.Workflow()
+	if workflow == nil {
+		return fmt.Errorf("workflow '%s' is not reusable as it is missing a `on.workflow_call` trigger", uses)
+	}
+	config := workflow.WorkflowCallConfig()
+
+	job := rc.Run.Job()
+	for name := range job.With {
+		if _, ok := config.Inputs[name]; !ok {
+			return fmt.Errorf("invalid input, '%s' is not defined in the referenced workflow '%s'", name, uses)
+		}
+	}
+	for name, input := range config.Inputs {
+		value, ok := job.With[name]
+		if !ok || value == nil {
+			if input.Required {
+				return fmt.Errorf("input '%s' is required, but not provided while calling '%s'", name, uses)
+			}
+			continue
+		}
+		if str, ok := value.(string); ok {
+			value = rc.ExprEval.Interpolate(ctx, str)
+		}
+		if _, err := convertWorkflowCallInput(name, input, value); err != nil {
+			return fmt.Errorf("invalid input while calling '%s': %w", uses, err)
+		}
+	}
+
+	if job.InheritSecrets() {
+		return nil
+	}
+	secrets := job.Secrets()
+	for name := range secrets {
+		if _, ok := lookupWorkflowCallSecret(config.Secrets, name); !ok {
+			common.Logger(ctx).Warnf("secret '%s' is not defined in the referenced workflow '%s' and will not be passed", name, uses)
+		}
+	}
+	for name, secret := range config.Secrets {
+		if !secret.Required {
+			continue
+		}
+		found := false
+		for k := range secrets {
+			if strings.EqualFold(k, name) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("secret '%s' is required, but not provided while calling '%s'", name, uses)
+		}
+	}
+
+	return nil
+}
+
+// setReusedWorkflowCallerOutputs maps `on.workflow_call.outputs` to the outputs of the calling job,
+// once all jobs of the reusable workflow are done
+func setReusedWorkflowCallerOutputs(rc *RunContext, runner Runner, plan *model.Plan) common.Executor {
+	return func(ctx context.Context) error {
+		runnerImpl, ok := runner.(*runnerImpl)
+		if !ok {
+			return nil
+		}
+		workflow := plan.Workflow()
+		if workflow == nil {
+			return nil
+		}
+
+		calledRc := runnerImpl.caller.getCalledRunContext()
+		if calledRc == nil {
+			return nil
+		}
+		ee := calledRc.NewExpressionEvaluator(ctx)
+
+		outputs := make(map[string]string)
+		for k, v := range workflow.WorkflowCallConfig().Outputs {
+			outputs[k] = ee.Interpolate(ctx, ee.Interpolate(ctx, v.Value))
+		}
+		rc.Run.Job().Outputs = outputs
+
+		return nil
 	}
 }
 
@@ -299,6 +416,7 @@ func setReusedWorkflowCallerResult(rc *RunContext, runner Runner) common.Executo
 			}
 
 			if rc.caller != nil {
+				rc.result(reusedWorkflowJobResult)
 				rc.caller.setReusedWorkflowJobResult(rc.JobName, reusedWorkflowJobResult)
 			} else {
 				rc.result(reusedWorkflowJobResult)
@@ -0,0 +1,95 @@
+package runner
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/nektos/act/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+type reusableWorkflowActionCacheMock struct {
+	mu        sync.Mutex
+	urls      []string
+	workflows map[string]string
+}
+
+func (c *reusableWorkflowActionCacheMock) Fetch(_ context.Context, _, url, _, _ string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.urls = append(c.urls, url)
+	return "sha", nil
+}
+
+func (c *reusableWorkflowActionCacheMock) GetTarArchive(_ context.Context, _, _, includePrefix string) (io.ReadCloser, error) {
+	content, ok := c.workflows[includePrefix]
+	if !ok {
+		return nil, fmt.Errorf("%s not found", includePrefix)
+	}
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+	if err := tw.WriteHeader(&tar.Header{Name: "", Mode: 0o644, Size: int64(len(content))}); err != nil {
+		return nil, err
+	}
+	if _, err := tw.Write([]byte(content)); err != nil {
+		return nil, err
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	return io.NopCloser(buf), nil
+}
+
+func TestActionCacheReusableWorkflow(t *testing.T) {
+	reusable := `
+on: workflow_call
+jobs:
+  skipped:
+    if: false
+    runs-on: ubuntu-latest
+    steps:
+      - run: exit 1
+`
+	cache := &reusableWorkflowActionCacheMock{
+		workflows: map[string]string{
+			".gitea/workflows/reusable.yml":         reusable,
+			".allspice/workflows/reusable.yml":      reusable,
+			".github/workflows/nested/reusable.yml": reusable,
+		},
+	}
+
+	workdir, err := filepath.Abs(workdir)
+	assert.Nil(t, err)
+
+	runner, err := New(&Config{
+		Workdir:        workdir,
+		EventName:      "push",
+		Platforms:      platforms,
+		GitHubInstance: "https://gitea.example.com",
+		ActionCache:    cache,
+	})
+	assert.Nil(t, err)
+
+	planner, err := model.NewWorkflowPlanner(filepath.Join(workdir, "reusable-workflow-action-cache"), true)
+	assert.Nil(t, err)
+
+	plan, err := planner.PlanEvent("push")
+	assert.Nil(t, err)
+
+	err = runner.NewPlanExecutor(plan)(context.Background())
+	assert.Nil(t, err)
+
+	sort.Strings(cache.urls)
+	assert.Equal(t, []string{
+		"https://example.com/owner/repo",
+		"https://gitea.example.com/owner/repo",
+		"https://gitea.example.com/owner/repo",
+	}, cache.urls)
+}
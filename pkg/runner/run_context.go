@@ -19,6 +19,7 @@ import (
 	"time"
 
 	"github.com/docker/go-connections/nat"
+	"github.com/kballard/go-shellquote"
 	"github.com/opencontainers/selinux/go-selinux"
 
 	"github.com/nektos/act/pkg/common"
@@ -50,6 +51,12 @@ type RunContext struct {
 	Masks               []string
 	cleanUpJobContainer common.Executor
 	caller              *caller // job calling this RunContext (reusable workflows)
+	stepReports         []*StepReport
+	resourceUsage       container.ResourceUsage // the usage of the resources of the steps of the job so far
+	stepMetrics         []*StepMetrics
+	serviceStartups     []*serviceStartup
+	composeVolumes      []string // the named volumes of the docker-compose.yml of the job
+	warmSnapshot        *warmSnapshot
 }
 
 func (rc *RunContext) AddMask(mask string) {
@@ -104,7 +111,7 @@ func (rc *RunContext) jobContainerName() string {
 // networkName return the name of the network which will be created by `act` automatically for job,
 // only create network if using a service container
 func (rc *RunContext) networkName() (string, bool) {
-	if len(rc.Run.Job().Services) > 0 {
+	if rc.hasServices() {
 		return fmt.Sprintf("%s-%s-network", rc.jobContainerName(), rc.Run.JobID), true
 	}
 	if rc.Config.ContainerNetworkMode == "" {
@@ -113,9 +120,10 @@ func (rc *RunContext) networkName() (string, bool) {
 	return string(rc.Config.ContainerNetworkMode), false
 }
 
-// networkNameForGitea return the name of the network
+// networkNameForGitea return the name of the network,
+// a job with a Docker-in-Docker sidecar has a network of its own, so that only the job reaches the sidecar
 func (rc *RunContext) networkNameForGitea() (string, bool) {
-	if rc.Config.ContainerNetworkMode != "" {
+	if rc.Config.ContainerNetworkMode != "" && !rc.dockerIsolated() {
 		return string(rc.Config.ContainerNetworkMode), false
 	}
 	return fmt.Sprintf("%s-%s-network", rc.jobContainerName(), rc.Run.JobID), true
@@ -139,17 +147,28 @@ func getDockerDaemonSocketMountPath(daemonPath string) string {
 	return daemonPath
 }
 
+// daemonSocket returns the daemon socket to mount in the containers, the default one of the container engine if not configured.
+// The config is shared by the jobs of the run and isn't updated.
+func (rc *RunContext) daemonSocket() string {
+	if rc.Config.ContainerDaemonSocket != "" {
+		return rc.Config.ContainerDaemonSocket
+	}
+	if rc.Config.ContainerEngine == container.EnginePodman {
+		if socketHost, err := container.GetPodmanSocketAndHost(""); err == nil {
+			return socketHost.Socket
+		}
+	}
+	return "/var/run/docker.sock"
+}
+
 // Returns the binds and mounts for the container, resolving paths as appopriate
 func (rc *RunContext) GetBindsAndMounts() ([]string, map[string]string) {
 	name := rc.jobContainerName()
 
-	if rc.Config.ContainerDaemonSocket == "" {
-		rc.Config.ContainerDaemonSocket = "/var/run/docker.sock"
-	}
-
+	daemonSocket := rc.daemonSocket()
 	binds := []string{}
-	if rc.Config.ContainerDaemonSocket != "-" {
-		daemonPath := getDockerDaemonSocketMountPath(rc.Config.ContainerDaemonSocket)
+	if daemonSocket != "-" && !rc.dockerIsolated() {
+		daemonPath := getDockerDaemonSocketMountPath(daemonSocket)
 		binds = append(binds, fmt.Sprintf("%s:%s", daemonPath, "/var/run/docker.sock"))
 	}
 
@@ -163,6 +182,7 @@ func (rc *RunContext) GetBindsAndMounts() ([]string, map[string]string) {
 	if job := rc.Run.Job(); job != nil {
 		if container := job.Container(); container != nil {
 			for _, v := range container.Volumes {
+				v = rc.jobVolume(v)
 				if !strings.Contains(v, ":") || filepath.IsAbs(v) {
 					// Bind anonymous volume or host file.
 					binds = append(binds, v)
@@ -193,8 +213,13 @@ func (rc *RunContext) GetBindsAndMounts() ([]string, map[string]string) {
 	rc.Config.ValidVolumes = append(rc.Config.ValidVolumes, "act-toolcache")
 	rc.Config.ValidVolumes = append(rc.Config.ValidVolumes, name)
 	rc.Config.ValidVolumes = append(rc.Config.ValidVolumes, name+"-env")
+	for _, volume := range rc.composeVolumes {
+		rc.Config.ValidVolumes = append(rc.Config.ValidVolumes, rc.composeVolumeName(volume))
+	}
 	// TODO: add a new configuration to control whether the docker daemon can be mounted
-	rc.Config.ValidVolumes = append(rc.Config.ValidVolumes, getDockerDaemonSocketMountPath(rc.Config.ContainerDaemonSocket))
+	if !rc.dockerIsolated() {
+		rc.Config.ValidVolumes = append(rc.Config.ValidVolumes, getDockerDaemonSocketMountPath(daemonSocket))
+	}
 
 	return binds, mounts
 }
@@ -228,7 +253,7 @@ func (rc *RunContext) startHostEnvironment() common.Executor {
 			return err
 		}
 		toolCache := filepath.Join(cacheDir, "tool_cache")
-		rc.JobContainer = &container.HostEnvironment{
+		hostEnvironment := &container.HostEnvironment{
 			Path:      path,
 			TmpDir:    runnerTmp,
 			ToolCache: toolCache,
@@ -239,6 +264,16 @@ func (rc *RunContext) startHostEnvironment() common.Executor {
 			},
 			StdOut: logWriter,
 		}
+		rc.JobContainer = hostEnvironment
+		if rc.IsSandboxEnv(ctx) {
+			// the remote actions run from the cache and the local actions from the working directory, both read-only
+			sandbox, err := container.NewSandboxEnvironment(hostEnvironment, rc.Config.SandboxNetwork, cacheDir, rc.Config.Workdir)
+			if err != nil {
+				os.RemoveAll(miscpath)
+				return err
+			}
+			rc.JobContainer = sandbox
+		}
 		rc.cleanUpJobContainer = rc.JobContainer.Remove()
 		for k, v := range rc.JobContainer.GetRunnerContext(ctx) {
 			if v, ok := v.(string); ok {
@@ -288,6 +323,12 @@ func (rc *RunContext) startJobContainer() common.Executor {
 			return fmt.Errorf("failed to handle credentials: %s", err)
 		}
 
+		services, composeVolumes, err := rc.jobServices(ctx)
+		if err != nil {
+			return err
+		}
+		rc.composeVolumes = composeVolumes
+
 		logger.Infof("\U0001f680  Start image=%s", image)
 		name := rc.jobContainerName()
 		// For gitea, to support --volumes-from <container_name_or_id> in options.
@@ -298,7 +339,7 @@ func (rc *RunContext) startJobContainer() common.Executor {
 
 		envList = append(envList, fmt.Sprintf("%s=%s", "RUNNER_TOOL_CACHE", "/opt/hostedtoolcache"))
 		envList = append(envList, fmt.Sprintf("%s=%s", "RUNNER_OS", "Linux"))
-		envList = append(envList, fmt.Sprintf("%s=%s", "RUNNER_ARCH", container.RunnerArch(ctx)))
+		envList = append(envList, fmt.Sprintf("%s=%s", "RUNNER_ARCH", rc.runnerArch(ctx)))
 		envList = append(envList, fmt.Sprintf("%s=%s", "RUNNER_TEMP", "/tmp"))
 		envList = append(envList, fmt.Sprintf("%s=%s", "LANG", "C.UTF-8")) // Use same locale as GitHub Actions
 
@@ -309,9 +350,16 @@ func (rc *RunContext) startJobContainer() common.Executor {
 		// if using service containers, will create a new network for the containers.
 		// and it will be removed after at last.
 		networkName, createAndDeleteNetwork := rc.networkNameForGitea()
+		// with podman, the containers of the job join a pod instead of the network
+		usePod := createAndDeleteNetwork && rc.Config.ContainerEngine == container.EnginePodman
+		containerNetwork, containerPod := networkName, ""
+		pod := container.PodmanPodInput{Name: networkName, PortBindings: nat.PortMap{}}
+		if usePod {
+			containerNetwork, containerPod = "", networkName
+		}
 
 		// add service containers
-		for serviceID, spec := range rc.Run.Job().Services {
+		for serviceID, spec := range services {
 			// interpolate env
 			interpolatedEnvs := make(map[string]string, len(spec.Env))
 			for k, v := range spec.Env {
@@ -333,7 +381,7 @@ func (rc *RunContext) startJobContainer() common.Executor {
 
 			interpolatedVolumes := make([]string, 0, len(spec.Volumes))
 			for _, volume := range spec.Volumes {
-				interpolatedVolumes = append(interpolatedVolumes, rc.ExprEval.Interpolate(ctx, volume))
+				interpolatedVolumes = append(interpolatedVolumes, rc.jobVolume(rc.ExprEval.Interpolate(ctx, volume)))
 			}
 			serviceBinds, serviceMounts := rc.GetServiceBindsAndMounts(interpolatedVolumes)
 
@@ -346,14 +394,35 @@ func (rc *RunContext) startJobContainer() common.Executor {
 				return fmt.Errorf("failed to parse service %s ports: %w", serviceID, err)
 			}
 
+			var entrypoint []string
+			if spec.Entrypoint != "" {
+				if entrypoint, err = shellquote.Split(rc.ExprEval.Interpolate(ctx, spec.Entrypoint)); err != nil {
+					return fmt.Errorf("failed to parse service %s entrypoint: %w", serviceID, err)
+				}
+			}
+			aliases := []string{serviceID}
+			for _, alias := range spec.Aliases {
+				aliases = append(aliases, rc.ExprEval.Interpolate(ctx, alias))
+			}
+
+			if usePod {
+				// the ports are published by the pod, the services are reached on localhost by their ID and aliases
+				pod.Hosts = append(pod.Hosts, aliases...)
+				for port, bindings := range portBindings {
+					pod.PortBindings[port] = append(pod.PortBindings[port], bindings...)
+				}
+				exposedPorts, portBindings = nil, nil
+			}
+
 			serviceContainerName := createContainerName(rc.jobContainerName(), serviceID)
-			c := container.NewContainer(&container.NewContainerInput{
+			c := rc.newContainer(&container.NewContainerInput{
 				Name:           serviceContainerName,
 				WorkingDir:     ext.ToContainerPath(rc.Config.Workdir),
 				Image:          rc.ExprEval.Interpolate(ctx, spec.Image),
 				Username:       username,
 				Password:       password,
 				Cmd:            interpolatedCmd,
+				Entrypoint:     entrypoint,
 				Env:            envs,
 				Mounts:         serviceMounts,
 				Binds:          serviceBinds,
@@ -361,15 +430,27 @@ func (rc *RunContext) startJobContainer() common.Executor {
 				Stderr:         logWriter,
 				Privileged:     rc.Config.Privileged,
 				UsernsMode:     rc.Config.UsernsMode,
-				Platform:       rc.Config.ContainerArchitecture,
+				Platform:       rc.containerArchitecture(ctx),
 				AutoRemove:     rc.Config.AutoRemove,
 				Options:        rc.ExprEval.Interpolate(ctx, spec.Options),
-				NetworkMode:    networkName,
-				NetworkAliases: []string{serviceID},
+				Resources:      rc.resourceLimits(ctx),
+				NetworkMode:    containerNetwork,
+				NetworkAliases: aliases,
 				ExposedPorts:   exposedPorts,
 				PortBindings:   portBindings,
+				Pod:            containerPod,
 			})
 			rc.ServiceContainers = append(rc.ServiceContainers, c)
+			rc.serviceStartups = append(rc.serviceStartups, newServiceStartup(serviceID, serviceContainerName, c, spec))
+		}
+
+		// the containers of the job reach the Docker-in-Docker sidecar of the run instead of the host docker socket
+		if rc.dockerIsolated() {
+			if _, ok := services[dindServiceID]; ok {
+				return fmt.Errorf("service %s conflicts with the Docker-in-Docker sidecar of the run", dindServiceID)
+			}
+			rc.Env["DOCKER_HOST"] = dindHost
+			envList = append(envList, fmt.Sprintf("%s=%s", "DOCKER_HOST", dindHost))
 		}
 
 		rc.cleanUpJobContainer = func(ctx context.Context) error {
@@ -380,6 +461,7 @@ func (rc *RunContext) startJobContainer() common.Executor {
 			if rc.JobContainer != nil {
 				return rc.JobContainer.Remove().IfNot(reuseJobContainer).
 					Then(container.NewDockerVolumeRemoveExecutor(rc.jobContainerName(), false)).IfNot(reuseJobContainer).
+					Then(container.NewDockerVolumeRemoveExecutor(rc.jobContainerName()+"-upper", false)).IfNot(reuseJobContainer).
 					Then(container.NewDockerVolumeRemoveExecutor(rc.jobContainerName()+"-env", false)).IfNot(reuseJobContainer).
 					Then(func(ctx context.Context) error {
 						if len(rc.ServiceContainers) > 0 {
@@ -388,11 +470,28 @@ func (rc *RunContext) startJobContainer() common.Executor {
 								logger.Errorf("Error while cleaning services: %v", err)
 							}
 						}
+						for _, volume := range rc.composeVolumes {
+							if err := container.NewDockerVolumeRemoveExecutor(rc.composeVolumeName(volume), false)(ctx); err != nil {
+								logger.Errorf("Error while cleaning volume %s: %v", volume, err)
+							}
+						}
 						if createAndDeleteNetwork {
 							// clean network if it has been created by act
 							// if using service containers
 							// it means that the network to which containers are connecting is created by `act_runner`,
 							// so, we should remove the network at last.
+							if usePod {
+								logger.Infof("Cleaning up pod for job %s, and pod name is: %s", rc.JobName, networkName)
+								if err := container.NewPodmanPodRemoveExecutor(networkName)(ctx); err != nil {
+									logger.Errorf("Error while cleaning pod: %v", err)
+								}
+								return nil
+							}
+							if rc.dockerIsolated() {
+								if err := rc.leaveDindSidecar(networkName)(ctx); err != nil {
+									logger.Errorf("Error while disconnecting the Docker-in-Docker sidecar: %v", err)
+								}
+							}
 							logger.Infof("Cleaning up network for job %s, and network name is: %s", rc.JobName, networkName)
 							if err := container.NewDockerNetworkRemoveExecutor(networkName)(ctx); err != nil {
 								logger.Errorf("Error while cleaning network: %v", err)
@@ -412,9 +511,9 @@ func (rc *RunContext) startJobContainer() common.Executor {
 		}
 
 		// For Gitea, `jobContainerNetwork` should be the same as `networkName`
-		jobContainerNetwork = networkName
+		jobContainerNetwork = containerNetwork
 
-		rc.JobContainer = container.NewContainer(&container.NewContainerInput{
+		jobContainerInput := &container.NewContainerInput{
 			Cmd:            nil,
 			Entrypoint:     []string{"/bin/sleep", fmt.Sprint(rc.Config.ContainerMaxLifetime.Round(time.Second).Seconds())},
 			WorkingDir:     ext.ToContainerPath(rc.Config.Workdir),
@@ -431,20 +530,40 @@ func (rc *RunContext) startJobContainer() common.Executor {
 			Stderr:         logWriter,
 			Privileged:     rc.Config.Privileged,
 			UsernsMode:     rc.Config.UsernsMode,
-			Platform:       rc.Config.ContainerArchitecture,
+			Platform:       rc.containerArchitecture(ctx),
 			Options:        rc.options(ctx),
+			Resources:      rc.resourceLimits(ctx),
 			AutoRemove:     rc.Config.AutoRemove,
 			ValidVolumes:   rc.Config.ValidVolumes,
-		})
+			WorkspaceSync:  rc.Config.WorkspaceSnapshot,
+			Pod:            containerPod,
+		}
+		jobContainerInput.Definition = rc.jobDefinition(jobContainerInput, rc.Config.ContainerCapAdd, rc.Config.ContainerCapDrop, services)
+		rc.JobContainer = rc.newContainer(jobContainerInput)
 		if rc.JobContainer == nil {
 			return errors.New("Failed to create job container")
 		}
 
 		return common.NewPipelineExecutor(
+			rc.checkEmulation(rc.containerArchitecture(ctx)).IfNot(common.Dryrun),
 			rc.pullServicesImages(rc.Config.ForcePull),
 			rc.JobContainer.Pull(rc.Config.ForcePull),
+			rc.checkImageDigest(image).IfNot(common.Dryrun),
+			rc.startFromWarmSnapshot(jobContainerInput).IfNot(common.Dryrun),
 			rc.stopJobContainer(),
-			container.NewDockerNetworkCreateExecutor(networkName).IfBool(createAndDeleteNetwork),
+			container.NewDockerNetworkCreateExecutor(networkName).IfBool(createAndDeleteNetwork && !usePod),
+			container.NewPodmanPodCreateExecutor(pod).IfBool(usePod).IfNot(common.Dryrun),
+			container.NewWorkspaceVolumeExecutor(container.WorkspaceVolumeInput{
+				Name:         name,
+				ActVolume:    name + "-env",
+				WorkingDir:   ext.ToContainerPath(rc.Config.Workdir),
+				Image:        image,
+				Platform:     rc.containerArchitecture(ctx),
+				Options:      rc.options(ctx),
+				SrcPath:      rc.Config.Workdir + string(filepath.Separator) + ".",
+				UseGitIgnore: rc.Config.UseGitIgnore,
+			}).IfBool(rc.Config.WorkspaceSnapshot && !rc.Config.BindWorkdir).IfNot(common.Dryrun),
+			rc.joinDindSidecar(networkName, rc.dindImages(ctx, image, services)).IfBool(rc.dockerIsolated()).IfNot(common.Dryrun),
 			rc.startServiceContainers(networkName),
 			rc.JobContainer.Create(rc.Config.ContainerCapAdd, rc.Config.ContainerCapDrop),
 			rc.JobContainer.Start(false),
@@ -461,6 +580,14 @@ func (rc *RunContext) startJobContainer() common.Executor {
 	}
 }
 
+// newContainer creates a reference to a container of the container engine of the config
+func (rc *RunContext) newContainer(input *container.NewContainerInput) container.ExecutionsEnvironment {
+	if rc.Config.ContainerEngine == container.EnginePodman {
+		return container.NewPodmanContainer(input)
+	}
+	return ContainerNewContainer(input)
+}
+
 func (rc *RunContext) execJobContainer(cmd []string, env map[string]string, user, workdir string) common.Executor {
 	return func(ctx context.Context) error {
 		return rc.JobContainer.Exec(cmd, env, user, workdir)(ctx)
@@ -543,13 +670,15 @@ func (rc *RunContext) pullServicesImages(forcePull bool) common.Executor {
 
 func (rc *RunContext) startServiceContainers(_ string) common.Executor {
 	return func(ctx context.Context) error {
+		// the services start together, each once the services it depends on meet their condition,
+		// and the job starts once all of them are ready
+		startups := make(map[string]*serviceStartup, len(rc.serviceStartups))
+		for _, s := range rc.serviceStartups {
+			startups[s.id] = s
+		}
 		execs := []common.Executor{}
-		for _, c := range rc.ServiceContainers {
-			execs = append(execs, common.NewPipelineExecutor(
-				c.Pull(false),
-				c.Create(rc.Config.ContainerCapAdd, rc.Config.ContainerCapDrop),
-				c.Start(false),
-			))
+		for _, s := range rc.serviceStartups {
+			execs = append(execs, s.start(rc, startups))
 		}
 		return common.NewParallelExecutor(len(execs), execs...)(ctx)
 	}
@@ -611,7 +740,23 @@ func (rc *RunContext) startContainer() common.Executor {
 func (rc *RunContext) IsHostEnv(ctx context.Context) bool {
 	platform := rc.runsOnImage(ctx)
 	image := rc.containerImage(ctx)
-	return image == "" && strings.EqualFold(platform, "-self-hosted")
+	return image == "" && (strings.EqualFold(platform, "-self-hosted") || strings.EqualFold(platform, "-sandbox"))
+}
+
+// IsSandboxEnv returns true if the job runs on the host in a sandbox, with the -sandbox platform
+func (rc *RunContext) IsSandboxEnv(ctx context.Context) bool {
+	return rc.IsHostEnv(ctx) && strings.EqualFold(rc.runsOnImage(ctx), "-sandbox")
+}
+
+// hostEnvironment returns the host environment of the job, sandboxed or not, or nil if the job runs in a container
+func (rc *RunContext) hostEnvironment() *container.HostEnvironment {
+	switch e := rc.JobContainer.(type) {
+	case *container.HostEnvironment:
+		return e
+	case *container.SandboxEnvironment:
+		return e.HostEnvironment
+	}
+	return nil
 }
 
 func (rc *RunContext) stopContainer() common.Executor {
@@ -656,14 +801,48 @@ func (rc *RunContext) Executor() (common.Executor, error) {
 	}
 
 	return func(ctx context.Context) error {
+		startedAt := time.Now()
+		if rc.restoreJobState(ctx) {
+			rc.reportJob(ctx, "success", startedAt, nil)
+			rc.emitJobEvent(ctx, Event{Type: EventJobFinished, Result: "success", Outputs: rc.Run.Job().Outputs})
+			return nil
+		}
 		res, err := rc.isEnabled(ctx)
 		if err != nil {
 			rc.caller.setReusedWorkflowJobResult(rc.JobName, "failure") // For Gitea
+			rc.finishJobState(ctx, "failure", startedAt)
+			rc.reportJob(ctx, "failure", startedAt, err)
+			rc.emitJobEvent(ctx, Event{Type: EventJobFinished, Result: "failure", Error: err.Error()})
 			return err
 		}
 		if res {
-			return executor(ctx)
+			if jobType != model.JobTypeDefault {
+				// the jobs of the called workflow emit their own events
+				rc.emitJobEvent(ctx, Event{Type: EventJobStarted})
+			}
+			err = executor(ctx)
+			result := "success"
+			if err != nil || common.JobError(ctx) != nil || (jobType != model.JobTypeDefault && rc.Run.Job().Result == "failure") {
+				result = "failure"
+			}
+			rc.finishJobState(ctx, result, startedAt)
+			jobErr := err
+			if jobErr == nil {
+				jobErr = common.JobError(ctx)
+			}
+			rc.reportJob(ctx, result, startedAt, jobErr)
+			if jobType != model.JobTypeDefault {
+				event := Event{Type: EventJobFinished, Result: result, Outputs: rc.Run.Job().Outputs}
+				if jobErr != nil {
+					event.Error = jobErr.Error()
+				}
+				rc.emitJobEvent(ctx, event)
+			}
+			return err
 		}
+		rc.finishJobState(ctx, "skipped", startedAt)
+		rc.reportJob(ctx, "skipped", startedAt, nil)
+		rc.emitJobEvent(ctx, Event{Type: EventJobFinished, Result: "skipped"})
 		return nil
 	}, nil
 }
@@ -696,6 +875,10 @@ func (rc *RunContext) runsOnImage(ctx context.Context) string {
 		}
 	}
 
+	if platform := rc.Config.PlatformRegistry.Match(runsOn); platform != nil {
+		return platform.Image
+	}
+
 	for _, platformName := range rc.runsOnPlatformNames(ctx) {
 		image := rc.Config.Platforms[strings.ToLower(platformName)]
 		if image != "" {
@@ -703,9 +886,99 @@ func (rc *RunContext) runsOnImage(ctx context.Context) string {
 		}
 	}
 
+	if profile := rc.runnerProfile(ctx); profile != nil && profile.Image != "" {
+		return profile.Image
+	}
+
+	return ""
+}
+
+// containerArchitecture returns the platform of the containers of the job: the one of --container-architecture, which may refer
+// to the matrix, e.g. ${{ matrix.platform }}, or the one of the arch of the runner of the platform registry
+func (rc *RunContext) containerArchitecture(ctx context.Context) string {
+	if rc.Config.ContainerArchitecture != "" {
+		if platform := strings.TrimSpace(rc.ExprEval.Interpolate(ctx, rc.Config.ContainerArchitecture)); platform != "" {
+			return platform
+		}
+	}
+	if platform := rc.Config.PlatformRegistry.Match(rc.runsOnPlatformNames(ctx)); platform != nil && platform.Arch != "" {
+		return container.ArchPlatform(platform.OS, platform.Arch)
+	}
 	return ""
 }
 
+// runnerArch returns runner.arch of the containers of the job
+func (rc *RunContext) runnerArch(ctx context.Context) string {
+	if arch := container.PlatformArch(rc.containerArchitecture(ctx)); arch != "" {
+		return arch
+	}
+	return container.RunnerArch(ctx)
+}
+
+// checkEmulation checks that the docker daemon of the host can run the containers of platform, when they need an emulator
+func (rc *RunContext) checkEmulation(platform string) common.Executor {
+	return func(ctx context.Context) error {
+		if platform == "" || runtime.GOOS != "linux" {
+			// Docker Desktop emulates the other architectures
+			return nil
+		}
+		info, err := container.GetHostInfo(ctx)
+		if err != nil {
+			common.Logger(ctx).Debugf("Failed to read the architecture of the docker daemon: %v", err)
+			return nil
+		}
+		// binfmt_misc is the one of the host of the daemon, which can only be checked when it runs on this host
+		if hostname, err := os.Hostname(); err != nil || info.OSType != "linux" || info.Name != hostname {
+			return nil
+		}
+		return container.CheckEmulation(platform, info.Architecture)
+	}
+}
+
+// checkImageDigest records the digest the image of the job resolved to, and warns when its tag has moved since the last run
+func (rc *RunContext) checkImageDigest(image string) common.Executor {
+	return func(ctx context.Context) error {
+		logger := common.Logger(ctx)
+		if rc.Config.ImageDigests == nil || strings.Contains(image, "@") {
+			return nil
+		}
+		digest, err := container.ImageDigest(ctx, image)
+		if err != nil || digest == "" {
+			logger.Debugf("Failed to find the digest of image %s: %v", image, err)
+			return nil
+		}
+		previous, err := rc.Config.ImageDigests.record(image, digest)
+		if err != nil {
+			logger.Warnf("Failed to record the digest of image %s: %v", image, err)
+		}
+		if previous != "" && previous != digest {
+			logger.Warnf("\u26A0  Image %s is now %s, it was %s on the last run, pin it by digest in the platform registry to keep it", image, digest, previous)
+		}
+		return nil
+	}
+}
+
+// runnerProfile returns the profile of the first label of runs-on which has one
+func (rc *RunContext) runnerProfile(ctx context.Context) *RunnerProfile {
+	if len(rc.Config.RunnerProfiles) == 0 {
+		return nil
+	}
+	for _, platformName := range rc.runsOnPlatformNames(ctx) {
+		if profile, ok := rc.Config.RunnerProfiles[strings.ToLower(platformName)]; ok {
+			return profile
+		}
+	}
+	return nil
+}
+
+// resourceLimits returns the limits of the containers of the job, from its runner profile
+func (rc *RunContext) resourceLimits(ctx context.Context) *container.ResourceLimits {
+	if profile := rc.runnerProfile(ctx); profile != nil {
+		return &profile.ResourceLimits
+	}
+	return nil
+}
+
 func (rc *RunContext) runsOnPlatformNames(ctx context.Context) []string {
 	job := rc.Run.Job()
 
@@ -1160,12 +1433,9 @@ func (rc *RunContext) handleServiceCredentials(ctx context.Context, creds map[st
 
 // GetServiceBindsAndMounts returns the binds and mounts for the service container, resolving paths as appopriate
 func (rc *RunContext) GetServiceBindsAndMounts(svcVolumes []string) ([]string, map[string]string) {
-	if rc.Config.ContainerDaemonSocket == "" {
-		rc.Config.ContainerDaemonSocket = "/var/run/docker.sock"
-	}
 	binds := []string{}
-	if rc.Config.ContainerDaemonSocket != "-" {
-		daemonPath := getDockerDaemonSocketMountPath(rc.Config.ContainerDaemonSocket)
+	if daemonSocket := rc.daemonSocket(); daemonSocket != "-" && !rc.dockerIsolated() {
+		daemonPath := getDockerDaemonSocketMountPath(daemonSocket)
 		binds = append(binds, fmt.Sprintf("%s:%s", daemonPath, "/var/run/docker.sock"))
 	}
 
@@ -1184,3 +1454,34 @@ func (rc *RunContext) GetServiceBindsAndMounts(svcVolumes []string) ([]string, m
 
 	return binds, mounts
 }
+
+// SecretResolver resolves the secrets referenced by URI, by their name
+type SecretResolver func(ctx context.Context) (map[string]string, error)
+
+// resolveSecrets adds the secrets referenced by URI to the secrets of the job, right before it starts
+func (rc *RunContext) resolveSecrets(ctx context.Context) error {
+	if rc.Config.ResolveSecrets == nil {
+		return nil
+	}
+	resolved, err := rc.Config.ResolveSecrets(ctx)
+	if err != nil {
+		return err
+	}
+
+	config := *rc.Config
+	config.Secrets = make(map[string]string, len(rc.Config.Secrets)+len(resolved))
+	for k, v := range rc.Config.Secrets {
+		config.Secrets[k] = v
+	}
+	for k, v := range resolved {
+		config.Secrets[k] = v
+	}
+	if config.Token == "" {
+		config.Token = config.Secrets["GITHUB_TOKEN"]
+	}
+	// the secrets are resolved once per job
+	config.ResolveSecrets = nil
+	rc.Config = &config
+	rc.ExprEval = rc.NewExpressionEvaluator(ctx)
+	return nil
+}
@@ -703,3 +703,35 @@ func Test_createSimpleContainerName(t *testing.T) {
 		})
 	}
 }
+
+func TestRunContextResolveSecrets(t *testing.T) {
+	calls := 0
+	config := &Config{
+		Secrets: map[string]string{"PLAIN": "value"},
+		ResolveSecrets: func(context.Context) (map[string]string, error) {
+			calls++
+			return map[string]string{"GITHUB_TOKEN": "token", "DB_PASSWORD": "hunter2"}, nil
+		},
+	}
+	rc := &RunContext{
+		Config:      config,
+		Run:         &model.Run{JobID: "job1", Workflow: &model.Workflow{Jobs: map[string]*model.Job{"job1": {}}}},
+		StepResults: map[string]*model.StepResult{},
+	}
+
+	assert.NoError(t, rc.resolveSecrets(context.Background()))
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, map[string]string{"PLAIN": "value", "GITHUB_TOKEN": "token", "DB_PASSWORD": "hunter2"}, rc.Config.Secrets)
+	assert.Equal(t, "token", rc.Config.Token)
+	assert.Equal(t, "hunter2", rc.ExprEval.Interpolate(context.Background(), "${{ secrets.DB_PASSWORD }}"))
+	// the config shared by the jobs is left as is
+	assert.Equal(t, map[string]string{"PLAIN": "value"}, config.Secrets)
+
+	assert.NoError(t, rc.resolveSecrets(context.Background()))
+	assert.Equal(t, 1, calls)
+
+	rc.Config = &Config{ResolveSecrets: func(context.Context) (map[string]string, error) {
+		return nil, fmt.Errorf("secret TOKEN: not found")
+	}}
+	assert.EqualError(t, rc.resolveSecrets(context.Background()), "secret TOKEN: not found")
+}
@@ -0,0 +1,743 @@
+package runner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/common/git"
+	"github.com/nektos/act/pkg/model"
+)
+
+const (
+	runStateFile  = "state.json"
+	runLogFile    = "log.json"
+	lastRunIDFile = "last-id" // the ID of the last run, kept once the run is pruned so that the IDs aren't reused
+
+	followLogsInterval = 200 * time.Millisecond
+)
+
+// RunState is the state of the jobs and steps of a run, recorded to disk so that a failed run can be resumed
+// and kept as the history of the run
+type RunState struct {
+	ID         string               `json:"id"`
+	EventName  string               `json:"event"`
+	SHA        string               `json:"sha,omitempty"`
+	Ref        string               `json:"ref,omitempty"`
+	Plan       [][]string           `json:"plan,omitempty"` // the qualified job IDs of each stage of the plan
+	Result     string               `json:"result,omitempty"`
+	StartedAt  time.Time            `json:"started_at"`
+	FinishedAt *time.Time           `json:"finished_at,omitempty"`
+	Jobs       map[string]*JobState `json:"jobs"` // by the qualified job ID, suffixed with the index of the matrix combination
+
+	dir     string
+	resumed bool // restore the completed jobs and steps instead of running them again
+	mu      sync.Mutex
+	log     *os.File
+}
+
+// JobState is the recorded state of a job
+type JobState struct {
+	Name       string            `json:"name,omitempty"`
+	Result     string            `json:"result,omitempty"`
+	Outputs    map[string]string `json:"outputs,omitempty"`
+	StartedAt  *time.Time        `json:"started_at,omitempty"`
+	FinishedAt *time.Time        `json:"finished_at,omitempty"`
+	Steps      []*StepState      `json:"steps,omitempty"` // the finished main steps, in order
+}
+
+// StepState is the recorded state of a job right after one of its steps has finished
+type StepState struct {
+	ID               string                       `json:"id"`
+	Name             string                       `json:"name,omitempty"`
+	Result           *model.StepResult            `json:"result"`
+	StartedAt        *time.Time                   `json:"started_at,omitempty"`
+	FinishedAt       *time.Time                   `json:"finished_at,omitempty"`
+	Env              map[string]string            `json:"env,omitempty"`   // the env set by the GITHUB_ENV file command so far
+	Path             []string                     `json:"path,omitempty"`  // the paths added by the GITHUB_PATH file command so far
+	IntraActionState map[string]map[string]string `json:"state,omitempty"` // the values saved by the GITHUB_STATE file command so far
+}
+
+// NewRunState creates the state of a new run in dir, its ID is the number following the last run ever recorded in dir
+func NewRunState(dir, eventName string) (*RunState, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	last := 0
+	if content, err := os.ReadFile(filepath.Join(dir, lastRunIDFile)); err == nil {
+		last, _ = strconv.Atoi(strings.TrimSpace(string(content)))
+	}
+	for _, entry := range entries {
+		if n, err := strconv.Atoi(entry.Name()); err == nil && entry.IsDir() && n > last {
+			last = n
+		}
+	}
+
+	for id := last + 1; ; id++ {
+		runDir := filepath.Join(dir, strconv.Itoa(id))
+		// creating the directory claims the ID, in case of concurrent runs
+		if err := os.Mkdir(runDir, 0o755); errors.Is(err, os.ErrExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if err := writeLastRunID(dir, id); err != nil {
+			return nil, err
+		}
+		s := &RunState{
+			ID:        strconv.Itoa(id),
+			EventName: eventName,
+			StartedAt: time.Now(),
+			Jobs:      map[string]*JobState{},
+			dir:       runDir,
+		}
+		return s, s.save()
+	}
+}
+
+// writeLastRunID records the ID of the last run, through a temporary file so that a concurrent run doesn't read it truncated
+func writeLastRunID(dir string, id int) error {
+	tmp, err := os.CreateTemp(dir, lastRunIDFile+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(strconv.Itoa(id) + "\n"); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, lastRunIDFile))
+}
+
+// LoadRunState reads the state of the run id from dir, to resume it
+func LoadRunState(dir, id string) (*RunState, error) {
+	runDir := filepath.Join(dir, id)
+	content, err := os.ReadFile(filepath.Join(runDir, runStateFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("run '%s' not found in %s", id, dir)
+	} else if err != nil {
+		return nil, err
+	}
+	s := &RunState{}
+	if err := json.Unmarshal(content, s); err != nil {
+		return nil, fmt.Errorf("failed to read the state of run '%s': %w", id, err)
+	}
+	if s.Jobs == nil {
+		s.Jobs = map[string]*JobState{}
+	}
+	s.dir = runDir
+	s.resumed = true
+	return s, nil
+}
+
+// ListRunStates reads the states of all the runs recorded in dir, ordered by ID
+func ListRunStates(dir string) ([]*RunState, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	ids := make([]int, 0, len(entries))
+	for _, entry := range entries {
+		if n, err := strconv.Atoi(entry.Name()); err == nil && entry.IsDir() {
+			ids = append(ids, n)
+		}
+	}
+	sort.Ints(ids)
+
+	runs := make([]*RunState, 0, len(ids))
+	for _, id := range ids {
+		s, err := LoadRunState(dir, strconv.Itoa(id))
+		if err != nil {
+			// the run has just started, or its state is broken
+			continue
+		}
+		runs = append(runs, s)
+	}
+	return runs, nil
+}
+
+// PruneRunStates removes the finished runs of dir but the last keep ones, and those of them started before olderThan ago
+// unless olderThan is 0. The runs in progress are kept. It returns the IDs of the removed runs.
+func PruneRunStates(dir string, keep int, olderThan time.Duration) ([]string, error) {
+	runs, err := ListRunStates(dir)
+	if err != nil {
+		return nil, err
+	}
+	removed := []string{}
+	for i, run := range runs {
+		if run.FinishedAt == nil {
+			continue
+		}
+		if i < len(runs)-keep || (olderThan > 0 && time.Since(run.StartedAt) > olderThan) {
+			if err := os.RemoveAll(run.dir); err != nil {
+				return removed, err
+			}
+			removed = append(removed, run.ID)
+		}
+	}
+	return removed, nil
+}
+
+// Redacted returns a copy of the state without the env and the action state of the steps,
+// which are recorded unmasked to resume the run and may hold secrets
+func (s *RunState) Redacted() *RunState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	redacted := &RunState{
+		ID:         s.ID,
+		EventName:  s.EventName,
+		SHA:        s.SHA,
+		Ref:        s.Ref,
+		Plan:       s.Plan,
+		Result:     s.Result,
+		StartedAt:  s.StartedAt,
+		FinishedAt: s.FinishedAt,
+		Jobs:       make(map[string]*JobState, len(s.Jobs)),
+		dir:        s.dir,
+	}
+	for key, job := range s.Jobs {
+		redactedJob := *job
+		redactedJob.Steps = make([]*StepState, len(job.Steps))
+		for i, step := range job.Steps {
+			redactedStep := *step
+			redactedStep.Env = nil
+			redactedStep.IntraActionState = nil
+			redactedJob.Steps[i] = &redactedStep
+		}
+		redacted.Jobs[key] = &redactedJob
+	}
+	return redacted
+}
+
+// Dir returns the directory of the run
+func (s *RunState) Dir() string {
+	return s.dir
+}
+
+// LogPath returns the path of the JSON log stream of the run, one entry per line
+func (s *RunState) LogPath() string {
+	return filepath.Join(s.dir, runLogFile)
+}
+
+// save writes the state to a temporary file first, so that an interrupted run doesn't leave a truncated state behind
+func (s *RunState) save() error {
+	content, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(s.dir, runStateFile+".tmp")
+	if err := os.WriteFile(tmp, content, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(s.dir, runStateFile))
+}
+
+// RunLogEntry is an entry of the log of a run
+type RunLogEntry struct {
+	Time      time.Time `json:"time"`
+	Level     string    `json:"level"`
+	Msg       string    `json:"msg"`
+	Job       string    `json:"job"`
+	JobID     string    `json:"jobID"`
+	Step      string    `json:"step"`
+	StepIDs   []string  `json:"stepID"`
+	Stage     string    `json:"stage"`
+	RawOutput bool      `json:"raw_output"`
+
+	Line []byte `json:"-"` // the entry as recorded
+}
+
+// matches returns true if the entry is logged by the job and step, which are matched by ID or name, and are matched by any when empty
+func (e *RunLogEntry) matches(job, step string) bool {
+	if job != "" {
+		name := strings.TrimSpace(e.Job)
+		if e.JobID != job && name != job && name[strings.LastIndex(name, "/")+1:] != job {
+			return false
+		}
+	}
+	if step != "" && e.Step != step {
+		for _, id := range e.StepIDs {
+			if id == step {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// ReadLogs returns the entries logged by the job and step of the run, all of them when job and step are empty
+func (s *RunState) ReadLogs(job, step string) ([]*RunLogEntry, error) {
+	f, err := os.Open(s.LogPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	entries := make([]*RunLogEntry, 0)
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			entry, parseErr := s.parseLogEntry(line)
+			if parseErr != nil {
+				return nil, parseErr
+			}
+			if entry.matches(job, step) {
+				entries = append(entries, entry)
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		} else if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// FollowLogs calls fn with the entries logged by the job and step of the run as they are written, until finished returns true
+// and the entries logged so far have been read, or ctx is done
+func (s *RunState) FollowLogs(ctx context.Context, job, step string, finished func() bool, fn func(*RunLogEntry) error) error {
+	var f *os.File
+	defer func() {
+		if f != nil {
+			_ = f.Close()
+		}
+	}()
+	var partial []byte
+	for {
+		// check before reading, so that the entries written before the run finished are read
+		done := finished()
+		if f == nil {
+			var err error
+			if f, err = os.Open(s.LogPath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+		}
+		if f != nil {
+			reader := bufio.NewReader(f)
+			for {
+				line, err := reader.ReadBytes('\n')
+				if err != nil && !errors.Is(err, io.EOF) {
+					return err
+				}
+				partial = append(partial, line...)
+				if errors.Is(err, io.EOF) {
+					// the rest of the line is not written yet
+					break
+				}
+				if len(bytes.TrimSpace(partial)) > 0 {
+					entry, err := s.parseLogEntry(partial)
+					if err != nil {
+						return err
+					}
+					if entry.matches(job, step) {
+						if err := fn(entry); err != nil {
+							return err
+						}
+					}
+				}
+				partial = nil
+			}
+		}
+		if done {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(followLogsInterval):
+		}
+	}
+}
+
+func (s *RunState) parseLogEntry(line []byte) (*RunLogEntry, error) {
+	entry := &RunLogEntry{}
+	if err := json.Unmarshal(line, entry); err != nil {
+		return nil, fmt.Errorf("failed to read the log of run '%s': %w", s.ID, err)
+	}
+	entry.Line = bytes.TrimRight(line, "\n")
+	return entry, nil
+}
+
+// startRun records the plan and the commit of a run, or of a resumed run, before its jobs run
+func (s *RunState) startRun(ctx context.Context, plan *model.Plan, workdir string) error {
+	_, sha, err := git.FindGitRevision(ctx, workdir)
+	if err != nil {
+		common.Logger(ctx).Debugf("Failed to find the commit of the run: %v", err)
+	}
+	ref, err := git.FindGitRef(ctx, workdir)
+	if err != nil {
+		common.Logger(ctx).Debugf("Failed to find the ref of the run: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Plan = planStages(plan)
+	s.SHA = sha
+	s.Ref = ref
+	s.Result = ""
+	s.FinishedAt = nil
+	return s.save()
+}
+
+// finishRun records the result of the run and closes its log
+func (s *RunState) finishRun(failed bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Result = "success"
+	if failed {
+		s.Result = "failure"
+	}
+	now := time.Now()
+	s.FinishedAt = &now
+	if s.log != nil {
+		_ = s.log.Close()
+		s.log = nil
+	}
+	return s.save()
+}
+
+// Cancel records that the run has been cancelled, before or while its jobs ran
+func (s *RunState) Cancel() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Result = "cancelled"
+	if s.FinishedAt == nil {
+		now := time.Now()
+		s.FinishedAt = &now
+	}
+	return s.save()
+}
+
+// writeLog appends a line to the log of the run
+func (s *RunState) writeLog(line []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.log == nil {
+		f, err := os.OpenFile(s.LogPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return err
+		}
+		s.log = f
+	}
+	_, err := s.log.Write(line)
+	return err
+}
+
+func (s *RunState) job(key string) *JobState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Jobs[key]
+}
+
+// startJob forgets the recorded steps of the job from the one at index on, since they are about to run again
+func (s *RunState) startJob(key string, index int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.Jobs[key]
+	if !ok {
+		job = &JobState{}
+		s.Jobs[key] = job
+	}
+	job.Result = ""
+	job.Outputs = nil
+	if index < len(job.Steps) {
+		job.Steps = job.Steps[:index]
+	}
+	return s.save()
+}
+
+func (s *RunState) finishStep(key string, step *StepState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.Jobs[key]
+	if !ok {
+		job = &JobState{}
+		s.Jobs[key] = job
+	}
+	job.Steps = append(job.Steps, step)
+	return s.save()
+}
+
+func (s *RunState) finishJob(key, name, result string, outputs map[string]string, startedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.Jobs[key]
+	if !ok {
+		job = &JobState{}
+		s.Jobs[key] = job
+	}
+	now := time.Now()
+	job.Name = name
+	job.Result = result
+	job.Outputs = outputs
+	job.StartedAt = &startedAt
+	job.FinishedAt = &now
+	return s.save()
+}
+
+// stateKey identifies the job in the RunState, a matrix combination by the index suffix of its name
+func (rc *RunContext) stateKey() string {
+	key := rc.Run.QualifiedJobID()
+	if len(rc.Name) > len(rc.JobName) && rc.Name[:len(rc.JobName)] == rc.JobName {
+		key += rc.Name[len(rc.JobName):]
+	}
+	if rc.caller != nil {
+		key = rc.caller.runContext.stateKey() + "/" + key
+	}
+	return key
+}
+
+// restoreJobState returns true when the job has succeeded in the resumed run and doesn't need to run again,
+// its result and outputs are restored for the jobs needing it
+func (rc *RunContext) restoreJobState(ctx context.Context) bool {
+	runState := rc.Config.RunState
+	if runState == nil || !runState.resumed || rc.Run == nil {
+		return false
+	}
+	job := runState.job(rc.stateKey())
+	if job == nil || job.Result != "success" {
+		return false
+	}
+	if from := rc.Config.ResumeFromJob; from != "" && (rc.Run.MatchesJobID(from) || rc.needsJob(from)) {
+		return false
+	}
+
+	common.Logger(ctx).Infof("\u23E9  Skipping job %s, it succeeded in run %s", rc.JobName, runState.ID)
+	if rc.Run.Job().Result == "" {
+		rc.Run.Job().Result = job.Result
+	}
+	if rc.Run.Job().Outputs == nil {
+		rc.Run.Job().Outputs = map[string]string{}
+	}
+	for k, v := range job.Outputs {
+		rc.Run.Job().Outputs[k] = v
+	}
+	if rc.caller != nil {
+		rc.caller.setReusedWorkflowJobResult(rc.JobName, job.Result) // For Gitea
+	}
+	return true
+}
+
+// needsJob returns true when the job needs the job with the (qualified) jobID, directly or through other jobs
+func (rc *RunContext) needsJob(jobID string) bool {
+	file, id := model.SplitQualifiedJobID(jobID)
+	if file != "" && !rc.Run.MatchesJobID(file+":"+rc.Run.JobID) {
+		return false
+	}
+	seen := map[string]bool{}
+	var needs func(string) bool
+	needs = func(current string) bool {
+		job := rc.Run.Workflow.GetJob(current)
+		if job == nil || seen[current] {
+			return false
+		}
+		seen[current] = true
+		for _, need := range job.Needs() {
+			if need == id || needs(need) {
+				return true
+			}
+		}
+		return false
+	}
+	return needs(rc.Run.JobID)
+}
+
+// finishJobState records the result, outputs and timing of the job once it has run or has been skipped
+func (rc *RunContext) finishJobState(ctx context.Context, result string, startedAt time.Time) {
+	runState := rc.Config.RunState
+	if runState == nil || rc.Run == nil {
+		return
+	}
+	outputs := maskOutputs(ctx, rc.Config, rc.Run.Job().Outputs)
+	if err := runState.finishJob(rc.stateKey(), rc.Name, result, outputs, startedAt); err != nil {
+		common.Logger(ctx).Warnf("Failed to record the state of job %s: %v", rc.JobName, err)
+	}
+	rc.finishJobMetrics(ctx)
+}
+
+// resumeStepIndex returns the index of the step the job continues at, the steps before it are restored from the resumed run
+func (rc *RunContext) resumeStepIndex(ctx context.Context, steps []*model.Step) (int, error) {
+	runState := rc.Config.RunState
+	if runState == nil || !runState.resumed || rc.Run == nil {
+		return 0, nil
+	}
+	logger := common.Logger(ctx)
+	job := runState.job(rc.stateKey())
+
+	index := 0
+	if from := rc.Config.ResumeFromJob; from != "" && rc.Run.MatchesJobID(from) {
+		if rc.Config.ResumeFromStep == "" {
+			return 0, nil
+		}
+		index = -1
+		for i, step := range steps {
+			// the ID of a step without one is its index, which isn't matched to not confuse it with the position
+			if step != nil && step.ID != "" && step.ID != strconv.Itoa(i) && step.ID == rc.Config.ResumeFromStep {
+				index = i
+				break
+			}
+		}
+		if n, err := strconv.Atoi(rc.Config.ResumeFromStep); index < 0 && err == nil && n >= 1 && n <= len(steps) {
+			index = n - 1
+		}
+		if index < 0 {
+			return 0, fmt.Errorf("step '%s' not found in job '%s'", rc.Config.ResumeFromStep, rc.Run.JobID)
+		}
+	} else if from != "" && rc.needsJob(from) {
+		return 0, nil
+	} else if job != nil {
+		// continue at the first step that hasn't completed
+		for index < len(job.Steps) && job.Steps[index].Result != nil && job.Steps[index].Result.Conclusion != model.StepStatusFailure {
+			index++
+		}
+		if index == len(steps) {
+			// all the steps have completed, but the job has not
+			return 0, nil
+		}
+	}
+	if index == 0 {
+		return 0, nil
+	}
+
+	if job == nil || len(job.Steps) < index {
+		return 0, fmt.Errorf("the steps before step %d of job '%s' haven't completed in run %s", index+1, rc.Run.JobID, runState.ID)
+	}
+	for i := 0; i < index; i++ {
+		id := steps[i].ID
+		if id == "" {
+			id = strconv.Itoa(i)
+		}
+		if job.Steps[i].ID != id {
+			logger.Warnf("The steps of job %s have changed since run %s, running all of them", rc.JobName, runState.ID)
+			return 0, nil
+		}
+	}
+	return index, nil
+}
+
+// restoreStepStates restores the steps context, env, path and saved state recorded after the steps before index
+func (rc *RunContext) restoreStepStates(ctx context.Context, index int) {
+	if index == 0 {
+		return
+	}
+	runState := rc.Config.RunState
+	job := runState.job(rc.stateKey())
+	rc.applyStepStates(job.Steps[:index])
+	common.Logger(ctx).Infof("\u23E9  Skipping %d steps of job %s, they completed in run %s", index, rc.JobName, runState.ID)
+}
+
+// applyStepStates restores the steps context of steps, and the env, path and saved state recorded after the last one
+func (rc *RunContext) applyStepStates(steps []*StepState) {
+	for _, step := range steps {
+		result := *step.Result
+		if result.Outputs == nil {
+			result.Outputs = map[string]string{}
+		}
+		rc.StepResults[step.ID] = &result
+	}
+
+	last := steps[len(steps)-1]
+	if rc.Env == nil {
+		rc.Env = map[string]string{}
+	}
+	if rc.GlobalEnv == nil {
+		rc.GlobalEnv = map[string]string{}
+	}
+	mergeIntoMap := mergeIntoMapCaseSensitive
+	if rc.JobContainer != nil && rc.JobContainer.IsEnvironmentCaseInsensitive() {
+		mergeIntoMap = mergeIntoMapCaseInsensitive
+	}
+	mergeIntoMap(rc.Env, last.Env)
+	mergeIntoMap(rc.GlobalEnv, last.Env)
+	rc.ExtraPath = append([]string{}, last.Path...)
+	rc.IntraActionState = map[string]map[string]string{}
+	for id, state := range last.IntraActionState {
+		rc.IntraActionState[id] = state
+	}
+}
+
+// finishStepState records the result and timing of a main step, and the state of the job right after it
+func (rc *RunContext) finishStepState(ctx context.Context, stepModel *model.Step, startedAt time.Time) {
+	runState := rc.Config.RunState
+	if runState == nil || rc.Run == nil {
+		return
+	}
+	step := rc.newStepState(ctx, stepModel, startedAt)
+	// the name and the outputs are shown by act runs and act serve, the env and the action state are redacted there instead
+	step.Name = maskValue(ctx, rc.Config, step.Name)
+	result := *step.Result
+	result.Outputs = maskOutputs(ctx, rc.Config, result.Outputs)
+	step.Result = &result
+	if err := runState.finishStep(rc.stateKey(), step); err != nil {
+		common.Logger(ctx).Warnf("Failed to record the state of step %s: %v", stepModel, err)
+	}
+}
+
+// maskOutputs returns a copy of the outputs with the secrets and the values masked by the job masked
+func maskOutputs(ctx context.Context, config *Config, outputs map[string]string) map[string]string {
+	if outputs == nil {
+		return nil
+	}
+	masked := make(map[string]string, len(outputs))
+	for k, v := range outputs {
+		masked[k] = maskValue(ctx, config, v)
+	}
+	return masked
+}
+
+// newStepState returns the result and timing of a main step, and the state of the job right after it
+func (rc *RunContext) newStepState(ctx context.Context, stepModel *model.Step, startedAt time.Time) *StepState {
+	result := &model.StepResult{
+		Conclusion: model.StepStatusFailure,
+		Outcome:    model.StepStatusFailure,
+	}
+	if r, ok := rc.StepResults[stepModel.ID]; ok {
+		result = r
+	}
+	now := time.Now()
+	step := &StepState{
+		ID:               stepModel.ID,
+		Name:             rc.ExprEval.Interpolate(ctx, stepModel.String()),
+		Result:           result,
+		StartedAt:        &startedAt,
+		FinishedAt:       &now,
+		Env:              make(map[string]string, len(rc.GlobalEnv)),
+		Path:             append([]string{}, rc.ExtraPath...),
+		IntraActionState: make(map[string]map[string]string, len(rc.IntraActionState)),
+	}
+	for k, v := range rc.GlobalEnv {
+		step.Env[k] = v
+	}
+	for id, state := range rc.IntraActionState {
+		step.IntraActionState[id] = make(map[string]string, len(state))
+		for k, v := range state {
+			step.IntraActionState[id][k] = v
+		}
+	}
+	return step
+}
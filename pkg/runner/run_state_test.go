@@ -0,0 +1,363 @@
+package runner
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunState(t *testing.T) {
+	dir := t.TempDir()
+
+	first, err := NewRunState(dir, "push")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", first.ID)
+
+	second, err := NewRunState(dir, "pull_request")
+	assert.NoError(t, err)
+	assert.Equal(t, "2", second.ID)
+
+	assert.NoError(t, second.finishStep("ci.yml:build", &StepState{
+		ID:     "checkout",
+		Result: &model.StepResult{Outputs: map[string]string{"ref": "main"}, Conclusion: model.StepStatusSuccess, Outcome: model.StepStatusSuccess},
+		Env:    map[string]string{"FOO": "bar"},
+		Path:   []string{"/opt/tool/bin"},
+	}))
+	assert.NoError(t, second.finishJob("ci.yml:build", "build", "failure", map[string]string{"out": "value"}, time.Now()))
+	assert.NoError(t, second.finishRun(true))
+
+	resumed, err := LoadRunState(dir, "2")
+	assert.NoError(t, err)
+	assert.True(t, resumed.resumed)
+	assert.Equal(t, "pull_request", resumed.EventName)
+	assert.Equal(t, "failure", resumed.Result)
+	assert.NotNil(t, resumed.FinishedAt)
+	job := resumed.job("ci.yml:build")
+	assert.Equal(t, "build", job.Name)
+	assert.Equal(t, "failure", job.Result)
+	assert.NotNil(t, job.FinishedAt)
+	assert.Equal(t, map[string]string{"out": "value"}, job.Outputs)
+	assert.Len(t, job.Steps, 1)
+	assert.Equal(t, model.StepStatusSuccess, job.Steps[0].Result.Conclusion)
+	assert.Equal(t, map[string]string{"FOO": "bar"}, job.Steps[0].Env)
+
+	_, err = LoadRunState(dir, "3")
+	assert.ErrorContains(t, err, "run '3' not found")
+
+	runs, err := ListRunStates(dir)
+	assert.NoError(t, err)
+	assert.Len(t, runs, 2)
+	assert.Equal(t, "1", runs[0].ID)
+	assert.Equal(t, "2", runs[1].ID)
+
+	redacted := resumed.Redacted()
+	assert.Nil(t, redacted.Jobs["ci.yml:build"].Steps[0].Env)
+	assert.Equal(t, []string{"/opt/tool/bin"}, redacted.Jobs["ci.yml:build"].Steps[0].Path)
+	assert.Equal(t, map[string]string{"FOO": "bar"}, job.Steps[0].Env)
+}
+
+func TestPruneRunStates(t *testing.T) {
+	dir := t.TempDir()
+	runs := make([]*RunState, 0, 4)
+	for i := 0; i < 4; i++ {
+		run, err := NewRunState(dir, "push")
+		assert.NoError(t, err)
+		runs = append(runs, run)
+	}
+	// the first run is still in progress
+	for _, run := range runs[1:] {
+		assert.NoError(t, run.finishRun(false))
+	}
+
+	removed, err := PruneRunStates(dir, 2, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2"}, removed)
+	listed, err := ListRunStates(dir)
+	assert.NoError(t, err)
+	assert.Len(t, listed, 3)
+	assert.Equal(t, "1", listed[0].ID)
+
+	removed, err = PruneRunStates(dir, 2, time.Hour)
+	assert.NoError(t, err)
+	assert.Empty(t, removed)
+	removed, err = PruneRunStates(dir, 2, time.Nanosecond)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"3", "4"}, removed)
+
+	// the IDs of the pruned runs aren't reused
+	next, err := NewRunState(dir, "push")
+	assert.NoError(t, err)
+	assert.Equal(t, "5", next.ID)
+	assert.NoError(t, runs[0].finishRun(false))
+	assert.NoError(t, next.finishRun(false))
+	removed, err = PruneRunStates(dir, 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1", "5"}, removed)
+	next, err = NewRunState(dir, "push")
+	assert.NoError(t, err)
+	assert.Equal(t, "6", next.ID)
+}
+
+func TestRunStateMasksOutputs(t *testing.T) {
+	runState, err := NewRunState(t.TempDir(), "push")
+	assert.NoError(t, err)
+	workflow, err := model.ReadWorkflow(strings.NewReader(`
+on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    outputs:
+      token: ${{ steps.login.outputs.token }}
+    steps:
+      - id: login
+        name: login with s3cr3t
+        run: echo
+`))
+	assert.NoError(t, err)
+	workflow.File = "ci.yml"
+	rc := &RunContext{
+		Config:      &Config{RunState: runState, Secrets: map[string]string{"TOKEN": "s3cr3t"}},
+		Run:         &model.Run{Workflow: workflow, JobID: "build"},
+		StepResults: map[string]*model.StepResult{},
+	}
+	ctx := WithMasks(context.Background(), &[]string{"masked-value"})
+	rc.ExprEval = rc.NewExpressionEvaluator(ctx)
+	step := workflow.Jobs["build"].Steps[0]
+	rc.StepResults["login"] = &model.StepResult{Outputs: map[string]string{"token": "s3cr3t", "user": "masked-value", "ref": "main"}}
+	rc.finishStepState(ctx, step, time.Now())
+	workflow.Jobs["build"].Outputs = map[string]string{"token": "s3cr3t"}
+	rc.finishJobState(ctx, "success", time.Now())
+
+	job := runState.job("ci.yml:build")
+	assert.Equal(t, "login with ***", job.Steps[0].Name)
+	assert.Equal(t, map[string]string{"token": "***", "user": "***", "ref": "main"}, job.Steps[0].Result.Outputs)
+	assert.Equal(t, map[string]string{"token": "***"}, job.Outputs)
+	// the results of the job keep the values
+	assert.Equal(t, "s3cr3t", rc.StepResults["login"].Outputs["token"])
+}
+
+func TestRunStateLogs(t *testing.T) {
+	runState, err := NewRunState(t.TempDir(), "push")
+	assert.NoError(t, err)
+
+	config := &Config{RunState: runState, Secrets: map[string]string{"TOKEN": "s3cr3t"}}
+	ctx := WithJobLogger(context.Background(), "build", "CI/build-2", config, &[]string{}, nil)
+	common.Logger(ctx).Infof("job started")
+	stepCtx := withStepLogger(ctx, 0, "checkout", "Checkout", "Main")
+	common.Logger(stepCtx).WithField("raw_output", true).Infof("using s3cr3t")
+	ctx = WithJobLogger(context.Background(), "test", "CI/test", config, &[]string{}, nil)
+	common.Logger(ctx).Infof("other job")
+	assert.NoError(t, runState.finishRun(false))
+
+	entries, err := runState.ReadLogs("", "")
+	assert.NoError(t, err)
+	assert.Len(t, entries, 3)
+
+	entries, err = runState.ReadLogs("build-2", "checkout")
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "using ***", entries[0].Msg)
+	assert.True(t, entries[0].RawOutput)
+	assert.Equal(t, "Checkout", entries[0].Step)
+
+	entries, err = runState.ReadLogs("build", "")
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	entries, err = runState.ReadLogs("test", "Checkout")
+	assert.NoError(t, err)
+	assert.Len(t, entries, 0)
+}
+
+func TestRunStateFollowLogs(t *testing.T) {
+	runState, err := NewRunState(t.TempDir(), "push")
+	assert.NoError(t, err)
+
+	config := &Config{RunState: runState}
+	ctx := WithJobLogger(context.Background(), "build", "CI/build", config, &[]string{}, nil)
+	common.Logger(ctx).Infof("first")
+
+	finished := atomic.Bool{}
+	msgs := []string{}
+	err = runState.FollowLogs(context.Background(), "build", "", finished.Load, func(entry *RunLogEntry) error {
+		msgs = append(msgs, entry.Msg)
+		if entry.Msg == "first" {
+			common.Logger(ctx).Infof("second")
+			finished.Store(true)
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"first", "second"}, msgs)
+
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = runState.FollowLogs(cancelCtx, "", "", func() bool { return false }, func(*RunLogEntry) error { return nil })
+	assert.ErrorIs(t, err, context.Canceled)
+
+	assert.NoError(t, runState.Cancel())
+	loaded, err := LoadRunState(filepath.Dir(runState.Dir()), runState.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "cancelled", loaded.Result)
+	assert.NotNil(t, loaded.FinishedAt)
+}
+
+func TestRunStateRestoreJob(t *testing.T) {
+	workflow, err := model.ReadWorkflow(strings.NewReader(`
+on: push
+jobs:
+  setup:
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo
+  build:
+    needs: setup
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo
+  test:
+    needs: [build]
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo
+  release:
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo
+`))
+	assert.NoError(t, err)
+	workflow.File = "ci.yml"
+
+	runState := &RunState{
+		ID:      "1",
+		resumed: true,
+		Jobs: map[string]*JobState{
+			"ci.yml:setup":   {Result: "success", Outputs: map[string]string{"version": "1.0"}},
+			"ci.yml:build":   {Result: "success"},
+			"ci.yml:test":    {Result: "success"},
+			"ci.yml:release": {Result: "failure"},
+		},
+	}
+	restored := func(jobID, fromJob string) bool {
+		workflow.Jobs[jobID].Result = ""
+		rc := &RunContext{
+			Config: &Config{RunState: runState, ResumeFromJob: fromJob},
+			Run:    &model.Run{Workflow: workflow, JobID: jobID},
+		}
+		return rc.restoreJobState(context.Background())
+	}
+
+	assert.True(t, restored("setup", ""))
+	assert.Equal(t, "success", workflow.Jobs["setup"].Result)
+	assert.Equal(t, map[string]string{"version": "1.0"}, workflow.Jobs["setup"].Outputs)
+	assert.False(t, restored("release", ""))
+
+	assert.True(t, restored("setup", "build"))
+	assert.False(t, restored("build", "build"))
+	assert.False(t, restored("test", "ci.yml:build"))
+	assert.True(t, restored("test", "other.yml:build"))
+}
+
+func TestJobExecutorResume(t *testing.T) {
+	steps := []*model.Step{{ID: "checkout"}, {}, {}, {}, {}}
+	success := &model.StepResult{Outputs: map[string]string{}, Conclusion: model.StepStatusSuccess, Outcome: model.StepStatusSuccess}
+	failure := &model.StepResult{Outputs: map[string]string{}, Conclusion: model.StepStatusFailure, Outcome: model.StepStatusFailure}
+
+	for _, tt := range []struct {
+		name          string
+		fromJob       string
+		fromStep      string
+		executedSteps []string
+		err           string
+	}{
+		{"first failed step", "", "", []string{"step2", "step3", "step4"}, ""},
+		{"from step position", "test", "2", []string{"step1", "step2", "step3", "step4"}, ""},
+		{"from step after failed step", "test", "4", []string{"step3", "step4"}, ""},
+		{"from step ID", "test", "checkout", []string{"stepcheckout", "step1", "step2", "step3", "step4"}, ""},
+		{"from job", "test", "", []string{"stepcheckout", "step1", "step2", "step3", "step4"}, ""},
+		{"from uncompleted step", "test", "5", nil, "the steps before step 5 of job 'test' haven't completed in run 1"},
+		{"from missing step", "test", "build", nil, "step 'build' not found in job 'test'"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			runState := &RunState{
+				ID:      "1",
+				dir:     t.TempDir(),
+				resumed: true,
+				Jobs: map[string]*JobState{
+					"test": {Result: "failure", Steps: []*StepState{
+						{ID: "checkout", Result: success, Env: map[string]string{"FOO": "foo"}},
+						{ID: "1", Result: success, Env: map[string]string{"FOO": "bar"}, Path: []string{"/opt/tool/bin"}},
+						{ID: "2", Result: failure, Env: map[string]string{"FOO": "baz"}},
+					}},
+				},
+			}
+			ctx := common.WithJobErrorContainer(context.Background())
+			jim := &jobInfoMock{}
+			sfm := &stepFactoryMock{}
+			rc := &RunContext{
+				JobContainer: &jobContainerMock{},
+				Run: &model.Run{
+					JobID: "test",
+					Workflow: &model.Workflow{
+						Jobs: map[string]*model.Job{
+							"test": {},
+						},
+					},
+				},
+				Config:      &Config{RunState: runState, ResumeFromJob: tt.fromJob, ResumeFromStep: tt.fromStep},
+				StepResults: map[string]*model.StepResult{},
+			}
+			rc.ExprEval = rc.NewExpressionEvaluator(ctx)
+			executorOrder := make([]string, 0)
+
+			stepModels := make([]*model.Step, 0, len(steps))
+			for _, s := range steps {
+				s := *s
+				stepModels = append(stepModels, &s)
+			}
+			jim.On("steps").Return(stepModels)
+			jim.On("startContainer").Return(func(_ context.Context) error {
+				return nil
+			}).Maybe()
+			for _, stepModel := range stepModels {
+				stepModel := stepModel
+				sm := &stepMock{}
+				sfm.On("newStep", stepModel, rc).Return(sm, nil).Maybe()
+				sm.On("pre").Return(func(_ context.Context) error { return nil }).Maybe()
+				sm.On("main").Return(func(_ context.Context) error {
+					executorOrder = append(executorOrder, "step"+stepModel.ID)
+					rc.StepResults[stepModel.ID] = success
+					return nil
+				}).Maybe()
+				sm.On("post").Return(func(_ context.Context) error { return nil }).Maybe()
+			}
+			jim.On("matrix").Return(map[string]interface{}{}).Maybe()
+			jim.On("interpolateOutputs").Return(func(_ context.Context) error { return nil }).Maybe()
+			jim.On("stopContainer").Return(func(_ context.Context) error { return nil }).Maybe()
+			jim.On("result", "success").Maybe()
+			jim.On("closeContainer").Return(func(_ context.Context) error { return nil }).Maybe()
+
+			err := newJobExecutor(jim, sfm, rc)(ctx)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.executedSteps, executorOrder)
+
+			if tt.fromJob == "" {
+				assert.Equal(t, success, rc.StepResults["checkout"])
+				assert.Equal(t, "bar", rc.Env["FOO"])
+				assert.Equal(t, []string{"/opt/tool/bin"}, rc.ExtraPath)
+			}
+			assert.Len(t, runState.job("test").Steps, len(steps))
+		})
+	}
+}
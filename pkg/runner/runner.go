@@ -5,14 +5,18 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path"
 	"runtime"
 	"sync"
 	"time"
 
 	docker_container "github.com/moby/moby/api/types/container"
 	log "github.com/sirupsen/logrus"
+	"go.yaml.in/yaml/v4"
 
 	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/container"
+	"github.com/nektos/act/pkg/exprparser"
 	"github.com/nektos/act/pkg/model"
 )
 
@@ -32,6 +36,9 @@ type Config struct {
 	EventPath                          string                       // path to JSON file to use for event.json in containers
 	DefaultBranch                      string                       // name of the main branch for this repository
 	ReuseContainers                    bool                         // reuse containers to maintain state
+	WorkspaceSnapshot                  bool                         // copy the working directory once per run to a volume the workspaces of the jobs overlay, and only the changed files to the reused containers
+	WarmSnapshotAfter                  string                       // the ID of the step the job containers are committed to a warm snapshot after, which the later runs of the jobs with the same definition start from
+	WarmSnapshotKey                    string                       // the key of the warm snapshots replacing the digest of the files of the working directory, if not empty
 	ForcePull                          bool                         // force pulling of the image, even if already present
 	ForceRebuild                       bool                         // force rebuilding local docker image action
 	LogOutput                          bool                         // log the output from docker run
@@ -44,11 +51,19 @@ type Config struct {
 	Token                              string                       // GitHub token
 	InsecureSecrets                    bool                         // switch hiding output when printing to terminal
 	Platforms                          map[string]string            // list of platforms
+	PlatformRegistry                   *PlatformRegistry            // the runners matched by all the labels of runs-on, they take precedence over Platforms
+	ImageDigests                       *ImageDigests                // the digests the images of the jobs resolved to, to warn when their tags have moved since the last run
 	Privileged                         bool                         // use privileged mode
 	UsernsMode                         string                       // user namespace to use
 	ContainerArchitecture              string                       // Desired OS/architecture platform for running containers
 	ContainerDaemonSocket              string                       // Path to Docker daemon socket
+	DockerIsolation                    string                       // "dind" gives each run a Docker-in-Docker sidecar, which its jobs share, instead of the host docker socket
+	DindImage                          string                       // the image of the Docker-in-Docker sidecars, default docker:dind
+	DindCacheVolume                    string                       // the volume of the /var/lib/docker of the Docker-in-Docker sidecars, which keeps the images they pulled, built or loaded from the host for the next sidecars, none if empty
+	ContainerEngine                    string                       // the container engine running the containers, docker (default) or podman
+	SandboxNetwork                     bool                         // share the network of the host with the jobs running in a sandbox, on the -sandbox platform
 	ContainerOptions                   string                       // Options for the job container
+	RunnerProfiles                     map[string]*RunnerProfile    // runner profiles by runs-on label, with the image and the resource limits of the containers of the jobs
 	UseGitIgnore                       bool                         // controls if paths in .gitignore should not be copied into container, default true
 	GitHubInstance                     string                       // GitHub instance to use, default "github.com"
 	ContainerCapAdd                    []string                     // list of kernel capabilities to add to the containers
@@ -61,7 +76,8 @@ type Config struct {
 	RemoteName                         string                       // remote name in local git repo config
 	ReplaceGheActionWithGithubCom      []string                     // Use actions from GitHub Enterprise instance to GitHub
 	ReplaceGheActionTokenWithGithubCom string                       // Token of private action repo on GitHub.
-	Matrix                             map[string]map[string]bool   // Matrix config to run
+	Matrix                             map[string]map[string]bool   // Matrix config to run, values mapped to false are excluded
+	MatrixIndexes                      map[int]bool                 // Matrix combinations to run by their position, starting at 1
 	ContainerNetworkMode               docker_container.NetworkMode // the network mode of job containers (the value of --network)
 	ActionCache                        ActionCache                  // Use a custom ActionCache Implementation
 
@@ -75,6 +91,15 @@ type Config struct {
 	ValidVolumes          []string                     // only volumes (and bind mounts) in this slice can be mounted on the job container or service containers
 	InsecureSkipTLS       bool                         // whether to skip verifying TLS certificate of the Gitea instance
 	MaxParallel           int                          // max parallel jobs to run across all workflows (0 = no limit, uses CPU count)
+	RunState              *RunState                    // records the state of the run, a loaded state resumes the run and skips the work it has completed
+	ResumeFromJob         string                       // job of the resumed run to run again, together with the jobs needing it
+	ResumeFromStep        string                       // step (ID or position, starting at 1) of ResumeFromJob to continue at, the steps before it are restored
+	DebugOnFailure        bool                         // pause the job when a step fails, to debug it in a shell with the env of the step
+	BreakBefore           []string                     // IDs of the steps to pause the job before, to debug them in a shell with their env
+	DebugPrompt           DebugPrompt                  // asks whether to run, skip or abort a debugged step
+	Report                *Report                      // collects the results and timings of the jobs and steps for the test reports
+	Events                *EventWriter                 // writes the events of the run, for tools following its progress
+	ResolveSecrets        SecretResolver               // resolves the secrets referenced by URI when a job starts, they are added to the Secrets of the job
 }
 
 // GetToken: Adapt to Gitea
@@ -91,6 +116,7 @@ type caller struct {
 
 	updateResultLock         sync.Mutex        // For Gitea
 	reusedWorkflowJobResults map[string]string // For Gitea
+	calledRunContext         *RunContext       // the run context of the last job of the called workflow which ran
 }
 
 type runnerImpl struct {
@@ -169,28 +195,23 @@ func (runner *runnerImpl) NewPlanExecutor(plan *model.Plan) common.Executor {
 				// log.Debugf("Job.RawSecrets: %v", job.RawSecrets)
 				log.Debugf("Job.Result: %v", job.Result)
 
-				if job.Strategy != nil {
-					log.Debugf("Job.Strategy.FailFast: %v", job.Strategy.FailFast)
-					log.Debugf("Job.Strategy.MaxParallel: %v", job.Strategy.MaxParallel)
-					log.Debugf("Job.Strategy.FailFastString: %v", job.Strategy.FailFastString)
-					log.Debugf("Job.Strategy.MaxParallelString: %v", job.Strategy.MaxParallelString)
-					log.Debugf("Job.Strategy.RawMatrix: %v", job.Strategy.RawMatrix)
-
-					strategyRc := runner.newRunContext(ctx, run, nil)
-					if err := strategyRc.NewExpressionEvaluator(ctx).EvaluateYamlNode(ctx, &job.Strategy.RawMatrix); err != nil {
-						log.Errorf("Error while evaluating matrix: %v", err)
-					}
-				}
-
-				var matrixes []map[string]interface{}
-				if m, err := job.GetMatrixes(); err != nil {
+				matrixes, err := runner.expandMatrix(ctx, run)
+				if err != nil {
 					log.Errorf("Error while get job's matrix: %v", err)
-				} else {
-					log.Debugf("Job Matrices: %v", m)
-					log.Debugf("Runner Matrices: %v", runner.config.Matrix)
-					matrixes = selectMatrixes(m, runner.config.Matrix)
+					rc := runner.newRunContext(ctx, run, nil)
+					rc.JobName = rc.Name
+					if len(rc.String()) > maxJobNameLen {
+						maxJobNameLen = len(rc.String())
+					}
+					if rc.caller != nil { // For Gitea
+						rc.caller.setReusedWorkflowJobResult(rc.JobName, "pending")
+					}
+					pipeline = append(pipeline, newMatrixErrorExecutor(rc, err, maxJobNameLen))
+					continue
 				}
-				log.Debugf("Final matrix after applying user inclusions '%v'", matrixes)
+				log.Debugf("Runner Matrices: %v", runner.config.Matrix)
+				selected := selectMatrixes(matrixes, runner.config.Matrix, runner.config.MatrixIndexes)
+				log.Debugf("Final matrix after applying user inclusions '%v'", selected)
 
 				maxParallel := 4
 				if job.Strategy != nil {
@@ -202,18 +223,19 @@ func (runner *runnerImpl) NewPlanExecutor(plan *model.Plan) common.Executor {
 					log.Debugf("Using job.Strategy.MaxParallel: %d", maxParallel)
 				}
 
-				if len(matrixes) < maxParallel {
-					log.Debugf("Adjusting maxParallel from %d to %d (number of matrix combinations)", maxParallel, len(matrixes))
-					maxParallel = len(matrixes)
+				if len(selected) < maxParallel {
+					log.Debugf("Adjusting maxParallel from %d to %d (number of matrix combinations)", maxParallel, len(selected))
+					maxParallel = len(selected)
 				}
 
-				log.Infof("Running job with maxParallel=%d for %d matrix combinations", maxParallel, len(matrixes))
+				log.Infof("Running job with maxParallel=%d for %d matrix combinations", maxParallel, len(selected))
 
-				for i, matrix := range matrixes {
-					matrix := matrix
+				for _, i := range selected {
+					matrix := matrixes[i]
 					rc := runner.newRunContext(ctx, run, matrix)
 					rc.JobName = rc.Name
 					if len(matrixes) > 1 {
+						// keep the position in the whole matrix, so that a selected combination has the same name as in a full run
 						rc.Name = fmt.Sprintf("%s-%d", rc.Name, i+1)
 					}
 					if len(rc.String()) > maxJobNameLen {
@@ -222,14 +244,22 @@ func (runner *runnerImpl) NewPlanExecutor(plan *model.Plan) common.Executor {
 					if rc.caller != nil { // For Gitea
 						rc.caller.setReusedWorkflowJobResult(rc.JobName, "pending")
 					}
+					rc.emitJobEvent(ctx, Event{Type: EventJobQueued})
 					stageExecutor = append(stageExecutor, func(ctx context.Context) error {
 						jobName := fmt.Sprintf("%-*s", maxJobNameLen, rc.String())
+						if err := rc.resolveSecrets(ctx); err != nil {
+							return err
+						}
 						executor, err := rc.Executor()
 
 						if err != nil {
 							return err
 						}
 
+						if rc.caller != nil {
+							// the outputs of the called workflow are evaluated with the matrix and the results of its jobs
+							defer rc.caller.setCalledRunContext(rc)
+						}
 						return executor(common.WithJobErrorContainer(WithJobLogger(ctx, rc.Run.JobID, jobName, rc.Config, &rc.Masks, matrix)))
 					})
 				}
@@ -271,7 +301,74 @@ func (runner *runnerImpl) NewPlanExecutor(plan *model.Plan) common.Executor {
 		})
 	}
 
-	return common.NewPipelineExecutor(stagePipeline...).Then(handleFailure(plan))
+	executor := common.NewPipelineExecutor(stagePipeline...).Then(handleFailure(plan))
+	if runner.config.WorkspaceSnapshot {
+		// the working directory is hashed once for all the jobs of the run
+		snapshotExecutor := executor
+		executor = func(ctx context.Context) error {
+			return snapshotExecutor(container.WithWorkspaceSnapshots(ctx))
+		}
+	}
+	if runner.caller == nil && runner.config.DockerIsolation == DockerIsolationDind {
+		// the jobs of the run, those of its reusable workflows included, share the Docker-in-Docker sidecar
+		executor = executor.Finally(removeDindSidecar(runner.config))
+	}
+	// the runners of reusable workflows share the config, the run is recorded by the top-level runner
+	if runner.caller != nil || (runner.config.RunState == nil && runner.config.Events == nil) {
+		return executor
+	}
+	return func(ctx context.Context) error {
+		runState := runner.config.RunState
+		if runState != nil {
+			if err := runState.startRun(ctx, plan, runner.config.Workdir); err != nil {
+				log.Warnf("Failed to record the state of run %s: %v", runState.ID, err)
+			}
+		}
+		runner.config.Events.Emit(Event{Type: EventRunStarted, RunID: runner.config.runID(), Plan: planStages(plan)})
+
+		err := executor(ctx)
+
+		result := Event{Type: EventRunFinished, RunID: runner.config.runID(), Result: "success"}
+		if err != nil {
+			result.Result = "failure"
+			result.Error = maskValue(ctx, runner.config, err.Error())
+		}
+		runner.config.Events.Emit(result)
+		if runState != nil {
+			if finishErr := runState.finishRun(err != nil); finishErr != nil {
+				log.Warnf("Failed to record the state of run %s: %v", runState.ID, finishErr)
+			}
+		}
+		return err
+	}
+}
+
+// newMatrixErrorExecutor fails the job whose matrix couldn't be expanded, unless the job is skipped anyway
+func newMatrixErrorExecutor(rc *RunContext, matrixErr error, maxJobNameLen int) common.Executor {
+	return func(ctx context.Context) error {
+		jobName := fmt.Sprintf("%-*s", maxJobNameLen, rc.String())
+		ctx = common.WithJobErrorContainer(WithJobLogger(ctx, rc.Run.JobID, jobName, rc.Config, &rc.Masks, nil))
+
+		// only the if-expression is checked, as runs-on may depend on the matrix
+		runJob, err := EvalBool(ctx, rc.ExprEval, rc.Run.Job().If.Value, exprparser.DefaultStatusCheckSuccess)
+		if err != nil {
+			return err
+		}
+		if !runJob {
+			if rc.caller != nil { // For Gitea
+				rc.caller.setReusedWorkflowJobResult(rc.JobName, "skipped")
+			}
+			common.Logger(ctx).WithField("jobResult", "skipped").Debugf("Skipping job '%s' due to '%s'", rc.Run.Job().Name, rc.Run.Job().If.Value)
+			return nil
+		}
+
+		rc.result("failure")
+		if rc.caller != nil { // For Gitea
+			rc.caller.setReusedWorkflowJobResult(rc.JobName, "failure")
+		}
+		common.Logger(ctx).WithField("jobResult", "failure").Errorf("\u274C  Failed to expand the matrix: %v", matrixErr)
+		return matrixErr
+	}
 }
 
 func handleFailure(plan *model.Plan) common.Executor {
@@ -287,23 +384,110 @@ func handleFailure(plan *model.Plan) common.Executor {
 	}
 }
 
-func selectMatrixes(originalMatrixes []map[string]interface{}, targetMatrixValues map[string]map[string]bool) []map[string]interface{} {
-	matrixes := make([]map[string]interface{}, 0)
-	for _, original := range originalMatrixes {
+// MatrixCombination is a single combination of an expanded job matrix
+type MatrixCombination struct {
+	Index  int                    `json:"index"`  // position in the expanded matrix, starting at 1 like the suffix of the job name
+	Matrix map[string]interface{} `json:"matrix"` // the values of the matrix context
+}
+
+// ExpandMatrix evaluates and expands the matrix of the job like NewPlanExecutor does,
+// and returns the combinations selected by Config.Matrix and Config.MatrixIndexes
+func ExpandMatrix(ctx context.Context, runnerConfig *Config, run *model.Run) ([]MatrixCombination, error) {
+	r, err := New(runnerConfig)
+	if err != nil {
+		return nil, err
+	}
+	runner := r.(*runnerImpl)
+
+	matrixes, err := runner.expandMatrix(ctx, run)
+	if err != nil {
+		return nil, err
+	}
+	combinations := make([]MatrixCombination, 0, len(matrixes))
+	for _, i := range selectMatrixes(matrixes, runnerConfig.Matrix, runnerConfig.MatrixIndexes) {
+		combinations = append(combinations, MatrixCombination{Index: i + 1, Matrix: matrixes[i]})
+	}
+	return combinations, nil
+}
+
+// expandMatrix evaluates the expressions of the job matrix and returns all of its combinations,
+// after applying include and exclude
+func (runner *runnerImpl) expandMatrix(ctx context.Context, run *model.Run) ([]map[string]interface{}, error) {
+	job := run.Job()
+	if job.Strategy != nil {
+		log.Debugf("Job.Strategy.FailFast: %v", job.Strategy.FailFast)
+		log.Debugf("Job.Strategy.MaxParallel: %v", job.Strategy.MaxParallel)
+		log.Debugf("Job.Strategy.FailFastString: %v", job.Strategy.FailFastString)
+		log.Debugf("Job.Strategy.MaxParallelString: %v", job.Strategy.MaxParallelString)
+		log.Debugf("Job.Strategy.RawMatrix: %v", job.Strategy.RawMatrix)
+
+		// stages run one after another, so a matrix using the needs context is evaluated
+		// with the actual outputs of the jobs it needs
+		strategyRc := runner.newRunContext(ctx, run, nil)
+		if err := strategyRc.NewExpressionEvaluator(ctx).EvaluateYamlNode(ctx, &job.Strategy.RawMatrix); err != nil {
+			log.Errorf("Error while evaluating matrix: %v", err)
+		}
+		if job.Strategy.RawMatrix.Kind == yaml.ScalarNode && job.Strategy.RawMatrix.ShortTag() != "!!null" {
+			return nil, fmt.Errorf("matrix must be a mapping, but evaluated to '%s'", job.Strategy.RawMatrix.Value)
+		}
+	}
+
+	matrixes, err := job.GetMatrixes()
+	if err != nil {
+		return nil, err
+	}
+	log.Debugf("Job Matrices: %v", matrixes)
+	return matrixes, nil
+}
+
+// selectMatrixes returns the indexes of the matrix combinations selected by the user.
+// For every key, a combination must match one of the included values (if any) and none of the excluded ones,
+// values are compared as strings and may be glob patterns.
+// Target indexes start at 1 and only apply to jobs with a matrix.
+func selectMatrixes(originalMatrixes []map[string]interface{}, targetMatrixValues map[string]map[string]bool, targetIndexes map[int]bool) []int {
+	selected := make([]int, 0, len(originalMatrixes))
+	for i, original := range originalMatrixes {
+		if len(targetIndexes) > 0 && len(original) > 0 && !targetIndexes[i+1] {
+			continue
+		}
 		flag := true
 		for key, val := range original {
 			if allowedVals, ok := targetMatrixValues[key]; ok {
-				valToString := fmt.Sprintf("%v", val)
-				if _, ok := allowedVals[valToString]; !ok {
+				if !matchMatrixValues(allowedVals, fmt.Sprintf("%v", val)) {
 					flag = false
 				}
 			}
 		}
 		if flag {
-			matrixes = append(matrixes, original)
+			selected = append(selected, i)
 		}
 	}
-	return matrixes
+	return selected
+}
+
+func matchMatrixValues(allowedVals map[string]bool, value string) bool {
+	hasInclusions := false
+	included := false
+	for pattern, include := range allowedVals {
+		matched := matchMatrixValue(pattern, value)
+		if !include {
+			if matched {
+				return false
+			}
+			continue
+		}
+		hasInclusions = true
+		included = included || matched
+	}
+	return included || !hasInclusions
+}
+
+func matchMatrixValue(pattern, value string) bool {
+	if pattern == value {
+		return true
+	}
+	matched, err := path.Match(pattern, value)
+	return err == nil && matched
 }
 
 func (runner *runnerImpl) newRunContext(ctx context.Context, run *model.Run, matrix map[string]interface{}) *RunContext {
@@ -327,3 +511,15 @@ func (c *caller) setReusedWorkflowJobResult(jobName string, result string) {
 	defer c.updateResultLock.Unlock()
 	c.reusedWorkflowJobResults[jobName] = result
 }
+
+func (c *caller) setCalledRunContext(rc *RunContext) {
+	c.updateResultLock.Lock()
+	defer c.updateResultLock.Unlock()
+	c.calledRunContext = rc
+}
+
+func (c *caller) getCalledRunContext() *RunContext {
+	c.updateResultLock.Lock()
+	defer c.updateResultLock.Unlock()
+	return c.calledRunContext
+}
@@ -0,0 +1,49 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"go.yaml.in/yaml/v4"
+
+	"github.com/nektos/act/pkg/container"
+)
+
+// RunnerProfile is the size of a runner: the image and the resource limits of the containers of the jobs running on one of its labels
+//
+//	ubuntu-latest-4core:
+//	  image: catthehacker/ubuntu:act-latest
+//	  cpus: 4
+//	  memory: 16g
+//	  pids: 4096
+//	  shm-size: 2g
+//	  tmpfs:
+//	    /tmp: size=4g
+type RunnerProfile struct {
+	Image                    string `yaml:"image,omitempty"` // image of the jobs, unless the platform of the label is set
+	container.ResourceLimits `yaml:",inline"`
+}
+
+// LoadRunnerProfiles reads the runner profiles of a file, by runs-on label
+func LoadRunnerProfiles(path string) (map[string]*RunnerProfile, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	profiles := map[string]*RunnerProfile{}
+	if err := yaml.Unmarshal(content, &profiles); err != nil {
+		return nil, fmt.Errorf("failed to read the runner profiles of %s: %w", path, err)
+	}
+	ret := make(map[string]*RunnerProfile, len(profiles))
+	for label, profile := range profiles {
+		if profile == nil {
+			profile = &RunnerProfile{}
+		}
+		if err := profile.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid runner profile '%s' of %s: %w", label, path, err)
+		}
+		ret[strings.ToLower(label)] = profile
+	}
+	return ret, nil
+}
@@ -0,0 +1,63 @@
+package runner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nektos/act/pkg/model"
+)
+
+func TestLoadRunnerProfiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "runner-profiles.yml")
+	assert.NoError(t, os.WriteFile(path, []byte(`
+Ubuntu-Latest-4Core:
+  image: catthehacker/ubuntu:act-latest
+  cpus: 4
+  memory: 16g
+  pids: 4096
+  tmpfs:
+    /tmp: size=4g
+small:
+  cpus: 1
+`), 0o600))
+
+	profiles, err := LoadRunnerProfiles(path)
+	assert.NoError(t, err)
+	assert.Len(t, profiles, 2)
+	profile := profiles["ubuntu-latest-4core"]
+	if assert.NotNil(t, profile) {
+		assert.Equal(t, "catthehacker/ubuntu:act-latest", profile.Image)
+		assert.Equal(t, "4", profile.CPUs)
+		assert.Equal(t, "16g", profile.Memory)
+		assert.Equal(t, int64(4096), profile.Pids)
+		assert.Equal(t, map[string]string{"/tmp": "size=4g"}, profile.Tmpfs)
+	}
+
+	assert.NoError(t, os.WriteFile(path, []byte("small:\n  memory: lots\n"), 0o600))
+	_, err = LoadRunnerProfiles(path)
+	assert.ErrorContains(t, err, "invalid runner profile 'small'")
+}
+
+func TestRunContextRunnerProfile(t *testing.T) {
+	ctx := context.Background()
+	profile := &RunnerProfile{Image: "catthehacker/ubuntu:act-latest"}
+	profile.Memory = "16g"
+
+	rc := createIfTestRunContext(map[string]*model.Job{
+		"job1": createJob(t, `runs-on: [self-hosted, Ubuntu-Latest-4Core]`, ""),
+	})
+	assert.Nil(t, rc.resourceLimits(ctx))
+
+	rc.Config.RunnerProfiles = map[string]*RunnerProfile{"ubuntu-latest-4core": profile}
+	assert.Same(t, &profile.ResourceLimits, rc.resourceLimits(ctx))
+	assert.Equal(t, "catthehacker/ubuntu:act-latest", rc.runsOnImage(ctx))
+
+	// the platforms take precedence over the image of the profile
+	rc.Config.Platforms["ubuntu-latest-4core"] = "node:16-buster-slim"
+	assert.Equal(t, "node:16-buster-slim", rc.runsOnImage(ctx))
+	assert.Same(t, &profile.ResourceLimits, rc.resourceLimits(ctx))
+}
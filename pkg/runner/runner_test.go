@@ -216,6 +216,87 @@ type TestConfig struct {
 	LocalRepositories map[string]string `yaml:"local-repositories"`
 }
 
+func TestRunEventReusableWorkflowErrors(t *testing.T) {
+	ctx := context.Background()
+
+	tables := []struct {
+		workflowPath string
+		errorMessage string
+	}{
+		{"too-deep.yml", "exceeds the maximum of 4 nested workflow levels"},
+		{"invalid-input-type.yml", "input 'count' has type 'number', but got 'two'"},
+		{"missing-input.yml", "input 'name' is required"},
+		{"undefined-input.yml", "'unknown' is not defined in the referenced workflow"},
+		{"missing-secret.yml", "secret 'token' is required"},
+	}
+
+	for _, table := range tables {
+		t.Run(table.workflowPath, func(t *testing.T) {
+			workdir, err := filepath.Abs(workdir)
+			assert.Nil(t, err)
+			// the local reusable workflows are relative to the current directory
+			t.Chdir(workdir)
+
+			runner, err := New(&Config{
+				Workdir:        workdir,
+				EventName:      "push",
+				Platforms:      platforms,
+				Secrets:        map[string]string{"secret": "keep_it_private"},
+				GitHubInstance: "github.com",
+			})
+			assert.Nil(t, err)
+
+			planner, err := model.NewWorkflowPlanner(filepath.Join(workdir, "reusable-workflow-errors", table.workflowPath), true)
+			assert.Nil(t, err)
+
+			plan, err := planner.PlanEvent("push")
+			assert.Nil(t, err)
+
+			err = runner.NewPlanExecutor(plan)(ctx)
+			assert.ErrorContains(t, err, table.errorMessage)
+		})
+	}
+}
+
+func TestRunEventMatrixErrors(t *testing.T) {
+	ctx := context.Background()
+
+	tables := []struct {
+		workflowPath string
+		errorMessage string
+	}{
+		// the legs are expanded from the outputs of the needed job, the last one is not a number
+		{"evalmatrix-needs-dynamic", "input 'count' has type 'number', but got 'three'"},
+		{"evalmatrix-invalid", "matrix must be a mapping, but evaluated to 'not-a-mapping'"},
+	}
+
+	for _, table := range tables {
+		t.Run(table.workflowPath, func(t *testing.T) {
+			workdir, err := filepath.Abs(workdir)
+			assert.Nil(t, err)
+			// the local reusable workflows are relative to the current directory
+			t.Chdir(workdir)
+
+			runner, err := New(&Config{
+				Workdir:        workdir,
+				EventName:      "push",
+				Platforms:      platforms,
+				GitHubInstance: "github.com",
+			})
+			assert.Nil(t, err)
+
+			planner, err := model.NewWorkflowPlanner(filepath.Join(workdir, table.workflowPath), true)
+			assert.Nil(t, err)
+
+			plan, err := planner.PlanEvent("push")
+			assert.Nil(t, err)
+
+			err = runner.NewPlanExecutor(plan)(ctx)
+			assert.ErrorContains(t, err, table.errorMessage)
+		})
+	}
+}
+
 func TestRunEvent(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test")
@@ -247,6 +328,7 @@ func TestRunEvent(t *testing.T) {
 		{workdir, "remote-action-composite-action-ref", "push", "", platforms, secrets},
 		{workdir, "uses-workflow", "push", "", platforms, map[string]string{"secret": "keep_it_private"}},
 		{workdir, "uses-workflow", "pull_request", "", platforms, map[string]string{"secret": "keep_it_private"}},
+		{workdir, "reusable-workflow-nested", "push", "", platforms, map[string]string{"secret": "keep_it_private"}},
 		{workdir, "uses-docker-url", "push", "", platforms, secrets},
 		{workdir, "act-composite-env-test", "push", "", platforms, secrets},
 
@@ -682,3 +764,104 @@ func TestRunMatrixWithUserDefinedInclusions(t *testing.T) {
 
 	tjfi.runTest(context.Background(), t, &Config{Matrix: matrix})
 }
+
+func TestSelectMatrixes(t *testing.T) {
+	matrixes := []map[string]interface{}{
+		{"os": "ubuntu-latest", "node": 16},
+		{"os": "ubuntu-latest", "node": 18},
+		{"os": "macos-latest", "node": 16},
+		{"os": "windows-latest", "node": 18},
+	}
+
+	tables := []struct {
+		name    string
+		matrix  map[string]map[string]bool
+		indexes map[int]bool
+		want    []int
+	}{
+		{"all", nil, nil, []int{0, 1, 2, 3}},
+		{"exact", map[string]map[string]bool{"node": {"16": true}}, nil, []int{0, 2}},
+		{"multiple values", map[string]map[string]bool{"os": {"macos-latest": true, "windows-latest": true}}, nil, []int{2, 3}},
+		{"wildcard", map[string]map[string]bool{"os": {"*-latest": true}, "node": {"1?": false}}, nil, []int{}},
+		{"negation", map[string]map[string]bool{"os": {"ubuntu-*": false}}, nil, []int{2, 3}},
+		{"inclusion and negation", map[string]map[string]bool{"os": {"*-latest": true, "windows-*": false}, "node": {"18": true}}, nil, []int{1}},
+		{"unknown key", map[string]map[string]bool{"arch": {"arm64": true}}, nil, []int{0, 1, 2, 3}},
+		{"index range", nil, map[int]bool{2: true, 3: true}, []int{1, 2}},
+		{"index range and values", map[string]map[string]bool{"node": {"16": true}}, map[int]bool{2: true, 3: true}, []int{2}},
+	}
+
+	for _, table := range tables {
+		t.Run(table.name, func(t *testing.T) {
+			assert.Equal(t, table.want, selectMatrixes(matrixes, table.matrix, table.indexes))
+		})
+	}
+
+	// jobs without a matrix are not filtered by index
+	assert.Equal(t, []int{0}, selectMatrixes([]map[string]interface{}{{}}, nil, map[int]bool{2: true}))
+}
+
+func TestExpandMatrix(t *testing.T) {
+	workdir, err := filepath.Abs(workdir)
+	assert.Nil(t, err)
+
+	planner, err := model.NewWorkflowPlanner(filepath.Join(workdir, "matrix-include-exclude"), true)
+	assert.Nil(t, err)
+	plan, err := planner.PlanJob("build")
+	assert.Nil(t, err)
+
+	config := &Config{
+		Workdir:       workdir,
+		EventName:     "push",
+		Matrix:        map[string]map[string]bool{"os": {"macos-*": false}},
+		MatrixIndexes: map[int]bool{3: true, 4: true, 5: true, 8: true},
+	}
+	combinations, err := ExpandMatrix(context.Background(), config, plan.Stages[0].Runs[0])
+	assert.Nil(t, err)
+	assert.Equal(t, []MatrixCombination{
+		{Index: 3, Matrix: map[string]interface{}{"os": "ubuntu-18.04", "node": 8}},
+		{Index: 4, Matrix: map[string]interface{}{"os": "ubuntu-18.04", "node": 10}},
+		{Index: 8, Matrix: map[string]interface{}{"os": "ubuntu-16.04", "node": 10}},
+	}, combinations)
+}
+
+func TestRunEventReusableWorkflowEvents(t *testing.T) {
+	workdir, err := filepath.Abs(workdir)
+	assert.Nil(t, err)
+	// the local reusable workflows are relative to the current directory
+	t.Chdir(workdir)
+
+	runState, err := NewRunState(t.TempDir(), "push")
+	assert.Nil(t, err)
+	buf := &bytes.Buffer{}
+	runner, err := New(&Config{
+		Workdir:        workdir,
+		EventName:      "push",
+		Platforms:      platforms,
+		GitHubInstance: "github.com",
+		RunState:       runState,
+		Events:         NewEventWriter(buf),
+	})
+	assert.Nil(t, err)
+
+	planner, err := model.NewWorkflowPlanner(filepath.Join(workdir, "reusable-workflow-events", "push.yml"), true)
+	assert.Nil(t, err)
+	plan, err := planner.PlanEvent("push")
+	assert.Nil(t, err)
+
+	assert.NoError(t, runner.NewPlanExecutor(plan)(context.Background()))
+
+	// the reusable workflow runs within the run of the caller
+	runEvents := []Event{}
+	for _, event := range readEvents(t, buf) {
+		if event.Type == EventRunStarted || event.Type == EventRunFinished {
+			runEvents = append(runEvents, event)
+		}
+	}
+	if assert.Len(t, runEvents, 2) {
+		assert.Equal(t, EventRunStarted, runEvents[0].Type)
+		assert.Equal(t, [][]string{{"push.yml:call"}}, runEvents[0].Plan)
+		assert.Equal(t, EventRunFinished, runEvents[1].Type)
+	}
+	assert.Equal(t, [][]string{{"push.yml:call"}}, runState.Plan)
+	assert.Equal(t, "success", runState.Result)
+}
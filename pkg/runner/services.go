@@ -0,0 +1,144 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/container"
+	"github.com/nektos/act/pkg/model"
+)
+
+// jobServices returns the services of the job with the services of its docker-compose.yml, which they take precedence over,
+// and the named volumes the file declares
+func (rc *RunContext) jobServices(ctx context.Context) (map[string]*model.ContainerSpec, []string, error) {
+	job := rc.Run.Job()
+	services := map[string]*model.ContainerSpec{}
+	var volumes []string
+	if job.Compose != nil && job.Compose.File != "" {
+		path := rc.ExprEval.Interpolate(ctx, job.Compose.File)
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(rc.Config.Workdir, path)
+		}
+		project, err := model.ReadCompose(path, job.Compose.Services)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load the services of the job: %w", err)
+		}
+		maps.Copy(services, project.Services)
+		volumes = project.Volumes
+	}
+	maps.Copy(services, job.Services)
+	if err := model.CheckServiceDependencies(services); err != nil {
+		return nil, nil, err
+	}
+	return services, volumes, nil
+}
+
+// hasServices returns true if the job has services, of its own or of a docker-compose.yml
+func (rc *RunContext) hasServices() bool {
+	job := rc.Run.Job()
+	return len(job.Services) > 0 || (job.Compose != nil && job.Compose.File != "")
+}
+
+// composeVolumeName returns the name of the docker volume of a named volume of the docker-compose.yml,
+// which only the containers of the job share and which is removed with them
+func (rc *RunContext) composeVolumeName(volume string) string {
+	return rc.jobContainerName() + "-" + volume
+}
+
+// jobVolume returns a volume of a container of the job, whose name is the one of the job if it is a named volume of the docker-compose.yml
+func (rc *RunContext) jobVolume(volume string) string {
+	name, path, ok := strings.Cut(volume, ":")
+	if ok && slices.Contains(rc.composeVolumes, name) {
+		return rc.composeVolumeName(name) + ":" + path
+	}
+	return volume
+}
+
+// serviceStartup is the startup of a service container, which the services depending on it wait for
+type serviceStartup struct {
+	id        string
+	name      string // the name of the container
+	container container.ExecutionsEnvironment
+	dependsOn model.ServiceDependencies
+	oneShot   bool
+	started   chan struct{} // closed once the container has started, or a one-shot container has exited
+	startErr  error         // set before started is closed
+	ready     chan struct{} // closed once the healthcheck of the container has passed, right after started if it has none
+	err       error         // set before ready is closed
+}
+
+func newServiceStartup(id, name string, c container.ExecutionsEnvironment, spec *model.ContainerSpec) *serviceStartup {
+	return &serviceStartup{
+		id:        id,
+		name:      name,
+		container: c,
+		dependsOn: spec.DependsOn,
+		oneShot:   spec.OneShot,
+		started:   make(chan struct{}),
+		ready:     make(chan struct{}),
+	}
+}
+
+// start starts the container once the services it depends on meet their condition, a one-shot container
+// is attached to until it exits and fails if its exit code isn't 0
+func (s *serviceStartup) start(rc *RunContext, startups map[string]*serviceStartup) common.Executor {
+	return func(ctx context.Context) error {
+		err := s.waitForDependencies(ctx, startups)
+		if err == nil {
+			if s.oneShot {
+				common.Logger(ctx).Infof("Running one-shot service %s", s.id)
+			}
+			err = common.NewPipelineExecutor(
+				s.container.Pull(false),
+				s.container.Create(rc.Config.ContainerCapAdd, rc.Config.ContainerCapDrop),
+				s.container.Start(s.oneShot),
+			)(ctx)
+		}
+		s.startErr = err
+		close(s.started)
+
+		if err == nil && !s.oneShot {
+			err = container.NewContainerHealthyExecutor(s.name).IfNot(common.Dryrun)(ctx)
+		}
+		s.err = err
+		close(s.ready)
+		if err != nil {
+			return fmt.Errorf("failed to start service %s: %w", s.id, err)
+		}
+		return nil
+	}
+}
+
+func (s *serviceStartup) waitForDependencies(ctx context.Context, startups map[string]*serviceStartup) error {
+	ids := make([]string, 0, len(s.dependsOn))
+	for id := range s.dependsOn {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		dependency := startups[id]
+		if dependency == nil {
+			return fmt.Errorf("service %s depends on service %s, which doesn't exist", s.id, id)
+		}
+		common.Logger(ctx).Debugf("Service %s waits for service %s (%s)", s.id, id, s.dependsOn[id])
+		done, err := dependency.ready, &dependency.err
+		if s.dependsOn[id] == model.ServiceStarted {
+			done, err = dependency.started, &dependency.startErr
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-done:
+		}
+		if *err != nil {
+			return fmt.Errorf("service %s depends on service %s, which failed to start", s.id, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/container"
+	"github.com/nektos/act/pkg/model"
+)
+
+func TestRunContextJobServices(t *testing.T) {
+	ctx := context.Background()
+	workdir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(workdir, "docker-compose.yml"), []byte(`
+services:
+  db:
+    image: postgres:16
+    volumes: [pgdata:/var/lib/postgresql/data]
+  cache:
+    image: redis
+volumes:
+  pgdata:
+`), 0o600))
+
+	rc := createIfTestRunContext(map[string]*model.Job{
+		"job1": createJob(t, `
+runs-on: ubuntu-latest
+compose:
+  file: docker-compose.yml
+  services: [db]
+container:
+  image: node:20
+  volumes: [pgdata:/data]
+services:
+  api:
+    image: api
+    depends-on:
+      db:
+        condition: service_healthy
+`, ""),
+	})
+	rc.Config.Workdir = workdir
+	assert.True(t, rc.hasServices())
+
+	services, volumes, err := rc.jobServices(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, services, 2)
+	assert.Equal(t, "postgres:16", services["db"].Image)
+	assert.Equal(t, model.ServiceDependencies{"db": model.ServiceHealthy}, services["api"].DependsOn)
+	assert.Equal(t, []string{"pgdata"}, volumes)
+
+	// the named volumes of the file are the ones of the job
+	rc.composeVolumes = volumes
+	assert.Equal(t, rc.jobContainerName()+"-pgdata:/data", rc.jobVolume("pgdata:/data"))
+	assert.Equal(t, "other:/data", rc.jobVolume("other:/data"))
+	_, mounts := rc.GetBindsAndMounts()
+	assert.Equal(t, "/data", mounts[rc.jobContainerName()+"-pgdata"])
+	assert.Contains(t, rc.Config.ValidVolumes, rc.jobContainerName()+"-pgdata")
+
+	rc.Run.Job().Services["api"].DependsOn = model.ServiceDependencies{"web": model.ServiceStarted}
+	_, _, err = rc.jobServices(ctx)
+	assert.EqualError(t, err, "service api depends on service web, which doesn't exist")
+}
+
+type serviceContainerMock struct {
+	container.Container
+	container.LinuxContainerEnvironmentExtensions
+	id      string
+	started *[]string
+	mu      *sync.Mutex
+	err     error
+}
+
+func (m *serviceContainerMock) Pull(_ bool) common.Executor {
+	return func(_ context.Context) error { return nil }
+}
+
+func (m *serviceContainerMock) Create(_, _ []string) common.Executor {
+	return func(_ context.Context) error { return nil }
+}
+
+func (m *serviceContainerMock) Start(_ bool) common.Executor {
+	return func(_ context.Context) error {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		*m.started = append(*m.started, m.id)
+		return m.err
+	}
+}
+
+func TestStartServiceContainers(t *testing.T) {
+	// the healthchecks are skipped on a dry run
+	ctx := common.WithDryrun(context.Background(), true)
+	services := map[string]*model.ContainerSpec{
+		"api":     {DependsOn: model.ServiceDependencies{"migrate": model.ServiceCompletedSuccessfully, "cache": model.ServiceStarted}},
+		"migrate": {OneShot: true, DependsOn: model.ServiceDependencies{"db": model.ServiceHealthy}},
+		"db":      {},
+		"cache":   {},
+	}
+	newRunContext := func(failing string) (*RunContext, *[]string) {
+		rc := &RunContext{Config: &Config{}}
+		started := &[]string{}
+		mu := &sync.Mutex{}
+		for _, id := range []string{"api", "migrate", "db", "cache"} {
+			c := &serviceContainerMock{id: id, started: started, mu: mu}
+			if id == failing {
+				c.err = errors.New("exit with `FAILURE`: 1")
+			}
+			rc.serviceStartups = append(rc.serviceStartups, newServiceStartup(id, id, c, services[id]))
+		}
+		return rc, started
+	}
+
+	rc, started := newRunContext("")
+	assert.NoError(t, rc.startServiceContainers("")(ctx))
+	assert.Len(t, *started, 4)
+	index := func(id string) int {
+		for i, s := range *started {
+			if s == id {
+				return i
+			}
+		}
+		return -1
+	}
+	assert.Less(t, index("db"), index("migrate"))
+	assert.Less(t, index("migrate"), index("api"))
+	assert.Less(t, index("cache"), index("api"))
+
+	rc, started = newRunContext("migrate")
+	err := rc.startServiceContainers("")(ctx)
+	assert.ErrorContains(t, err, "failed to start service")
+	assert.NotContains(t, *started, "api")
+}
@@ -2,6 +2,7 @@ package runner
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"path"
 	"strconv"
@@ -62,7 +63,7 @@ func processRunnerEnvFileCommand(ctx context.Context, fileName string, rc *RunCo
 }
 
 func runStepExecutor(step step, stage stepStage, executor common.Executor) common.Executor {
-	return func(ctx context.Context) error {
+	return func(ctx context.Context) (err error) {
 		logger := common.Logger(ctx)
 		rc := step.getRunContext()
 		stepModel := step.getStepModel()
@@ -79,7 +80,7 @@ func runStepExecutor(step step, stage stepStage, executor common.Executor) commo
 			rc.StepResults[rc.CurrentStep] = stepResult
 		}
 
-		err := setupEnv(ctx, step)
+		err = setupEnv(ctx, step)
 		if err != nil {
 			return err
 		}
@@ -95,6 +96,7 @@ func runStepExecutor(step step, stage stepStage, executor common.Executor) commo
 			stepResult.Conclusion = model.StepStatusSkipped
 			stepResult.Outcome = model.StepStatusSkipped
 			logger.WithField("stepResult", stepResult.Outcome).Debugf("Skipping step '%s' due to '%s'", stepModel, ifExpression)
+			rc.emitStepEvent(ctx, EventStepFinished, stepModel, stage, stepResult, nil)
 			return nil
 		}
 
@@ -103,6 +105,10 @@ func runStepExecutor(step step, stage stepStage, executor common.Executor) commo
 			stepString = "add-mask command"
 		}
 		logger.Infof("\u2B50 Run %s %s", stage, stepString)
+		rc.emitStepEvent(ctx, EventStepStarted, stepModel, stage, nil, nil)
+		defer func() {
+			rc.emitStepEvent(ctx, EventStepFinished, stepModel, stage, stepResult, err)
+		}()
 
 		// Prepare and clean Runner File Commands
 		actPath := rc.JobContainer.GetActPath()
@@ -157,13 +163,56 @@ func runStepExecutor(step step, stage stepStage, executor common.Executor) commo
 			Mode: 0o666,
 		})(ctx)
 
-		timeoutctx, cancelTimeOut := evaluateStepTimeout(ctx, rc.ExprEval, stepModel)
-		defer cancelTimeOut()
-		err = executor(timeoutctx)
+		if stage == stepStageMain && isBreakpoint(rc, step) {
+			action, err := debugStep(ctx, step, stepString, false)
+			if err != nil {
+				return err
+			}
+			switch action {
+			case DebugActionSkip:
+				stepResult.Conclusion = model.StepStatusSkipped
+				stepResult.Outcome = model.StepStatusSkipped
+				logger.WithField("stepResult", stepResult.Outcome).Infof("Skipping step '%s' after debugging it", stepString)
+				return nil
+			case DebugActionAbort:
+				stepResult.Conclusion = model.StepStatusFailure
+				stepResult.Outcome = model.StepStatusFailure
+				return fmt.Errorf("step '%s' has been aborted while debugging it", stepString)
+			}
+		}
 
+		usage := &container.ResourceUsage{}
+		defer rc.recordResourceUsage(ctx, stepModel, stage, usage)
+		runExecutor := func() error {
+			timeoutctx, cancelTimeOut := evaluateStepTimeout(ctx, rc.ExprEval, stepModel)
+			defer cancelTimeOut()
+			return executor(container.WithResourceUsage(timeoutctx, usage))
+		}
+		err = runExecutor()
+		for err != nil && stage == stepStageMain && canDebugFailure(ctx, rc) {
+			logger.Errorf("  \u274C  Failure - %s %s: %v", stage, stepString, err)
+			action, debugErr := debugStep(ctx, step, stepString, true)
+			if debugErr != nil {
+				logger.Errorf("Failed to debug step '%s': %v", stepString, debugErr)
+				break
+			}
+			if action == DebugActionSkip {
+				stepResult.Conclusion = model.StepStatusSkipped
+				stepResult.Outcome = model.StepStatusSkipped
+				logger.WithField("stepResult", stepResult.Outcome).Infof("Skipping step '%s' after debugging it", stepString)
+				return nil
+			} else if action == DebugActionAbort {
+				break
+			}
+			logger.Infof("\u2B50 Retry %s %s", stage, stepString)
+			err = runExecutor()
+		}
+
+		resultLogger := logger.WithField("resourceUsage", usage)
 		if err == nil {
-			logger.WithField("stepResult", stepResult.Outcome).Infof("  \u2705  Success - %s %s", stage, stepString)
+			resultLogger.WithField("stepResult", stepResult.Outcome).Infof("  \u2705  Success - %s %s", stage, stepString)
 		} else {
+			stepErr := err
 			stepResult.Outcome = model.StepStatusFailure
 
 			continueOnError, parseErr := isContinueOnError(ctx, stepModel.RawContinueOnError, step, stage)
@@ -180,7 +229,11 @@ func runStepExecutor(step step, stage stepStage, executor common.Executor) commo
 				stepResult.Conclusion = model.StepStatusFailure
 			}
 
-			logger.WithField("stepResult", stepResult.Outcome).Errorf("  \u274C  Failure - %s %s", stage, stepString)
+			if errors.Is(stepErr, container.ErrOutOfMemory) {
+				resultLogger.WithField("stepResult", stepResult.Outcome).Errorf("  \u274C  Failure - %s %s: %v", stage, stepString, stepErr)
+			} else {
+				resultLogger.WithField("stepResult", stepResult.Outcome).Errorf("  \u274C  Failure - %s %s", stage, stepString)
+			}
 		}
 		// Process Runner File Commands
 		orgerr := err
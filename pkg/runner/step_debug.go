@@ -0,0 +1,79 @@
+package runner
+
+import (
+	"context"
+	"sync"
+
+	"github.com/nektos/act/pkg/common"
+)
+
+// DebugAction is what to do with a step once it has been debugged in a shell
+type DebugAction int
+
+const (
+	DebugActionRun   DebugAction = iota // run the step, again if it has failed
+	DebugActionSkip                     // skip the step, like a step whose if condition is false
+	DebugActionAbort                    // fail the step
+)
+
+// DebugPrompt asks what to do with the debugged step after its shell has exited, failed tells if the step has failed
+type DebugPrompt func(ctx context.Context, step string, failed bool) (DebugAction, error)
+
+// debugLock keeps the jobs running in parallel from debugging in the terminal at the same time
+var debugLock sync.Mutex
+
+// isBreakpoint returns true if the job pauses before the step, only the steps of the job itself are breakpoints
+func isBreakpoint(rc *RunContext, step step) bool {
+	if rc.Parent != nil {
+		return false
+	}
+	for _, id := range rc.Config.BreakBefore {
+		if id == step.getStepModel().ID {
+			return true
+		}
+	}
+	return false
+}
+
+// canDebugFailure returns true if the job pauses when the step fails, composite actions are debugged as a whole
+func canDebugFailure(ctx context.Context, rc *RunContext) bool {
+	return rc.Config.DebugOnFailure && rc.Parent == nil && ctx.Err() == nil
+}
+
+// debugStep opens a shell with the env, working directory and PATH of the step, and asks what to do with the step once it exits
+func debugStep(ctx context.Context, step step, stepString string, failed bool) (DebugAction, error) {
+	logger := common.Logger(ctx)
+	rc := step.getRunContext()
+
+	debugLock.Lock()
+	defer debugLock.Unlock()
+
+	env := make(map[string]string, len(*step.getEnv()))
+	for k, v := range *step.getEnv() {
+		env[k] = v
+	}
+	rc.ApplyExtraPath(ctx, &env)
+
+	workdir := ""
+	if sr, ok := step.(*stepRun); ok {
+		sr.setupWorkingDirectory(ctx)
+		workdir = sr.WorkingDirectory
+	}
+
+	if failed {
+		logger.Infof("\U0001F41E  Step %s has failed, opening a shell to debug it, exit the shell to continue", stepString)
+	} else {
+		logger.Infof("\U0001F41E  Pausing before step %s, opening a shell to debug it, exit the shell to continue", stepString)
+	}
+	if err := rc.JobContainer.Shell(env, "", workdir)(ctx); err != nil {
+		logger.Debugf("The debug shell has exited: %v", err)
+	}
+
+	if rc.Config.DebugPrompt == nil {
+		if failed {
+			return DebugActionAbort, nil
+		}
+		return DebugActionRun, nil
+	}
+	return rc.Config.DebugPrompt(ctx, stepString, failed)
+}
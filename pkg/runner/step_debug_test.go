@@ -0,0 +1,89 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/nektos/act/pkg/model"
+)
+
+func TestStepRunDebug(t *testing.T) {
+	for _, tt := range []struct {
+		name        string
+		breakBefore []string
+		action      DebugAction
+		execErrors  []error
+		outcome     string
+		err         string
+	}{
+		{"retry failed step", nil, DebugActionRun, []error{errors.New("failed"), nil}, "success", ""},
+		{"skip failed step", nil, DebugActionSkip, []error{errors.New("failed")}, "skipped", ""},
+		{"abort failed step", nil, DebugActionAbort, []error{errors.New("failed")}, "failure", "failed"},
+		{"run step at breakpoint", []string{"build"}, DebugActionRun, []error{nil}, "success", ""},
+		{"skip step at breakpoint", []string{"build"}, DebugActionSkip, nil, "skipped", ""},
+		{"abort step at breakpoint", []string{"build"}, DebugActionAbort, nil, "failure", "step 'cmd' has been aborted while debugging it"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			cm := &containerMock{}
+			sr := &stepRun{
+				RunContext: &RunContext{
+					StepResults: map[string]*model.StepResult{},
+					ExprEval:    &expressionEvaluator{},
+					Config: &Config{
+						DebugOnFailure: true,
+						BreakBefore:    tt.breakBefore,
+						DebugPrompt: func(_ context.Context, _ string, _ bool) (DebugAction, error) {
+							return tt.action, nil
+						},
+					},
+					Run: &model.Run{
+						JobID: "1",
+						Workflow: &model.Workflow{
+							Jobs: map[string]*model.Job{
+								"1": {},
+							},
+						},
+					},
+					JobContainer: cm,
+				},
+				Step: &model.Step{
+					ID:    "build",
+					Run:   "cmd",
+					Shell: "bash",
+				},
+			}
+
+			cm.On("Shell", mock.AnythingOfType("map[string]string"), "", "").Return(func(_ context.Context) error {
+				return nil
+			})
+			cm.On("Copy", "/var/run/act", mock.AnythingOfType("[]*container.FileEntry")).Return(func(_ context.Context) error {
+				return nil
+			})
+			for _, execErr := range tt.execErrors {
+				execErr := execErr
+				cm.On("Exec", mock.AnythingOfType("[]string"), mock.AnythingOfType("map[string]string"), "", "").Return(func(_ context.Context) error {
+					return execErr
+				}).Once()
+			}
+			cm.On("UpdateFromEnv", mock.AnythingOfType("string"), mock.AnythingOfType("*map[string]string")).Return(func(_ context.Context) error {
+				return nil
+			})
+			cm.On("GetContainerArchive", mock.Anything, "/var/run/act/workflow/pathcmd.txt").Return(io.NopCloser(&bytes.Buffer{}), nil)
+
+			err := sr.main()(context.Background())
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.outcome, sr.RunContext.StepResults["build"].Outcome.String())
+			cm.AssertNumberOfCalls(t, "Exec", len(tt.execErrors))
+		})
+	}
+}
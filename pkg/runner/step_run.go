@@ -35,7 +35,7 @@ func (sr *stepRun) main() common.Executor {
 		sr.setupShellCommandExecutor(),
 		func(ctx context.Context) error {
 			sr.getRunContext().ApplyExtraPath(ctx, &sr.env)
-			if he, ok := sr.getRunContext().JobContainer.(*container.HostEnvironment); ok && he != nil {
+			if he := sr.getRunContext().hostEnvironment(); he != nil {
 				return he.ExecWithCmdLine(sr.cmd, sr.cmdline, sr.env, "", sr.WorkingDirectory)(ctx)
 			}
 			return sr.getRunContext().JobContainer.Exec(sr.cmd, sr.env, "", sr.WorkingDirectory)(ctx)
@@ -176,7 +176,7 @@ func (sr *stepRun) setupShell(ctx context.Context) {
 	}
 
 	if step.Shell == "" {
-		if _, ok := rc.JobContainer.(*container.HostEnvironment); ok {
+		if rc.hostEnvironment() != nil {
 			shellWithFallback := []string{"bash", "sh"}
 			// Don't use bash on windows by default, if not using a docker container
 			if runtime.GOOS == "windows" {
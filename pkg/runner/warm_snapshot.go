@@ -0,0 +1,275 @@
+package runner
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.yaml.in/yaml/v4"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/container"
+	"github.com/nektos/act/pkg/model"
+)
+
+const (
+	warmSnapshotRepository = "act-snapshot"
+	warmSnapshotJobLabel   = "act.snapshot.job"
+	warmSnapshotLegLabel   = "act.snapshot.leg"   // the job and matrix leg of the working directory, whose earlier snapshots are removed
+	warmSnapshotStepsLabel = "act.snapshot.steps" // the states of the steps the snapshot was taken after
+)
+
+// warmSnapshot is the image a job container was committed to after its setup steps, which later runs of the job start from
+type warmSnapshot struct {
+	image string
+	steps []*StepState
+}
+
+// jobDefinition returns the hash of the definition of the job container: its image, options, environment, volumes and services.
+// A reused container is replaced when it changes.
+func (rc *RunContext) jobDefinition(input *container.NewContainerInput, capAdd, capDrop []string, services map[string]*model.ContainerSpec) string {
+	env := append([]string{}, input.Env...)
+	sort.Strings(env)
+	binds := append([]string{}, input.Binds...)
+	sort.Strings(binds)
+	definition, _ := json.Marshal(map[string]interface{}{
+		"image":      input.Image,
+		"entrypoint": input.Entrypoint,
+		"env":        env,
+		"binds":      binds,
+		"mounts":     input.Mounts,
+		"network":    input.NetworkMode,
+		"privileged": input.Privileged,
+		"userns":     input.UsernsMode,
+		"platform":   input.Platform,
+		"options":    input.Options,
+		"resources":  input.Resources,
+		"cap_add":    capAdd,
+		"cap_drop":   capDrop,
+		"services":   services,
+	})
+	hash := sha256.Sum256(definition)
+	return hex.EncodeToString(hash[:])
+}
+
+// warmSnapshotIndex returns the index of the step of --warm-snapshot-after in steps, -1 if the job has no such step
+func (rc *RunContext) warmSnapshotIndex(steps []*model.Step) int {
+	if rc.Config == nil || rc.Config.WarmSnapshotAfter == "" {
+		return -1
+	}
+	for i, step := range steps {
+		if step != nil && step.ID == rc.Config.WarmSnapshotAfter {
+			return i
+		}
+	}
+	return -1
+}
+
+// warmSnapshotImage returns the image of the warm snapshot of the job, whose tag is the hash of the definition of the job,
+// of the ID of its image, of its matrix and env, of the steps up to the one the snapshot is taken after, and of the files
+// of the working directory the steps may read, or of --warm-snapshot-key instead
+func (rc *RunContext) warmSnapshotImage(ctx context.Context, definition, imageID string) (string, error) {
+	key := rc.Config.WarmSnapshotKey
+	if key == "" {
+		snapshot, err := container.GetWorkspaceSnapshot(ctx, rc.Config.Workdir+string(filepath.Separator)+".", rc.Config.UseGitIgnore)
+		if err != nil {
+			return "", fmt.Errorf("failed to hash the files of the working directory: %w", err)
+		}
+		key = snapshot.Digest
+	}
+	steps := rc.Run.Job().Steps
+	index := rc.warmSnapshotIndex(steps)
+	setup, err := yaml.Marshal(steps[:index+1])
+	if err != nil {
+		return "", err
+	}
+	matrix, err := json.Marshal(rc.Matrix)
+	if err != nil {
+		return "", err
+	}
+	env, err := json.Marshal(mergeMaps(rc.Run.Workflow.Env, rc.Run.Job().Environment(), rc.Config.Env))
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	for _, part := range [][]byte{[]byte(definition), []byte(imageID), matrix, env, setup, []byte(key)} {
+		hash.Write(part)
+		hash.Write([]byte{0})
+	}
+	return fmt.Sprintf("%s:%s", warmSnapshotRepository, hex.EncodeToString(hash.Sum(nil))[:32]), nil
+}
+
+// warmSnapshotLeg returns the hash of the working directory, the workflow, the job and its matrix, which the snapshots
+// of the same job and matrix leg share whatever their definition
+func (rc *RunContext) warmSnapshotLeg() string {
+	matrix, _ := json.Marshal(rc.Matrix)
+	hash := sha256.New()
+	for _, part := range [][]byte{[]byte(rc.Config.Workdir), []byte(rc.Run.Workflow.File), []byte(rc.String()), matrix} {
+		hash.Write(part)
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// startFromWarmSnapshot starts the job container from the warm snapshot of the job if there is one, the steps up to
+// the one it was taken after are restored from it instead of running them
+func (rc *RunContext) startFromWarmSnapshot(input *container.NewContainerInput) common.Executor {
+	return func(ctx context.Context) error {
+		logger := common.Logger(ctx)
+		rc.warmSnapshot = nil
+		if rc.warmSnapshotIndex(rc.Run.Job().Steps) < 0 {
+			return nil
+		}
+		imageID, err := container.ImageID(ctx, input.Image)
+		if err != nil {
+			logger.Warnf("Failed to find the image of job %s, it won't start from a warm snapshot: %v", rc.JobName, err)
+			return nil
+		}
+		image, err := rc.warmSnapshotImage(ctx, input.Definition, imageID)
+		if err != nil {
+			return err
+		}
+		rc.warmSnapshot = &warmSnapshot{image: image}
+
+		labels, ok, err := container.ImageLabels(ctx, image)
+		if err != nil || !ok {
+			logger.Infof("No warm snapshot %s for job %s yet, it will be taken after step %s", image, rc.JobName, rc.Config.WarmSnapshotAfter)
+			return nil
+		}
+		if err := json.Unmarshal([]byte(labels[warmSnapshotStepsLabel]), &rc.warmSnapshot.steps); err != nil || len(rc.warmSnapshot.steps) == 0 {
+			logger.Warnf("Ignoring the warm snapshot %s, it has no steps: %v", image, err)
+			return nil
+		}
+		logger.Infof("\u267B  Starting job %s from the warm snapshot %s", rc.JobName, image)
+		input.Image = image
+		return nil
+	}
+}
+
+// warmSnapshotRestored returns true if the job container started from a warm snapshot
+func (rc *RunContext) warmSnapshotRestored() bool {
+	return rc.warmSnapshot != nil && len(rc.warmSnapshot.steps) > 0
+}
+
+// restoreWarmSnapshot restores the steps context, env, path and saved state of the steps of the warm snapshot
+func (rc *RunContext) restoreWarmSnapshot(ctx context.Context) error {
+	if !rc.warmSnapshotRestored() {
+		return nil
+	}
+	steps := rc.warmSnapshot.steps
+	rc.applyStepStates(steps)
+	if runState := rc.Config.RunState; runState != nil && rc.Run != nil {
+		for _, step := range steps {
+			if err := runState.finishStep(rc.stateKey(), step); err != nil {
+				common.Logger(ctx).Warnf("Failed to record the state of step %s: %v", step.ID, err)
+			}
+		}
+	}
+	common.Logger(ctx).Infof("\u23E9  Skipping %d steps of job %s, they ran before the warm snapshot %s was taken", len(steps), rc.JobName, rc.warmSnapshot.image)
+	return nil
+}
+
+// unlessWarmSnapshot skips a stage of a step which ran before the warm snapshot the job container started from was taken
+func (rc *RunContext) unlessWarmSnapshot(executor common.Executor) common.Executor {
+	return executor.IfNot(func(context.Context) bool {
+		return rc.warmSnapshotRestored()
+	})
+}
+
+// takeWarmSnapshot commits the job container to the warm snapshot of the job once the steps up to setupSteps have succeeded
+func (rc *RunContext) takeWarmSnapshot(setupSteps []*model.Step) common.Executor {
+	return func(ctx context.Context) error {
+		logger := common.Logger(ctx)
+		if rc.warmSnapshot == nil || rc.warmSnapshotRestored() || common.JobError(ctx) != nil {
+			return nil
+		}
+		steps := make([]*StepState, 0, len(setupSteps))
+		for _, stepModel := range setupSteps {
+			step := rc.newStepState(ctx, stepModel, time.Now())
+			if step.Result.Conclusion == model.StepStatusFailure {
+				return nil
+			}
+			step.StartedAt, step.FinishedAt = nil, nil
+			steps = append(steps, step)
+		}
+		// the env, path and saved state are restored from the last step
+		for _, step := range steps[:len(steps)-1] {
+			step.Env, step.Path, step.IntraActionState = nil, nil, nil
+		}
+		// the states are kept in a label of the image, which anyone inspecting it can read
+		if value := rc.warmSnapshotSecret(ctx, steps); value != "" {
+			logger.Warnf("Not taking the warm snapshot of job %s, the %s holds a secret or a masked value", rc.JobName, value)
+			return nil
+		}
+		content, err := json.Marshal(steps)
+		if err != nil {
+			return err
+		}
+		labels := map[string]string{
+			warmSnapshotJobLabel:   rc.String(),
+			warmSnapshotLegLabel:   rc.warmSnapshotLeg(),
+			warmSnapshotStepsLabel: string(content),
+		}
+		if err := container.NewDockerCommitExecutor(rc.jobContainerName(), rc.warmSnapshot.image, labels)(ctx); err != nil {
+			logger.Warnf("Failed to take the warm snapshot of job %s: %v", rc.JobName, err)
+			return nil
+		}
+		logger.Infof("\U0001F4F8  Took the warm snapshot %s of job %s after step %s", rc.warmSnapshot.image, rc.JobName, rc.Config.WarmSnapshotAfter)
+		if common.Dryrun(ctx) {
+			return nil
+		}
+		// unlike the outputs and env of the steps, the files can't be checked for secrets
+		logger.Warnf("The warm snapshot %s keeps the files the steps up to %s wrote to the job container, including the credentials they wrote to files such as ~/.npmrc, "+
+			"anyone with access to the docker daemon can read them", rc.warmSnapshot.image, rc.Config.WarmSnapshotAfter)
+
+		removed, err := container.RemoveLabeledImages(ctx, warmSnapshotLegLabel, labels[warmSnapshotLegLabel], rc.warmSnapshot.image)
+		if err != nil {
+			logger.Debugf("Failed to remove the earlier warm snapshots of job %s: %v", rc.JobName, err)
+		}
+		for _, image := range removed {
+			logger.Infof("\U0001F5D1  Removed the earlier warm snapshot %s of job %s", image, rc.JobName)
+		}
+		return nil
+	}
+}
+
+// warmSnapshotSecret returns the name of a value of the states of the steps which holds a secret or a value masked by the job,
+// empty if there is none. The names of the steps are masked instead.
+func (rc *RunContext) warmSnapshotSecret(ctx context.Context, steps []*StepState) string {
+	masker := valueMasker(false, rc.Config.Secrets)
+	mask := func(value string) string {
+		return masker(&logrus.Entry{Message: value, Context: ctx}).Message
+	}
+	for _, step := range steps {
+		step.Name = mask(step.Name)
+		for k, v := range step.Result.Outputs {
+			if mask(v) != v {
+				return fmt.Sprintf("output %s of step %s", k, step.ID)
+			}
+		}
+		for k, v := range step.Env {
+			if mask(v) != v {
+				return fmt.Sprintf("env %s", k)
+			}
+		}
+		for _, p := range step.Path {
+			if mask(p) != p {
+				return "path"
+			}
+		}
+		for id, state := range step.IntraActionState {
+			for k, v := range state {
+				if mask(v) != v {
+					return fmt.Sprintf("state %s of step %s", k, id)
+				}
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,228 @@
+package runner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nektos/act/pkg/common"
+	"github.com/nektos/act/pkg/container"
+	"github.com/nektos/act/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobDefinition(t *testing.T) {
+	rc := &RunContext{}
+	input := func() *container.NewContainerInput {
+		return &container.NewContainerInput{
+			Image:   "node:20",
+			Env:     []string{"B=2", "A=1"},
+			Binds:   []string{"/var/run/docker.sock:/var/run/docker.sock"},
+			Options: "--cpus 2",
+		}
+	}
+	services := map[string]*model.ContainerSpec{"db": {Image: "postgres:16"}}
+	definition := rc.jobDefinition(input(), nil, nil, services)
+	assert.Len(t, definition, 64)
+
+	reordered := input()
+	reordered.Env = []string{"A=1", "B=2"}
+	assert.Equal(t, definition, rc.jobDefinition(reordered, nil, nil, services))
+
+	changed := input()
+	changed.Options = "--cpus 4"
+	assert.NotEqual(t, definition, rc.jobDefinition(changed, nil, nil, services))
+	assert.NotEqual(t, definition, rc.jobDefinition(input(), []string{"SYS_ADMIN"}, nil, services))
+	assert.NotEqual(t, definition, rc.jobDefinition(input(), nil, nil, map[string]*model.ContainerSpec{"db": {Image: "postgres:17"}}))
+}
+
+func TestWarmSnapshotImage(t *testing.T) {
+	workdir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(workdir, "package-lock.json"), []byte("{}\n"), 0o644))
+	newRunContext := func(after, steps string) *RunContext {
+		workflow, err := model.ReadWorkflow(strings.NewReader(`
+on: push
+jobs:
+  test:
+    runs-on: ubuntu-latest
+    steps:
+` + steps))
+		assert.NoError(t, err)
+		return &RunContext{
+			Config: &Config{WarmSnapshotAfter: after, Workdir: workdir},
+			Run:    &model.Run{Workflow: workflow, JobID: "test"},
+			Matrix: map[string]interface{}{"node": 20},
+		}
+	}
+	const setup = `
+      - id: deps
+        run: apt-get install -y curl
+`
+	rc := newRunContext("deps", setup+"      - run: make test\n")
+	assert.Equal(t, 0, rc.warmSnapshotIndex(rc.Run.Job().Steps))
+	assert.Equal(t, -1, newRunContext("", setup).warmSnapshotIndex(rc.Run.Job().Steps))
+	assert.Equal(t, -1, newRunContext("build", setup).warmSnapshotIndex(rc.Run.Job().Steps))
+
+	ctx := context.Background()
+	image, err := rc.warmSnapshotImage(ctx, "definition", "sha256:1234")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(image, warmSnapshotRepository+":"))
+
+	// the steps after the snapshot don't change it
+	other, err := newRunContext("deps", setup+"      - run: make lint\n").warmSnapshotImage(ctx, "definition", "sha256:1234")
+	assert.NoError(t, err)
+	assert.Equal(t, image, other)
+
+	other, err = newRunContext("deps", strings.Replace(setup, "curl", "wget", 1)).warmSnapshotImage(ctx, "definition", "sha256:1234")
+	assert.NoError(t, err)
+	assert.NotEqual(t, image, other)
+
+	other, err = rc.warmSnapshotImage(ctx, "definition", "sha256:5678")
+	assert.NoError(t, err)
+	assert.NotEqual(t, image, other)
+
+	rc.Matrix = map[string]interface{}{"node": 22}
+	other, err = rc.warmSnapshotImage(ctx, "definition", "sha256:1234")
+	assert.NoError(t, err)
+	assert.NotEqual(t, image, other)
+
+	// the files of the working directory the setup steps may read change it
+	rc.Matrix = map[string]interface{}{"node": 20}
+	assert.NoError(t, os.WriteFile(filepath.Join(workdir, "package-lock.json"), []byte("{\"lockfileVersion\": 3}\n"), 0o644))
+	other, err = rc.warmSnapshotImage(ctx, "definition", "sha256:1234")
+	assert.NoError(t, err)
+	assert.NotEqual(t, image, other)
+
+	// the key replaces the digest of the files of the working directory
+	rc.Config.WarmSnapshotKey = "lock-1"
+	image, err = rc.warmSnapshotImage(ctx, "definition", "sha256:1234")
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(workdir, "README.md"), []byte("# readme\n"), 0o644))
+	other, err = rc.warmSnapshotImage(ctx, "definition", "sha256:1234")
+	assert.NoError(t, err)
+	assert.Equal(t, image, other)
+	rc.Config.WarmSnapshotKey = "lock-2"
+	other, err = rc.warmSnapshotImage(ctx, "definition", "sha256:1234")
+	assert.NoError(t, err)
+	assert.NotEqual(t, image, other)
+
+	// the earlier snapshots of the same job and matrix leg are removed whatever their steps
+	leg := rc.warmSnapshotLeg()
+	assert.Equal(t, leg, newRunContext("deps", strings.Replace(setup, "curl", "wget", 1)).warmSnapshotLeg())
+	rc.Matrix = map[string]interface{}{"node": 22}
+	assert.NotEqual(t, leg, rc.warmSnapshotLeg())
+	rc.Matrix = map[string]interface{}{"node": 20}
+	rc.Config.Workdir = t.TempDir()
+	assert.NotEqual(t, leg, rc.warmSnapshotLeg())
+}
+
+func TestWarmSnapshotSecret(t *testing.T) {
+	rc := &RunContext{Config: &Config{Secrets: map[string]string{"TOKEN": "s3cr3t-token"}}}
+	newSteps := func() []*StepState {
+		return []*StepState{{
+			ID:     "deps",
+			Name:   "install with s3cr3t-token",
+			Result: &model.StepResult{Outputs: map[string]string{"version": "1.0"}},
+			Env:    map[string]string{"NODE_ENV": "ci"},
+			Path:   []string{"/opt/node/bin"},
+		}}
+	}
+	ctx := WithMasks(context.Background(), &[]string{"masked-value"})
+	steps := newSteps()
+	assert.Empty(t, rc.warmSnapshotSecret(ctx, steps))
+	assert.Equal(t, "install with ***", steps[0].Name)
+
+	steps = newSteps()
+	steps[0].Env["NPM_TOKEN"] = "s3cr3t-token"
+	assert.Equal(t, "env NPM_TOKEN", rc.warmSnapshotSecret(ctx, steps))
+
+	steps = newSteps()
+	steps[0].Result.Outputs["key"] = "masked-value"
+	assert.Equal(t, "output key of step deps", rc.warmSnapshotSecret(ctx, steps))
+
+	steps = newSteps()
+	steps[0].IntraActionState = map[string]map[string]string{"deps": {"auth": "masked-value"}}
+	assert.Equal(t, "state auth of step deps", rc.warmSnapshotSecret(ctx, steps))
+}
+
+func TestJobExecutorWarmSnapshot(t *testing.T) {
+	success := &model.StepResult{Outputs: map[string]string{"version": "1.0"}, Conclusion: model.StepStatusSuccess, Outcome: model.StepStatusSuccess}
+
+	for _, tt := range []struct {
+		name          string
+		snapshot      *warmSnapshot
+		executedSteps []string
+	}{
+		{"no snapshot", nil, []string{"pre-deps", "pre-build", "deps", "build", "test", "post-build", "post-deps"}},
+		{"snapshot restored", &warmSnapshot{image: "act-snapshot:1", steps: []*StepState{
+			{ID: "deps", Result: success},
+			{ID: "build", Result: success, Env: map[string]string{"FOO": "bar"}, Path: []string{"/opt/tool/bin"}},
+		}}, []string{"test"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := common.WithJobErrorContainer(context.Background())
+			jim := &jobInfoMock{}
+			sfm := &stepFactoryMock{}
+			rc := &RunContext{
+				JobContainer: &jobContainerMock{},
+				Run: &model.Run{
+					JobID: "test",
+					Workflow: &model.Workflow{
+						Jobs: map[string]*model.Job{
+							"test": {},
+						},
+					},
+				},
+				Config:       &Config{WarmSnapshotAfter: "build"},
+				StepResults:  map[string]*model.StepResult{},
+				warmSnapshot: tt.snapshot,
+			}
+			rc.ExprEval = rc.NewExpressionEvaluator(ctx)
+			executorOrder := make([]string, 0)
+
+			stepModels := []*model.Step{{ID: "deps"}, {ID: "build"}, {ID: "test"}}
+			jim.On("steps").Return(stepModels)
+			jim.On("startContainer").Return(func(_ context.Context) error {
+				return nil
+			})
+			for _, stepModel := range stepModels {
+				stepModel := stepModel
+				sm := &stepMock{}
+				sfm.On("newStep", stepModel, rc).Return(sm, nil)
+				sm.On("pre").Return(func(_ context.Context) error {
+					if stepModel.ID != "test" {
+						executorOrder = append(executorOrder, "pre-"+stepModel.ID)
+					}
+					return nil
+				})
+				sm.On("main").Return(func(_ context.Context) error {
+					executorOrder = append(executorOrder, stepModel.ID)
+					rc.StepResults[stepModel.ID] = success
+					return nil
+				})
+				sm.On("post").Return(func(_ context.Context) error {
+					if stepModel.ID != "test" {
+						executorOrder = append(executorOrder, "post-"+stepModel.ID)
+					}
+					return nil
+				})
+			}
+			jim.On("matrix").Return(map[string]interface{}{})
+			jim.On("interpolateOutputs").Return(func(_ context.Context) error { return nil })
+			jim.On("stopContainer").Return(func(_ context.Context) error { return nil })
+			jim.On("result", "success")
+			jim.On("closeContainer").Return(func(_ context.Context) error { return nil })
+
+			assert.NoError(t, newJobExecutor(jim, sfm, rc)(ctx))
+			assert.Equal(t, tt.executedSteps, executorOrder)
+
+			if rc.warmSnapshotRestored() {
+				assert.Equal(t, success, rc.StepResults["deps"])
+				assert.Equal(t, "bar", rc.Env["FOO"])
+				assert.Equal(t, []string{"/opt/tool/bin"}, rc.ExtraPath)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package secretprovider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/nektos/act/pkg/common"
+)
+
+// Reference is a secret referenced by URI, of the form scheme://path#field
+type Reference struct {
+	URI    string
+	Scheme string
+	Path   string // the part between :// and #, its meaning depends on the provider
+	Field  string // the field of a structured secret, empty for the whole secret
+}
+
+// Provider resolves the secrets referenced by the URIs of its scheme
+type Provider interface {
+	Resolve(ctx context.Context, ref Reference) (string, error)
+}
+
+// Resolver resolves the secrets referenced by URI with the provider registered for their scheme, each URI is resolved once
+type Resolver struct {
+	providers map[string]Provider
+
+	mu       sync.Mutex
+	resolved map[string]string
+}
+
+// NewResolver creates a Resolver with the built-in providers
+func NewResolver() *Resolver {
+	r := &Resolver{
+		providers: map[string]Provider{},
+		resolved:  map[string]string{},
+	}
+	r.Register("sops", &SopsProvider{})
+	r.Register("pass", &PassProvider{})
+	r.Register("exec", &ExecProvider{})
+	r.Register("vault", NewVaultProvider())
+	return r
+}
+
+// Register sets the provider of the URIs of scheme
+func (r *Resolver) Register(scheme string, provider Provider) {
+	r.providers[scheme] = provider
+}
+
+// Parse returns the reference of value, false if value isn't a URI of a registered scheme and is the secret itself
+func (r *Resolver) Parse(value string) (Reference, bool) {
+	scheme, rest, ok := strings.Cut(value, "://")
+	if !ok || r.providers[scheme] == nil {
+		return Reference{}, false
+	}
+	path, field, _ := strings.Cut(rest, "#")
+	return Reference{URI: value, Scheme: scheme, Path: path, Field: field}, true
+}
+
+// Resolve returns the secret referenced by uri
+func (r *Resolver) Resolve(ctx context.Context, uri string) (string, error) {
+	ref, ok := r.Parse(uri)
+	if !ok {
+		return "", fmt.Errorf("'%s' isn't a secret reference", uri)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if value, ok := r.resolved[uri]; ok {
+		return value, nil
+	}
+	value, err := r.providers[ref.Scheme].Resolve(ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve secret from %s: %w", uri, err)
+	}
+	r.resolved[uri] = value
+	return value, nil
+}
+
+// ResolveAll resolves the secrets referenced by URI by their name, and logs where each of them comes from
+func (r *Resolver) ResolveAll(ctx context.Context, refs map[string]string) (map[string]string, error) {
+	names := make([]string, 0, len(refs))
+	for name := range refs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	logger := common.Logger(ctx)
+	secrets := make(map[string]string, len(refs))
+	for _, name := range names {
+		value, err := r.Resolve(ctx, refs[name])
+		if err != nil {
+			return nil, fmt.Errorf("secret %s: %w", name, err)
+		}
+		logger.Infof("Resolved secret %s from %s", name, refs[name])
+		secrets[name] = value
+	}
+	return secrets, nil
+}
@@ -0,0 +1,135 @@
+package secretprovider
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type countingProvider struct {
+	calls int
+}
+
+func (p *countingProvider) Resolve(_ context.Context, ref Reference) (string, error) {
+	p.calls++
+	if ref.Path == "missing" {
+		return "", errors.New("not found")
+	}
+	return ref.Path + "/" + ref.Field, nil
+}
+
+func TestResolver(t *testing.T) {
+	r := NewResolver()
+	provider := &countingProvider{}
+	r.Register("test", provider)
+
+	ref, ok := r.Parse("test://app/db#password")
+	assert.True(t, ok)
+	assert.Equal(t, Reference{URI: "test://app/db#password", Scheme: "test", Path: "app/db", Field: "password"}, ref)
+	for _, value := range []string{"hunter2", "pass:word", "unknown://app", ""} {
+		_, ok := r.Parse(value)
+		assert.False(t, ok, value)
+	}
+
+	secrets, err := r.ResolveAll(context.Background(), map[string]string{
+		"DB_PASSWORD": "test://app/db#password",
+		"DB_USER":     "test://app/db#user",
+		"PASSWORD":    "test://app/db#password",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"DB_PASSWORD": "app/db/password", "DB_USER": "app/db/user", "PASSWORD": "app/db/password"}, secrets)
+	assert.Equal(t, 2, provider.calls)
+
+	_, err = r.ResolveAll(context.Background(), map[string]string{"TOKEN": "test://missing"})
+	assert.EqualError(t, err, "secret TOKEN: failed to resolve secret from test://missing: not found")
+}
+
+// writeScript writes a shell script standing in for a command
+func writeScript(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+content), 0o755))
+	return path
+}
+
+func TestCommandProviders(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the commands are shell scripts")
+	}
+	ctx := context.Background()
+
+	sops := &SopsProvider{Command: writeScript(t, "sops", `echo "$@"`)}
+	value, err := sops.Resolve(ctx, Reference{Path: "secrets.enc.yaml", Field: "db.password"})
+	assert.NoError(t, err)
+	assert.Equal(t, `--decrypt --extract ["db"]["password"] secrets.enc.yaml`, value)
+
+	pass := &PassProvider{Command: writeScript(t, "pass", "echo hunter2\necho 'username: admin'\necho 'url: https://example.com'\n")}
+	value, err = pass.Resolve(ctx, Reference{Path: "work/db"})
+	assert.NoError(t, err)
+	assert.Equal(t, "hunter2", value)
+	value, err = pass.Resolve(ctx, Reference{Path: "work/db", Field: "url"})
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com", value)
+	_, err = pass.Resolve(ctx, Reference{Path: "work/db", Field: "email"})
+	assert.EqualError(t, err, "field 'email' not found")
+
+	counter := filepath.Join(t.TempDir(), "calls")
+	script := writeScript(t, "get-secrets", `echo run >> "$1"; echo '{"TOKEN": "s3cr3t", "PORT": 5432}'`)
+	exec := &ExecProvider{}
+	value, err = exec.Resolve(ctx, Reference{Path: script + " " + counter, Field: "TOKEN"})
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cr3t", value)
+	value, err = exec.Resolve(ctx, Reference{Path: script + " " + counter, Field: "PORT"})
+	assert.NoError(t, err)
+	assert.Equal(t, "5432", value)
+	calls, err := os.ReadFile(counter)
+	assert.NoError(t, err)
+	assert.Equal(t, "run\n", string(calls))
+
+	failing := writeScript(t, "failing", "echo 'access denied' >&2\nexit 3\n")
+	_, err = exec.Resolve(ctx, Reference{Path: failing, Field: "TOKEN"})
+	assert.ErrorContains(t, err, "exit status 3: access denied")
+}
+
+func TestVaultProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors": ["permission denied"]}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/app":
+			_, _ = w.Write([]byte(`{"data": {"data": {"password": "kv2"}, "metadata": {"version": 1}}}`))
+		case "/v1/kv/app":
+			_, _ = w.Write([]byte(`{"data": {"password": "kv1"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors": []}`))
+		}
+	}))
+	defer server.Close()
+
+	vault := &VaultProvider{Address: server.URL, Token: "root", Client: server.Client()}
+	ctx := context.Background()
+	value, err := vault.Resolve(ctx, Reference{Path: "secret/data/app", Field: "password"})
+	assert.NoError(t, err)
+	assert.Equal(t, "kv2", value)
+	value, err = vault.Resolve(ctx, Reference{Path: "kv/app", Field: "password"})
+	assert.NoError(t, err)
+	assert.Equal(t, "kv1", value)
+	_, err = vault.Resolve(ctx, Reference{Path: "kv/other", Field: "password"})
+	assert.EqualError(t, err, "404 Not Found")
+	_, err = vault.Resolve(ctx, Reference{Path: "kv/app"})
+	assert.EqualError(t, err, "missing the field of the secret, e.g. vault://kv/app#password")
+
+	vault.Token = "other"
+	_, err = vault.Resolve(ctx, Reference{Path: "kv/app", Field: "password"})
+	assert.EqualError(t, err, "403 Forbidden: permission denied")
+}
@@ -0,0 +1,212 @@
+package secretprovider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/google/shlex"
+)
+
+// runCommand runs the command and returns its output, the error includes what the command printed to stderr
+func runCommand(ctx context.Context, name string, args ...string) ([]byte, error) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s: %w: %s", name, err, msg)
+		}
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// fieldValue returns the value of field in values, values which aren't strings are returned as JSON
+func fieldValue(values map[string]interface{}, field string) (string, error) {
+	value, ok := values[field]
+	if !ok {
+		return "", fmt.Errorf("field '%s' not found", field)
+	}
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	content, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// SopsProvider decrypts the secrets of SOPS encrypted files (sops://path/to/secrets.enc.yaml#key.subkey) with the sops command,
+// the keys (e.g. SOPS_AGE_KEY_FILE for age) are configured as for sops
+type SopsProvider struct {
+	Command string // the sops command, sops by default
+}
+
+func (p *SopsProvider) Resolve(ctx context.Context, ref Reference) (string, error) {
+	command := p.Command
+	if command == "" {
+		command = "sops"
+	}
+	args := []string{"--decrypt"}
+	if ref.Field != "" {
+		extract := ""
+		for _, key := range strings.Split(ref.Field, ".") {
+			extract += fmt.Sprintf("[%q]", key)
+		}
+		args = append(args, "--extract", extract)
+	}
+	output, err := runCommand(ctx, command, append(args, ref.Path)...)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(output), "\n"), nil
+}
+
+// PassProvider reads the secrets of the pass password store (pass://path/to/entry), the password is the first line of the entry
+// and the fields (pass://path/to/entry#username) are the following lines of the form field: value
+type PassProvider struct {
+	Command string // the pass command, pass by default
+}
+
+func (p *PassProvider) Resolve(ctx context.Context, ref Reference) (string, error) {
+	command := p.Command
+	if command == "" {
+		command = "pass"
+	}
+	output, err := runCommand(ctx, command, "show", ref.Path)
+	if err != nil {
+		return "", err
+	}
+	lines := strings.Split(strings.TrimRight(string(output), "\n"), "\n")
+	if ref.Field == "" {
+		return lines[0], nil
+	}
+	for _, line := range lines[1:] {
+		if name, value, ok := strings.Cut(line, ":"); ok && strings.TrimSpace(name) == ref.Field {
+			return strings.TrimSpace(value), nil
+		}
+	}
+	return "", fmt.Errorf("field '%s' not found", ref.Field)
+}
+
+// ExecProvider runs a command printing the secrets as a JSON object (exec://get-secrets --env prod#DB_PASSWORD),
+// the command runs once for all the secrets referencing it, without a field the secret is the whole output of the command
+type ExecProvider struct {
+	mu      sync.Mutex
+	outputs map[string][]byte // by command
+}
+
+func (p *ExecProvider) Resolve(ctx context.Context, ref Reference) (string, error) {
+	output, err := p.output(ctx, ref.Path)
+	if err != nil {
+		return "", err
+	}
+	if ref.Field == "" {
+		return strings.TrimRight(string(output), "\n"), nil
+	}
+	values := map[string]interface{}{}
+	if err := json.Unmarshal(output, &values); err != nil {
+		return "", fmt.Errorf("the output of '%s' isn't a JSON object: %w", ref.Path, err)
+	}
+	return fieldValue(values, ref.Field)
+}
+
+func (p *ExecProvider) output(ctx context.Context, command string) ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if output, ok := p.outputs[command]; ok {
+		return output, nil
+	}
+	args, err := shlex.Split(command)
+	if err != nil {
+		return nil, fmt.Errorf("invalid command '%s': %w", command, err)
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("missing command")
+	}
+	output, err := runCommand(ctx, args[0], args[1:]...)
+	if err != nil {
+		return nil, err
+	}
+	if p.outputs == nil {
+		p.outputs = map[string][]byte{}
+	}
+	p.outputs[command] = output
+	return output, nil
+}
+
+// VaultProvider reads the secrets of a HashiCorp Vault compatible HTTP API (vault://secret/data/myapp#password),
+// from the KV secrets engine version 1 or 2
+type VaultProvider struct {
+	Address   string // the address of the server, VAULT_ADDR by default
+	Token     string // the token to authenticate with, VAULT_TOKEN by default
+	Namespace string // the namespace of the secrets, VAULT_NAMESPACE by default
+	Client    *http.Client
+}
+
+// NewVaultProvider creates a VaultProvider configured by the environment variables of the vault command
+func NewVaultProvider() *VaultProvider {
+	address := os.Getenv("VAULT_ADDR")
+	if address == "" {
+		address = "https://127.0.0.1:8200"
+	}
+	return &VaultProvider{
+		Address:   address,
+		Token:     os.Getenv("VAULT_TOKEN"),
+		Namespace: os.Getenv("VAULT_NAMESPACE"),
+		Client:    http.DefaultClient,
+	}
+}
+
+func (p *VaultProvider) Resolve(ctx context.Context, ref Reference) (string, error) {
+	if ref.Field == "" {
+		return "", fmt.Errorf("missing the field of the secret, e.g. vault://%s#password", ref.Path)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(p.Address, "/")+"/v1/"+strings.TrimLeft(ref.Path, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	if p.Token != "" {
+		req.Header.Set("X-Vault-Token", p.Token)
+	}
+	if p.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", p.Namespace)
+	}
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Data   map[string]interface{} `json:"data"`
+		Errors []string               `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil && resp.StatusCode == http.StatusOK {
+		return "", fmt.Errorf("invalid response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if len(body.Errors) > 0 {
+			return "", fmt.Errorf("%s: %s", resp.Status, strings.Join(body.Errors, ", "))
+		}
+		return "", fmt.Errorf("%s", resp.Status)
+	}
+
+	values := body.Data
+	if data, ok := values["data"].(map[string]interface{}); ok {
+		if _, ok := values["metadata"]; ok {
+			// the KV secrets engine version 2 wraps the secret with its metadata
+			values = data
+		}
+	}
+	return fieldValue(values, ref.Field)
+}